@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClosed is returned by Enqueue after Close
+var ErrClosed = errors.New("client: closed")
+
+// BatchItemError reports a buffered trace the server rejected
+type BatchItemError struct {
+	Trace  IngestTraceRequest
+	Result BatchItemResult
+}
+
+func (e *BatchItemError) Error() string {
+	if e.Result.Error == nil {
+		return fmt.Sprintf("trace %q rejected", e.Trace.Name)
+	}
+	return fmt.Sprintf("trace %q rejected: %s: %s", e.Trace.Name, e.Result.Error.Code, e.Result.Error.Message)
+}
+
+// Enqueue buffers a trace for the next batch. A full batch (BatchSize
+// traces) is sent in the background; failures go to Config.OnError.
+func (c *Client) Enqueue(trace IngestTraceRequest) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.pending = append(c.pending, trace)
+	full := len(c.pending) >= c.cfg.BatchSize
+	c.mu.Unlock()
+
+	if full {
+		go func() {
+			if err := c.Flush(context.Background()); err != nil {
+				c.cfg.OnError(err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Flush sends all buffered traces, BatchSize at a time. A batch that
+// failed transiently (after retries) is put back at the front of the
+// buffer for the next flush; one the server refused outright is dropped.
+// Traces the server rejected individually are reported as *BatchItemError.
+func (c *Client) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	var errs []error
+	for {
+		c.mu.Lock()
+		n := min(len(c.pending), c.cfg.BatchSize)
+		batch := c.pending[:n:n]
+		c.pending = c.pending[n:]
+		c.mu.Unlock()
+		if n == 0 {
+			break
+		}
+
+		resp, err := c.IngestBatch(ctx, batch)
+		if err != nil {
+			if retryable(err) || ctx.Err() != nil {
+				c.mu.Lock()
+				c.pending = append(batch, c.pending...)
+				c.mu.Unlock()
+				errs = append(errs, fmt.Errorf("client: batch of %d traces failed, kept for the next flush: %w", n, err))
+				break
+			}
+			errs = append(errs, fmt.Errorf("client: batch of %d traces rejected: %w", n, err))
+			continue
+		}
+		for _, result := range resp.Results {
+			if !result.Success && result.Index < len(batch) {
+				errs = append(errs, &BatchItemError{Trace: batch[result.Index], Result: result})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flushLoop flushes buffered traces every FlushInterval until Close
+func (c *Client) flushLoop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.Flush(context.Background()); err != nil {
+				c.cfg.OnError(err)
+			}
+		}
+	}
+}
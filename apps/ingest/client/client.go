@@ -0,0 +1,322 @@
+// Package client is a Go SDK for the ingest API, for services that report
+// traces and scores without hand-rolling HTTP calls.
+//
+// Request and response types are the server's own, from pkg/api (see the
+// aliases below), so the client can't drift from the API. Traces are sent
+// over HTTP, either one at a time with IngestTrace or buffered with Enqueue
+// and sent through the batch endpoint. Scores are sent over gRPC, the only
+// transport that accepts them. Every call authenticates with the API key, gzips large
+// bodies and retries transient failures under a single idempotency key.
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/cognobserve/ingest/pkg/api"
+)
+
+// Request and response types shared with the server
+type (
+	IngestTraceRequest  = api.IngestTraceRequest
+	IngestSpanInput     = api.IngestSpanInput
+	IngestTraceResponse = api.IngestTraceResponse
+	IngestBatchRequest  = api.IngestBatchRequest
+	IngestBatchResponse = api.IngestBatchResponse
+	BatchItemResult     = api.BatchItemResult
+	TokenUsageInput     = api.TokenUsageInput
+	UserInfoInput       = api.UserInfoInput
+	FlexibleTime        = api.FlexibleTime
+)
+
+// Defaults applied by New for zero Config values
+const (
+	DefaultMaxRetries    = 3
+	DefaultRetryBackoff  = 200 * time.Millisecond
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	DefaultGzipMinSize   = 1024
+)
+
+// maxRetryBackoff caps the delay between retries, including Retry-After
+const maxRetryBackoff = 30 * time.Second
+
+// Config configures a Client
+type Config struct {
+	BaseURL     string // HTTP API root, e.g. https://ingest.example.com
+	APIKey      string
+	GRPCAddress string // host:port of the gRPC API; required for IngestScore
+	GRPCOptions []grpc.DialOption
+
+	HTTPClient   *http.Client  // Defaults to a client with a 30s timeout
+	MaxRetries   int           // Retries after the first attempt; negative disables retries
+	RetryBackoff time.Duration // First retry delay, doubled per attempt
+	GzipMinSize  int           // Request bodies at least this large are gzipped; negative disables gzip
+
+	// Buffered mode: Enqueue sends traces through the batch endpoint once
+	// BatchSize are pending, or every FlushInterval (negative disables the timer)
+	BatchSize     int
+	FlushInterval time.Duration
+	OnError       func(error) // Reports background flush failures; defaults to logging
+}
+
+// Client sends traces and scores to the ingest API. It is safe for
+// concurrent use. Call Close to flush buffered traces and release the
+// gRPC connection.
+type Client struct {
+	cfg     Config
+	baseURL string
+	http    *http.Client
+
+	grpcOnce sync.Once
+	grpcConn *grpc.ClientConn
+	grpcErr  error
+
+	mu      sync.Mutex
+	pending []IngestTraceRequest
+	flushMu sync.Mutex // Serializes flushes so batches go out in enqueue order
+	stop    chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// APIError is an error response from the ingest API
+type APIError struct {
+	Status     int
+	Code       string
+	Message    string
+	Field      string // Offending request field, if any
+	Details    map[string]any
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ingest API error %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// New creates a Client. In buffered mode a background goroutine flushes
+// pending traces every FlushInterval until Close.
+func New(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("client: BaseURL is required")
+	}
+	if cfg.APIKey == "" {
+		return nil, errors.New("client: APIKey is required")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultRetryBackoff
+	}
+	if cfg.GzipMinSize == 0 {
+		cfg.GzipMinSize = DefaultGzipMinSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(err error) { slog.Error("ingest client flush failed", "error", err) }
+	}
+
+	c := &Client{
+		cfg:     cfg,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		http:    cfg.HTTPClient,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if cfg.FlushInterval > 0 {
+		go c.flushLoop()
+	} else {
+		close(c.done)
+	}
+	return c, nil
+}
+
+// IngestTrace sends one trace and waits for it to be accepted
+func (c *Client) IngestTrace(ctx context.Context, req *IngestTraceRequest) (*IngestTraceResponse, error) {
+	var resp IngestTraceResponse
+	if err := c.post(ctx, "/v1/traces", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// IngestBatch sends several traces in one request. Traces are accepted
+// or rejected individually; check each result's Success.
+func (c *Client) IngestBatch(ctx context.Context, traces []IngestTraceRequest) (*IngestBatchResponse, error) {
+	var resp IngestBatchResponse
+	if err := c.post(ctx, "/v1/traces/batch", IngestBatchRequest{Traces: traces}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Close stops the flush timer, sends any buffered traces and closes the
+// gRPC connection. Enqueue fails after Close.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	close(c.stop)
+	<-c.done
+
+	err := c.Flush(ctx)
+	if c.grpcConn != nil {
+		err = errors.Join(err, c.grpcConn.Close())
+	}
+	return err
+}
+
+// post sends body as JSON and decodes the response into out, retrying
+// transient failures. All attempts carry the same Idempotency-Key, so a
+// retry of a request the server already handled is replayed, not re-run.
+func (c *Client) post(ctx context.Context, path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("client: failed to encode request: %w", err)
+	}
+	gzipped := c.cfg.GzipMinSize > 0 && len(payload) >= c.cfg.GzipMinSize
+	if gzipped {
+		if payload, err = gzipBytes(payload); err != nil {
+			return fmt.Errorf("client: failed to compress request: %w", err)
+		}
+	}
+	idempotencyKey, err := newIdempotencyKey()
+	if err != nil {
+		return err
+	}
+
+	backoff := c.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = c.attempt(ctx, path, payload, gzipped, idempotencyKey, out)
+		if err == nil || attempt >= c.cfg.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		delay := backoff
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(min(delay, maxRetryBackoff)):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt makes a single request
+func (c *Client) attempt(ctx context.Context, path string, payload []byte, gzipped bool, idempotencyKey string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("client: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", c.cfg.APIKey)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	// Lets the server bound the trace workflow by how long we wait
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline).Milliseconds(); left > 0 {
+			req.Header.Set("X-Request-Timeout-Ms", strconv.FormatInt(left, 10))
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return readAPIError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// readAPIError converts an error response into an *APIError
+func readAPIError(resp *http.Response) error {
+	apiErr := &APIError{Status: resp.StatusCode, Code: "unknown_error", Message: resp.Status}
+	var body api.ErrorBody
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil && json.Unmarshal(data, &body) == nil && body.Code != "" {
+		apiErr.Code = body.Code
+		apiErr.Message = body.Message
+		apiErr.Field = body.Field
+		apiErr.Details = body.Details
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// retryable reports whether a failed request may succeed if sent again:
+// network errors, rate limiting and server-side failures. Other 4xx
+// responses will fail the same way every time.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch apiErr.Status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("client: failed to generate idempotency key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
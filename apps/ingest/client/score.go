@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+
+	cognobservev1 "github.com/cognobserve/ingest/internal/proto/cognobserve/v1"
+)
+
+// Score types shared with the server's gRPC API
+type (
+	IngestScoreRequest  = cognobservev1.IngestScoreRequest
+	IngestScoreResponse = cognobservev1.IngestScoreResponse
+)
+
+// IngestScore sends a score over gRPC. The connection to GRPCAddress is
+// opened on first use, with TLS unless GRPCOptions say otherwise.
+func (c *Client) IngestScore(ctx context.Context, req *IngestScoreRequest) (*IngestScoreResponse, error) {
+	conn, err := c.grpcConnection()
+	if err != nil {
+		return nil, err
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", c.cfg.APIKey)
+	return cognobservev1.NewIngestServiceClient(conn).IngestScore(ctx, req)
+}
+
+// grpcConnection lazily creates the shared gRPC connection
+func (c *Client) grpcConnection() (*grpc.ClientConn, error) {
+	c.grpcOnce.Do(func() {
+		if c.cfg.GRPCAddress == "" {
+			c.grpcErr = errors.New("client: GRPCAddress is required to send scores")
+			return
+		}
+		opts := append([]grpc.DialOption{
+			grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, "")),
+		}, c.cfg.GRPCOptions...)
+		conn, err := grpc.NewClient(c.cfg.GRPCAddress, opts...)
+		if err != nil {
+			c.grpcErr = fmt.Errorf("client: failed to create gRPC connection: %w", err)
+			return
+		}
+		c.grpcConn = conn
+	})
+	return c.grpcConn, c.grpcErr
+}
@@ -8,9 +8,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/cognobserve/ingest/internal/audit"
+	"github.com/cognobserve/ingest/internal/budget"
 	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/dedup"
+	"github.com/cognobserve/ingest/internal/geoip"
 	"github.com/cognobserve/ingest/internal/server"
 	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/upload"
+	"github.com/cognobserve/ingest/internal/webapi"
 )
 
 // Environment variables are injected by Doppler at runtime.
@@ -18,6 +24,18 @@ import (
 // See: docs/specs/issue-104-doppler-secret-management.md
 
 func main() {
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run starts the service and blocks until it shuts down. Failures are
+// logged where they happen and returned rather than exiting, so deferred
+// cleanup runs before main exits non-zero.
+func run() error {
+	// Captured first so uptime covers the whole process lifetime
+	startedAt := time.Now()
+
 	// Setup structured logging
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
@@ -28,7 +46,7 @@ func main() {
 	cfg, err := config.Load()
 	if err != nil {
 		slog.Error("failed to load config", "error", err)
-		os.Exit(1)
+		return err
 	}
 
 	// Initialize Temporal client (required)
@@ -41,17 +59,95 @@ func main() {
 		cfg.TemporalAddress,
 		cfg.TemporalNamespace,
 		cfg.TemporalTaskQueue,
+		cfg.TemporalMaxConcurrentStarts,
+		cfg.TraceDuplicateWindow,
+		cfg.TemporalSearchAttributes,
 	)
 	if err != nil {
 		slog.Error("failed to connect to temporal", "error", err)
-		os.Exit(1)
+		return err
 	}
-	defer temporalClient.Close()
 	slog.Info("temporal client connected")
+	// Covers failures before the server starts; once it runs, srv.Close
+	// closes the client first and this call is a no-op
+	defer temporalClient.Close()
+
+	// Fail fast on a misconfigured namespace rather than on every workflow start
+	if cfg.TemporalVerifyNamespace {
+		verifyCtx, cancelVerify := context.WithTimeout(context.Background(), 10*time.Second)
+		err := temporalClient.VerifyNamespace(verifyCtx)
+		cancelVerify()
+		if err != nil {
+			slog.Error("temporal namespace check failed", "error", err)
+			return err
+		}
+	}
+
+	// Initialize trace deduplication (optional)
+	var deduplicator *dedup.Deduplicator
+	if cfg.TraceDedupEnabled {
+		deduplicator, err = dedup.New(cfg.RedisURL, cfg.TraceDedupWindow)
+		if err != nil {
+			slog.Error("failed to initialize trace dedup", "error", err)
+			return err
+		}
+		defer deduplicator.Close()
+		slog.Info("trace dedup enabled", "window", cfg.TraceDedupWindow)
+	}
+
+	// Initialize upload sessions for chunked trace ingestion (optional)
+	var uploads *upload.Store
+	if cfg.UploadSessionsEnabled {
+		uploads, err = upload.New(cfg.RedisURL, cfg.UploadSessionTTL, cfg.MaxUploadSessionBytes)
+		if err != nil {
+			slog.Error("failed to initialize upload sessions", "error", err)
+			return err
+		}
+		defer uploads.Close()
+		slog.Info("upload sessions enabled", "ttl", cfg.UploadSessionTTL, "max_bytes", cfg.MaxUploadSessionBytes)
+	}
+
+	// Initialize per-project cost budgets (optional)
+	var budgets *budget.Tracker
+	if cfg.CostBudgetMode != "off" {
+		budgets, err = budget.New(cfg.RedisURL, webapi.New(cfg))
+		if err != nil {
+			slog.Error("failed to initialize cost budgets", "error", err)
+			return err
+		}
+		defer budgets.Close()
+		slog.Info("cost budgets enabled", "mode", cfg.CostBudgetMode, "default_daily_usd", cfg.CostBudgetDailyUSD)
+	}
+
+	// Load the GeoIP database for client IP enrichment (optional)
+	var geo geoip.Lookup
+	if cfg.EnrichClientIP && cfg.GeoIPDatabase != "" {
+		table, err := geoip.LoadCSV(cfg.GeoIPDatabase)
+		if err != nil {
+			slog.Error("failed to load GeoIP database", "error", err)
+			return err
+		}
+		geo = table
+		slog.Info("GeoIP database loaded", "path", cfg.GeoIPDatabase, "networks", table.Len())
+	}
+
+	// Initialize auth audit logging
+	var auditLog *audit.Logger
+	switch cfg.AuditSink {
+	case "stdout":
+		auditLog = audit.New(audit.NewWriterSink(os.Stdout), cfg.AuditBufferSize)
+	case "redis":
+		sink, err := audit.NewRedisSink(cfg.RedisURL, cfg.AuditRedisKey, cfg.AuditRedisMaxEvents)
+		if err != nil {
+			slog.Error("failed to initialize audit sink", "error", err)
+			return err
+		}
+		auditLog = audit.New(sink, cfg.AuditBufferSize)
+	}
+	slog.Info("auth audit logging configured", "sink", cfg.AuditSink)
 
 	// Create and start server
-	srv := server.New(cfg, temporalClient)
-	defer srv.Close()
+	srv := server.New(cfg, temporalClient, deduplicator, uploads, budgets, geo, auditLog, startedAt)
 
 	// Graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -65,17 +161,46 @@ func main() {
 		cancel()
 	}()
 
+	// Reload runtime configuration (maintenance mode) on SIGHUP
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go reloadOnSignal(hupCh, srv.Reload)
+
 	slog.Info("starting ingest service",
 		"port", cfg.Port,
+		"grpc_port", cfg.GRPCPort,
 		"version", cfg.Version,
 	)
+	return serve(ctx, srv)
+}
+
+// reloadOnSignal calls reload for each signal received, until signals
+// is closed
+func reloadOnSignal(signals <-chan os.Signal, reload func()) {
+	for range signals {
+		slog.Info("reload signal received")
+		reload()
+	}
+}
+
+// service is the part of *server.Server that serve drives
+type service interface {
+	Run(ctx context.Context) error
+	Close()
+}
+
+// serve runs srv until ctx is cancelled. srv.Close closes the Temporal
+// client once Run has drained in-flight requests, whether or not Run failed.
+func serve(ctx context.Context, srv service) error {
+	defer srv.Close()
 
 	if err := srv.Run(ctx); err != nil {
 		slog.Error("server error", "error", err)
-		os.Exit(1)
+		return err
 	}
 
 	// Allow time for graceful shutdown
 	time.Sleep(100 * time.Millisecond)
 	slog.Info("ingest service stopped")
+	return nil
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/maintenance"
+	"github.com/cognobserve/ingest/internal/server"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+// freePort returns a TCP port nothing is listening on
+func freePort(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+	return strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+}
+
+// accepting reports whether something accepts connections on port
+func accepting(port string) bool {
+	conn, err := net.DialTimeout("tcp", "127.0.0.1:"+port, 100*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func TestServeClosesAfterShutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		portInUse bool // The HTTP port is taken, so Run fails
+		wantErr   bool
+	}{
+		{name: "clean shutdown"},
+		{name: "run failure", portInUse: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := freePort(t)
+			t.Setenv("INTERNAL_API_SECRET", "test-internal-secret-0123456789abcdef")
+			t.Setenv("JWT_SHARED_SECRET", "test-jwt-secret-0123456789abcdef0123")
+			t.Setenv("PORT", port)
+			t.Setenv("GRPC_PORT", freePort(t))
+			cfg, err := config.Load()
+			if err != nil {
+				t.Fatalf("config.Load: %v", err)
+			}
+
+			if tt.portInUse {
+				lis, err := net.Listen("tcp", ":"+port)
+				if err != nil {
+					t.Fatalf("listen: %v", err)
+				}
+				defer lis.Close()
+			}
+
+			fake := &temporaltest.Client{}
+			var acceptingAtClose bool
+			fake.CloseFunc = func() { acceptingAtClose = accepting(port) }
+			var _ server.TemporalClient = fake
+
+			srv := server.New(cfg, fake, nil, nil, nil, nil, nil, time.Now())
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- serve(ctx, srv) }()
+
+			if !tt.portInUse {
+				deadline := time.Now().Add(5 * time.Second)
+				for !accepting(port) {
+					if time.Now().After(deadline) {
+						t.Fatal("server never started accepting")
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				if fake.Closes() != 0 {
+					t.Fatal("temporal client closed while the server was running")
+				}
+				cancel()
+			}
+
+			select {
+			case err := <-done:
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("serve() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			case <-time.After(15 * time.Second):
+				t.Fatal("serve did not return")
+			}
+
+			if got := fake.Closes(); got != 1 {
+				t.Errorf("temporal client closed %d times, want 1", got)
+			}
+			if !tt.portInUse && acceptingAtClose {
+				t.Error("temporal client closed while the server still accepted connections")
+			}
+		})
+	}
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	flagFile := filepath.Join(t.TempDir(), "maintenance")
+	mode := maintenance.New(false, flagFile)
+
+	signals := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reloadOnSignal(signals, mode.Reload)
+	}()
+
+	if err := os.WriteFile(flagFile, nil, 0o600); err != nil {
+		t.Fatalf("write flag file: %v", err)
+	}
+	// The channel is unbuffered, so the first reload has finished once
+	// the second signal is received
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGHUP
+	if !mode.Enabled() {
+		t.Error("maintenance off after SIGHUP with the flag file, want on")
+	}
+
+	if err := os.Remove(flagFile); err != nil {
+		t.Fatalf("remove flag file: %v", err)
+	}
+	signals <- syscall.SIGHUP
+	close(signals)
+	<-done
+	if mode.Enabled() {
+		t.Error("maintenance on after SIGHUP without the flag file, want off")
+	}
+}
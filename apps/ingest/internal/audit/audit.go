@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+)
+
+// Decision outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Transports an auth decision was made on
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+)
+
+// Event is one authentication or authorization decision
+type Event struct {
+	Event     string    `json:"event"` // Always "auth_decision", to tell audit lines apart
+	Time      time.Time `json:"time"`
+	Outcome   string    `json:"outcome"`
+	Method    string    `json:"method"`
+	Transport string    `json:"transport"`
+	ProjectID string    `json:"project_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	KeyPrefix string    `json:"key_prefix,omitempty"` // SHA-256 prefix of the API key, never the key itself
+	IP        string    `json:"ip,omitempty"`
+	Reason    string    `json:"reason,omitempty"` // Error code on failure
+}
+
+// Sink persists encoded audit events
+type Sink interface {
+	Write(ctx context.Context, event []byte) error
+	Close() error
+}
+
+// Logger records audit events without blocking the request path.
+// Events are queued on a buffered channel and written by a background
+// goroutine; when the buffer is full, events are dropped and counted.
+type Logger struct {
+	sink    Sink
+	events  chan Event
+	done    chan struct{} // Closed to stop the writer
+	stopped chan struct{} // Closed once the writer has drained and exited
+	once    sync.Once
+}
+
+// New creates a Logger buffering up to bufferSize events for sink
+func New(sink Sink, bufferSize int) *Logger {
+	l := &Logger{
+		sink:    sink,
+		events:  make(chan Event, bufferSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Log queues an event. It never blocks; a nil Logger discards events.
+func (l *Logger) Log(e Event) {
+	if l == nil {
+		return
+	}
+	e.Event = "auth_decision"
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	select {
+	case l.events <- e:
+	default:
+		metrics.AuditEventsDropped.WithLabelValues("buffer_full").Inc()
+	}
+}
+
+// Close writes out queued events and closes the sink, giving up when ctx
+// is done. Call it after in-flight requests have drained so their events
+// are captured. Events still queued at the deadline are counted as dropped.
+func (l *Logger) Close(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.once.Do(func() { close(l.done) })
+
+	select {
+	case <-l.stopped:
+		return l.sink.Close()
+	case <-ctx.Done():
+		metrics.AuditEventsDropped.WithLabelValues("shutdown").Add(float64(len(l.events)))
+		return fmt.Errorf("audit flush incomplete: %w", ctx.Err())
+	}
+}
+
+func (l *Logger) run() {
+	defer close(l.stopped)
+	for {
+		select {
+		case e := <-l.events:
+			l.write(e)
+		case <-l.done:
+			// Drain what was queued before shutdown
+			for {
+				select {
+				case e := <-l.events:
+					l.write(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *Logger) write(e Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("failed to encode audit event", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.sink.Write(ctx, data); err != nil {
+		metrics.AuditEventsDropped.WithLabelValues("sink_error").Inc()
+		slog.Error("failed to write audit event", "error", err)
+	}
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink records written events; each write blocks until release is closed
+type memorySink struct {
+	release chan struct{}
+
+	mu     sync.Mutex
+	events []Event
+	closed bool
+}
+
+func (s *memorySink) Write(ctx context.Context, data []byte) error {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestLoggerClose(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      int
+		blockWrites bool // Writes never complete before the Close deadline
+		wantErr     bool
+		wantWritten int
+		wantClosed  bool
+	}{
+		{name: "no events", wantClosed: true},
+		{name: "queued events flushed", events: 5, wantWritten: 5, wantClosed: true},
+		{name: "deadline reached", events: 3, blockWrites: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memorySink{release: make(chan struct{})}
+			if !tt.blockWrites {
+				close(sink.release)
+			}
+			l := New(sink, 10)
+			for i := 0; i < tt.events; i++ {
+				l.Log(Event{Outcome: OutcomeSuccess, Transport: TransportHTTP})
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := l.Close(ctx)
+			if tt.blockWrites {
+				close(sink.release)
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Close() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Close() error = %v, want context.DeadlineExceeded", err)
+			}
+
+			sink.mu.Lock()
+			defer sink.mu.Unlock()
+			if !tt.blockWrites && len(sink.events) != tt.wantWritten {
+				t.Errorf("wrote %d events, want %d", len(sink.events), tt.wantWritten)
+			}
+			for _, e := range sink.events {
+				if e.Event != "auth_decision" || e.Time.IsZero() {
+					t.Errorf("event = %+v, want auth_decision with a time", e)
+				}
+			}
+			if sink.closed != tt.wantClosed {
+				t.Errorf("sink closed = %v, want %v", sink.closed, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	var l *Logger
+	l.Log(Event{Outcome: OutcomeFailure})
+	if err := l.Close(context.Background()); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WriterSink writes one JSON event per line to w (e.g. os.Stdout)
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates a sink writing JSON lines to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write writes event followed by a newline
+func (s *WriterSink) Write(_ context.Context, event []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(append(event, '\n'))
+	return err
+}
+
+// Close is a no-op; the writer is owned by the caller
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// RedisSink appends events to a Redis list, trimmed to the newest maxLen entries
+type RedisSink struct {
+	redis  *redis.Client
+	key    string
+	maxLen int64
+}
+
+// NewRedisSink creates a sink pushing events onto the list at key
+func NewRedisSink(redisURL, key string, maxLen int) (*RedisSink, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	return &RedisSink{redis: redis.NewClient(opts), key: key, maxLen: int64(maxLen)}, nil
+}
+
+// Write appends event to the list and trims it so it can't grow unbounded
+func (s *RedisSink) Write(ctx context.Context, event []byte) error {
+	pipe := s.redis.Pipeline()
+	pipe.RPush(ctx, s.key, event)
+	pipe.LTrim(ctx, s.key, -s.maxLen, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to push audit event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis connection
+func (s *RedisSink) Close() error {
+	return s.redis.Close()
+}
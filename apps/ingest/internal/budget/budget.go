@@ -0,0 +1,243 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/webapi"
+)
+
+const (
+	// keyPrefix namespaces daily spend keys in Redis
+	keyPrefix = "ingest:budget:"
+
+	// spendKeyTTL keeps a day's spend key until the day is over everywhere
+	spendKeyTTL = 48 * time.Hour
+
+	// priceTTL is how long model prices (including "no price") are cached,
+	// matching the web app's pricing cache
+	priceTTL = 5 * time.Minute
+
+	// failureTTL is how long a failed price lookup is cached, so an
+	// unavailable web app isn't asked again for every trace
+	failureTTL = 10 * time.Second
+)
+
+// chargeScript adds ARGV[1] to the spend at KEYS[1] and returns the new
+// spend and 1. When ARGV[3] is "1" (hard mode) and the charge would take
+// the spend above the budget in ARGV[2], the spend is left unchanged and
+// the script returns the current spend and 0.
+var chargeScript = redis.NewScript(`
+local spend = tonumber(redis.call('GET', KEYS[1]) or '0')
+local cost = tonumber(ARGV[1])
+if ARGV[3] == '1' and spend + cost > tonumber(ARGV[2]) then
+	return {tostring(spend), 0}
+end
+spend = redis.call('INCRBYFLOAT', KEYS[1], ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return {spend, 1}
+`)
+
+// Usage is a project's estimated spend for the day after a charge
+type Usage struct {
+	CostUSD   float64 // Estimated cost of the charged trace
+	SpendUSD  float64 // Spend for the day, including the trace if it was charged
+	BudgetUSD float64
+	Charged   bool // False when hard mode rejected the trace
+	day       string
+	projectID string
+}
+
+// Exceeded reports whether the day's spend is above the budget
+func (u Usage) Exceeded() bool {
+	return u.SpendUSD > u.BudgetUSD
+}
+
+type cachedPrice struct {
+	price     *webapi.ModelPrice // nil when the model has no price
+	err       error              // Set when the lookup failed
+	expiresAt time.Time
+}
+
+// Tracker keeps each project's estimated daily spend in Redis, pricing
+// spans from the web app's model pricing table.
+type Tracker struct {
+	redis  *redis.Client
+	prices *webapi.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedPrice
+}
+
+// New creates a Tracker storing spend in redisURL and looking up model
+// prices through prices
+func New(redisURL string, prices *webapi.Client) (*Tracker, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	return &Tracker{
+		redis:  redis.NewClient(opts),
+		prices: prices,
+		cache:  make(map[string]cachedPrice),
+	}, nil
+}
+
+// Close closes the Redis connection
+func (t *Tracker) Close() error {
+	return t.redis.Close()
+}
+
+// EstimateCost prices a trace's spans in USD. A span's cost override takes
+// precedence over the price table; spans whose model has no price cost
+// nothing, as in the worker's cost calculation.
+func (t *Tracker) EstimateCost(ctx context.Context, spans []temporal.SpanInput) (float64, error) {
+	prices, err := t.lookupPrices(ctx, spans)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, span := range spans {
+		if span.CostOverrideUSD != nil {
+			total += *span.CostOverrideUSD
+			continue
+		}
+		price := prices[span.Model]
+		if price == nil {
+			continue
+		}
+		prompt, completion := int64(span.PromptTokens), int64(span.CompletionTokens)
+		if prompt == 0 && completion == 0 {
+			prompt, completion = span.EstimatedPromptTokens, span.EstimatedCompletionTokens
+		}
+		total += float64(prompt)*price.InputPricePerMillion/1e6 + float64(completion)*price.OutputPricePerMillion/1e6
+	}
+	return total, nil
+}
+
+// lookupPrices resolves the price of each model used by spans, fetching
+// models that aren't cached in one request. A failed fetch is cached for
+// failureTTL and returned to lookups of the same models meanwhile.
+func (t *Tracker) lookupPrices(ctx context.Context, spans []temporal.SpanInput) (map[string]*webapi.ModelPrice, error) {
+	prices := make(map[string]*webapi.ModelPrice)
+	var missing []string
+
+	now := time.Now()
+	t.mu.Lock()
+	for _, span := range spans {
+		if span.Model == "" || span.CostOverrideUSD != nil {
+			continue
+		}
+		if _, ok := prices[span.Model]; ok {
+			continue
+		}
+		cached, ok := t.cache[span.Model]
+		if ok && now.Before(cached.expiresAt) {
+			if cached.err != nil {
+				t.mu.Unlock()
+				return nil, cached.err
+			}
+			prices[span.Model] = cached.price
+			continue
+		}
+		prices[span.Model] = nil
+		missing = append(missing, span.Model)
+	}
+	t.mu.Unlock()
+
+	if len(missing) == 0 {
+		return prices, nil
+	}
+
+	fetched, err := t.prices.GetModelPrices(ctx, missing)
+	if err != nil {
+		// A canceled request says nothing about the web app
+		if ctx.Err() == nil {
+			expiresAt := time.Now().Add(failureTTL)
+			t.mu.Lock()
+			for _, model := range missing {
+				t.cache[model] = cachedPrice{err: err, expiresAt: expiresAt}
+			}
+			t.mu.Unlock()
+		}
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(priceTTL)
+	t.mu.Lock()
+	for _, model := range missing {
+		var price *webapi.ModelPrice
+		if p, ok := fetched[model]; ok {
+			price = &p
+		}
+		prices[model] = price
+		t.cache[model] = cachedPrice{price: price, expiresAt: expiresAt}
+	}
+	t.mu.Unlock()
+	return prices, nil
+}
+
+// Charge adds costUSD to projectID's spend for the current UTC day. In
+// hard mode a charge that would take the spend above budgetUSD is refused
+// and the returned usage has Charged false. Spend equal to the budget is
+// within it.
+func (t *Tracker) Charge(ctx context.Context, projectID string, costUSD, budgetUSD float64, hard bool) (Usage, error) {
+	day := time.Now().UTC().Format(time.DateOnly)
+	hardArg := "0"
+	if hard {
+		hardArg = "1"
+	}
+
+	result, err := chargeScript.Run(ctx, t.redis, []string{spendKey(projectID, day)},
+		formatUSD(costUSD), formatUSD(budgetUSD), hardArg, int(spendKeyTTL.Seconds())).Slice()
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to charge project budget: %w", err)
+	}
+	if len(result) != 2 {
+		return Usage{}, fmt.Errorf("unexpected budget script result %v", result)
+	}
+	spendStr, _ := result[0].(string)
+	spend, err := strconv.ParseFloat(spendStr, 64)
+	if err != nil {
+		return Usage{}, fmt.Errorf("invalid project spend %q: %w", spendStr, err)
+	}
+	charged, _ := result[1].(int64)
+
+	return Usage{
+		CostUSD:   costUSD,
+		SpendUSD:  spend,
+		BudgetUSD: budgetUSD,
+		Charged:   charged == 1,
+		day:       day,
+		projectID: projectID,
+	}, nil
+}
+
+// Refund takes a charged trace's cost back off the day it was charged to,
+// for traces that were charged but then not started
+func (t *Tracker) Refund(ctx context.Context, usage Usage) error {
+	if !usage.Charged || usage.CostUSD == 0 {
+		return nil
+	}
+	if err := t.redis.IncrByFloat(ctx, spendKey(usage.projectID, usage.day), -usage.CostUSD).Err(); err != nil {
+		return fmt.Errorf("failed to refund project budget: %w", err)
+	}
+	return nil
+}
+
+// spendKey is the Redis key holding projectID's spend on day
+func spendKey(projectID, day string) string {
+	return keyPrefix + projectID + ":" + day
+}
+
+// formatUSD renders an amount for the charge script without losing precision
+func formatUSD(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
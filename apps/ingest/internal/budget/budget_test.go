@@ -0,0 +1,196 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/webapi"
+)
+
+func TestEstimateCostCachesLookups(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      any
+		want      float64
+		wantErr   bool
+		wantCalls int32
+	}{
+		{
+			name:      "priced model",
+			status:    http.StatusOK,
+			body:      map[string]any{"prices": map[string]any{"gpt-4o": map[string]any{"inputPricePerMillion": 2.5, "outputPricePerMillion": 10}}},
+			want:      1000*2.5/1e6 + 500*10/1e6,
+			wantCalls: 1,
+		},
+		{
+			name:      "unpriced model",
+			status:    http.StatusOK,
+			body:      map[string]any{"prices": map[string]any{}},
+			wantCalls: 1,
+		},
+		{
+			name:      "lookup failure is cached",
+			status:    http.StatusInternalServerError,
+			body:      map[string]any{"success": false, "error": "Internal server error"},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "lookup error is cached",
+			status:    http.StatusBadRequest,
+			body:      map[string]any{"success": false, "error": "Invalid request"},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_ = json.NewEncoder(w).Encode(tt.body)
+			}))
+			defer srv.Close()
+
+			cfg := &config.Config{WebAPIURL: srv.URL, InternalAPISecrets: []string{"secret"}}
+			tracker, err := New("redis://localhost:6379", webapi.New(cfg))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer tracker.Close()
+
+			spans := []temporal.SpanInput{{Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 500}}
+			for range 2 {
+				got, err := tracker.EstimateCost(context.Background(), spans)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("EstimateCost() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("EstimateCost() = %v, want %v", got, tt.want)
+				}
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("pricing endpoint called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// newTestTracker creates a Tracker on an in-memory Redis, without prices
+func newTestTracker(t *testing.T) (*Tracker, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	tracker, err := New("redis://"+mr.Addr(), nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = tracker.Close() })
+	return tracker, mr
+}
+
+func TestCharge(t *testing.T) {
+	// Binary fractions, so sums compare exactly
+	const budgetUSD, spentUSD, epsilon = 1.0, 0.75, 1.0 / 1024
+
+	tests := []struct {
+		name        string
+		hard        bool
+		cost        float64
+		wantCharged bool
+		wantSpend   float64
+		wantOver    bool
+	}{
+		{name: "hard below the budget", hard: true, cost: 0.25 - epsilon, wantCharged: true, wantSpend: budgetUSD - epsilon},
+		// Spend equal to the budget is within it
+		{name: "hard at the budget", hard: true, cost: 0.25, wantCharged: true, wantSpend: budgetUSD},
+		{name: "hard above the budget", hard: true, cost: 0.25 + epsilon, wantSpend: spentUSD},
+		{name: "soft below the budget", cost: 0.25 - epsilon, wantCharged: true, wantSpend: budgetUSD - epsilon},
+		{name: "soft at the budget", cost: 0.25, wantCharged: true, wantSpend: budgetUSD},
+		{name: "soft above the budget", cost: 0.25 + epsilon, wantCharged: true, wantSpend: budgetUSD + epsilon, wantOver: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tracker, mr := newTestTracker(t)
+			if _, err := tracker.Charge(ctx, "proj-1", spentUSD, budgetUSD, tt.hard); err != nil {
+				t.Fatalf("Charge(spent): %v", err)
+			}
+
+			usage, err := tracker.Charge(ctx, "proj-1", tt.cost, budgetUSD, tt.hard)
+			if err != nil {
+				t.Fatalf("Charge: %v", err)
+			}
+			if usage.Charged != tt.wantCharged {
+				t.Errorf("Charged = %v, want %v", usage.Charged, tt.wantCharged)
+			}
+			if usage.SpendUSD != tt.wantSpend || usage.BudgetUSD != budgetUSD || usage.CostUSD != tt.cost {
+				t.Errorf("usage = %+v, want spend %g of %g", usage, tt.wantSpend, budgetUSD)
+			}
+			if usage.Exceeded() != tt.wantOver {
+				t.Errorf("Exceeded() = %v, want %v", usage.Exceeded(), tt.wantOver)
+			}
+
+			key := spendKey("proj-1", usage.day)
+			stored, err := mr.Get(key)
+			if err != nil {
+				t.Fatalf("read spend: %v", err)
+			}
+			if got, _ := strconv.ParseFloat(stored, 64); got != tt.wantSpend {
+				t.Errorf("stored spend = %s, want %g", stored, tt.wantSpend)
+			}
+			if ttl := mr.TTL(key); ttl != spendKeyTTL {
+				t.Errorf("spend key TTL = %s, want %s", ttl, spendKeyTTL)
+			}
+		})
+	}
+}
+
+func TestRefund(t *testing.T) {
+	tests := []struct {
+		name      string
+		hard      bool
+		cost      float64
+		wantSpend float64
+	}{
+		{name: "charged", cost: 0.25, wantSpend: 0.5},
+		{name: "free trace", wantSpend: 0.5},
+		// A refused charge was never added
+		{name: "refused", hard: true, cost: 0.75, wantSpend: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tracker, mr := newTestTracker(t)
+			if _, err := tracker.Charge(ctx, "proj-1", 0.5, 1, tt.hard); err != nil {
+				t.Fatalf("Charge(spent): %v", err)
+			}
+			usage, err := tracker.Charge(ctx, "proj-1", tt.cost, 1, tt.hard)
+			if err != nil {
+				t.Fatalf("Charge: %v", err)
+			}
+
+			if err := tracker.Refund(ctx, usage); err != nil {
+				t.Fatalf("Refund: %v", err)
+			}
+			stored, _ := mr.Get(spendKey("proj-1", usage.day))
+			if got, _ := strconv.ParseFloat(stored, 64); got != tt.wantSpend {
+				t.Errorf("spend after refund = %s, want %g", stored, tt.wantSpend)
+			}
+		})
+	}
+}
@@ -1,13 +1,55 @@
 package config
 
 import (
+	"crypto/subtle"
+	"crypto/tls"
 	"fmt"
+	"math"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cognobserve/ingest/internal/idgen"
 )
 
 const Version = "0.1.0"
 
+// APIVersion is the version of the public ingest API
+const APIVersion = "v1"
+
+// supportedContentTypes are the trace request body media types the ingest
+// handlers can decode
+var supportedContentTypes = []string{"application/json", "application/protobuf", "application/x-protobuf"}
+
+// supportedJWTAlgorithms are the signing algorithms the token verifier can check
+var supportedJWTAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+// defaultConcurrentRequestsPerCPU sizes MaxConcurrentRequests when unset
+const defaultConcurrentRequestsPerCPU = 128
+
+// maxUploadSessionBytesLimit keeps a committed upload session under
+// Temporal's 2 MiB payload limit once started as one workflow, leaving
+// room for the IDs and metadata added during normalization
+const maxUploadSessionBytesLimit = 1536 * 1024
+
+// Build metadata, injected at build time via ldflags:
+//
+//	go build -ldflags "-X github.com/cognobserve/ingest/internal/config.Commit=$(git rev-parse HEAD)"
+var (
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
 // Config holds all configuration for the ingest service.
 // Uses struct tags for validation (similar to @t3-oss/env-nextjs).
 //
@@ -19,21 +61,270 @@ type Config struct {
 	Port    string `env:"PORT" envDefault:"8080"`
 	Version string `env:"-"` // Set programmatically
 
+	// gRPC server for internal services (empty = disabled)
+	GRPCPort string `env:"GRPC_PORT" envDefault:"9090"`
+
+	// Maximum API requests processed at once (0 = based on CPU count)
+	MaxConcurrentRequests int `env:"MAX_CONCURRENT_REQUESTS" envDefault:"0"`
+
+	// API responses smaller than this are sent uncompressed
+	CompressionMinSize int `env:"COMPRESSION_MIN_SIZE" envDefault:"1024"`
+
+	// Maximum API request body size in bytes as sent, before decompression
+	MaxRawRequestBytes int64 `env:"MAX_RAW_REQUEST_BYTES" envDefault:"33554432"`
+
+	// Maximum size in bytes of a gzip request body once inflated
+	MaxDecompressedRequestBytes int64 `env:"MAX_DECOMPRESSED_REQUEST_BYTES" envDefault:"134217728"`
+
 	// Web API (for internal validation calls)
 	WebAPIURL string `env:"WEB_API_URL" envDefault:"http://localhost:3000"`
+	// Path of the API key validation endpoint, including any base-path prefix
+	InternalValidateKeyPath string `env:"INTERNAL_VALIDATE_KEY_PATH" envDefault:"/api/internal/validate-key"`
 
 	// Security - Required, injected via Doppler in production
-	InternalAPISecret string `env:"INTERNAL_API_SECRET,required"`
-	JWTSharedSecret   string `env:"JWT_SHARED_SECRET,required"`
+	// INTERNAL_API_SECRET accepts "primary,previous" during secret rotation;
+	// the primary must be at least 32 characters
+	InternalAPISecrets []string `env:"INTERNAL_API_SECRET,required" envSeparator:","`
+	JWTSharedSecret    string   `env:"JWT_SHARED_SECRET,required"`
+
+	// Trust auth done by an API gateway that sends UPSTREAM_AUTH_SECRET in
+	// X-Upstream-Auth-Secret along with X-Forwarded-Project-ID
+	TrustUpstreamAuth  bool   `env:"TRUST_UPSTREAM_AUTH" envDefault:"false"`
+	UpstreamAuthSecret string `env:"UPSTREAM_AUTH_SECRET"`
+
+	// Signing algorithms accepted on user JWTs
+	JWTAllowedAlgorithms []string `env:"JWT_ALLOWED_ALGORITHMS" envSeparator:"," envDefault:"HS256,HS384,HS512"`
+
+	// Public keys for RS*/PS*/ES* tokens (e.g. SSO provider)
+	JWTJWKSURL             string        `env:"JWT_JWKS_URL"`
+	JWTJWKSRefreshInterval time.Duration `env:"JWT_JWKS_REFRESH_INTERVAL" envDefault:"10m"`
+
+	// Expected iss and aud claims on every user JWT, checked only when set,
+	// so tokens minted for another service with the same secret are rejected
+	JWTIssuer   string `env:"JWT_ISSUER"`
+	JWTAudience string `env:"JWT_AUDIENCE"`
+
+	// Claim holding the user's project memberships, as a dotted path for
+	// providers that nest it (e.g. "app_metadata.projects")
+	JWTProjectsClaim string `env:"JWT_PROJECTS_CLAIM" envDefault:"projects"`
+
+	// Optional mTLS for internal calls to the web API (PEM file paths)
+	// Certificate and key must be set together; the CA defaults to system roots
+	InternalClientCert string      `env:"INTERNAL_CLIENT_CERT"`
+	InternalClientKey  string      `env:"INTERNAL_CLIENT_KEY"`
+	InternalCACert     string      `env:"INTERNAL_CA_CERT"`
+	InternalTLSConfig  *tls.Config `env:"-"` // Built from the files above, nil when unset
 
 	// API Key Configuration (matches web app env)
 	APIKeyPrefix            string `env:"API_KEY_PREFIX" envDefault:"co_sk_"`
 	APIKeyRandomBytesLength int    `env:"API_KEY_RANDOM_BYTES_LENGTH" envDefault:"32"`
 
+	// Project IDs supplied via header must match this pattern (cuid, UUID or proj_ prefixed)
+	ProjectIDPattern string         `env:"PROJECT_ID_PATTERN" envDefault:"^[A-Za-z0-9_-]{1,64}$"`
+	ProjectIDRegexp  *regexp.Regexp `env:"-"` // Compiled from ProjectIDPattern
+
 	// Temporal Configuration (required - Temporal is the only queue backend)
 	TemporalAddress   string `env:"TEMPORAL_ADDRESS" envDefault:"localhost:7233"`
 	TemporalNamespace string `env:"TEMPORAL_NAMESPACE" envDefault:"default"`
 	TemporalTaskQueue string `env:"TEMPORAL_TASK_QUEUE" envDefault:"cognobserve-tasks"`
+
+	// Check at startup that TEMPORAL_NAMESPACE exists; disable where
+	// DescribeNamespace is not permitted
+	TemporalVerifyNamespace bool `env:"TEMPORAL_VERIFY_NAMESPACE" envDefault:"true"`
+
+	// Set search attributes (Environment, Release, Tags, TraceStatus, ProjectID,
+	// SessionID, RetentionDays) on trace workflows. They must be registered on
+	// the namespace first (scripts/temporal-search-attributes.sh), otherwise
+	// every workflow start fails. Session cancellation requires them.
+	TemporalSearchAttributes bool `env:"TEMPORAL_SEARCH_ATTRIBUTES" envDefault:"false"`
+
+	// Maximum concurrent workflow starts for batch ingestion (shared across requests)
+	TemporalMaxConcurrentStarts int `env:"TEMPORAL_MAX_CONCURRENT_STARTS" envDefault:"32"`
+
+	// How long after a trace workflow completes its trace ID is still answered
+	// as a duplicate; 0 keeps it until the namespace retention removes the run.
+	// A trace whose workflow failed can always be resubmitted.
+	TraceDuplicateWindow time.Duration `env:"TRACE_DUPLICATE_WINDOW" envDefault:"0"`
+
+	// Temporal connection watchdog (re-dials after sustained health check failures)
+	TemporalHealthCheckInterval    time.Duration `env:"TEMPORAL_HEALTH_CHECK_INTERVAL" envDefault:"15s"`
+	TemporalHealthFailureThreshold int           `env:"TEMPORAL_HEALTH_FAILURE_THRESHOLD" envDefault:"3"`
+
+	// Liveness heartbeat: /health/live fails once the heartbeat goroutine
+	// hasn't beaten or answered for LIVENESS_STALE_AFTER
+	LivenessInterval   time.Duration `env:"LIVENESS_INTERVAL" envDefault:"1s"`
+	LivenessStaleAfter time.Duration `env:"LIVENESS_STALE_AFTER" envDefault:"10s"`
+
+	// Advisory estimated_ready_at on trace responses: the watchdog samples the
+	// task queue backlog, and each trace is assumed to take the processing time
+	EstimateReadyAt         bool          `env:"ESTIMATE_READY_AT" envDefault:"false"`
+	EstimatedProcessingTime time.Duration `env:"ESTIMATED_PROCESSING_TIME" envDefault:"2s"`
+
+	// Trace Defaults
+	DefaultEnvironment string `env:"DEFAULT_ENVIRONMENT" envDefault:"production"`
+
+	// Dev only: ingest traces without a project ID into the "default" project
+	AllowDefaultProject bool `env:"ALLOW_DEFAULT_PROJECT" envDefault:"false"`
+
+	// Accept traces without spans by default (otherwise clients opt in per request)
+	AllowEmptyTraces bool `env:"ALLOW_EMPTY_TRACES" envDefault:"false"`
+
+	// Reject span inputs and outputs that aren't JSON objects instead of
+	// wrapping them as {"value": ...}
+	StrictSpanPayloads bool `env:"STRICT_SPAN_PAYLOADS" envDefault:"false"`
+
+	// Maximum number of distinct tags on a trace
+	MaxTagsPerTrace int `env:"MAX_TAGS_PER_TRACE" envDefault:"50"`
+
+	// Maximum number of spans in one trace
+	MaxSpansPerTrace int `env:"MAX_SPANS_PER_TRACE" envDefault:"10000"`
+
+	// Maximum depth of a trace's span tree, counting the root as level 1
+	MaxSpanDepth int `env:"MAX_SPAN_DEPTH" envDefault:"100"`
+
+	// Limits on user.metadata, by key count and serialized JSON size in bytes
+	MaxUserMetadataKeys  int `env:"MAX_USER_METADATA_KEYS" envDefault:"50"`
+	MaxUserMetadataBytes int `env:"MAX_USER_METADATA_BYTES" envDefault:"8192"`
+
+	// Accepted span model names, case-insensitive (empty = accept any model)
+	// With ALLOWED_MODELS_SOFT unknown models are flagged instead of rejected
+	AllowedModels     []string            `env:"ALLOWED_MODELS" envSeparator:","`
+	AllowedModelsSoft bool                `env:"ALLOWED_MODELS_SOFT" envDefault:"false"`
+	allowedModelSet   map[string]struct{} // Built from AllowedModels
+
+	// Maximum trace and span name lengths in bytes
+	MaxTraceNameLength int `env:"MAX_TRACE_NAME_LENGTH" envDefault:"512"`
+	MaxSpanNameLength  int `env:"MAX_SPAN_NAME_LENGTH" envDefault:"512"`
+
+	// Reorder each trace's spans by start time (parents first on ties).
+	// Response span_ids then follow the sorted order.
+	SortSpansByStartTime bool `env:"SORT_SPANS_BY_START_TIME" envDefault:"false"`
+
+	// Maximum session_id and user_id length in bytes
+	MaxExternalIDLength int `env:"MAX_EXTERNAL_ID_LENGTH" envDefault:"256"`
+
+	// Span attachments: inline base64 content above the byte limit must be sent as a URL
+	MaxAttachmentsPerSpan    int `env:"MAX_ATTACHMENTS_PER_SPAN" envDefault:"20"`
+	MaxInlineAttachmentBytes int `env:"MAX_INLINE_ATTACHMENT_BYTES" envDefault:"65536"`
+
+	// Span model_parameters limits: serialized JSON size in bytes and nesting depth
+	MaxModelParametersBytes int `env:"MAX_MODEL_PARAMETERS_BYTES" envDefault:"16384"`
+	MaxModelParametersDepth int `env:"MAX_MODEL_PARAMETERS_DEPTH" envDefault:"5"`
+
+	// Redact model_parameters values whose key matches the pattern (case-insensitive,
+	// matched anywhere in the key), so accidentally forwarded credentials aren't stored
+	ScrubModelParameters bool           `env:"SCRUB_MODEL_PARAMETERS" envDefault:"true"`
+	SensitiveKeyPattern  string         `env:"SENSITIVE_KEY_PATTERN" envDefault:"api[_-]?key|authorization|secret"`
+	SensitiveKeyRegexp   *regexp.Regexp `env:"-"` // Compiled from SensitiveKeyPattern
+
+	// Share of total_tokens priced as prompt tokens when a span reports only a total
+	CostBlendedPromptRatio float64 `env:"COST_BLENDED_PROMPT_RATIO" envDefault:"0.75"`
+
+	// Spans longer than this are flagged (not rejected) as a duration anomaly
+	MaxPlausibleSpanDuration time.Duration `env:"MAX_PLAUSIBLE_SPAN_DURATION" envDefault:"1h"`
+
+	// Validate span outputs against per-project schemas from the web API
+	// (projects opt in through their schema registry)
+	OutputSchemaValidation bool          `env:"OUTPUT_SCHEMA_VALIDATION" envDefault:"false"`
+	OutputSchemaCacheTTL   time.Duration `env:"OUTPUT_SCHEMA_CACHE_TTL" envDefault:"1m"`
+
+	// Daily cost budget per project: "off", "soft" (traces over budget are
+	// flagged and accepted) or "hard" (rejected with 402). A project's own
+	// budget from API key validation overrides COST_BUDGET_DAILY_USD; 0 means
+	// no budget. Spend is estimated at ingest and tracked in Redis.
+	CostBudgetMode     string  `env:"COST_BUDGET_MODE" envDefault:"off"`
+	CostBudgetDailyUSD float64 `env:"COST_BUDGET_DAILY_USD" envDefault:"0"`
+
+	// Attach the client's IP address, and with GEOIP_DATABASE its coarse
+	// location, to trace metadata for abuse analysis. Needs privacy review
+	// before enabling. CLIENT_IP_PRECISION "coarse" records only the /24
+	// (IPv4) or /48 (IPv6) network; "full" records the address.
+	// GEOIP_DATABASE is a CSV of network,country[,region] rows.
+	EnrichClientIP    bool   `env:"ENRICH_CLIENT_IP" envDefault:"false"`
+	ClientIPPrecision string `env:"CLIENT_IP_PRECISION" envDefault:"coarse"`
+	GeoIPDatabase     string `env:"GEOIP_DATABASE"`
+
+	// Redis (optional, required by TRACE_DEDUP_ENABLED, UPLOAD_SESSIONS_ENABLED, COST_BUDGET_MODE and AUDIT_SINK=redis)
+	RedisURL string `env:"REDIS_URL"`
+
+	// Drop traces whose content repeats within the window (client double-sends)
+	TraceDedupEnabled bool          `env:"TRACE_DEDUP_ENABLED" envDefault:"false"`
+	TraceDedupWindow  time.Duration `env:"TRACE_DEDUP_WINDOW" envDefault:"30s"`
+
+	// Upload sessions: traces too large for one request are sent as span
+	// chunks held in Redis and started as one workflow on commit
+	UploadSessionsEnabled bool          `env:"UPLOAD_SESSIONS_ENABLED" envDefault:"false"`
+	UploadSessionTTL      time.Duration `env:"UPLOAD_SESSION_TTL" envDefault:"15m"`
+	MaxUploadSessionBytes int64         `env:"MAX_UPLOAD_SESSION_BYTES" envDefault:"1572864"`
+
+	// Server-generated trace/span/score IDs: "random-hex" (ID_LENGTH random
+	// bytes, hex-encoded) or "uuidv7" (time-ordered, ID_LENGTH is ignored)
+	IDStrategy string `env:"ID_STRATEGY" envDefault:"random-hex"`
+	IDLength   int    `env:"ID_LENGTH" envDefault:"16"`
+
+	// Media types accepted on single-trace request bodies; a missing
+	// Content-Type is treated as application/json
+	AcceptedContentTypes []string `env:"ACCEPTED_CONTENT_TYPES" envSeparator:"," envDefault:"application/json,application/protobuf,application/x-protobuf"`
+
+	// Reject request bodies containing fields the API doesn't define
+	JSONDisallowUnknownFields bool `env:"JSON_DISALLOW_UNKNOWN_FIELDS" envDefault:"false"`
+
+	// How numbers in free-form JSON (metadata, span input/output, model
+	// parameters) are decoded: "float" as float64, which rounds integers
+	// beyond 2^53, or "exact" to keep them as written
+	JSONNumberMode string `env:"JSON_NUMBER_MODE" envDefault:"float"`
+
+	// Request handling timeouts for /v1 routes. Synchronous ?wait=true
+	// requests get WAIT_REQUEST_TIMEOUT; 0 disables a timeout.
+	RequestTimeout     time.Duration `env:"REQUEST_TIMEOUT" envDefault:"30s"`
+	WaitRequestTimeout time.Duration `env:"WAIT_REQUEST_TIMEOUT" envDefault:"2m"`
+
+	// Log a warning for requests slower than this; 0 disables
+	SlowRequestThreshold time.Duration `env:"SLOW_REQUEST_THRESHOLD" envDefault:"0"`
+
+	// Bound trace workflow runs by the time left on the request's deadline,
+	// clamped to [MIN, MAX], so a caller's tight deadline isn't outlived by
+	// a long-running workflow. The deadline is the client's
+	// X-Request-Timeout-Ms when that is sooner, else REQUEST_TIMEOUT
+	// (WAIT_REQUEST_TIMEOUT for ?wait=true); gRPC uses the call's deadline.
+	// Without any deadline, runs keep the default timeout. MIN must be
+	// below REQUEST_TIMEOUT, or every run would get MIN.
+	WorkflowTimeoutFromDeadline bool          `env:"WORKFLOW_TIMEOUT_FROM_DEADLINE" envDefault:"false"`
+	WorkflowMinRunTimeout       time.Duration `env:"WORKFLOW_MIN_RUN_TIMEOUT" envDefault:"5s"`
+	WorkflowMaxRunTimeout       time.Duration `env:"WORKFLOW_MAX_RUN_TIMEOUT" envDefault:"5m"`
+
+	// Notify project webhooks (from API key validation) of ingested traces
+	WebhooksEnabled    bool          `env:"WEBHOOKS_ENABLED" envDefault:"false"`
+	WebhookWorkers     int           `env:"WEBHOOK_WORKERS" envDefault:"4"`
+	WebhookQueueSize   int           `env:"WEBHOOK_QUEUE_SIZE" envDefault:"1000"`
+	WebhookMaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"3"`
+	WebhookTimeout     time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"5s"`
+
+	// Maintenance mode rejects /v1 and gRPC ingest with a retryable 503.
+	// On SIGHUP it is reloaded: on while MAINTENANCE_FILE exists.
+	MaintenanceMode       bool          `env:"MAINTENANCE_MODE" envDefault:"false"`
+	MaintenanceFile       string        `env:"MAINTENANCE_FILE"`
+	MaintenanceRetryAfter time.Duration `env:"MAINTENANCE_RETRY_AFTER" envDefault:"30s"`
+
+	// Serve net/http/pprof under /debug/pprof (requires X-Internal-Secret)
+	PprofEnabled bool `env:"PPROF_ENABLED" envDefault:"false"`
+
+	// Trailing slashes on request paths: "strip" (/v1/traces/ is served as
+	// /v1/traces), "redirect" (308 to the canonical path) or "strict" (404)
+	TrailingSlash string `env:"TRAILING_SLASH" envDefault:"strip"`
+
+	// Batch Ingestion
+	MaxBatchSize int `env:"MAX_BATCH_SIZE" envDefault:"500"`
+
+	// Idempotency-Key retention (in-memory, per instance)
+	IdempotencyTTL     time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	IdempotencyMaxKeys int           `env:"IDEMPOTENCY_MAX_KEYS" envDefault:"10000"`
+
+	// Auth decision audit log: "stdout", "redis" (list at AUDIT_REDIS_KEY) or "none"
+	AuditSink           string `env:"AUDIT_SINK" envDefault:"stdout"`
+	AuditBufferSize     int    `env:"AUDIT_BUFFER_SIZE" envDefault:"1024"`
+	AuditRedisKey       string `env:"AUDIT_REDIS_KEY" envDefault:"ingest:audit:auth"`
+	AuditRedisMaxEvents int    `env:"AUDIT_REDIS_MAX_EVENTS" envDefault:"100000"`
 }
 
 // Load parses environment variables into Config struct.
@@ -47,23 +338,364 @@ func Load() (*Config, error) {
 
 	cfg.Version = Version
 
+	if cfg.MaxConcurrentRequests == 0 {
+		cfg.MaxConcurrentRequests = runtime.NumCPU() * defaultConcurrentRequestsPerCPU
+	}
+
+	for i, secret := range cfg.InternalAPISecrets {
+		cfg.InternalAPISecrets[i] = strings.TrimSpace(secret)
+	}
+
+	for _, model := range cfg.AllowedModels {
+		if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+			if cfg.allowedModelSet == nil {
+				cfg.allowedModelSet = make(map[string]struct{})
+			}
+			cfg.allowedModelSet[model] = struct{}{}
+		}
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
+	cfg.ProjectIDRegexp = regexp.MustCompile(anchorPattern(cfg.ProjectIDPattern))
+	cfg.SensitiveKeyRegexp = regexp.MustCompile("(?i)" + cfg.SensitiveKeyPattern)
+
+	tlsConfig, err := cfg.loadInternalTLS()
+	if err != nil {
+		return nil, err
+	}
+	cfg.InternalTLSConfig = tlsConfig
+
 	return cfg, nil
 }
 
 // Validate performs additional validation beyond struct tags.
 func (c *Config) Validate() error {
-	if len(c.InternalAPISecret) < 32 {
-		return fmt.Errorf("INTERNAL_API_SECRET must be at least 32 characters (got %d)", len(c.InternalAPISecret))
+	if c.MaxConcurrentRequests < 1 {
+		return fmt.Errorf("MAX_CONCURRENT_REQUESTS must be at least 1 (got %d)", c.MaxConcurrentRequests)
+	}
+	if c.MaxRawRequestBytes < 1 {
+		return fmt.Errorf("MAX_RAW_REQUEST_BYTES must be at least 1 (got %d)", c.MaxRawRequestBytes)
+	}
+	if c.MaxDecompressedRequestBytes < 1 {
+		return fmt.Errorf("MAX_DECOMPRESSED_REQUEST_BYTES must be at least 1 (got %d)", c.MaxDecompressedRequestBytes)
+	}
+	if c.CompressionMinSize < 0 {
+		return fmt.Errorf("COMPRESSION_MIN_SIZE must not be negative (got %d)", c.CompressionMinSize)
+	}
+	if len(c.InternalAPISecrets) == 0 || len(c.InternalAPISecrets) > 2 {
+		return fmt.Errorf("INTERNAL_API_SECRET must contain one or two comma-separated secrets (got %d)", len(c.InternalAPISecrets))
+	}
+	for _, secret := range c.InternalAPISecrets {
+		if secret == "" {
+			return fmt.Errorf("INTERNAL_API_SECRET must not contain empty secrets")
+		}
+	}
+	// The primary secret is sent on outgoing calls; a short previous secret is
+	// still accepted until the rotation completes
+	if primary := c.InternalAPISecrets[0]; len(primary) < 32 {
+		return fmt.Errorf("INTERNAL_API_SECRET primary secret must be at least 32 characters (got %d)", len(primary))
 	}
 	if len(c.JWTSharedSecret) < 32 {
 		return fmt.Errorf("JWT_SHARED_SECRET must be at least 32 characters (got %d)", len(c.JWTSharedSecret))
 	}
+	if c.TrustUpstreamAuth && len(c.UpstreamAuthSecret) < 32 {
+		return fmt.Errorf("UPSTREAM_AUTH_SECRET must be at least 32 characters when TRUST_UPSTREAM_AUTH is set (got %d)", len(c.UpstreamAuthSecret))
+	}
+	if len(c.AcceptedContentTypes) == 0 {
+		return fmt.Errorf("ACCEPTED_CONTENT_TYPES must not be empty")
+	}
+	for _, contentType := range c.AcceptedContentTypes {
+		if !slices.Contains(supportedContentTypes, contentType) {
+			return fmt.Errorf("ACCEPTED_CONTENT_TYPES contains unsupported type %q (supported: %s)", contentType, strings.Join(supportedContentTypes, ", "))
+		}
+	}
+	if len(c.JWTAllowedAlgorithms) == 0 {
+		return fmt.Errorf("JWT_ALLOWED_ALGORITHMS must not be empty")
+	}
+	for _, alg := range c.JWTAllowedAlgorithms {
+		if !slices.Contains(supportedJWTAlgorithms, alg) {
+			return fmt.Errorf("JWT_ALLOWED_ALGORITHMS contains unsupported algorithm %q (supported: %s)", alg, strings.Join(supportedJWTAlgorithms, ", "))
+		}
+		if !strings.HasPrefix(alg, "HS") && c.JWTJWKSURL == "" {
+			return fmt.Errorf("JWT_ALLOWED_ALGORITHMS contains %s, which requires JWT_JWKS_URL", alg)
+		}
+	}
+	if slices.Contains(strings.Split(c.JWTProjectsClaim, "."), "") {
+		return fmt.Errorf("JWT_PROJECTS_CLAIM must be a claim name or dotted path (got %q)", c.JWTProjectsClaim)
+	}
+	if c.JWTJWKSURL != "" && c.JWTJWKSRefreshInterval <= 0 {
+		return fmt.Errorf("JWT_JWKS_REFRESH_INTERVAL must be positive (got %s)", c.JWTJWKSRefreshInterval)
+	}
+	if c.APIKeyPrefix == "" {
+		return fmt.Errorf("API_KEY_PREFIX must not be empty")
+	}
 	if c.APIKeyRandomBytesLength < 16 || c.APIKeyRandomBytesLength > 64 {
 		return fmt.Errorf("API_KEY_RANDOM_BYTES_LENGTH must be between 16 and 64 (got %d)", c.APIKeyRandomBytesLength)
 	}
+	if _, err := regexp.Compile(anchorPattern(c.ProjectIDPattern)); err != nil {
+		return fmt.Errorf("PROJECT_ID_PATTERN is not a valid regular expression: %w", err)
+	}
+	if c.TemporalHealthCheckInterval <= 0 {
+		return fmt.Errorf("TEMPORAL_HEALTH_CHECK_INTERVAL must be positive (got %s)", c.TemporalHealthCheckInterval)
+	}
+	if c.TemporalHealthFailureThreshold < 1 {
+		return fmt.Errorf("TEMPORAL_HEALTH_FAILURE_THRESHOLD must be at least 1 (got %d)", c.TemporalHealthFailureThreshold)
+	}
+	if c.LivenessInterval <= 0 {
+		return fmt.Errorf("LIVENESS_INTERVAL must be positive (got %s)", c.LivenessInterval)
+	}
+	if c.LivenessStaleAfter <= c.LivenessInterval {
+		return fmt.Errorf("LIVENESS_STALE_AFTER must be greater than LIVENESS_INTERVAL (got %s, interval %s)", c.LivenessStaleAfter, c.LivenessInterval)
+	}
+	if c.EstimateReadyAt && c.EstimatedProcessingTime < 0 {
+		return fmt.Errorf("ESTIMATED_PROCESSING_TIME must not be negative (got %s)", c.EstimatedProcessingTime)
+	}
+	if c.TraceDuplicateWindow < 0 {
+		return fmt.Errorf("TRACE_DUPLICATE_WINDOW must not be negative (got %s)", c.TraceDuplicateWindow)
+	}
+	if c.TemporalMaxConcurrentStarts < 1 {
+		return fmt.Errorf("TEMPORAL_MAX_CONCURRENT_STARTS must be at least 1 (got %d)", c.TemporalMaxConcurrentStarts)
+	}
+	if c.MaxBatchSize < 1 {
+		return fmt.Errorf("MAX_BATCH_SIZE must be at least 1 (got %d)", c.MaxBatchSize)
+	}
+	if c.OutputSchemaCacheTTL <= 0 {
+		return fmt.Errorf("OUTPUT_SCHEMA_CACHE_TTL must be positive (got %s)", c.OutputSchemaCacheTTL)
+	}
+	switch c.IDStrategy {
+	case idgen.StrategyRandomHex:
+		if c.IDLength < idgen.MinRandomBytes || c.IDLength > idgen.MaxRandomBytes {
+			return fmt.Errorf("ID_LENGTH must be between %d and %d (got %d)", idgen.MinRandomBytes, idgen.MaxRandomBytes, c.IDLength)
+		}
+	case idgen.StrategyUUIDv7:
+	default:
+		return fmt.Errorf("ID_STRATEGY must be %s or %s (got %q)", idgen.StrategyRandomHex, idgen.StrategyUUIDv7, c.IDStrategy)
+	}
+	if c.TraceDedupEnabled {
+		if c.RedisURL == "" {
+			return fmt.Errorf("REDIS_URL is required when TRACE_DEDUP_ENABLED is set")
+		}
+		if _, err := redis.ParseURL(c.RedisURL); err != nil {
+			return fmt.Errorf("REDIS_URL is invalid: %w", err)
+		}
+		if c.TraceDedupWindow <= 0 {
+			return fmt.Errorf("TRACE_DEDUP_WINDOW must be positive (got %s)", c.TraceDedupWindow)
+		}
+	}
+	if c.UploadSessionsEnabled {
+		if c.RedisURL == "" {
+			return fmt.Errorf("REDIS_URL is required when UPLOAD_SESSIONS_ENABLED is set")
+		}
+		if _, err := redis.ParseURL(c.RedisURL); err != nil {
+			return fmt.Errorf("REDIS_URL is invalid: %w", err)
+		}
+		if c.UploadSessionTTL <= 0 {
+			return fmt.Errorf("UPLOAD_SESSION_TTL must be positive (got %s)", c.UploadSessionTTL)
+		}
+		if c.MaxUploadSessionBytes < 1 || c.MaxUploadSessionBytes > maxUploadSessionBytesLimit {
+			return fmt.Errorf("MAX_UPLOAD_SESSION_BYTES must be between 1 and %d to fit a Temporal payload (got %d)", maxUploadSessionBytesLimit, c.MaxUploadSessionBytes)
+		}
+	}
+	if c.ClientIPPrecision != "coarse" && c.ClientIPPrecision != "full" {
+		return fmt.Errorf("CLIENT_IP_PRECISION must be coarse or full (got %q)", c.ClientIPPrecision)
+	}
+	if c.JSONNumberMode != "float" && c.JSONNumberMode != "exact" {
+		return fmt.Errorf("JSON_NUMBER_MODE must be float or exact (got %q)", c.JSONNumberMode)
+	}
+	switch c.CostBudgetMode {
+	case "off":
+	case "soft", "hard":
+		if c.RedisURL == "" {
+			return fmt.Errorf("REDIS_URL is required when COST_BUDGET_MODE is %s", c.CostBudgetMode)
+		}
+		if _, err := redis.ParseURL(c.RedisURL); err != nil {
+			return fmt.Errorf("REDIS_URL is invalid: %w", err)
+		}
+	default:
+		return fmt.Errorf("COST_BUDGET_MODE must be off, soft or hard (got %q)", c.CostBudgetMode)
+	}
+	if c.CostBudgetDailyUSD < 0 || math.IsNaN(c.CostBudgetDailyUSD) || math.IsInf(c.CostBudgetDailyUSD, 0) {
+		return fmt.Errorf("COST_BUDGET_DAILY_USD must be a non-negative number (got %g)", c.CostBudgetDailyUSD)
+	}
+	if c.WebhooksEnabled {
+		if c.WebhookWorkers < 1 {
+			return fmt.Errorf("WEBHOOK_WORKERS must be at least 1 (got %d)", c.WebhookWorkers)
+		}
+		if c.WebhookQueueSize < 1 {
+			return fmt.Errorf("WEBHOOK_QUEUE_SIZE must be at least 1 (got %d)", c.WebhookQueueSize)
+		}
+		if c.WebhookMaxAttempts < 1 {
+			return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be at least 1 (got %d)", c.WebhookMaxAttempts)
+		}
+		if c.WebhookTimeout <= 0 {
+			return fmt.Errorf("WEBHOOK_TIMEOUT must be positive (got %s)", c.WebhookTimeout)
+		}
+	}
+	if c.MaintenanceRetryAfter < time.Second {
+		return fmt.Errorf("MAINTENANCE_RETRY_AFTER must be at least 1s (got %s)", c.MaintenanceRetryAfter)
+	}
+	if c.RequestTimeout < 0 || c.WaitRequestTimeout < 0 {
+		return fmt.Errorf("REQUEST_TIMEOUT and WAIT_REQUEST_TIMEOUT must not be negative")
+	}
+	if c.SlowRequestThreshold < 0 {
+		return fmt.Errorf("SLOW_REQUEST_THRESHOLD must not be negative (got %s)", c.SlowRequestThreshold)
+	}
+	if c.WorkflowTimeoutFromDeadline {
+		if c.WorkflowMinRunTimeout <= 0 {
+			return fmt.Errorf("WORKFLOW_MIN_RUN_TIMEOUT must be positive (got %s)", c.WorkflowMinRunTimeout)
+		}
+		if c.WorkflowMaxRunTimeout < c.WorkflowMinRunTimeout {
+			return fmt.Errorf("WORKFLOW_MAX_RUN_TIMEOUT must not be less than WORKFLOW_MIN_RUN_TIMEOUT (got %s, min %s)", c.WorkflowMaxRunTimeout, c.WorkflowMinRunTimeout)
+		}
+		if c.RequestTimeout > 0 && c.WorkflowMinRunTimeout >= c.RequestTimeout {
+			return fmt.Errorf("WORKFLOW_MIN_RUN_TIMEOUT must be less than REQUEST_TIMEOUT, or every run gets the minimum (got %s, REQUEST_TIMEOUT %s)", c.WorkflowMinRunTimeout, c.RequestTimeout)
+		}
+	}
+
+	switch c.TrailingSlash {
+	case "strip", "redirect", "strict":
+	default:
+		return fmt.Errorf("TRAILING_SLASH must be strip, redirect or strict (got %q)", c.TrailingSlash)
+	}
+	switch c.AuditSink {
+	case "stdout", "none":
+	case "redis":
+		if c.RedisURL == "" {
+			return fmt.Errorf("REDIS_URL is required when AUDIT_SINK is redis")
+		}
+		if _, err := redis.ParseURL(c.RedisURL); err != nil {
+			return fmt.Errorf("REDIS_URL is invalid: %w", err)
+		}
+		if c.AuditRedisKey == "" {
+			return fmt.Errorf("AUDIT_REDIS_KEY must not be empty")
+		}
+		if c.AuditRedisMaxEvents < 1 {
+			return fmt.Errorf("AUDIT_REDIS_MAX_EVENTS must be at least 1 (got %d)", c.AuditRedisMaxEvents)
+		}
+	default:
+		return fmt.Errorf("AUDIT_SINK must be stdout, redis or none (got %q)", c.AuditSink)
+	}
+	if c.AuditBufferSize < 1 {
+		return fmt.Errorf("AUDIT_BUFFER_SIZE must be at least 1 (got %d)", c.AuditBufferSize)
+	}
+	if c.IdempotencyTTL <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_TTL must be positive (got %s)", c.IdempotencyTTL)
+	}
+	if c.IdempotencyMaxKeys < 1 {
+		return fmt.Errorf("IDEMPOTENCY_MAX_KEYS must be at least 1 (got %d)", c.IdempotencyMaxKeys)
+	}
+	if c.DefaultEnvironment == "" {
+		return fmt.Errorf("DEFAULT_ENVIRONMENT must not be empty")
+	}
+	if c.MaxSpansPerTrace < 1 {
+		return fmt.Errorf("MAX_SPANS_PER_TRACE must be at least 1 (got %d)", c.MaxSpansPerTrace)
+	}
+	if c.MaxSpanDepth < 1 {
+		return fmt.Errorf("MAX_SPAN_DEPTH must be at least 1 (got %d)", c.MaxSpanDepth)
+	}
+	if c.MaxUserMetadataKeys < 1 {
+		return fmt.Errorf("MAX_USER_METADATA_KEYS must be at least 1 (got %d)", c.MaxUserMetadataKeys)
+	}
+	if c.MaxUserMetadataBytes < 1 {
+		return fmt.Errorf("MAX_USER_METADATA_BYTES must be at least 1 (got %d)", c.MaxUserMetadataBytes)
+	}
+	if c.MaxTagsPerTrace < 1 {
+		return fmt.Errorf("MAX_TAGS_PER_TRACE must be at least 1 (got %d)", c.MaxTagsPerTrace)
+	}
+	if c.MaxTraceNameLength < 1 {
+		return fmt.Errorf("MAX_TRACE_NAME_LENGTH must be at least 1 (got %d)", c.MaxTraceNameLength)
+	}
+	if c.MaxSpanNameLength < 1 {
+		return fmt.Errorf("MAX_SPAN_NAME_LENGTH must be at least 1 (got %d)", c.MaxSpanNameLength)
+	}
+	if c.MaxExternalIDLength < 1 {
+		return fmt.Errorf("MAX_EXTERNAL_ID_LENGTH must be at least 1 (got %d)", c.MaxExternalIDLength)
+	}
+	if c.MaxAttachmentsPerSpan < 0 {
+		return fmt.Errorf("MAX_ATTACHMENTS_PER_SPAN must not be negative (got %d)", c.MaxAttachmentsPerSpan)
+	}
+	if c.MaxInlineAttachmentBytes < 0 {
+		return fmt.Errorf("MAX_INLINE_ATTACHMENT_BYTES must not be negative (got %d)", c.MaxInlineAttachmentBytes)
+	}
+	if c.MaxModelParametersBytes < 1 {
+		return fmt.Errorf("MAX_MODEL_PARAMETERS_BYTES must be at least 1 (got %d)", c.MaxModelParametersBytes)
+	}
+	if c.MaxModelParametersDepth < 1 {
+		return fmt.Errorf("MAX_MODEL_PARAMETERS_DEPTH must be at least 1 (got %d)", c.MaxModelParametersDepth)
+	}
+	if _, err := regexp.Compile("(?i)" + c.SensitiveKeyPattern); err != nil {
+		return fmt.Errorf("SENSITIVE_KEY_PATTERN is not a valid regular expression: %w", err)
+	}
+	if c.CostBlendedPromptRatio < 0 || c.CostBlendedPromptRatio > 1 {
+		return fmt.Errorf("COST_BLENDED_PROMPT_RATIO must be between 0 and 1 (got %g)", c.CostBlendedPromptRatio)
+	}
+	if c.MaxPlausibleSpanDuration <= 0 {
+		return fmt.Errorf("MAX_PLAUSIBLE_SPAN_DURATION must be positive (got %s)", c.MaxPlausibleSpanDuration)
+	}
+	if !strings.HasPrefix(c.InternalValidateKeyPath, "/") {
+		return fmt.Errorf("INTERNAL_VALIDATE_KEY_PATH must begin with / (got %q)", c.InternalValidateKeyPath)
+	}
+	if (c.InternalClientCert == "") != (c.InternalClientKey == "") {
+		return fmt.Errorf("INTERNAL_CLIENT_CERT and INTERNAL_CLIENT_KEY must be set together")
+	}
+	if (c.InternalClientCert != "" || c.InternalCACert != "") && !strings.HasPrefix(c.WebAPIURL, "https://") {
+		return fmt.Errorf("WEB_API_URL must use https when internal TLS is configured (got %s)", c.WebAPIURL)
+	}
 	return nil
 }
+
+// anchorPattern forces a pattern to match the whole value
+func anchorPattern(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
+// IsModelAllowed reports whether a span model passes the ALLOWED_MODELS list.
+// Every model is allowed when no list is configured.
+func (c *Config) IsModelAllowed(model string) bool {
+	if c.allowedModelSet == nil {
+		return true
+	}
+	_, ok := c.allowedModelSet[strings.ToLower(model)]
+	return ok
+}
+
+// APIKeyMinLength is the length of a generated API key: the prefix plus
+// API_KEY_RANDOM_BYTES_LENGTH random bytes, base62-encoded and zero-padded
+// to ceil(bytes*8/log2(62)) characters, as the web app does
+func (c *Config) APIKeyMinLength() int {
+	encoded := int(math.Ceil(float64(c.APIKeyRandomBytesLength*8) / math.Log2(62)))
+	return len(c.APIKeyPrefix) + encoded
+}
+
+// WriteTimeout is the HTTP server write timeout: the longest request
+// timeout plus time to write the response. It is 0 (unbounded) when a
+// request timeout is disabled, so those requests aren't cut off.
+func (c *Config) WriteTimeout() time.Duration {
+	if c.RequestTimeout == 0 || c.WaitRequestTimeout == 0 {
+		return 0
+	}
+	return max(c.RequestTimeout, c.WaitRequestTimeout) + 5*time.Second
+}
+
+// InternalAPISecret returns the primary internal secret.
+// This is the secret sent on outgoing internal API calls.
+func (c *Config) InternalAPISecret() string {
+	if len(c.InternalAPISecrets) == 0 {
+		return ""
+	}
+	return c.InternalAPISecrets[0]
+}
+
+// IsValidInternalSecret reports whether secret matches any accepted internal secret.
+// Every configured secret is compared in constant time so that rotation state
+// cannot be inferred from response timing.
+func (c *Config) IsValidInternalSecret(secret string) bool {
+	valid := 0
+	for _, accepted := range c.InternalAPISecrets {
+		valid |= subtle.ConstantTimeCompare([]byte(secret), []byte(accepted))
+	}
+	return valid == 1
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// setRequiredEnv sets the variables Load requires
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("INTERNAL_API_SECRET", "test-internal-secret-0123456789abcdef")
+	t.Setenv("JWT_SHARED_SECRET", "test-jwt-secret-0123456789abcdef0123")
+}
+
+func TestLoadUploadSessionBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		bytes   string // MAX_UPLOAD_SESSION_BYTES; empty keeps the default
+		wantErr string
+	}{
+		{name: "default"},
+		{name: "at the limit", bytes: "1572864"},
+		{name: "above the limit", bytes: "1572865", wantErr: "MAX_UPLOAD_SESSION_BYTES must be between 1 and 1572864"},
+		{name: "zero", bytes: "0", wantErr: "MAX_UPLOAD_SESSION_BYTES must be between 1 and 1572864"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("UPLOAD_SESSIONS_ENABLED", "true")
+			t.Setenv("REDIS_URL", "redis://localhost:6379")
+			if tt.bytes != "" {
+				t.Setenv("MAX_UPLOAD_SESSION_BYTES", tt.bytes)
+			}
+
+			_, err := Load()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadProjectIDPattern(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string // PROJECT_ID_PATTERN; empty keeps the default
+		projectID string
+		wantMatch bool
+		wantErr   string
+	}{
+		{name: "default accepts cuid", projectID: "clx1a2b3c0000abcd1234efgh", wantMatch: true},
+		{name: "default rejects slash", projectID: "proj/1"},
+		{name: "custom prefix", pattern: "proj_[a-z0-9]+", projectID: "proj_abc", wantMatch: true},
+		// Unanchored patterns still have to match the whole value
+		{name: "custom anchored", pattern: "proj_[a-z0-9]+", projectID: "x-proj_abc"},
+		{name: "invalid", pattern: "proj_[", wantErr: "PROJECT_ID_PATTERN is not a valid regular expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			if tt.pattern != "" {
+				t.Setenv("PROJECT_ID_PATTERN", tt.pattern)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := cfg.ProjectIDRegexp.MatchString(tt.projectID); got != tt.wantMatch {
+				t.Errorf("match %q = %v, want %v", tt.projectID, got, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMinLength(t *testing.T) {
+	// Matches the web app's generateApiKey: the prefix plus the random
+	// bytes base62-encoded and zero-padded
+	tests := []struct {
+		prefix      string
+		randomBytes int
+		want        int
+	}{
+		{prefix: "co_sk_", randomBytes: 16, want: 28},
+		{prefix: "co_sk_", randomBytes: 32, want: 49},
+		{prefix: "co_sk_", randomBytes: 64, want: 92},
+		{prefix: "acme_", randomBytes: 32, want: 48},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{APIKeyPrefix: tt.prefix, APIKeyRandomBytesLength: tt.randomBytes}
+		if got := cfg.APIKeyMinLength(); got != tt.want {
+			t.Errorf("APIKeyMinLength(%q, %d) = %d, want %d", tt.prefix, tt.randomBytes, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAcceptedContentTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		types   string // ACCEPTED_CONTENT_TYPES; empty keeps the default
+		wantErr string
+	}{
+		{name: "default"},
+		{name: "json only", types: "application/json"},
+		{name: "unsupported", types: "application/json,application/msgpack", wantErr: `ACCEPTED_CONTENT_TYPES contains unsupported type "application/msgpack"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			if tt.types != "" {
+				t.Setenv("ACCEPTED_CONTENT_TYPES", tt.types)
+			}
+
+			_, err := Load()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadWorkflowRunTimeouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		min            string // WORKFLOW_MIN_RUN_TIMEOUT; empty keeps the default
+		requestTimeout string // REQUEST_TIMEOUT; empty keeps the default
+		wantErr        string
+	}{
+		{name: "defaults"},
+		{name: "below the request timeout", min: "29s"},
+		{name: "at the request timeout", min: "30s", wantErr: "WORKFLOW_MIN_RUN_TIMEOUT must be less than REQUEST_TIMEOUT"},
+		{name: "above a shorter request timeout", requestTimeout: "3s", wantErr: "WORKFLOW_MIN_RUN_TIMEOUT must be less than REQUEST_TIMEOUT"},
+		{name: "request timeout disabled", min: "1m", requestTimeout: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("WORKFLOW_TIMEOUT_FROM_DEADLINE", "true")
+			if tt.min != "" {
+				t.Setenv("WORKFLOW_MIN_RUN_TIMEOUT", tt.min)
+			}
+			if tt.requestTimeout != "" {
+				t.Setenv("REQUEST_TIMEOUT", tt.requestTimeout)
+			}
+
+			_, err := Load()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadInternalAPISecrets(t *testing.T) {
+	const strong = "test-internal-secret-0123456789abcdef"
+
+	tests := []struct {
+		name        string
+		secrets     string // INTERNAL_API_SECRET
+		wantPrimary string
+		wantErr     string
+	}{
+		{name: "primary", secrets: strong, wantPrimary: strong},
+		{name: "rotating from a short secret", secrets: strong + ",old-short-secret", wantPrimary: strong},
+		{name: "short primary", secrets: "short-secret", wantErr: "primary secret must be at least 32 characters"},
+		// A strong previous secret doesn't make up for a short primary
+		{name: "short primary with strong previous", secrets: "short-secret," + strong, wantErr: "primary secret must be at least 32 characters"},
+		{name: "empty previous", secrets: strong + ",", wantErr: "must not contain empty secrets"},
+		{name: "three secrets", secrets: strong + "," + strong + "," + strong, wantErr: "one or two comma-separated secrets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("INTERNAL_API_SECRET", tt.secrets)
+
+			cfg, err := Load()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				if got := cfg.InternalAPISecret(); got != tt.wantPrimary {
+					t.Errorf("InternalAPISecret() = %q, want %q", got, tt.wantPrimary)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
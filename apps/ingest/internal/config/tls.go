@@ -0,0 +1,40 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// loadInternalTLS builds the TLS config for internal web API calls.
+// Returns nil when no certificates are configured, keeping plain HTTP.
+func (c *Config) loadInternalTLS() (*tls.Config, error) {
+	if c.InternalClientCert == "" && c.InternalCACert == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.InternalClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.InternalClientCert, c.InternalClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load INTERNAL_CLIENT_CERT/INTERNAL_CLIENT_KEY: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.InternalCACert != "" {
+		pem, err := os.ReadFile(c.InternalCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read INTERNAL_CA_CERT: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("INTERNAL_CA_CERT contains no valid PEM certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,77 @@
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// keyPrefix namespaces fingerprint keys in Redis
+const keyPrefix = "ingest:dedup:"
+
+// Deduplicator drops traces whose content was already received within a
+// short window, catching client retries that regenerate trace and span IDs.
+type Deduplicator struct {
+	redis  *redis.Client
+	window time.Duration
+}
+
+// New creates a Deduplicator storing fingerprints in redisURL for window
+func New(redisURL string, window time.Duration) (*Deduplicator, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	return &Deduplicator{redis: redis.NewClient(opts), window: window}, nil
+}
+
+// Seen records the trace's fingerprint and reports whether it was already
+// recorded within the window. Callers should fail open on error.
+func (d *Deduplicator) Seen(ctx context.Context, input temporal.TraceWorkflowInput) (bool, error) {
+	stored, err := d.redis.SetNX(ctx, keyPrefix+Fingerprint(input), 1, d.window).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record trace fingerprint: %w", err)
+	}
+	return !stored, nil
+}
+
+// Forget removes the trace's fingerprint, so a retry of a send that was
+// recorded by Seen but then not ingested isn't dropped as a repeat
+func (d *Deduplicator) Forget(ctx context.Context, input temporal.TraceWorkflowInput) error {
+	if err := d.redis.Del(ctx, keyPrefix+Fingerprint(input)).Err(); err != nil {
+		return fmt.Errorf("failed to remove trace fingerprint: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis connection
+func (d *Deduplicator) Close() error {
+	return d.redis.Close()
+}
+
+// Fingerprint hashes the parts of a trace a retry would repeat: project,
+// trace name and each span's name and timestamps. Generated IDs are excluded.
+// Timestamps defaulted server-side differ between sends, so only spans with
+// client-supplied times deduplicate reliably.
+func Fingerprint(input temporal.TraceWorkflowInput) string {
+	h := sha256.New()
+	// Length-prefix each field so values can't run into each other
+	write := func(s string) {
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+
+	write(input.ProjectID)
+	write(input.Name)
+	for _, span := range input.Spans {
+		write(span.Name)
+		write(span.StartTime)
+		write(span.EndTime)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,132 @@
+package dedup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+func newTestDeduplicator(t *testing.T) (*Deduplicator, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	d, err := New("redis://"+mr.Addr(), time.Minute)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return d, mr
+}
+
+// testTrace is a trace as a client would send it, with the given IDs
+func testTrace(traceID, spanID string) temporal.TraceWorkflowInput {
+	return temporal.TraceWorkflowInput{
+		ID:        traceID,
+		ProjectID: "proj-1",
+		Name:      "chat",
+		Spans: []temporal.SpanInput{{
+			ID:        spanID,
+			Name:      "llm",
+			StartTime: "2024-05-01T12:00:00Z",
+			EndTime:   "2024-05-01T12:00:01Z",
+		}},
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(*temporal.TraceWorkflowInput)
+		wantEqual bool
+	}{
+		{name: "identical", mutate: func(*temporal.TraceWorkflowInput) {}, wantEqual: true},
+		// A retry regenerating IDs is still the same send
+		{name: "trace id", mutate: func(in *temporal.TraceWorkflowInput) { in.ID = "t2" }, wantEqual: true},
+		{name: "span id", mutate: func(in *temporal.TraceWorkflowInput) { in.Spans[0].ID = "s2" }, wantEqual: true},
+		{name: "project", mutate: func(in *temporal.TraceWorkflowInput) { in.ProjectID = "proj-2" }},
+		{name: "trace name", mutate: func(in *temporal.TraceWorkflowInput) { in.Name = "chat2" }},
+		{name: "span name", mutate: func(in *temporal.TraceWorkflowInput) { in.Spans[0].Name = "tool" }},
+		{name: "span start", mutate: func(in *temporal.TraceWorkflowInput) { in.Spans[0].StartTime = "2024-05-01T12:00:00.5Z" }},
+		{name: "span end", mutate: func(in *temporal.TraceWorkflowInput) { in.Spans[0].EndTime = "" }},
+		{name: "extra span", mutate: func(in *temporal.TraceWorkflowInput) {
+			in.Spans = append(in.Spans, temporal.SpanInput{ID: "s2", Name: "tool", StartTime: "2024-05-01T12:00:00Z"})
+		}},
+		// Fields are length-prefixed, so moving characters between them differs
+		{name: "field boundary", mutate: func(in *temporal.TraceWorkflowInput) {
+			in.Name = "chatl"
+			in.Spans[0].Name = "lm"
+		}},
+	}
+
+	base := Fingerprint(testTrace("t1", "s1"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := testTrace("t1", "s1")
+			tt.mutate(&input)
+			if got := Fingerprint(input) == base; got != tt.wantEqual {
+				t.Errorf("fingerprints equal = %v, want %v", got, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestSeenForget(t *testing.T) {
+	ctx := context.Background()
+	d, mr := newTestDeduplicator(t)
+
+	steps := []struct {
+		action   string // "seen", "forget" or "wait"
+		input    temporal.TraceWorkflowInput
+		wantSeen bool
+	}{
+		{action: "seen", input: testTrace("t1", "s1")},
+		// A retry with regenerated IDs repeats the send
+		{action: "seen", input: testTrace("t2", "s2"), wantSeen: true},
+		{action: "forget", input: testTrace("t3", "s3")},
+		{action: "seen", input: testTrace("t4", "s4")},
+		{action: "seen", input: testTrace("t5", "s5"), wantSeen: true},
+		// The fingerprint expires with the window
+		{action: "wait"},
+		{action: "seen", input: testTrace("t6", "s6")},
+	}
+
+	for i, step := range steps {
+		switch step.action {
+		case "seen":
+			seen, err := d.Seen(ctx, step.input)
+			if err != nil {
+				t.Fatalf("step %d: Seen: %v", i, err)
+			}
+			if seen != step.wantSeen {
+				t.Errorf("step %d: Seen() = %v, want %v", i, seen, step.wantSeen)
+			}
+		case "forget":
+			if err := d.Forget(ctx, step.input); err != nil {
+				t.Fatalf("step %d: Forget: %v", i, err)
+			}
+		case "wait":
+			mr.FastForward(time.Minute)
+		}
+	}
+}
+
+func TestSeenRedisError(t *testing.T) {
+	ctx := context.Background()
+	d, mr := newTestDeduplicator(t)
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+
+	// Callers ingest on error, so Seen must not report a repeat
+	seen, err := d.Seen(ctx, testTrace("t1", "s1"))
+	if err == nil {
+		t.Fatal("Seen() error = nil, want the Redis error")
+	}
+	if seen {
+		t.Error("Seen() = true on error, want false")
+	}
+	if err := d.Forget(ctx, testTrace("t1", "s1")); err == nil {
+		t.Error("Forget() error = nil, want the Redis error")
+	}
+}
@@ -0,0 +1,100 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Location is the coarse origin of an IP address
+type Location struct {
+	Country string `json:"country"`          // ISO 3166-1 alpha-2 code
+	Region  string `json:"region,omitempty"` // Subdivision code, when known
+}
+
+// Lookup resolves IP addresses to locations. Implementations must be safe
+// for concurrent use.
+type Lookup interface {
+	Lookup(addr netip.Addr) (Location, bool)
+}
+
+type entry struct {
+	prefix   netip.Prefix
+	location Location
+}
+
+// Table is an in-memory Lookup over non-overlapping networks
+type Table struct {
+	entries []entry // Sorted by network address
+}
+
+// LoadCSV reads a Table from a CSV file of network,country[,region] rows,
+// e.g. "203.0.113.0/24,NZ,AUK". Blank lines and lines starting with '#'
+// are skipped. Networks must not overlap.
+func LoadCSV(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var entries []entry
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("GeoIP database line %d: expected network,country[,region]", line)
+		}
+		prefix, err := netip.ParsePrefix(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("GeoIP database line %d: %w", line, err)
+		}
+		e := entry{prefix: prefix.Masked(), location: Location{Country: strings.ToUpper(record[1])}}
+		if len(record) == 3 {
+			e.location.Region = strings.ToUpper(record[2])
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].prefix.Addr().Less(entries[j].prefix.Addr())
+	})
+	return &Table{entries: entries}, nil
+}
+
+// Len returns the number of networks in the table
+func (t *Table) Len() int {
+	return len(t.entries)
+}
+
+// Lookup returns the location of the network containing addr
+func (t *Table) Lookup(addr netip.Addr) (Location, bool) {
+	addr = addr.Unmap()
+	// The candidate is the last network starting at or before addr
+	i := sort.Search(len(t.entries), func(i int) bool {
+		return addr.Less(t.entries[i].prefix.Addr())
+	})
+	if i == 0 {
+		return Location{}, false
+	}
+	if e := t.entries[i-1]; e.prefix.Contains(addr) {
+		return e.location, true
+	}
+	return Location{}, false
+}
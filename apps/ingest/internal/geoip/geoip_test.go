@@ -0,0 +1,81 @@
+package geoip
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeCSV writes a GeoIP database to a temporary file and returns its path
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "geoip.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTableLookup(t *testing.T) {
+	table, err := LoadCSV(writeCSV(t, `# network,country,region
+203.0.113.0/24,nz,auk
+198.51.100.0/24, US
+
+2001:db8::/32,DE,BE
+10.0.0.7/8,GB
+`))
+	if err != nil {
+		t.Fatalf("LoadCSV: %v", err)
+	}
+	if table.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", table.Len())
+	}
+
+	tests := []struct {
+		addr   string
+		want   Location
+		wantOK bool
+	}{
+		{addr: "203.0.113.9", want: Location{Country: "NZ", Region: "AUK"}, wantOK: true},
+		{addr: "198.51.100.255", want: Location{Country: "US"}, wantOK: true},
+		{addr: "::ffff:198.51.100.1", want: Location{Country: "US"}, wantOK: true},
+		{addr: "2001:db8:1::1", want: Location{Country: "DE", Region: "BE"}, wantOK: true},
+		// Host bits in the network are masked off
+		{addr: "10.200.0.1", want: Location{Country: "GB"}, wantOK: true},
+		{addr: "203.0.114.1"},
+		{addr: "1.1.1.1"},
+		{addr: "2001:db9::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			got, ok := table.Lookup(netip.MustParseAddr(tt.addr))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Lookup(%s) = %+v, %v, want %+v, %v", tt.addr, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "missing country", content: "203.0.113.0/24\n", wantErr: "line 1: expected network,country[,region]"},
+		{name: "too many fields", content: "203.0.113.0/24,NZ,AUK,x\n", wantErr: "line 1: expected network,country[,region]"},
+		{name: "bad network", content: "# header\n203.0.113.0/24,NZ\n203.0.113.0,NZ\n", wantErr: "line 3:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCSV(writeCSV(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadCSV() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handler
+
+import "github.com/cognobserve/ingest/pkg/api"
+
+// Request and response bodies, defined in pkg/api so the Go client shares
+// them without depending on the server
+type (
+	IngestTraceRequest  = api.IngestTraceRequest
+	IngestSpanInput     = api.IngestSpanInput
+	IngestTraceResponse = api.IngestTraceResponse
+	IngestBatchRequest  = api.IngestBatchRequest
+	IngestBatchResponse = api.IngestBatchResponse
+	BatchItemResult     = api.BatchItemResult
+	UserInfoInput       = api.UserInfoInput
+	SpanCostInput       = api.SpanCostInput
+	TokenUsageInput     = api.TokenUsageInput
+	AttachmentInput     = api.AttachmentInput
+	UsageSummary        = api.UsageSummary
+	Warning             = api.Warning
+	TraceResult         = api.TraceResult
+	FlexibleTime        = api.FlexibleTime
+)
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// MaxAttachmentURLLength bounds attachment URL references
+const MaxAttachmentURLLength = 2048
+
+// attachmentMimePrefixes maps attachment types to the MIME type they require.
+// "file" accepts any MIME type.
+var attachmentMimePrefixes = map[string]string{
+	"image": "image/",
+	"audio": "audio/",
+	"video": "video/",
+	"file":  "",
+}
+
+// buildAttachments validates span i's attachments and converts them into workflow input.
+// Inline content is capped so large media is referenced by URL instead of
+// being carried through the workflow.
+func (h *Handler) buildAttachments(i int, attachments []AttachmentInput) ([]temporal.AttachmentInput, error) {
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+	if len(attachments) > h.cfg.MaxAttachmentsPerSpan {
+		return nil, validationError(fmt.Sprintf("spans[%d].attachments must contain at most %d items (got %d)", i, h.cfg.MaxAttachmentsPerSpan, len(attachments)))
+	}
+
+	out := make([]temporal.AttachmentInput, len(attachments))
+	for j, a := range attachments {
+		field := fmt.Sprintf("spans[%d].attachments[%d]", i, j)
+
+		mimePrefix, ok := attachmentMimePrefixes[a.Type]
+		if !ok {
+			return nil, validationError(field + ".type must be one of image, audio, video, file")
+		}
+		mediaType, _, err := mime.ParseMediaType(a.Mime)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			return nil, validationError(field + ".mime must be a valid MIME type")
+		}
+		if !strings.HasPrefix(mediaType, mimePrefix) {
+			return nil, validationError(fmt.Sprintf("%s.mime %q does not match type %q", field, mediaType, a.Type))
+		}
+
+		attachment := temporal.AttachmentInput{Type: a.Type, MimeType: mediaType}
+		if a.SHA256 != nil {
+			digest := strings.ToLower(*a.SHA256)
+			if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+				return nil, validationError(field + ".sha256 must be a hex-encoded SHA-256 digest")
+			}
+			attachment.SHA256 = digest
+		}
+
+		switch {
+		case a.URL != nil && a.ContentBase64 != nil:
+			return nil, validationError(field + " must set only one of url and content_base64")
+		case a.URL != nil:
+			if err := validateAttachmentURL(*a.URL); err != nil {
+				return nil, validationError(fmt.Sprintf("%s.url %s", field, err))
+			}
+			attachment.URL = *a.URL
+		case a.ContentBase64 != nil:
+			// Check the encoded length first so oversized payloads aren't decoded
+			if base64.StdEncoding.DecodedLen(len(*a.ContentBase64)) > h.cfg.MaxInlineAttachmentBytes+2 {
+				return nil, attachmentTooLarge(field, h.cfg.MaxInlineAttachmentBytes)
+			}
+			content, err := base64.StdEncoding.DecodeString(*a.ContentBase64)
+			if err != nil {
+				return nil, validationError(field + ".content_base64 must be valid base64")
+			}
+			if len(content) > h.cfg.MaxInlineAttachmentBytes {
+				return nil, attachmentTooLarge(field, h.cfg.MaxInlineAttachmentBytes)
+			}
+
+			sum := sha256.Sum256(content)
+			digest := hex.EncodeToString(sum[:])
+			if attachment.SHA256 != "" && attachment.SHA256 != digest {
+				return nil, validationError(field + ".sha256 does not match content_base64")
+			}
+			attachment.SHA256 = digest
+			attachment.ContentBase64 = *a.ContentBase64
+		default:
+			return nil, validationError(field + " must set url or content_base64")
+		}
+
+		out[j] = attachment
+	}
+	return out, nil
+}
+
+// validateAttachmentURL requires an absolute http(s) URL of bounded length
+func validateAttachmentURL(raw string) error {
+	if len(raw) > MaxAttachmentURLLength {
+		return fmt.Errorf("must be at most %d characters", MaxAttachmentURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// attachmentTooLarge rejects inline content above the configured limit
+func attachmentTooLarge(field string, limit int) error {
+	return response.NewError(http.StatusBadRequest, "attachment_too_large",
+		fmt.Sprintf("%s.content_base64 exceeds %d bytes; upload the media and reference it by url instead", field, limit))
+}
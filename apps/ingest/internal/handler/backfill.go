@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// BackfillMetadataKey marks traces imported in backfill mode
+const BackfillMetadataKey = "_backfill"
+
+// backfillMode reports whether the request asked for ?backfill=true.
+// Backfill relaxes timestamp plausibility checks for historical imports,
+// so it requires the backfill scope.
+func backfillMode(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("backfill")
+	if raw == "" {
+		return false, nil
+	}
+	backfill, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, validationError("backfill must be true or false")
+	}
+	if backfill && !middleware.HasScope(r.Context(), middleware.ScopeBackfill) {
+		return false, response.NewError(http.StatusForbidden, "insufficient_scope", "API key lacks the "+middleware.ScopeBackfill+" scope")
+	}
+	return backfill, nil
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/budget"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// IngestBatch handles POST /v1/traces/batch
+// The response is JSON, or MessagePack for Accept: application/msgpack.
+// Each trace is validated independently; invalid items are reported in
+// their result slot without failing the rest of the batch.
+func (h *Handler) IngestBatch(w http.ResponseWriter, r *http.Request) {
+	backfill, err := backfillMode(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	var req IngestBatchRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	if len(req.Traces) == 0 {
+		response.Error(w, http.StatusBadRequest, "validation_error", "traces must not be empty")
+		return
+	}
+	if len(req.Traces) > h.cfg.MaxBatchSize {
+		response.Error(w, http.StatusBadRequest, "batch_too_large",
+			fmt.Sprintf("batch contains %d traces, maximum is %d", len(req.Traces), h.cfg.MaxBatchSize))
+		return
+	}
+
+	projectID, err := h.resolveProjectID(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	results, lastUsage := h.ingestTraces(r.Context(), req.Traces, projectID, backfill)
+
+	resp := IngestBatchResponse{Results: results}
+	for _, result := range results {
+		if result.Success {
+			resp.SuccessCount++
+		} else {
+			resp.ErrorCount++
+		}
+	}
+	slog.Info("trace batch processed", "traces", len(results), "succeeded", resp.SuccessCount, "failed", resp.ErrorCount)
+
+	setBudgetHeaders(w, lastUsage)
+
+	// Large result arrays decode faster as MessagePack; JSON stays the default
+	w.Header().Add("Vary", "Accept")
+	if response.AcceptsMsgPack(r) {
+		response.MsgPack(w, http.StatusAccepted, resp)
+		return
+	}
+	response.JSON(w, http.StatusAccepted, resp)
+}
+
+// ingestTraces validates the traces and starts their workflows as one
+// batch. It returns a result per trace, in order, and the last budget
+// usage charged. Shared by IngestBatch and IngestNDJSON.
+func (h *Handler) ingestTraces(ctx context.Context, traces []IngestTraceRequest, projectID string, backfill bool) ([]BatchItemResult, *budget.Usage) {
+	results := make([]BatchItemResult, len(traces))
+	inputs := make([]temporal.TraceWorkflowInput, 0, len(traces))
+	inputIndexes := make([]int, 0, len(traces))
+	usages := make([]*budget.Usage, 0, len(traces))
+	var lastUsage *budget.Usage
+
+	for i := range traces {
+		results[i].Index = i
+
+		input, err := h.buildTraceInput(&traces[i], projectID, backfill, nil)
+		if err != nil {
+			results[i].Error = errorDetail(err)
+			continue
+		}
+		if err := h.prepareTrace(ctx, &input, nil); err != nil {
+			results[i].Error = errorDetail(err)
+			continue
+		}
+
+		results[i].TraceID = input.ID
+		results[i].SpanIDs = spanIDs(input)
+		if h.isRepeatedSend(ctx, input) {
+			results[i].Deduplicated = true
+			results[i].Success = true
+			continue
+		}
+		usage, err := h.chargeBudget(ctx, &input)
+		if usage != nil {
+			lastUsage = usage
+		}
+		if err != nil {
+			h.forgetSend(ctx, input)
+			results[i].Error = errorDetail(err)
+			continue
+		}
+		inputs = append(inputs, input)
+		inputIndexes = append(inputIndexes, i)
+		usages = append(usages, usage)
+	}
+
+	starts := h.temporalClient.StartTraceWorkflowsBatch(ctx, inputs)
+	for j, start := range starts {
+		result := &results[inputIndexes[j]]
+		if start.Err != nil {
+			h.refundBudget(ctx, usages[j])
+			h.forgetSend(ctx, inputs[j])
+			switch classifyStartError(ctx, start.Err, result.TraceID) {
+			case startFailureClientCanceled:
+				result.Error = &response.ErrorDetail{Code: "client_closed_request", Message: "client closed request"}
+			case startFailureDeadlineExceeded:
+				result.Error = &response.ErrorDetail{Code: "request_timeout", Message: "request timed out"}
+			default:
+				result.Error = &response.ErrorDetail{Code: "internal_error", Message: "failed to process trace"}
+			}
+			continue
+		}
+		result.WorkflowID = start.Result.WorkflowID
+		result.Duplicate = start.Result.Duplicate
+		result.Success = true
+		if result.Duplicate {
+			h.refundBudget(ctx, usages[j])
+		} else {
+			recordPayloadSizes(inputs[j])
+			h.notifyIngested(ctx, inputs[j])
+		}
+	}
+	return results, lastUsage
+}
+
+// errorDetail converts a validation error into its envelope representation
+func errorDetail(err error) *response.ErrorDetail {
+	var apiErr *response.APIError
+	if errors.As(err, &apiErr) {
+		detail := apiErr.Detail()
+		return &detail
+	}
+	return &response.ErrorDetail{Code: "internal_error", Message: err.Error()}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+func TestIngestBatchEncoding(t *testing.T) {
+	// One valid trace and one failing validation, so both result shapes are encoded
+	body := `{"traces":[{"trace_id":"t1","name":"a","spans":[{"span_id":"s1","name":"s"}]},{"spans":[{"name":"s"}]}]}`
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+	}{
+		{name: "default", wantContentType: "application/json"},
+		{name: "json", accept: "application/json", wantContentType: "application/json"},
+		{name: "msgpack", accept: "application/msgpack", wantContentType: response.ContentTypeMsgPack},
+		{name: "msgpack refused", accept: "application/msgpack;q=0, application/json", wantContentType: "application/json"},
+	}
+
+	var want IngestBatchResponse
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTemporalTestHandler(t, &temporaltest.Client{}, nil)
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces/batch", strings.NewReader(body))
+			req.Header.Set("X-Project-ID", "proj-1")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.IngestBatch(rec, req)
+
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %s", got, tt.wantContentType)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+
+			var got IngestBatchResponse
+			if tt.wantContentType == response.ContentTypeMsgPack {
+				dec := msgpack.NewDecoder(rec.Body)
+				dec.SetCustomStructTag("json")
+				if err := dec.Decode(&got); err != nil {
+					t.Fatalf("decode msgpack: %v", err)
+				}
+			} else if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode json: %v", err)
+			}
+
+			if got.SuccessCount != 1 || got.ErrorCount != 1 {
+				t.Errorf("counts = %d/%d, want 1/1", got.SuccessCount, got.ErrorCount)
+			}
+			if len(got.Results) != 2 || got.Results[1].Error == nil || got.Results[1].Error.Code != "validation_error" {
+				t.Fatalf("results = %+v, want the second to fail validation", got.Results)
+			}
+			// Both encodings carry the same results
+			if want.Results == nil {
+				want = got
+			} else if !reflect.DeepEqual(got, want) {
+				t.Errorf("response = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestIngestBatchItemErrors(t *testing.T) {
+	const good = `{"trace_id":"good","name":"a","spans":[{"span_id":"s1","name":"s"}]}`
+
+	tests := []struct {
+		name      string
+		bad       string
+		wantCode  string
+		wantField string
+	}{
+		{
+			name:      "end before start",
+			bad:       `{"trace_id":"bad","name":"a","spans":[{"name":"s","start_time":"2024-01-01T00:00:02Z","end_time":"2024-01-01T00:00:01Z"}]}`,
+			wantCode:  "end_before_start",
+			wantField: "spans[0].end_time",
+		},
+		{
+			name:      "invalid span graph",
+			bad:       `{"trace_id":"bad","name":"a","spans":[{"span_id":"s1","name":"s"},{"span_id":"s1","name":"s"}]}`,
+			wantCode:  "invalid_span_graph",
+			wantField: "spans[1].span_id",
+		},
+		{
+			name:      "too many spans",
+			bad:       `{"trace_id":"bad","name":"a","spans":[{"name":"s"},{"name":"s"},{"name":"s"}]}`,
+			wantCode:  "too_many_spans",
+			wantField: "spans",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTemporalTestHandler(t, &temporaltest.Client{}, func(cfg *config.Config) { cfg.MaxSpansPerTrace = 2 })
+			body := `{"traces":[` + tt.bad + `,` + good + `]}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces/batch", strings.NewReader(body))
+			req.Header.Set("X-Project-ID", "proj-1")
+			rec := httptest.NewRecorder()
+			h.IngestBatch(rec, req)
+
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+			}
+			var got IngestBatchResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode json: %v", err)
+			}
+			if got.SuccessCount != 1 || got.ErrorCount != 1 || len(got.Results) != 2 {
+				t.Fatalf("response = %+v, want one success and one error", got)
+			}
+
+			bad, ok := got.Results[0], got.Results[1]
+			if bad.Index != 0 || bad.Success || bad.Error == nil {
+				t.Fatalf("results[0] = %+v, want a failed item at index 0", bad)
+			}
+			if bad.Error.Code != tt.wantCode || bad.Error.Field != tt.wantField {
+				t.Errorf("results[0].error = %s at %q, want %s at %q", bad.Error.Code, bad.Error.Field, tt.wantCode, tt.wantField)
+			}
+			if ok.Index != 1 || !ok.Success || ok.Error != nil || ok.TraceID != "good" {
+				t.Errorf("results[1] = %+v, want trace good to succeed at index 1", ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cognobserve/ingest/internal/budget"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// BudgetExceededMetadataKey flags traces accepted over their project's
+// daily cost budget in soft mode
+const BudgetExceededMetadataKey = "_budget_exceeded"
+
+// Response headers reporting the project's estimated spend today against
+// its daily budget, in USD
+const (
+	BudgetSpendHeader = "X-Budget-Spend-USD"
+	BudgetLimitHeader = "X-Budget-Limit-USD"
+)
+
+// chargeBudget charges a trace's estimated cost to its project's daily
+// budget. It returns nil usage when budgets are off or the project has no
+// budget. Over budget, hard mode rejects the trace with 402
+// budget_exceeded and soft mode flags it in the trace metadata. Pricing
+// and Redis errors fail open so budgets never block ingestion.
+func (h *Handler) chargeBudget(ctx context.Context, input *temporal.TraceWorkflowInput) (*budget.Usage, error) {
+	if h.budgets == nil {
+		return nil, nil
+	}
+	limit, ok := middleware.GetProjectDailyBudget(ctx)
+	if !ok {
+		limit = h.cfg.CostBudgetDailyUSD
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	cost, err := h.budgets.EstimateCost(ctx, input.Spans)
+	if err != nil {
+		slog.Warn("trace cost estimate failed, ingesting without budget check", "error", err, "trace_id", input.ID)
+		return nil, nil
+	}
+	usage, err := h.budgets.Charge(ctx, input.ProjectID, cost, limit, h.cfg.CostBudgetMode == "hard")
+	if err != nil {
+		slog.Warn("project budget check failed, ingesting anyway", "error", err, "trace_id", input.ID)
+		return nil, nil
+	}
+
+	if !usage.Charged {
+		slog.Info("rejected trace over project budget", "trace_id", input.ID, "project_id", input.ProjectID, "spend_usd", usage.SpendUSD, "cost_usd", cost, "budget_usd", limit)
+		apiErr := response.NewError(http.StatusPaymentRequired, "budget_exceeded",
+			fmt.Sprintf("trace would bring today's estimated spend to $%.4f, above the project's daily budget of $%.4f", usage.SpendUSD+cost, limit))
+		apiErr.Details = map[string]any{"spend_usd": usage.SpendUSD, "budget_usd": limit, "trace_cost_usd": cost}
+		return &usage, apiErr
+	}
+	if usage.Exceeded() {
+		if input.Metadata == nil {
+			input.Metadata = make(map[string]any, 1)
+		}
+		input.Metadata[BudgetExceededMetadataKey] = true
+		slog.Info("accepted trace over project budget", "trace_id", input.ID, "project_id", input.ProjectID, "spend_usd", usage.SpendUSD, "budget_usd", limit)
+	}
+	return &usage, nil
+}
+
+// refundBudget takes back the charge for a trace that wasn't started. It
+// runs even when the request was canceled, since the charge stands otherwise.
+func (h *Handler) refundBudget(ctx context.Context, usage *budget.Usage) {
+	if usage == nil {
+		return
+	}
+	if err := h.budgets.Refund(context.WithoutCancel(ctx), *usage); err != nil {
+		slog.Warn("project budget refund failed", "error", err)
+	}
+}
+
+// setBudgetHeaders reports the project's spend against its budget
+func setBudgetHeaders(w http.ResponseWriter, usage *budget.Usage) {
+	if usage == nil {
+		return
+	}
+	w.Header().Set(BudgetSpendHeader, strconv.FormatFloat(usage.SpendUSD, 'f', 6, 64))
+	w.Header().Set(BudgetLimitHeader, strconv.FormatFloat(usage.BudgetUSD, 'f', 6, 64))
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/cognobserve/ingest/internal/budget"
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+// newBudgetTestHandler creates a Handler charging a $1 daily budget in
+// mode to an in-memory Redis, where proj-1 has already spent spentUSD
+func newBudgetTestHandler(t *testing.T, client *temporaltest.Client, mode string, spentUSD float64) (*Handler, *budget.Tracker) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	tracker, err := budget.New("redis://"+mr.Addr(), nil)
+	if err != nil {
+		t.Fatalf("budget.New: %v", err)
+	}
+	t.Cleanup(func() { _ = tracker.Close() })
+	if _, err := tracker.Charge(context.Background(), "proj-1", spentUSD, 1, false); err != nil {
+		t.Fatalf("Charge(spent): %v", err)
+	}
+
+	cfg := testConfig(t, func(cfg *config.Config) {
+		cfg.CostBudgetMode = mode
+		cfg.CostBudgetDailyUSD = 1
+	})
+	watchdog := temporal.NewWatchdog(client, time.Minute, 3, false)
+	return New(cfg, client, watchdog, nil, nil, nil, tracker, nil, time.Now()), tracker
+}
+
+// costedTrace is a trace whose only span costs costUSD by override, so no
+// price lookup is needed
+func costedTrace(costUSD float64) string {
+	return fmt.Sprintf(`{"trace_id":"t1","name":"chat","spans":[{"name":"llm","model_parameters":{"cost_override_usd":%s}}]}`,
+		strconv.FormatFloat(costUSD, 'f', -1, 64))
+}
+
+// spendAfter charges nothing and returns proj-1's spend today
+func spendAfter(t *testing.T, tracker *budget.Tracker) float64 {
+	t.Helper()
+	usage, err := tracker.Charge(context.Background(), "proj-1", 0, 1, false)
+	if err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	return usage.SpendUSD
+}
+
+func TestIngestTraceBudget(t *testing.T) {
+	// Binary fractions, so sums compare exactly
+	const spentUSD, epsilon = 0.75, 1.0 / 1024
+
+	tests := []struct {
+		name         string
+		mode         string
+		cost         float64
+		wantStatus   int
+		wantHeader   string // X-Budget-Spend-USD
+		wantFlagged  bool   // BudgetExceededMetadataKey set on the started trace
+		wantSpendUSD float64
+	}{
+		{name: "hard below the budget", mode: "hard", cost: 0.25 - epsilon, wantStatus: http.StatusAccepted, wantHeader: "0.999023", wantSpendUSD: 1 - epsilon},
+		// Spend equal to the budget is within it
+		{name: "hard at the budget", mode: "hard", cost: 0.25, wantStatus: http.StatusAccepted, wantHeader: "1.000000", wantSpendUSD: 1},
+		{name: "hard above the budget", mode: "hard", cost: 0.25 + epsilon, wantStatus: http.StatusPaymentRequired, wantHeader: "0.750000", wantSpendUSD: spentUSD},
+		{name: "soft below the budget", mode: "soft", cost: 0.25 - epsilon, wantStatus: http.StatusAccepted, wantHeader: "0.999023", wantSpendUSD: 1 - epsilon},
+		{name: "soft at the budget", mode: "soft", cost: 0.25, wantStatus: http.StatusAccepted, wantHeader: "1.000000", wantSpendUSD: 1},
+		{name: "soft above the budget", mode: "soft", cost: 0.25 + epsilon, wantStatus: http.StatusAccepted, wantHeader: "1.000977", wantFlagged: true, wantSpendUSD: 1 + epsilon},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &temporaltest.Client{}
+			h, tracker := newBudgetTestHandler(t, client, tt.mode, spentUSD)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(costedTrace(tt.cost)))
+			req.Header.Set("X-Project-ID", "proj-1")
+			rec := httptest.NewRecorder()
+			h.IngestTrace(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get(BudgetSpendHeader); got != tt.wantHeader {
+				t.Errorf("%s = %q, want %q", BudgetSpendHeader, got, tt.wantHeader)
+			}
+			if got := rec.Header().Get(BudgetLimitHeader); got != "1.000000" {
+				t.Errorf("%s = %q, want 1.000000", BudgetLimitHeader, got)
+			}
+			if got := spendAfter(t, tracker); got != tt.wantSpendUSD {
+				t.Errorf("spend = %g, want %g", got, tt.wantSpendUSD)
+			}
+
+			started := client.Started()
+			if tt.wantStatus == http.StatusPaymentRequired {
+				var body response.ErrorBody
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if body.Code != "budget_exceeded" {
+					t.Errorf("code = %q, want budget_exceeded", body.Code)
+				}
+				if len(started) != 0 {
+					t.Errorf("started %d workflows, want none", len(started))
+				}
+				return
+			}
+			if len(started) != 1 {
+				t.Fatalf("started %d workflows, want 1", len(started))
+			}
+			if _, flagged := started[0].Metadata[BudgetExceededMetadataKey]; flagged != tt.wantFlagged {
+				t.Errorf("%s flagged = %v, want %v", BudgetExceededMetadataKey, flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestIngestTraceBudgetRefund(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      func(ctx context.Context, input temporal.TraceWorkflowInput) (*temporal.StartResult, error)
+		wantStatus int
+	}{
+		{
+			name: "start failed",
+			start: func(context.Context, temporal.TraceWorkflowInput) (*temporal.StartResult, error) {
+				return nil, errors.New("frontend unavailable")
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			// The trace was charged when it first started
+			name: "duplicate",
+			start: func(_ context.Context, input temporal.TraceWorkflowInput) (*temporal.StartResult, error) {
+				return &temporal.StartResult{WorkflowID: "trace-" + input.ID, Duplicate: true}, nil
+			},
+			wantStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &temporaltest.Client{StartTraceWorkflowFunc: tt.start}
+			h, tracker := newBudgetTestHandler(t, client, "hard", 0.5)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(costedTrace(0.25)))
+			req.Header.Set("X-Project-ID", "proj-1")
+			rec := httptest.NewRecorder()
+			h.IngestTrace(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := spendAfter(t, tracker); got != 0.5 {
+				t.Errorf("spend = %g, want the charge refunded to 0.5", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// ClientIPMetadataKey holds the client's IP address, or its network when
+// CLIENT_IP_PRECISION is coarse, on traces enriched by ENRICH_CLIENT_IP
+const ClientIPMetadataKey = "_client_ip"
+
+// ClientGeoMetadataKey holds the client's coarse location (a geoip.Location)
+// when the GeoIP database knows the address
+const ClientGeoMetadataKey = "_client_geo"
+
+// Networks recorded for coarse client IPs
+const (
+	coarseIPv4Bits = 24
+	coarseIPv6Bits = 48
+)
+
+// enrichClient attaches the client's IP address and location to the trace
+// metadata when ENRICH_CLIENT_IP is set. The location is looked up from the
+// full address before it is coarsened.
+func (h *Handler) enrichClient(ctx context.Context, input *temporal.TraceWorkflowInput) {
+	if !h.cfg.EnrichClientIP {
+		return
+	}
+	addr, ok := middleware.GetClientIP(ctx)
+	if !ok {
+		return
+	}
+
+	if input.Metadata == nil {
+		input.Metadata = make(map[string]any, 2)
+	}
+	input.Metadata[ClientIPMetadataKey] = clientIPValue(addr, h.cfg.ClientIPPrecision)
+	if h.geo != nil {
+		if location, ok := h.geo.Lookup(addr); ok {
+			input.Metadata[ClientGeoMetadataKey] = location
+		}
+	}
+}
+
+// clientIPValue renders addr for metadata: the address itself, or in
+// coarse precision its /24 or /48 network
+func clientIPValue(addr netip.Addr, precision string) string {
+	if precision != "coarse" {
+		return addr.String()
+	}
+	bits := coarseIPv6Bits
+	if addr.Is4() {
+		bits = coarseIPv4Bits
+	}
+	prefix, _ := addr.WithZone("").Prefix(bits)
+	return prefix.String()
+}
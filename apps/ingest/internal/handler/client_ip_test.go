@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/geoip"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// fakeGeo resolves addresses from a fixed map
+type fakeGeo map[netip.Addr]geoip.Location
+
+func (g fakeGeo) Lookup(addr netip.Addr) (geoip.Location, bool) {
+	location, ok := g[addr]
+	return location, ok
+}
+
+func TestEnrichClient(t *testing.T) {
+	nz := geoip.Location{Country: "NZ", Region: "AUK"}
+	geo := fakeGeo{netip.MustParseAddr("203.0.113.9"): nz}
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		precision string
+		addr      string // Client IP in the context; empty means unknown
+		want      map[string]any
+	}{
+		{name: "disabled", precision: "coarse", addr: "203.0.113.9"},
+		{name: "unknown client", enabled: true, precision: "coarse"},
+		{
+			// The location comes from the full address
+			name:      "coarse ipv4",
+			enabled:   true,
+			precision: "coarse",
+			addr:      "203.0.113.9",
+			want:      map[string]any{ClientIPMetadataKey: "203.0.113.0/24", ClientGeoMetadataKey: nz},
+		},
+		{
+			name:      "full ipv4",
+			enabled:   true,
+			precision: "full",
+			addr:      "203.0.113.9",
+			want:      map[string]any{ClientIPMetadataKey: "203.0.113.9", ClientGeoMetadataKey: nz},
+		},
+		{
+			name:      "coarse ipv6 without location",
+			enabled:   true,
+			precision: "coarse",
+			addr:      "2001:db8:1234:5678::1",
+			want:      map[string]any{ClientIPMetadataKey: "2001:db8:1234::/48"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, func(cfg *config.Config) {
+				cfg.EnrichClientIP = tt.enabled
+				cfg.ClientIPPrecision = tt.precision
+			})
+			h.geo = geo
+			ctx := context.Background()
+			if tt.addr != "" {
+				ctx = context.WithValue(ctx, middleware.ClientIPKey, netip.MustParseAddr(tt.addr))
+			}
+
+			input := temporal.TraceWorkflowInput{ID: "t1"}
+			h.enrichClient(ctx, &input)
+			if !reflect.DeepEqual(input.Metadata, tt.want) {
+				t.Errorf("Metadata = %#v, want %#v", input.Metadata, tt.want)
+			}
+		})
+	}
+}
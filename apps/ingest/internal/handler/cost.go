@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// CostOverrideKey is the model_parameters (or trace metadata) key carrying an
+// explicit span cost in USD, for self-hosted models with negotiated pricing
+const CostOverrideKey = "cost_override_usd"
+
+// CostSourceOverride marks spans whose cost comes from CostOverrideKey
+// rather than the price table
+const CostSourceOverride = "override"
+
+// CostSourceClient marks spans whose costs the client supplied in the
+// span's cost field
+const CostSourceClient = "client"
+
+// CostSourceEstimatedBlended marks spans priced from total_tokens alone,
+// split into prompt and completion tokens by COST_BLENDED_PROMPT_RATIO
+const CostSourceEstimatedBlended = "estimated_blended"
+
+// resolveCostOverride returns the cost override for span i, if any.
+// A value in the span's model_parameters takes precedence over one in the
+// trace metadata, which applies to every span in the trace.
+func resolveCostOverride(i int, modelParameters, traceMetadata map[string]any) (*float64, error) {
+	if raw, ok := modelParameters[CostOverrideKey]; ok {
+		return parseCostOverride(fmt.Sprintf("spans[%d].model_parameters.%s", i, CostOverrideKey), raw)
+	}
+	if raw, ok := traceMetadata[CostOverrideKey]; ok {
+		return parseCostOverride("metadata."+CostOverrideKey, raw)
+	}
+	return nil, nil
+}
+
+// parseCostOverride checks that a cost override is a finite, non-negative number
+func parseCostOverride(field string, raw any) (*float64, error) {
+	cost, ok := raw.(float64)
+	if n, isNumber := raw.(json.Number); isNumber {
+		f, err := n.Float64()
+		cost, ok = f, err == nil
+	}
+	if !ok || math.IsNaN(cost) || math.IsInf(cost, 0) || cost < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative number", field)
+	}
+	return &cost, nil
+}
+
+// resolveClientCost validates span i's client-supplied costs and returns
+// the span total: total_cost_usd, or the sum of the prompt and completion
+// costs when it is omitted
+func resolveClientCost(i int, cost *SpanCostInput) (*float64, error) {
+	fields := []struct {
+		name  string
+		value *float64
+	}{
+		{"prompt_cost_usd", cost.PromptCostUSD},
+		{"completion_cost_usd", cost.CompletionCostUSD},
+		{"total_cost_usd", cost.TotalCostUSD},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		if _, err := parseCostOverride(fmt.Sprintf("spans[%d].cost.%s", i, f.name), *f.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if cost.TotalCostUSD != nil {
+		return cost.TotalCostUSD, nil
+	}
+	if cost.PromptCostUSD == nil || cost.CompletionCostUSD == nil {
+		return nil, fmt.Errorf("spans[%d].cost needs total_cost_usd, or both prompt_cost_usd and completion_cost_usd", i)
+	}
+	total := *cost.PromptCostUSD + *cost.CompletionCostUSD
+	return &total, nil
+}
+
+// blendedTokenSplit estimates the prompt/completion split of a total-only
+// token count, for providers that don't report the breakdown
+func blendedTokenSplit(total int64, promptRatio float64) (prompt, completion int64) {
+	prompt = int64(math.Round(float64(total) * promptRatio))
+	return prompt, total - prompt
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestResolveCostOverride(t *testing.T) {
+	tests := []struct {
+		name            string
+		modelParameters map[string]any
+		traceMetadata   map[string]any
+		want            *float64
+		wantErr         bool
+	}{
+		{
+			name:            "span override",
+			modelParameters: map[string]any{CostOverrideKey: 0.25},
+			want:            ptr(0.25),
+		},
+		{
+			name:          "trace override",
+			traceMetadata: map[string]any{CostOverrideKey: json.Number("1.5")},
+			want:          ptr(1.5),
+		},
+		{
+			name:            "span override wins over trace",
+			modelParameters: map[string]any{CostOverrideKey: 0.25},
+			traceMetadata:   map[string]any{CostOverrideKey: 1.5},
+			want:            ptr(0.25),
+		},
+		{
+			name:            "no override falls back to the price table",
+			modelParameters: map[string]any{"temperature": 0.2},
+			traceMetadata:   map[string]any{"team": "search"},
+		},
+		{
+			name:            "negative",
+			modelParameters: map[string]any{CostOverrideKey: -0.1},
+			wantErr:         true,
+		},
+		{
+			name:          "not a number",
+			traceMetadata: map[string]any{CostOverrideKey: "free"},
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveCostOverride(0, tt.modelParameters, tt.traceMetadata)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveCostOverride() = %v, want error", *got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equalCost(got, tt.want) {
+				t.Errorf("resolveCostOverride() = %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputCostSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		metadata   map[string]any
+		span       IngestSpanInput
+		wantCost   *float64
+		wantSource string
+		wantSplit  [2]int64 // Estimated prompt and completion tokens
+	}{
+		{
+			name: "override",
+			span: IngestSpanInput{
+				Name:            "llm",
+				Model:           ptr("gpt-4o"),
+				ModelParameters: map[string]any{CostOverrideKey: 0.02},
+				Usage:           &TokenUsageInput{PromptTokens: ptr[int64](100), CompletionTokens: ptr[int64](50)},
+			},
+			wantCost:   ptr(0.02),
+			wantSource: CostSourceOverride,
+		},
+		{
+			name:     "trace-wide override",
+			metadata: map[string]any{CostOverrideKey: 0.5},
+			span: IngestSpanInput{
+				Name:  "llm",
+				Model: ptr("gpt-4o"),
+			},
+			wantCost:   ptr(0.5),
+			wantSource: CostSourceOverride,
+		},
+		{
+			name: "price table",
+			span: IngestSpanInput{
+				Name:  "llm",
+				Model: ptr("gpt-4o"),
+				Usage: &TokenUsageInput{PromptTokens: ptr[int64](100), CompletionTokens: ptr[int64](50)},
+			},
+		},
+		{
+			name: "total tokens only",
+			span: IngestSpanInput{
+				Name:  "llm",
+				Model: ptr("gpt-4o"),
+				Usage: &TokenUsageInput{TotalTokens: ptr[int64](1000)},
+			},
+			wantSource: CostSourceEstimatedBlended,
+			wantSplit:  [2]int64{750, 250},
+		},
+		{
+			name: "total tokens with a cost",
+			span: IngestSpanInput{
+				Name:            "llm",
+				Model:           ptr("gpt-4o"),
+				ModelParameters: map[string]any{CostOverrideKey: 0.02},
+				Usage:           &TokenUsageInput{TotalTokens: ptr[int64](1000)},
+			},
+			wantCost:   ptr(0.02),
+			wantSource: CostSourceOverride,
+		},
+		{
+			name: "no usage",
+			span: IngestSpanInput{Name: "retrieve"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			req := &IngestTraceRequest{Name: "chat", Metadata: tt.metadata, Spans: []IngestSpanInput{tt.span}}
+
+			input, err := h.buildTraceInput(req, "proj-1", false, nil)
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			span := input.Spans[0]
+			if !equalCost(span.CostOverrideUSD, tt.wantCost) {
+				t.Errorf("CostOverrideUSD = %v, want %v", deref(span.CostOverrideUSD), deref(tt.wantCost))
+			}
+			if span.CostSource != tt.wantSource {
+				t.Errorf("CostSource = %q, want %q", span.CostSource, tt.wantSource)
+			}
+			if got := [2]int64{span.EstimatedPromptTokens, span.EstimatedCompletionTokens}; got != tt.wantSplit {
+				t.Errorf("estimated split = %v, want %v", got, tt.wantSplit)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputClientCosts(t *testing.T) {
+	usage := &TokenUsageInput{PromptTokens: ptr[int64](1000), CompletionTokens: ptr[int64](500)}
+	tests := []struct {
+		name           string
+		span           IngestSpanInput
+		wantCost       *float64 // nil leaves the span to the price table
+		wantPrompt     *float64
+		wantCompletion *float64
+		wantSource     string
+		wantErrCode    string
+	}{
+		{
+			name: "computed from usage",
+			span: IngestSpanInput{Name: "llm", Model: ptr("gpt-4o"), Usage: usage},
+		},
+		{
+			name: "client breakdown",
+			span: IngestSpanInput{
+				Name:  "llm",
+				Model: ptr("gpt-4o"),
+				Usage: usage,
+				Cost:  &SpanCostInput{PromptCostUSD: ptr(0.01), CompletionCostUSD: ptr(0.02)},
+			},
+			wantCost:       ptr(0.03),
+			wantPrompt:     ptr(0.01),
+			wantCompletion: ptr(0.02),
+			wantSource:     CostSourceClient,
+		},
+		{
+			name: "client total",
+			span: IngestSpanInput{
+				Name:  "llm",
+				Model: ptr("gpt-4o"),
+				Usage: usage,
+				Cost:  &SpanCostInput{TotalCostUSD: ptr(0.05)},
+			},
+			wantCost:   ptr(0.05),
+			wantSource: CostSourceClient,
+		},
+		{
+			name: "client total matching the override",
+			span: IngestSpanInput{
+				Name:            "llm",
+				ModelParameters: map[string]any{CostOverrideKey: 0.05},
+				Cost:            &SpanCostInput{TotalCostUSD: ptr(0.05)},
+			},
+			wantCost:   ptr(0.05),
+			wantSource: CostSourceClient,
+		},
+		{
+			name: "client total differing from the override",
+			span: IngestSpanInput{
+				Name:            "llm",
+				ModelParameters: map[string]any{CostOverrideKey: 0.02},
+				Cost:            &SpanCostInput{TotalCostUSD: ptr(0.05)},
+			},
+			wantErrCode: "conflicting_costs",
+		},
+		{
+			name: "partial breakdown",
+			span: IngestSpanInput{
+				Name: "llm",
+				Cost: &SpanCostInput{PromptCostUSD: ptr(0.01)},
+			},
+			wantErrCode: "validation_error",
+		},
+		{
+			name: "negative cost",
+			span: IngestSpanInput{
+				Name: "llm",
+				Cost: &SpanCostInput{TotalCostUSD: ptr(-1.0)},
+			},
+			wantErrCode: "validation_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			req := &IngestTraceRequest{Name: "chat", Spans: []IngestSpanInput{tt.span}}
+
+			input, err := h.buildTraceInput(req, "proj-1", false, nil)
+			if tt.wantErrCode != "" {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantErrCode {
+					t.Fatalf("buildTraceInput() error = %v, want %s", err, tt.wantErrCode)
+				}
+				if field := apiErr.Detail().Field; field != "spans[0].cost" {
+					t.Errorf("error field = %q, want spans[0].cost", field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+
+			span := input.Spans[0]
+			if !equalCost(span.CostOverrideUSD, tt.wantCost) {
+				t.Errorf("CostOverrideUSD = %v, want %v", deref(span.CostOverrideUSD), deref(tt.wantCost))
+			}
+			if !equalCost(span.PromptCostUSD, tt.wantPrompt) {
+				t.Errorf("PromptCostUSD = %v, want %v", deref(span.PromptCostUSD), deref(tt.wantPrompt))
+			}
+			if !equalCost(span.CompletionCostUSD, tt.wantCompletion) {
+				t.Errorf("CompletionCostUSD = %v, want %v", deref(span.CompletionCostUSD), deref(tt.wantCompletion))
+			}
+			if span.CostSource != tt.wantSource {
+				t.Errorf("CostSource = %q, want %q", span.CostSource, tt.wantSource)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func equalCost(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// decodeBody decodes a JSON request body into v. Failures are returned as
+// 400 invalid_request_body naming the offending field, expected type and
+// byte offset where known, or as 413 request_too_large past the raw body
+// limit. Unknown fields are rejected when
+// JSON_DISALLOW_UNKNOWN_FIELDS is set.
+func (h *Handler) decodeBody(r *http.Request, v any) error {
+	dec := h.newDecoder(r.Body)
+	if h.cfg.JSONDisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		if apiErr := middleware.BodyTooLarge(err); apiErr != nil {
+			return apiErr
+		}
+		slog.Warn("failed to decode request", "error", err)
+		return decodeError(err)
+	}
+
+	// The body must hold exactly one JSON value
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if apiErr := middleware.BodyTooLarge(err); apiErr != nil {
+			return apiErr
+		}
+		return response.NewError(http.StatusBadRequest, "invalid_request_body", "request body contains data after the JSON value")
+	}
+	return nil
+}
+
+// newDecoder returns a JSON decoder honouring JSON_NUMBER_MODE. In exact
+// mode numbers in free-form values decode as json.Number, so large integer
+// IDs in metadata survive unrounded; typed fields are unaffected.
+func (h *Handler) newDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if h.cfg.JSONNumberMode == "exact" {
+		dec.UseNumber()
+	}
+	return dec
+}
+
+// unmarshalJSON decodes data into v like decodeBody, for bodies stored
+// before decoding (e.g. upload sessions)
+func (h *Handler) unmarshalJSON(data []byte, v any) error {
+	return h.newDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// checkContentType rejects request bodies whose Content-Type is not in
+// accepted with 415 unsupported_media_type listing the accepted types.
+// A missing Content-Type is treated as application/json, as sent by older clients.
+func checkContentType(r *http.Request, accepted []string) error {
+	mediaType := "application/json"
+	if header := r.Header.Get("Content-Type"); header != "" {
+		parsed, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			parsed = header
+		}
+		mediaType = parsed
+	}
+	if slices.Contains(accepted, mediaType) {
+		return nil
+	}
+	apiErr := response.NewError(http.StatusUnsupportedMediaType, "unsupported_media_type",
+		fmt.Sprintf("Content-Type %q is not supported, use one of: %s", mediaType, strings.Join(accepted, ", ")))
+	apiErr.Details = map[string]any{"accepted": accepted}
+	return apiErr
+}
+
+// decodeError describes a json.Decoder failure as an invalid_request_body error
+func decodeError(err error) *response.APIError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return response.NewError(http.StatusBadRequest, "invalid_request_body", "request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return response.NewError(http.StatusBadRequest, "invalid_request_body", "request body is truncated JSON")
+	case errors.As(err, &syntaxErr):
+		apiErr := response.NewError(http.StatusBadRequest, "invalid_request_body",
+			fmt.Sprintf("malformed JSON at byte offset %d: %s", syntaxErr.Offset, syntaxErr))
+		apiErr.Details = map[string]any{"offset": syntaxErr.Offset}
+		return apiErr
+	case errors.As(err, &typeErr):
+		expected := jsonTypeName(typeErr.Type)
+		if isIntegerOverflow(typeErr) {
+			expected = integerRangeName(typeErr.Type)
+		}
+		apiErr := response.NewError(http.StatusBadRequest, "invalid_request_body",
+			fmt.Sprintf("field %q must be %s, got %s (byte offset %d)", typeErr.Field, expected, typeErr.Value, typeErr.Offset))
+		apiErr.Details = map[string]any{
+			"field":    typeErr.Field,
+			"expected": expected,
+			"got":      typeErr.Value,
+			"offset":   typeErr.Offset,
+		}
+		return apiErr
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// DisallowUnknownFields has no typed error
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		apiErr := response.NewError(http.StatusBadRequest, "invalid_request_body", fmt.Sprintf("unknown field %q", field))
+		apiErr.Details = map[string]any{"field": field}
+		return apiErr
+	default:
+		// e.g. custom UnmarshalJSON failures such as an unparseable timestamp
+		return response.NewError(http.StatusBadRequest, "invalid_request_body", "invalid request body: "+err.Error())
+	}
+}
+
+// isIntegerOverflow reports whether a type error is a whole number too
+// large for an integer field, as opposed to a fraction or another type
+func isIntegerOverflow(typeErr *json.UnmarshalTypeError) bool {
+	digits, ok := strings.CutPrefix(typeErr.Value, "number ")
+	if !ok || strings.ContainsAny(digits, ".eE") {
+		return false
+	}
+	t := typeErr.Type
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// integerRangeName describes the values an integer type holds
+func integerRangeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	bits := t.Bits()
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("an integer between 0 and %d", uint64(math.MaxUint64)>>(64-bits))
+	default:
+		return fmt.Sprintf("an integer between %d and %d", int64(math.MinInt64)>>(64-bits), int64(math.MaxInt64)>>(64-bits))
+	}
+}
+
+// jsonTypeName names the JSON type a Go type decodes from
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return t.String()
+	}
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		disallowUnknown bool
+		wantMessage     string         // Substring of the error message; empty expects success
+		wantDetails     map[string]any // Expected entries of the error details
+	}{
+		{name: "valid", body: `{"name":"chat","spans":[{"name":"llm"}]}`},
+		{name: "empty", body: ``, wantMessage: "request body is empty"},
+		{name: "truncated", body: `{"name":"chat","spans":[`, wantMessage: "request body is truncated JSON"},
+		{
+			name:        "syntax error",
+			body:        `{"name":"chat",}`,
+			wantMessage: "malformed JSON at byte offset 16",
+			wantDetails: map[string]any{"offset": int64(16)},
+		},
+		{
+			name:        "wrong type",
+			body:        `{"name":"chat","spans":[{"name":"llm"},{"name":42}]}`,
+			wantMessage: `field "spans.1.name" must be a string, got number (byte offset 49)`,
+			wantDetails: map[string]any{"field": "spans.1.name", "expected": "a string", "got": "number"},
+		},
+		{
+			name:        "integer overflow",
+			body:        `{"name":"chat","spans":[{"name":"llm","usage":{"prompt_tokens":10000000000000000000}}]}`,
+			wantMessage: "must be an integer between -9223372036854775808 and 9223372036854775807",
+		},
+		{name: "trailing data", body: `{"name":"chat"} {}`, wantMessage: "data after the JSON value"},
+		{name: "unknown field allowed", body: `{"name":"chat","nmae":"typo"}`},
+		{
+			name:            "unknown field rejected",
+			body:            `{"name":"chat","nmae":"typo"}`,
+			disallowUnknown: true,
+			wantMessage:     `unknown field "nmae"`,
+			wantDetails:     map[string]any{"field": "nmae"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, func(cfg *config.Config) { cfg.JSONDisallowUnknownFields = tt.disallowUnknown })
+			r := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(tt.body))
+
+			var req IngestTraceRequest
+			err := h.decodeBody(r, &req)
+			if tt.wantMessage == "" {
+				if err != nil {
+					t.Fatalf("decodeBody: %v", err)
+				}
+				return
+			}
+
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != "invalid_request_body" {
+				t.Fatalf("decodeBody() error = %v, want invalid_request_body", err)
+			}
+			if !strings.Contains(apiErr.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", apiErr.Message, tt.wantMessage)
+			}
+			for key, want := range tt.wantDetails {
+				if got := apiErr.Details[key]; got != want {
+					t.Errorf("details[%s] = %v (%T), want %v (%T)", key, got, got, want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	accepted := []string{"application/json", "application/x-protobuf"}
+	tests := []struct {
+		name        string
+		contentType string
+		wantType    string // Media type named in the error; empty expects success
+	}{
+		{name: "missing", contentType: ""},
+		{name: "json", contentType: "application/json"},
+		{name: "json with charset", contentType: "application/json; charset=utf-8"},
+		{name: "protobuf", contentType: "application/x-protobuf"},
+		{name: "form", contentType: "application/x-www-form-urlencoded", wantType: "application/x-www-form-urlencoded"},
+		{name: "not accepted", contentType: "application/protobuf", wantType: "application/protobuf"},
+		{name: "unparseable", contentType: "text/plain;;", wantType: "text/plain;;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(`{}`))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			err := checkContentType(r, accepted)
+			if tt.wantType == "" {
+				if err != nil {
+					t.Fatalf("checkContentType: %v", err)
+				}
+				return
+			}
+
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnsupportedMediaType || apiErr.Code != "unsupported_media_type" {
+				t.Fatalf("checkContentType() error = %v, want 415 unsupported_media_type", err)
+			}
+			if !strings.Contains(apiErr.Message, `"`+tt.wantType+`"`) {
+				t.Errorf("message = %q, want it to name %q", apiErr.Message, tt.wantType)
+			}
+			if got, ok := apiErr.Details["accepted"].([]string); !ok || !slices.Equal(got, accepted) {
+				t.Errorf("details[accepted] = %v, want %v", apiErr.Details["accepted"], accepted)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyNumberMode(t *testing.T) {
+	// 2^53+1 can't be represented as a float64
+	body := `{"name":"chat","metadata":{"external_id":9007199254740993},"spans":[{"name":"llm","usage":{"prompt_tokens":12}}]}`
+
+	tests := []struct {
+		mode string
+		want any
+	}{
+		{mode: "float", want: float64(9007199254740992)},
+		{mode: "exact", want: json.Number("9007199254740993")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			h := newTestHandler(t, func(cfg *config.Config) { cfg.JSONNumberMode = tt.mode })
+			r := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(body))
+
+			var req IngestTraceRequest
+			if err := h.decodeBody(r, &req); err != nil {
+				t.Fatalf("decodeBody: %v", err)
+			}
+			if got := req.Metadata["external_id"]; got != tt.want {
+				t.Errorf("metadata[external_id] = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+			// Typed fields decode the same in either mode
+			if got := req.Spans[0].Usage.PromptTokens; got == nil || *got != 12 {
+				t.Errorf("prompt_tokens = %v, want 12", got)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyTokenCounts(t *testing.T) {
+	tests := []struct {
+		name         string
+		tokens       string
+		want         int64
+		wantExpected string // Expected type in the invalid_request_body error; empty expects success
+	}{
+		{name: "beyond int32", tokens: "2147483648", want: 2147483648},
+		// 2^53+1 would round to 2^53 through a float64
+		{name: "beyond 2^53", tokens: "9007199254740993", want: 9007199254740993},
+		{name: "int64 max", tokens: "9223372036854775807", want: 9223372036854775807},
+		{name: "beyond int64", tokens: "9223372036854775808", wantExpected: "an integer between -9223372036854775808 and 9223372036854775807"},
+		{name: "fractional", tokens: "12.5", wantExpected: "an integer"},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []string{"float", "exact"} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				h := newTestHandler(t, func(cfg *config.Config) { cfg.JSONNumberMode = mode })
+				body := `{"name":"chat","spans":[{"name":"llm","usage":{"prompt_tokens":` + tt.tokens + `}}]}`
+				r := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(body))
+
+				var req IngestTraceRequest
+				err := h.decodeBody(r, &req)
+				if tt.wantExpected == "" {
+					if err != nil {
+						t.Fatalf("decodeBody: %v", err)
+					}
+					if got := req.Spans[0].Usage.PromptTokens; got == nil || *got != tt.want {
+						t.Fatalf("prompt_tokens = %v, want %d", got, tt.want)
+					}
+
+					// The worker parses the workflow input as JavaScript
+					// numbers, so the count must reach it as a string
+					input, err := h.buildTraceInput(&req, "proj-1", false, nil)
+					if err != nil {
+						t.Fatalf("buildTraceInput: %v", err)
+					}
+					payload, err := json.Marshal(input.Spans[0])
+					if err != nil {
+						t.Fatalf("marshal span: %v", err)
+					}
+					if want := `"promptTokens":"` + tt.tokens + `"`; !strings.Contains(string(payload), want) {
+						t.Errorf("workflow span = %s, want %s", payload, want)
+					}
+					return
+				}
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != "invalid_request_body" {
+					t.Fatalf("decodeBody() error = %v, want invalid_request_body", err)
+				}
+				if got := apiErr.Details["expected"]; got != tt.wantExpected {
+					t.Errorf("expected = %v, want %q", got, tt.wantExpected)
+				}
+				if got := apiErr.Details["got"]; got != "number "+tt.tokens {
+					t.Errorf("got = %v, want the value as sent", got)
+				}
+				// Newer Go releases include the array index in the path
+				if field := apiErr.Detail().Field; !strings.HasPrefix(field, "spans.") || !strings.HasSuffix(field, ".usage.prompt_tokens") {
+					t.Errorf("field = %q, want spans[0].usage.prompt_tokens", field)
+				}
+			})
+		}
+	}
+}
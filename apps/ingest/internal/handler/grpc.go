@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	cognobservev1 "github.com/cognobserve/ingest/internal/proto/cognobserve/v1"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// IngestService implements the gRPC IngestService.
+// Requests are converted to their HTTP equivalents so both transports share
+// validation and the Temporal client.
+type IngestService struct {
+	cognobservev1.UnimplementedIngestServiceServer
+
+	h *Handler
+}
+
+// NewIngestService creates the gRPC ingest service backed by h
+func NewIngestService(h *Handler) *IngestService {
+	return &IngestService{h: h}
+}
+
+// IngestTrace handles the IngestTrace RPC
+func (s *IngestService) IngestTrace(ctx context.Context, req *cognobservev1.IngestTraceRequest) (*cognobservev1.IngestTraceResponse, error) {
+	input, err := s.h.buildTraceInput(traceRequestFromProto(req), middleware.GetProjectID(ctx), false, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.h.prepareTrace(ctx, &input, nil); err != nil {
+		return nil, err
+	}
+
+	if s.h.isRepeatedSend(ctx, input) {
+		return &cognobservev1.IngestTraceResponse{
+			TraceId:      input.ID,
+			SpanIds:      spanIDs(input),
+			Deduplicated: true,
+			Success:      true,
+		}, nil
+	}
+
+	usage, err := s.h.chargeBudget(ctx, &input)
+	if err != nil {
+		s.h.forgetSend(ctx, input)
+		return nil, err
+	}
+
+	result, err := s.h.temporalClient.StartTraceWorkflow(ctx, input)
+	if err != nil {
+		s.h.refundBudget(ctx, usage)
+		s.h.forgetSend(ctx, input)
+		if classifyStartError(ctx, err, input.ID) != startFailureServerError {
+			// Canceled or DeadlineExceeded, matching the caller's context
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, status.Error(codes.Internal, "failed to process trace")
+	}
+	slog.Info("trace workflow started", "trace_id", input.ID, "workflow_id", result.WorkflowID, "duplicate", result.Duplicate, "transport", "grpc")
+	if result.Duplicate {
+		s.h.refundBudget(ctx, usage)
+	} else {
+		recordPayloadSizes(input)
+		s.h.notifyIngested(ctx, input)
+	}
+
+	return &cognobservev1.IngestTraceResponse{
+		TraceId:    input.ID,
+		SpanIds:    spanIDs(input),
+		WorkflowId: result.WorkflowID,
+		Duplicate:  result.Duplicate,
+		Success:    true,
+	}, nil
+}
+
+// IngestScore handles the IngestScore RPC
+func (s *IngestService) IngestScore(ctx context.Context, req *cognobservev1.IngestScoreRequest) (*cognobservev1.IngestScoreResponse, error) {
+	input, err := s.h.buildScoreInput(req, middleware.GetProjectID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.h.validateScoreValue(ctx, input); err != nil {
+		return nil, err
+	}
+
+	workflowID, err := s.h.temporalClient.StartScoreWorkflow(ctx, input)
+	if err != nil {
+		slog.Error("failed to start score workflow", "error", err, "score_id", input.ID)
+		return nil, status.Error(codes.Internal, "failed to process score")
+	}
+	slog.Info("score workflow started", "score_id", input.ID, "workflow_id", workflowID, "transport", "grpc")
+
+	return &cognobservev1.IngestScoreResponse{
+		ScoreId:    input.ID,
+		WorkflowId: workflowID,
+		Success:    true,
+	}, nil
+}
+
+// buildScoreInput validates a score request and converts it into workflow input
+func (h *Handler) buildScoreInput(req *cognobservev1.IngestScoreRequest, projectID string) (temporal.ScoreWorkflowInput, error) {
+	if req.GetName() == "" {
+		return temporal.ScoreWorkflowInput{}, validationError("name is required")
+	}
+
+	var value any
+	switch v := req.GetValue().(type) {
+	case *cognobservev1.IngestScoreRequest_NumericValue:
+		if err := checkNumericScore(v.NumericValue); err != nil {
+			return temporal.ScoreWorkflowInput{}, err
+		}
+		value = v.NumericValue
+	case *cognobservev1.IngestScoreRequest_StringValue:
+		value = v.StringValue
+	case *cognobservev1.IngestScoreRequest_BooleanValue:
+		value = v.BooleanValue
+	default:
+		return temporal.ScoreWorkflowInput{}, validationError("value is required")
+	}
+
+	if req.GetTraceId() == "" && req.GetSpanId() == "" && req.GetSessionId() == "" && req.GetUserId() == "" {
+		return temporal.ScoreWorkflowInput{}, validationError("one of trace_id, span_id, session_id or user_id is required")
+	}
+
+	scoreID := req.GetScoreId()
+	if scoreID == "" {
+		scoreID = h.ids.New()
+	}
+
+	return temporal.ScoreWorkflowInput{
+		ID:            scoreID,
+		ProjectID:     projectID,
+		ConfigID:      req.GetConfigId(),
+		TraceID:       req.GetTraceId(),
+		SpanID:        req.GetSpanId(),
+		SessionID:     req.GetSessionId(),
+		TrackedUserID: req.GetUserId(),
+		Name:          req.GetName(),
+		Value:         value,
+		Comment:       req.GetComment(),
+		Metadata:      structMap(req.GetMetadata()),
+	}, nil
+}
+
+// traceRequestFromProto converts a gRPC trace request into its JSON equivalent
+func traceRequestFromProto(req *cognobservev1.IngestTraceRequest) *IngestTraceRequest {
+	out := &IngestTraceRequest{
+		TraceID:         req.TraceId,
+		SessionID:       req.SessionId,
+		UserID:          req.UserId,
+		Environment:     req.Environment,
+		Release:         req.Release,
+		Tags:            req.GetTags(),
+		AllowEmptyTrace: req.GetAllowEmptyTrace(),
+		Status:          traceStatusName(req.GetStatus()),
+		Name:            req.GetName(),
+		Metadata:        structMap(req.GetMetadata()),
+		Spans:           make([]IngestSpanInput, len(req.GetSpans())),
+	}
+
+	if user := req.GetUser(); user != nil {
+		out.User = &UserInfoInput{
+			Name:     user.Name,
+			Email:    user.Email,
+			Metadata: structMap(user.GetMetadata()),
+		}
+	}
+
+	for i, s := range req.GetSpans() {
+		span := IngestSpanInput{
+			SpanID:          s.SpanId,
+			ParentSpanID:    s.ParentSpanId,
+			Name:            s.GetName(),
+			Input:           structMap(s.GetInput()),
+			Output:          structMap(s.GetOutput()),
+			Metadata:        structMap(s.GetMetadata()),
+			Model:           s.Model,
+			ModelParameters: structMap(s.GetModelParameters()),
+			Level:           spanLevelName(s.GetLevel()),
+			StatusMessage:   s.StatusMessage,
+		}
+		for _, a := range s.GetAttachments() {
+			span.Attachments = append(span.Attachments, AttachmentInput{
+				Type:          a.GetType(),
+				Mime:          a.GetMime(),
+				URL:           a.Url,
+				ContentBase64: a.ContentBase64,
+				SHA256:        a.Sha256,
+			})
+		}
+		if s.GetStartTime() != nil {
+			span.StartTime = &FlexibleTime{Time: s.GetStartTime().AsTime()}
+		}
+		if s.GetEndTime() != nil {
+			span.EndTime = &FlexibleTime{Time: s.GetEndTime().AsTime()}
+		}
+		if usage := s.GetUsage(); usage != nil {
+			span.Usage = &TokenUsageInput{
+				PromptTokens:     usage.PromptTokens,
+				CompletionTokens: usage.CompletionTokens,
+				TotalTokens:      usage.TotalTokens,
+			}
+		}
+		out.Spans[i] = span
+	}
+
+	return out
+}
+
+// spanLevelName maps a proto SpanLevel to the level names used over JSON
+func spanLevelName(level cognobservev1.SpanLevel) string {
+	if level == cognobservev1.SpanLevel_SPAN_LEVEL_UNSPECIFIED {
+		return ""
+	}
+	return strings.TrimPrefix(level.String(), "SPAN_LEVEL_")
+}
+
+// traceStatusName maps a proto TraceStatus to the status names used over JSON
+func traceStatusName(status cognobservev1.TraceStatus) string {
+	if status == cognobservev1.TraceStatus_TRACE_STATUS_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(status.String(), "TRACE_STATUS_"))
+}
+
+// structMap converts an optional protobuf Struct to a map, keeping nil as nil
+func structMap(s *structpb.Struct) map[string]any {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
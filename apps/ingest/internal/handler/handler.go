@@ -1,17 +1,99 @@
 package handler
 
 import (
+	"context"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/budget"
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/dedup"
+	"github.com/cognobserve/ingest/internal/geoip"
+	"github.com/cognobserve/ingest/internal/idgen"
+	"github.com/cognobserve/ingest/internal/liveness"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/schema"
 	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/upload"
+	"github.com/cognobserve/ingest/internal/webapi"
+	"github.com/cognobserve/ingest/internal/webhook"
 )
 
+// TemporalClient is the part of *temporal.Client the handlers use, so
+// tests can substitute a fake
+type TemporalClient interface {
+	StartTraceWorkflow(ctx context.Context, input temporal.TraceWorkflowInput) (*temporal.StartResult, error)
+	StartTraceWorkflowsBatch(ctx context.Context, inputs []temporal.TraceWorkflowInput) []temporal.BatchStartResult
+	WaitTraceWorkflow(ctx context.Context, workflowID, runID string) (*temporal.TraceWorkflowResult, error)
+	QueryTraceProgress(ctx context.Context, projectID, traceID string) (*temporal.TraceStatus, error)
+	QueryTraceInput(ctx context.Context, projectID, traceID string) (*temporal.TraceWorkflowInput, error)
+	UpdateSpan(ctx context.Context, projectID, traceID string, update temporal.SpanUpdateInput) error
+	CancelSessionTraces(ctx context.Context, projectID, sessionID string) (int, error)
+	StartScoreWorkflow(ctx context.Context, input temporal.ScoreWorkflowInput) (string, error)
+}
+
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	temporalClient *temporal.Client
+	cfg            *config.Config
+	temporalClient TemporalClient
+	watchdog       *temporal.Watchdog
+	heartbeat      *liveness.Heartbeat
+	webAPI         *webapi.Client
+	dedup          *dedup.Deduplicator // nil unless TRACE_DEDUP_ENABLED
+	schemas        *schema.Registry    // nil unless OUTPUT_SCHEMA_VALIDATION
+	webhooks       *webhook.Dispatcher // nil unless WEBHOOKS_ENABLED
+	uploads        *upload.Store       // nil unless UPLOAD_SESSIONS_ENABLED
+	budgets        *budget.Tracker     // nil unless COST_BUDGET_MODE is soft or hard
+	geo            geoip.Lookup        // nil without GEOIP_DATABASE
+	ids            *idgen.Generator
+	startedAt      time.Time
 }
 
-// New creates a new Handler with Temporal client
-func New(temporalClient *temporal.Client) *Handler {
-	return &Handler{
+// New creates a new Handler with config, Temporal client and its watchdog,
+// and the process heartbeat checked by /health/live.
+// startedAt is the process start time, used to report uptime.
+// deduplicator may be nil to disable trace deduplication, uploads to
+// disable upload sessions, budgets to disable cost budgets and geo to skip
+// client location lookups.
+func New(cfg *config.Config, temporalClient TemporalClient, watchdog *temporal.Watchdog, heartbeat *liveness.Heartbeat, deduplicator *dedup.Deduplicator, uploads *upload.Store, budgets *budget.Tracker, geo geoip.Lookup, startedAt time.Time) *Handler {
+	h := &Handler{
+		cfg:            cfg,
 		temporalClient: temporalClient,
+		watchdog:       watchdog,
+		heartbeat:      heartbeat,
+		webAPI:         webapi.New(cfg),
+		dedup:          deduplicator,
+		uploads:        uploads,
+		budgets:        budgets,
+		geo:            geo,
+		ids:            idgen.New(cfg.IDStrategy, cfg.IDLength),
+		startedAt:      startedAt,
+	}
+	if cfg.OutputSchemaValidation {
+		h.schemas = schema.NewRegistry(h.webAPI, cfg.OutputSchemaCacheTTL)
+	}
+	if cfg.WebhooksEnabled {
+		h.webhooks = webhook.New(cfg.WebhookWorkers, cfg.WebhookQueueSize, cfg.WebhookMaxAttempts, cfg.WebhookTimeout)
+	}
+	return h
+}
+
+// Close delivers queued webhook events, giving up when ctx is done.
+// Call it after in-flight requests have drained.
+func (h *Handler) Close(ctx context.Context) error {
+	return h.webhooks.Close(ctx)
+}
+
+// notifyIngested queues a webhook event for a newly started trace
+// workflow, if the project has a webhook. It never blocks.
+func (h *Handler) notifyIngested(ctx context.Context, input temporal.TraceWorkflowInput) {
+	target := middleware.GetProjectWebhook(ctx)
+	if h.webhooks == nil || target == nil {
+		return
 	}
+	h.webhooks.Enqueue(webhook.Target{URL: target.URL, Secret: target.Secret}, webhook.Event{
+		TraceID:   input.ID,
+		ProjectID: input.ProjectID,
+		SpanCount: len(input.Spans),
+		Timestamp: time.Now().UTC(),
+	})
 }
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+// testConfig loads the default configuration, as the service would with
+// only the required secrets set. mutate, if non-nil, adjusts it.
+func testConfig(t testing.TB, mutate func(*config.Config)) *config.Config {
+	t.Helper()
+	t.Setenv("INTERNAL_API_SECRET", "test-internal-secret-0123456789abcdef")
+	t.Setenv("JWT_SHARED_SECRET", "test-jwt-secret-0123456789abcdef0123")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if mutate != nil {
+		mutate(cfg)
+	}
+	return cfg
+}
+
+// newTestHandler creates a Handler without Temporal or Redis backends
+func newTestHandler(t testing.TB, mutate func(*config.Config)) *Handler {
+	t.Helper()
+	return New(testConfig(t, mutate), nil, nil, nil, nil, nil, nil, nil, time.Now())
+}
+
+// newTemporalTestHandler creates a Handler that starts workflows on a fake
+// Temporal client, without Redis backends
+func newTemporalTestHandler(t *testing.T, client *temporaltest.Client, mutate func(*config.Config)) *Handler {
+	t.Helper()
+	watchdog := temporal.NewWatchdog(client, time.Minute, 3, false)
+	return New(testConfig(t, mutate), client, watchdog, nil, nil, nil, nil, nil, time.Now())
+}
@@ -3,23 +3,96 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/cognobserve/ingest/internal/config"
 )
 
 type HealthResponse struct {
-	Status  string `json:"status"`
-	Version string `json:"version"`
+	Status            string `json:"status"`
+	Version           string `json:"version"`
+	GitCommit         string `json:"git_commit"`
+	UptimeSeconds     int64  `json:"uptime_seconds"`
+	TemporalConnected bool   `json:"temporal_connected"` // Cached flag from the watchdog, no live probe
 }
 
 // Health handles GET /health
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	resp := HealthResponse{
-		Status:  "ok",
-		Version: config.Version,
+		Status:            "ok",
+		Version:           config.Version,
+		GitCommit:         config.Commit,
+		UptimeSeconds:     int64(time.Since(h.startedAt).Seconds()),
+		TemporalConnected: h.watchdog.Status().Connected,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+type ReadyResponse struct {
+	Status   string                 `json:"status"`
+	Temporal TemporalHealthResponse `json:"temporal"`
+}
+
+type TemporalHealthResponse struct {
+	Connected           bool    `json:"connected"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	LastReconnectAt     *string `json:"last_reconnect_at,omitempty"` // ISO 8601, omitted if never re-dialed
+}
+
+// Ready handles GET /health/ready
+// Returns 503 while the Temporal connection is considered down.
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	status := h.watchdog.Status()
+
+	resp := ReadyResponse{
+		Status: "ready",
+		Temporal: TemporalHealthResponse{
+			Connected:           status.Connected,
+			ConsecutiveFailures: status.ConsecutiveFailures,
+		},
+	}
+	if !status.LastReconnect.IsZero() {
+		lastReconnect := status.LastReconnect.Format(time.RFC3339)
+		resp.Temporal.LastReconnectAt = &lastReconnect
+	}
+
+	code := http.StatusOK
+	if !status.Connected {
+		resp.Status = "not_ready"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type LiveResponse struct {
+	Status         string `json:"status"`
+	HeartbeatAgeMs int64  `json:"heartbeat_age_ms"`
+}
+
+// Live handles GET /health/live
+// Unlike /health/ready it checks no dependencies, only that the process can
+// still round-trip through its heartbeat goroutine. Returns 503 once the
+// heartbeat is older than LIVENESS_STALE_AFTER, so a wedged pod is restarted.
+func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
+	age, alive := h.heartbeat.Check(r.Context(), h.cfg.LivenessStaleAfter)
+
+	resp := LiveResponse{
+		Status:         "alive",
+		HeartbeatAgeMs: age.Milliseconds(),
+	}
+	code := http.StatusOK
+	if !alive {
+		resp.Status = "wedged"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
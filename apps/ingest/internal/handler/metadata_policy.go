@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// FilteredKeysMetadataKey records how many metadata keys a project's
+// metadata policy dropped from a trace and its spans
+const FilteredKeysMetadataKey = "_filtered_keys"
+
+// ReservedMetadataPrefix starts every metadata key ingestion adds itself.
+// Client keys with it are dropped, so a client can't forge system keys.
+const ReservedMetadataPrefix = "_"
+
+// systemMetadataKeys are keys ingestion adds itself before the policy
+// applies; they are never filtered. Clients can't send them, as their
+// reserved prefix is dropped from client metadata first.
+var systemMetadataKeys = map[string]struct{}{
+	BackfillMetadataKey:        {},
+	ScrubbedKeysMetadataKey:    {},
+	DurationAnomalyMetadataKey: {},
+	UnknownModelMetadataKey:    {},
+	UnknownProviderMetadataKey: {},
+	UsageSummaryMetadataKey:    {},
+}
+
+// applyMetadataPolicy drops trace and span metadata keys the project's
+// policy doesn't permit. Dropped keys are counted and reported as warnings
+// rather than rejecting the trace. A nil policy, or one with an unknown
+// mode, keeps every key.
+func applyMetadataPolicy(policy *middleware.ProjectMetadataPolicy, input *temporal.TraceWorkflowInput, warn *warnings) {
+	if policy == nil || (policy.Mode != middleware.MetadataPolicyAllow && policy.Mode != middleware.MetadataPolicyBlock) {
+		return
+	}
+
+	var dropped int
+	var filtered []string
+	input.Metadata, filtered = filterMetadata(policy, input.Metadata)
+	if len(filtered) > 0 {
+		dropped += len(filtered)
+		warn.add(WarningMetadataKeysDropped, "metadata", metadataPolicyMessage("metadata", filtered))
+	}
+	for i := range input.Spans {
+		input.Spans[i].Metadata, filtered = filterMetadata(policy, input.Spans[i].Metadata)
+		if len(filtered) > 0 {
+			dropped += len(filtered)
+			path := fmt.Sprintf("spans[%d].metadata", i)
+			warn.add(WarningMetadataKeysDropped, path, metadataPolicyMessage(path, filtered))
+		}
+	}
+	if dropped == 0 {
+		return
+	}
+
+	if input.Metadata == nil {
+		input.Metadata = make(map[string]any, 1)
+	}
+	input.Metadata[FilteredKeysMetadataKey] = dropped
+	metrics.FilteredMetadataKeys.Add(float64(dropped))
+	slog.Warn("metadata keys dropped by project policy",
+		"project_id", input.ProjectID,
+		"trace_id", input.ID,
+		"mode", policy.Mode,
+		"dropped", dropped,
+	)
+}
+
+// filterMetadata returns metadata without the keys policy doesn't permit,
+// and the sorted keys it dropped. The map is copied rather than modified
+// so the caller's map is left intact.
+func filterMetadata(policy *middleware.ProjectMetadataPolicy, metadata map[string]any) (map[string]any, []string) {
+	var dropped []string
+	for key := range metadata {
+		if !metadataKeyPermitted(policy, key) {
+			dropped = append(dropped, key)
+		}
+	}
+	if len(dropped) == 0 {
+		return metadata, nil
+	}
+
+	kept := make(map[string]any, len(metadata)-len(dropped))
+	for key, value := range metadata {
+		if metadataKeyPermitted(policy, key) {
+			kept[key] = value
+		}
+	}
+	slices.Sort(dropped)
+	return kept, dropped
+}
+
+// clientMetadata copies client-sent metadata without keys that carry
+// ReservedMetadataPrefix, reporting any it dropped as a warning at path.
+// The copy leaves the request's map intact for callers that share it.
+func clientMetadata(path string, metadata map[string]any, warn *warnings) map[string]any {
+	var reserved []string
+	for key := range metadata {
+		if strings.HasPrefix(key, ReservedMetadataPrefix) {
+			reserved = append(reserved, key)
+		}
+	}
+	kept := maps.Clone(metadata)
+	if len(reserved) == 0 {
+		return kept
+	}
+
+	for _, key := range reserved {
+		delete(kept, key)
+	}
+	slices.Sort(reserved)
+	warn.add(WarningMetadataKeysDropped, path, fmt.Sprintf("%s keys starting with %q are reserved and were dropped: %s",
+		path, ReservedMetadataPrefix, strings.Join(reserved, ", ")))
+	return kept
+}
+
+// metadataKeyPermitted reports whether policy lets key be stored
+func metadataKeyPermitted(policy *middleware.ProjectMetadataPolicy, key string) bool {
+	if _, ok := systemMetadataKeys[key]; ok {
+		return true
+	}
+	listed := slices.Contains(policy.Keys, key)
+	if policy.Mode == middleware.MetadataPolicyAllow {
+		return listed
+	}
+	return !listed
+}
+
+// metadataPolicyMessage describes the keys dropped from one metadata map
+func metadataPolicyMessage(path string, dropped []string) string {
+	return fmt.Sprintf("%s keys not permitted by the project's metadata policy were dropped: %s", path, strings.Join(dropped, ", "))
+}
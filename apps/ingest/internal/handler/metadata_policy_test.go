@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+func TestApplyMetadataPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       *middleware.ProjectMetadataPolicy
+		wantTrace    []string // Keys kept on the trace, besides FilteredKeysMetadataKey
+		wantSpan     []string // Keys kept on the span
+		wantDropped  int
+		wantWarnings int
+	}{
+		{
+			name:      "default keeps every key",
+			wantTrace: []string{"team", "email", BackfillMetadataKey},
+			wantSpan:  []string{"step", "email"},
+		},
+		{
+			name:         "allow keeps the listed keys",
+			policy:       &middleware.ProjectMetadataPolicy{Mode: middleware.MetadataPolicyAllow, Keys: []string{"team", "step"}},
+			wantTrace:    []string{"team", BackfillMetadataKey},
+			wantSpan:     []string{"step"},
+			wantDropped:  2,
+			wantWarnings: 2,
+		},
+		{
+			name:         "block drops the listed keys",
+			policy:       &middleware.ProjectMetadataPolicy{Mode: middleware.MetadataPolicyBlock, Keys: []string{"email"}},
+			wantTrace:    []string{"team", BackfillMetadataKey},
+			wantSpan:     []string{"step"},
+			wantDropped:  2,
+			wantWarnings: 2,
+		},
+		{
+			name:      "unknown mode keeps every key",
+			policy:    &middleware.ProjectMetadataPolicy{Mode: "deny", Keys: []string{"email"}},
+			wantTrace: []string{"team", "email", BackfillMetadataKey},
+			wantSpan:  []string{"step", "email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceMetadata := map[string]any{"team": "search", "email": "a@example.com", BackfillMetadataKey: true}
+			spanMetadata := map[string]any{"step": "retrieve", "email": "a@example.com"}
+			input := &temporal.TraceWorkflowInput{
+				Metadata: maps.Clone(traceMetadata),
+				Spans:    []temporal.SpanInput{{Metadata: spanMetadata}},
+			}
+			warn := &warnings{}
+
+			applyMetadataPolicy(tt.policy, input, warn)
+
+			dropped, _ := input.Metadata[FilteredKeysMetadataKey].(int)
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", dropped, tt.wantDropped)
+			}
+			delete(input.Metadata, FilteredKeysMetadataKey)
+			assertKeys(t, "trace metadata", input.Metadata, tt.wantTrace)
+			assertKeys(t, "span metadata", input.Spans[0].Metadata, tt.wantSpan)
+			if len(*warn) != tt.wantWarnings {
+				t.Errorf("warnings = %v, want %d", *warn, tt.wantWarnings)
+			}
+			if len(spanMetadata) != 2 {
+				t.Errorf("caller's span metadata was modified: %v", spanMetadata)
+			}
+		})
+	}
+}
+
+func assertKeys(t *testing.T, name string, m map[string]any, want []string) {
+	t.Helper()
+	if len(m) != len(want) {
+		t.Errorf("%s = %v, want keys %v", name, m, want)
+		return
+	}
+	for _, key := range want {
+		if _, ok := m[key]; !ok {
+			t.Errorf("%s = %v, want key %s", name, m, key)
+		}
+	}
+}
+
+func TestBuildTraceInputReservedMetadata(t *testing.T) {
+	allow := &middleware.ProjectMetadataPolicy{Mode: middleware.MetadataPolicyAllow, Keys: []string{"team", "step"}}
+
+	tests := []struct {
+		name      string
+		policy    *middleware.ProjectMetadataPolicy
+		backfill  bool
+		wantTrace []string // Keys kept on the trace, besides FilteredKeysMetadataKey
+		wantSpan  []string
+	}{
+		{name: "no policy", wantTrace: []string{"team"}, wantSpan: []string{"step"}},
+		// A client _backfill would otherwise pass the allow list as a system key
+		{name: "allow drops a client _backfill", policy: allow, wantTrace: []string{"team"}, wantSpan: []string{"step"}},
+		{name: "allow keeps the server's _backfill", policy: allow, backfill: true, wantTrace: []string{"team", BackfillMetadataKey}, wantSpan: []string{"step"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			req := &IngestTraceRequest{
+				Name:     "chat",
+				Metadata: map[string]any{"team": "search", BackfillMetadataKey: "forged", UsageSummaryMetadataKey: "forged"},
+				Spans: []IngestSpanInput{{
+					Name:     "llm",
+					Metadata: map[string]any{"step": "retrieve", ScrubbedKeysMetadataKey: 99},
+				}},
+			}
+			warn := &warnings{}
+
+			input, err := h.buildTraceInput(req, "proj-1", tt.backfill, warn)
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			applyMetadataPolicy(tt.policy, &input, warn)
+
+			delete(input.Metadata, FilteredKeysMetadataKey)
+			delete(input.Metadata, UsageSummaryMetadataKey) // Computed by the server
+			if got := input.Metadata[BackfillMetadataKey]; got == "forged" {
+				t.Errorf("%s = %v, want the client's value dropped", BackfillMetadataKey, got)
+			}
+			assertKeys(t, "trace metadata", input.Metadata, tt.wantTrace)
+			assertKeys(t, "span metadata", input.Spans[0].Metadata, tt.wantSpan)
+
+			var reserved []string
+			for _, w := range *warn {
+				if w.Code == WarningMetadataKeysDropped && strings.Contains(w.Message, "reserved") {
+					reserved = append(reserved, w.Path)
+				}
+			}
+			if !slices.Equal(reserved, []string{"metadata", "spans[0].metadata"}) {
+				t.Errorf("reserved key warnings at %v, want metadata and spans[0].metadata", reserved)
+			}
+			if len(req.Metadata) != 3 {
+				t.Errorf("request metadata was modified: %v", req.Metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// ScrubbedKeysMetadataKey records how many model_parameters values were
+// redacted from a span
+const ScrubbedKeysMetadataKey = "_scrubbed_keys"
+
+// scrubbedValue replaces redacted model_parameters values
+const scrubbedValue = "[REDACTED]"
+
+// checkModelParameters enforces the depth and size limits on span i's
+// model_parameters. Clients sometimes send whole config blobs here.
+func (h *Handler) checkModelParameters(i int, params map[string]any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if depth := jsonDepth(params); depth > h.cfg.MaxModelParametersDepth {
+		return validationError(fmt.Sprintf("spans[%d].model_parameters must be nested at most %d levels deep (got %d)", i, h.cfg.MaxModelParametersDepth, depth))
+	}
+
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return validationError(fmt.Sprintf("spans[%d].model_parameters must be JSON-serializable", i))
+	}
+	if len(encoded) > h.cfg.MaxModelParametersBytes {
+		return response.NewError(http.StatusBadRequest, "model_parameters_too_large",
+			fmt.Sprintf("spans[%d].model_parameters is %d bytes, maximum is %d", i, len(encoded), h.cfg.MaxModelParametersBytes))
+	}
+	return nil
+}
+
+// scrubModelParameters redacts values under sensitive keys at any depth,
+// in place, and returns how many were redacted
+func (h *Handler) scrubModelParameters(params map[string]any) int {
+	if !h.cfg.ScrubModelParameters {
+		return 0
+	}
+	scrubbed := h.scrubValue(params)
+	if scrubbed > 0 {
+		metrics.ScrubbedModelParameters.Add(float64(scrubbed))
+	}
+	return scrubbed
+}
+
+func (h *Handler) scrubValue(v any) int {
+	scrubbed := 0
+	switch x := v.(type) {
+	case map[string]any:
+		for key, value := range x {
+			if h.cfg.SensitiveKeyRegexp.MatchString(key) {
+				x[key] = scrubbedValue
+				scrubbed++
+				continue
+			}
+			scrubbed += h.scrubValue(value)
+		}
+	case []any:
+		for _, item := range x {
+			scrubbed += h.scrubValue(item)
+		}
+	}
+	return scrubbed
+}
+
+// jsonDepth returns the nesting depth of a decoded JSON value; a flat object is 1
+func jsonDepth(v any) int {
+	deepest := 0
+	switch x := v.(type) {
+	case map[string]any:
+		for _, value := range x {
+			deepest = max(deepest, jsonDepth(value))
+		}
+	case []any:
+		for _, item := range x {
+			deepest = max(deepest, jsonDepth(item))
+		}
+	default:
+		return 0
+	}
+	return deepest + 1
+}
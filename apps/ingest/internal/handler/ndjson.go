@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON bodies
+const NDJSONContentType = "application/x-ndjson"
+
+// NDJSONSummary is the last line of an NDJSON ingest response
+type NDJSONSummary struct {
+	Lines        int `json:"lines"` // Non-blank lines read
+	SuccessCount int `json:"success_count"`
+	ErrorCount   int `json:"error_count"`
+
+	// Error is set when the body couldn't be read to the end (e.g. past
+	// MAX_RAW_REQUEST_BYTES); lines after it were not processed
+	Error *response.ErrorDetail `json:"error,omitempty"`
+}
+
+// ndjsonLine is a decoded request line awaiting ingestion
+type ndjsonLine struct {
+	index int // Zero-based line number
+	req   IngestTraceRequest
+	err   error // Decode failure; the line is reported without ingesting
+}
+
+// IngestNDJSON handles POST /v1/traces/ndjson
+// The body holds one trace object per line. Lines are decoded as they are
+// read and ingested in chunks of up to MAX_BATCH_SIZE, so memory stays
+// bounded however long the body is. The response is NDJSON too: a
+// BatchItemResult per non-blank line, whose index is the zero-based line
+// number, followed by an NDJSONSummary. Results are flushed after each
+// chunk. The route is exempt from the request-wide REQUEST_TIMEOUT, which
+// would buffer the stream; instead each chunk gets REQUEST_TIMEOUT to be
+// read, ingested and written, so a stalled client or Temporal still ends
+// the request.
+func (h *Handler) IngestNDJSON(w http.ResponseWriter, r *http.Request) {
+	backfill, err := backfillMode(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+	if err := checkContentType(r, []string{NDJSONContentType}); err != nil {
+		response.WriteError(w, err)
+		return
+	}
+	projectID, err := h.resolveProjectID(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.WriteHeader(http.StatusAccepted)
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	// Deadlines are best effort: connections that don't support them keep
+	// the server's own timeouts
+	var deadline time.Time
+	extendDeadline := func() {
+		if h.cfg.RequestTimeout <= 0 {
+			return
+		}
+		deadline = time.Now().Add(h.cfg.RequestTimeout)
+		_ = rc.SetReadDeadline(deadline)
+		_ = rc.SetWriteDeadline(deadline)
+	}
+	extendDeadline()
+
+	var summary NDJSONSummary
+	chunk := make([]ndjsonLine, 0, h.cfg.MaxBatchSize)
+	flush := func() {
+		ctx := r.Context()
+		if !deadline.IsZero() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+		results := h.ingestNDJSONChunk(ctx, chunk, projectID, backfill)
+		for _, result := range results {
+			if result.Success {
+				summary.SuccessCount++
+			} else {
+				summary.ErrorCount++
+			}
+			_ = enc.Encode(result)
+		}
+		_ = rc.Flush()
+		chunk = chunk[:0]
+		extendDeadline()
+	}
+
+	reader := bufio.NewReader(r.Body)
+	for index := 0; ; index++ {
+		data, readErr := reader.ReadBytes('\n')
+		if data = bytes.TrimSpace(data); len(data) > 0 && (readErr == nil || errors.Is(readErr, io.EOF)) {
+			summary.Lines++
+			line := ndjsonLine{index: index}
+			line.err = h.decodeLine(data, &line.req)
+			chunk = append(chunk, line)
+			if len(chunk) == h.cfg.MaxBatchSize {
+				flush()
+			}
+		}
+		if readErr == nil {
+			continue
+		}
+		if !errors.Is(readErr, io.EOF) {
+			if apiErr := middleware.BodyTooLarge(readErr); apiErr != nil {
+				detail := apiErr.Detail()
+				summary.Error = &detail
+			} else {
+				summary.Error = &response.ErrorDetail{Code: "invalid_request_body", Message: "failed to read request body"}
+			}
+		}
+		break
+	}
+	if len(chunk) > 0 {
+		flush()
+	}
+	_ = enc.Encode(summary)
+
+	slog.Info("ndjson traces processed",
+		"project_id", projectID,
+		"lines", summary.Lines,
+		"succeeded", summary.SuccessCount,
+		"failed", summary.ErrorCount,
+		"truncated", summary.Error != nil,
+	)
+}
+
+// ingestNDJSONChunk ingests the decoded lines of a chunk as one batch and
+// returns their results in line order, including lines that failed to decode
+func (h *Handler) ingestNDJSONChunk(ctx context.Context, chunk []ndjsonLine, projectID string, backfill bool) []BatchItemResult {
+	traces := make([]IngestTraceRequest, 0, len(chunk))
+	for _, line := range chunk {
+		if line.err == nil {
+			traces = append(traces, line.req)
+		}
+	}
+	ingested, _ := h.ingestTraces(ctx, traces, projectID, backfill)
+
+	results := make([]BatchItemResult, len(chunk))
+	next := 0
+	for i, line := range chunk {
+		if line.err != nil {
+			results[i] = BatchItemResult{Error: errorDetail(line.err)}
+		} else {
+			results[i] = ingested[next]
+			next++
+		}
+		results[i].Index = line.index
+	}
+	return results
+}
+
+// decodeLine decodes one NDJSON line into v, with the same rules and
+// errors as decodeBody
+func (h *Handler) decodeLine(data []byte, v any) error {
+	dec := h.newDecoder(bytes.NewReader(data))
+	if h.cfg.JSONDisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return response.NewError(http.StatusBadRequest, "invalid_request_body", "line contains data after the JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+func TestIngestNDJSON(t *testing.T) {
+	blockUntilDone := func(ctx context.Context, _ temporal.TraceWorkflowInput) (*temporal.StartResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		mutate      func(*config.Config)
+		start       func(context.Context, temporal.TraceWorkflowInput) (*temporal.StartResult, error)
+		wantIndexes []int    // Result line numbers, in order
+		wantCodes   []string // Error code per result; "" for success
+		wantStarted int
+	}{
+		{
+			name:        "mixed valid and invalid lines",
+			body:        `{"name":"a","spans":[{"name":"s"}]}` + "\n" + `{"name":` + "\n" + `{"spans":[{"name":"s"}]}` + "\n" + `{"name":"d","spans":[{"name":"s"}]}`,
+			wantIndexes: []int{0, 1, 2, 3},
+			wantCodes:   []string{"", "invalid_request_body", "validation_error", ""},
+			wantStarted: 2,
+		},
+		{
+			name:        "blank lines keep line numbers",
+			body:        "\n" + `{"name":"a","spans":[{"name":"s"}]}` + "\n\n" + `{"name":"b","spans":[{"name":"s"}]}` + "\n",
+			wantIndexes: []int{1, 3},
+			wantCodes:   []string{"", ""},
+			wantStarted: 2,
+		},
+		{
+			name:        "lines across chunks",
+			body:        strings.Repeat(`{"name":"a","spans":[{"name":"s"}]}`+"\n", 5),
+			mutate:      func(cfg *config.Config) { cfg.MaxBatchSize = 2 },
+			wantIndexes: []int{0, 1, 2, 3, 4},
+			wantCodes:   []string{"", "", "", "", ""},
+			wantStarted: 5,
+		},
+		{
+			name:        "chunk deadline",
+			body:        `{"name":"a","spans":[{"name":"s"}]}` + "\n" + `{"name":` + "\n",
+			mutate:      func(cfg *config.Config) { cfg.RequestTimeout = 50 * time.Millisecond },
+			start:       blockUntilDone,
+			wantIndexes: []int{0, 1},
+			wantCodes:   []string{"request_timeout", "invalid_request_body"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &temporaltest.Client{StartTraceWorkflowFunc: tt.start}
+			h := newTemporalTestHandler(t, client, tt.mutate)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces/ndjson", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", NDJSONContentType)
+			req.Header.Set("X-Project-ID", "proj-1")
+			rec := httptest.NewRecorder()
+			h.IngestNDJSON(rec, req)
+
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+			}
+
+			var results []BatchItemResult
+			var summary NDJSONSummary
+			scanner := bufio.NewScanner(rec.Body)
+			for scanner.Scan() {
+				var raw map[string]json.RawMessage
+				if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil {
+					t.Fatalf("response line %q: %v", scanner.Text(), err)
+				}
+				if _, ok := raw["lines"]; ok {
+					if err := json.Unmarshal(scanner.Bytes(), &summary); err != nil {
+						t.Fatalf("summary: %v", err)
+					}
+					continue
+				}
+				var result BatchItemResult
+				if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+					t.Fatalf("result: %v", err)
+				}
+				results = append(results, result)
+			}
+
+			if len(results) != len(tt.wantIndexes) {
+				t.Fatalf("got %d results, want %d: %s", len(results), len(tt.wantIndexes), rec.Body)
+			}
+			var wantSuccess int
+			for i, result := range results {
+				if result.Index != tt.wantIndexes[i] {
+					t.Errorf("results[%d].Index = %d, want %d", i, result.Index, tt.wantIndexes[i])
+				}
+				code := ""
+				if result.Error != nil {
+					code = result.Error.Code
+				}
+				if code != tt.wantCodes[i] {
+					t.Errorf("results[%d] error code = %q, want %q", i, code, tt.wantCodes[i])
+				}
+				if result.Success != (tt.wantCodes[i] == "") {
+					t.Errorf("results[%d].Success = %v", i, result.Success)
+				}
+				if tt.wantCodes[i] == "" {
+					wantSuccess++
+				}
+			}
+
+			want := NDJSONSummary{Lines: len(tt.wantIndexes), SuccessCount: wantSuccess, ErrorCount: len(tt.wantIndexes) - wantSuccess}
+			if summary != want {
+				t.Errorf("summary = %+v, want %+v", summary, want)
+			}
+			if got := len(client.Started()); got != tt.wantStarted {
+				t.Errorf("started %d workflows, want %d", got, tt.wantStarted)
+			}
+		})
+	}
+}
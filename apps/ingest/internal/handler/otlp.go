@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cognobserve/ingest/internal/budget"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// OTel attributes mapped onto trace and span fields
+const (
+	otelServiceName           = "service.name"
+	otelServiceVersion        = "service.version"
+	otelDeploymentEnvironment = "deployment.environment"
+	otelGenAISystem           = "gen_ai.system"
+	otelGenAIRequestModel     = "gen_ai.request.model"
+	otelGenAIResponseModel    = "gen_ai.response.model"
+	otelGenAIInputTokens      = "gen_ai.usage.input_tokens"
+	otelGenAIOutputTokens     = "gen_ai.usage.output_tokens"
+)
+
+// OTLPTraceService implements the OTLP/gRPC TraceService.
+// Exported spans are grouped by trace ID and converted to IngestTraceRequest,
+// so OTLP shares validation and workflow starts with the native API.
+//
+// A trace's workflow starts on the first export that contains it; spans of
+// the same trace arriving in a later export are reported as rejected.
+// Collectors should group spans by trace (e.g. the groupbytrace processor).
+type OTLPTraceService struct {
+	coltracepb.UnimplementedTraceServiceServer
+
+	h *Handler
+}
+
+// NewOTLPTraceService creates the OTLP trace service backed by h
+func NewOTLPTraceService(h *Handler) *OTLPTraceService {
+	return &OTLPTraceService{h: h}
+}
+
+// Export handles TraceService/Export. Traces failing validation are
+// reported through partial success; a Temporal failure returns Unavailable
+// so the exporter retries (workflow starts are idempotent per trace ID).
+func (s *OTLPTraceService) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	projectID := middleware.GetProjectID(ctx)
+	requests := tracesFromOTLP(req.GetResourceSpans())
+
+	var rejected int64
+	var firstErr string
+	reject := func(spans int, msg string) {
+		rejected += int64(spans)
+		if firstErr == "" {
+			firstErr = msg
+		}
+	}
+
+	inputs := make([]temporal.TraceWorkflowInput, 0, len(requests))
+	usages := make([]*budget.Usage, 0, len(requests))
+	for _, traceReq := range requests {
+		input, err := s.h.buildTraceInput(traceReq, projectID, false, nil)
+		if err == nil {
+			err = s.h.prepareTrace(ctx, &input, nil)
+		}
+		if err != nil {
+			reject(len(traceReq.Spans), fmt.Sprintf("trace %s: %s", *traceReq.TraceID, err))
+			continue
+		}
+		if s.h.isRepeatedSend(ctx, input) {
+			continue
+		}
+		usage, err := s.h.chargeBudget(ctx, &input)
+		if err != nil {
+			s.h.forgetSend(ctx, input)
+			reject(len(traceReq.Spans), fmt.Sprintf("trace %s: %s", *traceReq.TraceID, err))
+			continue
+		}
+		inputs = append(inputs, input)
+		usages = append(usages, usage)
+	}
+
+	starts := s.h.temporalClient.StartTraceWorkflowsBatch(ctx, inputs)
+	for i, start := range starts {
+		input := inputs[i]
+		if start.Err != nil {
+			// The exporter retries the whole export; refund and forget the
+			// traces that didn't start, as the ones that did are charged once
+			for j := i; j < len(starts); j++ {
+				if starts[j].Err != nil {
+					s.h.refundBudget(ctx, usages[j])
+					s.h.forgetSend(ctx, inputs[j])
+				}
+			}
+			if classifyStartError(ctx, start.Err, input.ID) != startFailureServerError {
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+			return nil, status.Error(codes.Unavailable, "failed to process traces, retry later")
+		}
+		if start.Result.Duplicate {
+			s.h.refundBudget(ctx, usages[i])
+			// Either a retry of an earlier export, or a trace split across
+			// exports whose later spans can't be added to the running workflow
+			reject(len(input.Spans), fmt.Sprintf("trace %s was started by an earlier export; export each trace in a single batch", input.ID))
+			continue
+		}
+		recordPayloadSizes(input)
+		s.h.notifyIngested(ctx, input)
+	}
+
+	slog.Info("otlp traces exported",
+		"project_id", projectID,
+		"traces", len(requests),
+		"rejected_spans", rejected,
+		"transport", "grpc",
+	)
+
+	resp := &coltracepb.ExportTraceServiceResponse{}
+	if rejected > 0 {
+		resp.PartialSuccess = &coltracepb.ExportTracePartialSuccess{
+			RejectedSpans: rejected,
+			ErrorMessage:  firstErr,
+		}
+	}
+	return resp, nil
+}
+
+// tracesFromOTLP groups OTLP spans by trace ID, in first-seen order.
+// Resource attributes become trace metadata; service.version and
+// deployment.environment map to release and environment.
+func tracesFromOTLP(resourceSpans []*tracepb.ResourceSpans) []*IngestTraceRequest {
+	var order []*IngestTraceRequest
+	byTraceID := make(map[string]*IngestTraceRequest)
+	rootNames := make(map[string]string)
+
+	for _, rs := range resourceSpans {
+		resourceAttrs := attributeMap(rs.GetResource().GetAttributes())
+
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				traceID := hex.EncodeToString(span.GetTraceId())
+				req, ok := byTraceID[traceID]
+				if !ok {
+					// Each trace owns its metadata; later stages add keys to it
+					req = &IngestTraceRequest{
+						TraceID:  &traceID,
+						Metadata: maps.Clone(resourceAttrs),
+					}
+					if v, ok := resourceAttrs[otelDeploymentEnvironment].(string); ok {
+						req.Environment = &v
+					}
+					if v, ok := resourceAttrs[otelServiceVersion].(string); ok {
+						req.Release = &v
+					}
+					byTraceID[traceID] = req
+					order = append(order, req)
+				}
+
+				if len(span.GetParentSpanId()) == 0 {
+					if _, ok := rootNames[traceID]; !ok {
+						rootNames[traceID] = span.GetName()
+					}
+				}
+				req.Spans = append(req.Spans, spanFromOTLP(span))
+			}
+		}
+	}
+
+	// Name each trace after its root span, falling back to the service name
+	// and then the first span for partial traces
+	for _, req := range order {
+		switch {
+		case rootNames[*req.TraceID] != "":
+			req.Name = rootNames[*req.TraceID]
+		case req.Metadata[otelServiceName] != nil:
+			req.Name = fmt.Sprint(req.Metadata[otelServiceName])
+		default:
+			req.Name = req.Spans[0].Name
+		}
+	}
+	return order
+}
+
+// spanFromOTLP converts an OTLP span; attributes become span metadata and
+// GenAI semantic-convention attributes fill model and token usage
+func spanFromOTLP(span *tracepb.Span) IngestSpanInput {
+	spanID := hex.EncodeToString(span.GetSpanId())
+	attrs := attributeMap(span.GetAttributes())
+
+	out := IngestSpanInput{
+		SpanID:   &spanID,
+		Name:     span.GetName(),
+		Metadata: attrs,
+	}
+	if parent := span.GetParentSpanId(); len(parent) > 0 {
+		parentID := hex.EncodeToString(parent)
+		out.ParentSpanID = &parentID
+	}
+	if ns := span.GetStartTimeUnixNano(); ns > 0 {
+		out.StartTime = &FlexibleTime{Time: time.Unix(0, int64(ns))}
+	}
+	if ns := span.GetEndTimeUnixNano(); ns > 0 {
+		out.EndTime = &FlexibleTime{Time: time.Unix(0, int64(ns))}
+	}
+
+	if span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+		out.Level = "ERROR"
+		if msg := span.GetStatus().GetMessage(); msg != "" {
+			out.StatusMessage = &msg
+		}
+	}
+
+	if model, ok := attrs[otelGenAIResponseModel].(string); ok {
+		out.Model = &model
+	} else if model, ok := attrs[otelGenAIRequestModel].(string); ok {
+		out.Model = &model
+	}
+	if provider, ok := attrs[otelGenAISystem].(string); ok {
+		out.Provider = &provider
+	}
+
+	prompt, hasPrompt := tokenAttribute(attrs, otelGenAIInputTokens)
+	completion, hasCompletion := tokenAttribute(attrs, otelGenAIOutputTokens)
+	if hasPrompt || hasCompletion {
+		out.Usage = &TokenUsageInput{}
+		if hasPrompt {
+			out.Usage.PromptTokens = &prompt
+		}
+		if hasCompletion {
+			out.Usage.CompletionTokens = &completion
+		}
+		if hasPrompt && hasCompletion && prompt <= math.MaxInt64-completion {
+			total := prompt + completion
+			out.Usage.TotalTokens = &total
+		}
+	}
+
+	return out
+}
+
+// attributeMap converts OTLP attributes into a JSON-friendly map
+func attributeMap(attrs []*commonpb.KeyValue) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(attrs))
+	for _, kv := range attrs {
+		out[kv.GetKey()] = anyValue(kv.GetValue())
+	}
+	return out
+}
+
+// anyValue converts an OTLP AnyValue; bytes are base64-encoded
+func anyValue(v *commonpb.AnyValue) any {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return x.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return x.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return x.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return x.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(x.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]any, len(x.ArrayValue.GetValues()))
+		for i, item := range x.ArrayValue.GetValues() {
+			values[i] = anyValue(item)
+		}
+		return values
+	case *commonpb.AnyValue_KvlistValue:
+		return attributeMap(x.KvlistValue.GetValues())
+	}
+	return nil
+}
+
+// tokenAttribute reads a non-negative integer attribute
+func tokenAttribute(attrs map[string]any, key string) (int64, bool) {
+	v, ok := attrs[key].(int64)
+	if !ok || v < 0 {
+		return 0, false
+	}
+	return v, true
+}
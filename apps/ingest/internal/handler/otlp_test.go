@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"testing"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestTracesFromOTLPMetadataIsPerTrace(t *testing.T) {
+	stringAttr := func(key, value string) *commonpb.KeyValue {
+		return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+	}
+	resourceSpans := []*tracepb.ResourceSpans{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+			stringAttr("service.name", "checkout"),
+			stringAttr(otelDeploymentEnvironment, "prod"),
+		}},
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Spans: []*tracepb.Span{
+				{TraceId: []byte{1}, SpanId: []byte{1}, Name: "first"},
+				{TraceId: []byte{2}, SpanId: []byte{2}, Name: "second"},
+			},
+		}},
+	}}
+
+	traces := tracesFromOTLP(resourceSpans)
+	if len(traces) != 2 {
+		t.Fatalf("got %d traces, want 2", len(traces))
+	}
+
+	traces[0].Metadata["_schema_valid"] = true
+	delete(traces[0].Metadata, "service.name")
+
+	second := traces[1].Metadata
+	if _, ok := second["_schema_valid"]; ok {
+		t.Error("key added to the first trace leaked into the second")
+	}
+	if second["service.name"] != "checkout" {
+		t.Errorf("second trace service.name = %v, want checkout", second["service.name"])
+	}
+	if got := traces[1].Environment; got == nil || *got != "prod" {
+		t.Errorf("second trace environment = %v, want prod", got)
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// SchemaValidMetadataKey records whether a span's output matched the schema
+// registered for its name. Only set on spans that have a schema.
+const SchemaValidMetadataKey = "_schema_valid"
+
+// SchemaViolationsMetadataKey lists why a span's output failed its schema
+const SchemaViolationsMetadataKey = "_schema_violations"
+
+// checkOutputSchemas flags spans whose output drifts from the schema the
+// project registered for the span name. Violations are recorded, never
+// rejected, and lookup failures skip the check so ingestion isn't blocked.
+// Projects with the schema_validation feature disabled are skipped.
+func (h *Handler) checkOutputSchemas(ctx context.Context, input *temporal.TraceWorkflowInput) {
+	if h.schemas == nil || !middleware.GetProjectFeature(ctx, middleware.FeatureSchemaValidation) {
+		return
+	}
+
+	set, err := h.schemas.ForProject(ctx, input.ProjectID)
+	if err != nil {
+		slog.Warn("output schema lookup failed, skipping validation", "error", err, "project_id", input.ProjectID)
+		return
+	}
+	if set == nil {
+		return
+	}
+
+	for i := range input.Spans {
+		span := &input.Spans[i]
+		if span.Output == nil {
+			continue
+		}
+		matched, violations := set.Validate(span.Name, span.Output)
+		if !matched {
+			continue
+		}
+
+		if span.Metadata == nil {
+			span.Metadata = make(map[string]any, 2)
+		}
+		span.Metadata[SchemaValidMetadataKey] = len(violations) == 0
+		if len(violations) == 0 {
+			metrics.OutputSchemaChecks.WithLabelValues("valid").Inc()
+			continue
+		}
+		span.Metadata[SchemaViolationsMetadataKey] = violations
+		metrics.OutputSchemaChecks.WithLabelValues("invalid").Inc()
+		slog.Info("span output violates schema",
+			"project_id", input.ProjectID,
+			"trace_id", input.ID,
+			"span_name", span.Name,
+			"violations", len(violations),
+		)
+	}
+}
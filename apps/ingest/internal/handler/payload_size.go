@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// recordPayloadSizes records each span's input and output size, to show
+// what drives storage growth. Sizes aren't labelled by project: that
+// would make the histogram's series count grow with every tenant.
+func recordPayloadSizes(input temporal.TraceWorkflowInput) {
+	var total int
+	for _, span := range input.Spans {
+		if span.Input != nil {
+			n := encodedSize(span.Input)
+			metrics.SpanPayloadBytes.WithLabelValues("input").Observe(float64(n))
+			total += n
+		}
+		if span.Output != nil {
+			n := encodedSize(span.Output)
+			metrics.SpanPayloadBytes.WithLabelValues("output").Observe(float64(n))
+			total += n
+		}
+	}
+	if total > 0 {
+		metrics.IngestedPayloadBytes.Add(float64(total))
+	}
+}
+
+// encodedSize returns the length of v as encoding/json would marshal it,
+// by walking the decoded value instead of serializing it again
+func encodedSize(v any) int {
+	switch v := v.(type) {
+	case nil:
+		return len("null")
+	case bool:
+		if v {
+			return len("true")
+		}
+		return len("false")
+	case string:
+		return stringSize(v)
+	case json.Number:
+		return len(v)
+	case float64:
+		return floatSize(v)
+	case map[string]any:
+		n := 2 // {}
+		for key, value := range v {
+			n += stringSize(key) + 1 + encodedSize(value) // "key":value
+		}
+		if len(v) > 1 {
+			n += len(v) - 1 // commas
+		}
+		return n
+	case []any:
+		n := 2 // []
+		for _, value := range v {
+			n += encodedSize(value)
+		}
+		if len(v) > 1 {
+			n += len(v) - 1
+		}
+		return n
+	default:
+		b, _ := json.Marshal(v)
+		return len(b)
+	}
+}
+
+// floatSize returns the length of f in encoding/json's format: plain
+// decimals, with exponents only for very small or large magnitudes
+func floatSize(f float64) int {
+	var buf [32]byte
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b := strconv.AppendFloat(buf[:0], f, format, -1, 64)
+	if format == 'e' {
+		// encoding/json trims e-07 to e-7
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			return n - 1
+		}
+	}
+	return len(b)
+}
+
+// stringSize returns the quoted length of s, counting encoding/json's escapes
+func stringSize(s string) int {
+	n := 2 // quotes
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\' || c == '\n' || c == '\r' || c == '\t' || c == '\b' || c == '\f':
+				n += 2
+			case c < 0x20 || c == '<' || c == '>' || c == '&':
+				n += 6 // \u00XX
+			default:
+				n++
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			n += 3 // Replaced with U+FFFD
+		case r == '\u2028' || r == '\u2029':
+			n += 6
+		default:
+			n += size
+		}
+		i += size
+	}
+	return n
+}
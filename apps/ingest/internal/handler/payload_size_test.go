@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestEncodedSizeMatchesMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "null", v: nil},
+		{name: "true", v: true},
+		{name: "false", v: false},
+		{name: "empty string", v: ""},
+		{name: "ascii", v: "hello world"},
+		{name: "escapes", v: "quote \" backslash \\ newline \n tab \t cr \r bs \b ff \f"},
+		{name: "control characters", v: "\x00\x01\x1f"},
+		{name: "html", v: "<script>a && b</script>"},
+		{name: "multibyte", v: "héllo 世界 🚀"},
+		{name: "line separators", v: "a\u2028b\u2029c"},
+		{name: "invalid utf8", v: "a\xffb\xc3"},
+		{name: "json number", v: json.Number("12345.678")},
+		{name: "zero", v: 0.0},
+		{name: "integer", v: 42.0},
+		{name: "negative fraction", v: -3.25},
+		{name: "small", v: 1e-7},
+		{name: "tiny", v: 1.5e-300},
+		{name: "large", v: 1e21},
+		{name: "just below exponent", v: 1e20},
+		{name: "max float", v: math.MaxFloat64},
+		{name: "empty object", v: map[string]any{}},
+		{name: "empty array", v: []any{}},
+		{
+			name: "nested",
+			v: map[string]any{
+				"messages": []any{
+					map[string]any{"role": "user", "content": "What's <2+2>?"},
+					map[string]any{"role": "assistant", "content": "4", "score": 0.98},
+				},
+				"tags":  []any{"a", nil, true, 1.0},
+				"key\n": map[string]any{"inner": []any{}},
+			},
+		},
+		{name: "typed value", v: map[string]string{"fallback": "marshal"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if got := encodedSize(tt.v); got != len(b) {
+				t.Errorf("encodedSize() = %d, want %d (%s)", got, len(b), b)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	cognobservev1 "github.com/cognobserve/ingest/internal/proto/cognobserve/v1"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// isProtobufBody reports whether the request body is a binary protobuf
+// message (application/protobuf or application/x-protobuf)
+func isProtobufBody(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/protobuf" || mediaType == "application/x-protobuf")
+}
+
+// decodeProtoTrace decodes a protobuf IngestTraceRequest body, as defined
+// in proto/cognobserve/v1/ingest.proto, into the request struct shared
+// with the JSON API. The message is converted field by field, as for gRPC,
+// with no JSON step in between.
+func decodeProtoTrace(r *http.Request) (*IngestTraceRequest, error) {
+	body, err := io.ReadAll(r.Body)
+	if apiErr := middleware.BodyTooLarge(err); apiErr != nil {
+		return nil, apiErr
+	}
+	if err != nil {
+		return nil, response.NewError(http.StatusBadRequest, "invalid_request_body", "invalid request body")
+	}
+	if len(body) == 0 {
+		return nil, response.NewError(http.StatusBadRequest, "invalid_request_body", "request body is empty")
+	}
+
+	var msg cognobservev1.IngestTraceRequest
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return nil, response.NewError(http.StatusBadRequest, "invalid_request_body", "malformed protobuf: "+err.Error())
+	}
+	return traceRequestFromProto(&msg), nil
+}
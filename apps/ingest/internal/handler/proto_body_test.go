@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	cognobservev1 "github.com/cognobserve/ingest/internal/proto/cognobserve/v1"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// testTrace returns the same trace as a protobuf message and as JSON
+func testTrace(t testing.TB, spans int) ([]byte, []byte) {
+	t.Helper()
+	start := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	metadata, err := structpb.NewStruct(map[string]any{"team": "search"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &cognobservev1.IngestTraceRequest{
+		TraceId:  proto.String("trace-1"),
+		Name:     "chat",
+		Metadata: metadata,
+		Tags:     []string{"billing"},
+	}
+	var jsonSpans []string
+	for i := 0; i < spans; i++ {
+		id := fmt.Sprintf("span-%d", i)
+		msg.Spans = append(msg.Spans, &cognobservev1.IngestSpan{
+			SpanId:    proto.String(id),
+			Name:      "llm",
+			StartTime: timestamppb.New(start),
+			EndTime:   timestamppb.New(start.Add(time.Second)),
+			Model:     proto.String("gpt-4o"),
+		})
+		jsonSpans = append(jsonSpans, fmt.Sprintf(
+			`{"span_id":%q,"name":"llm","start_time":"2024-05-01T12:30:00Z","end_time":"2024-05-01T12:30:01Z","model":"gpt-4o"}`, id))
+	}
+
+	pb, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := `{"trace_id":"trace-1","name":"chat","metadata":{"team":"search"},"tags":["billing"],"spans":[` + strings.Join(jsonSpans, ",") + `]}`
+	return pb, []byte(js)
+}
+
+func newTraceRequest(body []byte, contentType string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("X-Project-ID", "proj-1")
+	return r
+}
+
+func TestDecodeTraceProtobuf(t *testing.T) {
+	pb, js := testTrace(t, 2)
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		wantErr     string // Substring of the invalid_request_body message
+	}{
+		{name: "protobuf", body: pb, contentType: "application/protobuf"},
+		{name: "x-protobuf", body: pb, contentType: "application/x-protobuf"},
+		{name: "empty", contentType: "application/protobuf", wantErr: "request body is empty"},
+		{name: "malformed", body: []byte{0x0a, 0xff}, contentType: "application/protobuf", wantErr: "malformed protobuf"},
+	}
+
+	h := newTestHandler(t, nil)
+	want, err := h.decodeTrace(newTraceRequest(js, "application/json"), nil)
+	if err != nil {
+		t.Fatalf("decode JSON trace: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.decodeTrace(newTraceRequest(tt.body, tt.contentType), nil)
+			if tt.wantErr != "" {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != "invalid_request_body" || !strings.Contains(apiErr.Message, tt.wantErr) {
+					t.Fatalf("decodeTrace() error = %v, want invalid_request_body %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeTrace: %v", err)
+			}
+			// Protobuf and JSON bodies build the same workflow input
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("protobuf input = %+v\nwant JSON input %+v", got, want)
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeTrace(b *testing.B) {
+	for _, spans := range []int{1, 50} {
+		pb, js := testTrace(b, spans)
+		for _, tc := range []struct {
+			encoding    string
+			body        []byte
+			contentType string
+		}{
+			{encoding: "json", body: js, contentType: "application/json"},
+			{encoding: "protobuf", body: pb, contentType: "application/protobuf"},
+		} {
+			b.Run(fmt.Sprintf("%s/spans=%d", tc.encoding, spans), func(b *testing.B) {
+				h := newTestHandler(b, nil)
+				b.SetBytes(int64(len(tc.body)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := h.decodeTrace(newTraceRequest(tc.body, tc.contentType), nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
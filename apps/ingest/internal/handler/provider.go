@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// UnknownProviderMetadataKey marks spans whose provider is not in knownProviders
+const UnknownProviderMetadataKey = "_unknown_provider"
+
+// knownProviders are the providers cost attribution understands
+var knownProviders = map[string]struct{}{
+	"openai":    {},
+	"azure":     {},
+	"anthropic": {},
+	"bedrock":   {},
+	"google":    {},
+	"vertex":    {},
+	"mistral":   {},
+	"cohere":    {},
+	"meta":      {},
+}
+
+// providerMetadataKeys are span metadata keys SDKs use for the provider
+// when they have no dedicated field, in order of precedence
+var providerMetadataKeys = []string{"provider", "llm_provider", "ls_provider"}
+
+// modelProviders maps model names to providers, mirroring the price
+// table's provider detection. Order matters: more specific patterns first.
+var modelProviders = []struct {
+	pattern  *regexp.Regexp
+	provider string
+}{
+	{regexp.MustCompile(`^gpt-4`), "openai"},
+	{regexp.MustCompile(`^gpt-3\.5`), "openai"},
+	{regexp.MustCompile(`^o1`), "openai"},
+	{regexp.MustCompile(`^text-davinci`), "openai"},
+	{regexp.MustCompile(`^text-embedding`), "openai"},
+	{regexp.MustCompile(`^claude`), "anthropic"},
+	{regexp.MustCompile(`^gemini`), "google"},
+	{regexp.MustCompile(`^palm`), "google"},
+	{regexp.MustCompile(`^command`), "cohere"},
+	{regexp.MustCompile(`^embed`), "cohere"},
+	{regexp.MustCompile(`^mistral`), "mistral"},
+	{regexp.MustCompile(`^mixtral`), "mistral"},
+	{regexp.MustCompile(`^llama`), "meta"},
+}
+
+// spanProvider resolves a span's provider, lowercased: the explicit field,
+// then a provider key in the span metadata, then inference from the model
+// name. Returns "" when none applies. An invalid explicit provider is an
+// error; invalid metadata values are skipped.
+func spanProvider(i int, explicit *string, metadata map[string]any, model string) (string, error) {
+	if explicit != nil && *explicit != "" {
+		provider := normalizeProvider(*explicit)
+		if err := validateTagValue(fmt.Sprintf("spans[%d].provider", i), provider); err != nil {
+			return "", err
+		}
+		return provider, nil
+	}
+	for _, key := range providerMetadataKeys {
+		if value, ok := metadata[key].(string); ok {
+			provider := normalizeProvider(value)
+			if provider != "" && validateTagValue(key, provider) == nil {
+				return provider, nil
+			}
+		}
+	}
+	return inferProvider(model), nil
+}
+
+// inferProvider detects the provider from a model name, or returns ""
+func inferProvider(model string) string {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, mp := range modelProviders {
+		if mp.pattern.MatchString(model) {
+			return mp.provider
+		}
+	}
+	return ""
+}
+
+// isKnownProvider reports whether provider is in knownProviders
+func isKnownProvider(provider string) bool {
+	_, ok := knownProviders[provider]
+	return ok
+}
+
+// normalizeProvider canonicalizes a provider name
+func normalizeProvider(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestSpanProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit string // Empty means the field is omitted
+		metadata map[string]any
+		model    string
+		want     string
+		wantErr  string
+	}{
+		{name: "none"},
+		{name: "explicit", explicit: " Anthropic ", model: "gpt-4o", want: "anthropic"},
+		{name: "explicit invalid", explicit: "open ai", wantErr: "spans[2].provider may only contain"},
+		{name: "metadata", metadata: map[string]any{"provider": "Bedrock"}, model: "claude-3-haiku", want: "bedrock"},
+		{name: "metadata precedence", metadata: map[string]any{"ls_provider": "azure", "llm_provider": "openai"}, want: "openai"},
+		// Invalid or non-string metadata values fall through to the next source
+		{name: "metadata invalid", metadata: map[string]any{"provider": "not valid", "llm_provider": 3}, model: "mixtral-8x7b", want: "mistral"},
+		{name: "inferred openai", model: "GPT-4o-mini", want: "openai"},
+		{name: "inferred anthropic", model: "claude-3-5-sonnet", want: "anthropic"},
+		{name: "inferred cohere", model: "command-r-plus", want: "cohere"},
+		{name: "not inferred", model: "my-finetune"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var explicit *string
+			if tt.explicit != "" {
+				explicit = &tt.explicit
+			}
+
+			got, err := spanProvider(2, explicit, tt.metadata, tt.model)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("spanProvider() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("spanProvider: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("spanProvider() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		span        string
+		want        string
+		wantUnknown bool
+		wantErr     bool
+	}{
+		{name: "known", span: `{"name":"llm","model":"claude-3-opus"}`, want: "anthropic"},
+		{name: "unknown", span: `{"name":"llm","provider":"Together"}`, want: "together", wantUnknown: true},
+		{name: "invalid", span: `{"name":"llm","provider":"a b"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			var span IngestSpanInput
+			if err := json.Unmarshal([]byte(tt.span), &span); err != nil {
+				t.Fatalf("decode span: %v", err)
+			}
+			req := &IngestTraceRequest{Name: "chat", Spans: []IngestSpanInput{span}}
+
+			input, err := h.buildTraceInput(req, "proj-1", false, nil)
+			if tt.wantErr {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != "validation_error" || apiErr.Detail().Field != "spans[0].provider" {
+					t.Fatalf("buildTraceInput() error = %v, want validation_error for spans[0].provider", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			if got := input.Spans[0].Provider; got != tt.want {
+				t.Errorf("Provider = %q, want %q", got, tt.want)
+			}
+			if _, flagged := input.Spans[0].Metadata[UnknownProviderMetadataKey]; flagged != tt.wantUnknown {
+				t.Errorf("%s flagged = %v, want %v", UnknownProviderMetadataKey, flagged, tt.wantUnknown)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+func TestIngestTraceRequiredMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		required []string
+		metadata string // Trace metadata JSON; empty omits it
+		wantKey  string // Key reported missing; empty expects the trace to be accepted
+	}{
+		{name: "no requirements"},
+		{name: "present", required: []string{"cost_center"}, metadata: `{"cost_center":"cc-42"}`},
+		{name: "false is present", required: []string{"billable"}, metadata: `{"billable":false}`},
+		{name: "absent", required: []string{"cost_center"}, metadata: `{"team":"search"}`, wantKey: "cost_center"},
+		{name: "null", required: []string{"cost_center"}, metadata: `{"cost_center":null}`, wantKey: "cost_center"},
+		{name: "no metadata", required: []string{"team", "cost_center"}, wantKey: "team"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &temporaltest.Client{}
+			h := newTemporalTestHandler(t, client, nil)
+
+			body := `{"name":"chat","spans":[{"name":"llm"}]`
+			if tt.metadata != "" {
+				body += `,"metadata":` + tt.metadata
+			}
+			body += "}"
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(body))
+			req.Header.Set("X-Project-ID", "proj-1")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ProjectRequiredMetadataKey, tt.required))
+			rec := httptest.NewRecorder()
+			h.IngestTrace(rec, req)
+
+			if tt.wantKey == "" {
+				if rec.Code != http.StatusAccepted {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+				}
+				return
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+			var errBody response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &errBody); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if errBody.Code != "missing_required_metadata" || errBody.Details["key"] != tt.wantKey {
+				t.Errorf("error = %s %v, want missing_required_metadata for %s", errBody.Code, errBody.Details, tt.wantKey)
+			}
+			if n := len(client.Started()); n != 0 {
+				t.Errorf("started %d workflows, want none", n)
+			}
+		})
+	}
+}
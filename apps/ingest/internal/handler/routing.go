@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// routeMethods are the methods tried when building the Allow header
+var routeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// NotFound answers unmatched paths with the JSON error envelope
+func (h *Handler) NotFound(w http.ResponseWriter, r *http.Request) {
+	routeError(w, r, http.StatusNotFound, "not_found", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+}
+
+// MethodNotAllowed answers known paths requested with an unsupported
+// method, listing the supported ones in the Allow header
+func (h *Handler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if allowed := allowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	routeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", fmt.Sprintf("%s is not supported for %s", r.Method, r.URL.Path))
+}
+
+// routeError writes a routing error, with the request ID in details so
+// a client report can be matched to the access log
+func routeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	apiErr := response.NewError(status, code, message)
+	if id := middleware.GetReqID(r.Context()); id != "" {
+		apiErr.Details = map[string]any{"request_id": id}
+	}
+	response.WriteError(w, apiErr)
+}
+
+// allowedMethods matches the request path against the root router for each method
+func allowedMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+	var allowed []string
+	for _, method := range routeMethods {
+		if routeMatches(rctx.Routes, method, r.URL.Path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// routeMatches reports whether routes has a handler for method and path.
+// chi registers a subrouter's root path as a stub that matches every
+// method, so a match ending on one is resolved against the subrouter's "/".
+func routeMatches(routes chi.Routes, method, path string) bool {
+	rctx := chi.NewRouteContext()
+	if !routes.Match(rctx, method, path) {
+		return false
+	}
+
+	// Follow the matched patterns down to the router holding the last one
+	patterns := rctx.RoutePatterns
+	for _, pattern := range patterns[:len(patterns)-1] {
+		if routes = subRoutes(routes, pattern); routes == nil {
+			return true
+		}
+	}
+	mount := strings.TrimSuffix(patterns[len(patterns)-1], "/") + "/*"
+	if sub := subRoutes(routes, mount); sub != nil {
+		return sub.Match(chi.NewRouteContext(), method, "/")
+	}
+	return true
+}
+
+// subRoutes returns the subrouter mounted at pattern, if any
+func subRoutes(routes chi.Routes, pattern string) chi.Routes {
+	for _, route := range routes.Routes() {
+		if route.Pattern == pattern {
+			return route.SubRoutes
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestRouteErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantAllow  string
+	}{
+		{name: "unknown path", method: http.MethodGet, path: "/v1/nope", wantStatus: http.StatusNotFound, wantCode: "not_found"},
+		{name: "unsupported method", method: http.MethodDelete, path: "/v1/traces", wantStatus: http.StatusMethodNotAllowed, wantCode: "method_not_allowed", wantAllow: "GET, POST"},
+		{name: "unsupported method on subrouter", method: http.MethodPost, path: "/v1/traces/t1", wantStatus: http.StatusMethodNotAllowed, wantCode: "method_not_allowed", wantAllow: "PATCH"},
+		{name: "unsupported method on parameterized subrouter", method: http.MethodDelete, path: "/v1/traces/t1/spans", wantStatus: http.StatusMethodNotAllowed, wantCode: "method_not_allowed", wantAllow: "GET"},
+	}
+
+	h := newTestHandler(t, nil)
+	ok := func(http.ResponseWriter, *http.Request) {}
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.NotFound(h.NotFound)
+	r.MethodNotAllowed(h.MethodNotAllowed)
+	// Nested like the server's routes, so the paths end on subrouter roots
+	r.Route("/v1", func(r chi.Router) {
+		r.Route("/traces", func(r chi.Router) {
+			r.Get("/", ok)
+			r.Post("/", ok)
+			r.Patch("/{traceID}", ok)
+			r.Route("/{traceID}/spans", func(r chi.Router) {
+				r.Get("/", ok)
+			})
+		})
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			var body response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", rec.Body, err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			if body.Details["request_id"] == nil {
+				t.Error("details has no request_id")
+			}
+		})
+	}
+}
+
+func TestRouteErrorRequestID(t *testing.T) {
+	tests := []struct {
+		name          string
+		withRequestID bool
+	}{
+		{name: "with request ID", withRequestID: true},
+		{name: "without request ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			var handler http.Handler = http.HandlerFunc(h.NotFound)
+			if tt.withRequestID {
+				handler = middleware.RequestID(handler)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/nope", nil)
+			if tt.withRequestID {
+				req.Header.Set(middleware.RequestIDHeader, "req-123")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			var body response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", rec.Body, err)
+			}
+			// The request ID matches the access log line for the request
+			if tt.withRequestID {
+				if got := body.Details["request_id"]; got != "req-123" {
+					t.Errorf("details.request_id = %v, want req-123", got)
+				}
+			} else if body.Details != nil {
+				t.Errorf("details = %v, want none", body.Details)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/webapi"
+)
+
+// Score config data types
+const (
+	scoreDataTypeNumeric     = "NUMERIC"
+	scoreDataTypeCategorical = "CATEGORICAL"
+	scoreDataTypeBoolean     = "BOOLEAN"
+)
+
+// MaxScoreMagnitude bounds numeric score values; anything larger is almost
+// certainly a bug in the evaluator and would skew downstream aggregates
+const MaxScoreMagnitude = 1e12
+
+// checkNumericScore rejects NaN, ±Inf and implausibly large numeric scores
+func checkNumericScore(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return response.NewError(http.StatusBadRequest, "invalid_score_value", "numeric_value must be a finite number")
+	}
+	if math.Abs(value) > MaxScoreMagnitude {
+		return response.NewError(http.StatusBadRequest, "invalid_score_value",
+			fmt.Sprintf("numeric_value must be between %g and %g", -MaxScoreMagnitude, MaxScoreMagnitude))
+	}
+	return nil
+}
+
+// validateScoreValue checks a score against its config, when one is referenced.
+// Scores without a config accept any value. If the config can't be fetched
+// (the web API may not serve score configs) the check is skipped with a
+// warning rather than rejecting the score.
+func (h *Handler) validateScoreValue(ctx context.Context, input temporal.ScoreWorkflowInput) error {
+	if input.ConfigID == "" {
+		return nil
+	}
+
+	scoreConfig, err := h.webAPI.GetScoreConfig(ctx, input.ProjectID, input.ConfigID)
+	if err != nil {
+		if errors.Is(err, webapi.ErrScoreConfigNotFound) {
+			return response.NewError(http.StatusBadRequest, "invalid_config_id", "score config not found")
+		}
+		slog.Warn("score config lookup failed, skipping value validation", "error", err, "project_id", input.ProjectID, "config_id", input.ConfigID)
+		return nil
+	}
+
+	switch scoreConfig.DataType {
+	case scoreDataTypeNumeric:
+		value, ok := input.Value.(float64)
+		if !ok {
+			return response.NewError(http.StatusBadRequest, "invalid_score_value", "score config requires a numeric value")
+		}
+		if (scoreConfig.MinValue != nil && value < *scoreConfig.MinValue) ||
+			(scoreConfig.MaxValue != nil && value > *scoreConfig.MaxValue) {
+			return response.NewError(http.StatusBadRequest, "score_out_of_range",
+				fmt.Sprintf("value %v is outside the allowed range %s", value, scoreRange(scoreConfig)))
+		}
+	case scoreDataTypeCategorical:
+		value, ok := input.Value.(string)
+		if !ok {
+			return response.NewError(http.StatusBadRequest, "invalid_score_value", "score config requires a string value")
+		}
+		if !slices.Contains(scoreConfig.Categories, value) {
+			return response.NewError(http.StatusBadRequest, "invalid_category",
+				fmt.Sprintf("category %q is not one of the configured categories", value))
+		}
+	case scoreDataTypeBoolean:
+		if _, ok := input.Value.(bool); !ok {
+			return response.NewError(http.StatusBadRequest, "invalid_score_value", "score config requires a boolean value")
+		}
+	}
+
+	return nil
+}
+
+// scoreRange formats a config's numeric bounds, e.g. [0, 1] or [0, ∞)
+func scoreRange(c *webapi.ScoreConfig) string {
+	lower, upper := "(-∞", "∞)"
+	if c.MinValue != nil {
+		lower = fmt.Sprintf("[%v", *c.MinValue)
+	}
+	if c.MaxValue != nil {
+		upper = fmt.Sprintf("%v]", *c.MaxValue)
+	}
+	return lower + ", " + upper
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestCheckNumericScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		wantCode string
+	}{
+		{name: "zero", value: 0},
+		{name: "fraction", value: 0.875},
+		{name: "at the bound", value: -MaxScoreMagnitude},
+		{name: "above the bound", value: MaxScoreMagnitude * 10, wantCode: "invalid_score_value"},
+		{name: "nan", value: math.NaN(), wantCode: "invalid_score_value"},
+		{name: "positive infinity", value: math.Inf(1), wantCode: "invalid_score_value"},
+		{name: "negative infinity", value: math.Inf(-1), wantCode: "invalid_score_value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkNumericScore(tt.value)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("checkNumericScore(%v) = %v, want nil", tt.value, err)
+				}
+				return
+			}
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.wantCode {
+				t.Fatalf("checkNumericScore(%v) = %v, want %s", tt.value, err, tt.wantCode)
+			}
+		})
+	}
+}
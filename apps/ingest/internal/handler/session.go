@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// CancelSessionResponse reports the trace workflows cancelled for a session
+type CancelSessionResponse struct {
+	SessionID string `json:"session_id"`
+	Cancelled int    `json:"cancelled"`
+	Success   bool   `json:"success"`
+}
+
+// CancelSession handles POST /v1/sessions/{sessionID}/cancel.
+// Every in-flight trace workflow of the caller's project tagged with the
+// session is cancelled, for "delete my data" requests scoped to a
+// conversation. Traces already processed are not affected.
+func (h *Handler) CancelSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if err := checkExternalID("session_id", sessionID, h.cfg.MaxExternalIDLength); err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	projectID := r.Header.Get("X-Project-ID")
+
+	cancelled, err := h.temporalClient.CancelSessionTraces(r.Context(), projectID, sessionID)
+	if err != nil {
+		slog.Error("failed to cancel session traces", "error", err, "session_id", sessionID, "cancelled", cancelled)
+		response.Error(w, http.StatusInternalServerError, "internal_error", "failed to cancel session traces")
+		return
+	}
+	slog.Info("session traces cancelled", "project_id", projectID, "session_id", sessionID, "cancelled", cancelled)
+
+	response.JSON(w, http.StatusOK, CancelSessionResponse{
+		SessionID: sessionID,
+		Cancelled: cancelled,
+		Success:   true,
+	})
+}
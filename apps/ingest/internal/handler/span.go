@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// UpdateSpanRequest represents a sparse span update.
+// Only the fields present in the request are changed.
+type UpdateSpanRequest struct {
+	Output        any              `json:"output,omitempty"` // Non-objects are wrapped as {"value": ...} unless STRICT_SPAN_PAYLOADS is set
+	EndTime       *FlexibleTime    `json:"end_time,omitempty"`
+	Usage         *TokenUsageInput `json:"usage,omitempty"`
+	StatusMessage *string          `json:"status_message,omitempty"`
+	Level         *string          `json:"level,omitempty"`
+}
+
+// UpdateSpanResponse represents the response after accepting a span update
+type UpdateSpanResponse struct {
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id"`
+	Success bool   `json:"success"`
+}
+
+// UpdateSpan handles PATCH /v1/traces/{traceID}/spans/{spanID}
+func (h *Handler) UpdateSpan(w http.ResponseWriter, r *http.Request) {
+	traceID := chi.URLParam(r, "traceID")
+	spanID := chi.URLParam(r, "spanID")
+
+	var req UpdateSpanRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	update := temporal.SpanUpdateInput{
+		SpanID:        spanID,
+		StatusMessage: req.StatusMessage,
+	}
+	hasUpdate := req.StatusMessage != nil
+
+	if req.Level != nil {
+		level, err := checkSpanLevel("level", *req.Level)
+		if err != nil {
+			response.WriteError(w, err)
+			return
+		}
+		update.Level = &level
+		hasUpdate = true
+	}
+
+	output, err := spanPayload("output", req.Output, h.cfg.StrictSpanPayloads)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+	if output != nil {
+		update.Output = output
+		hasUpdate = true
+	}
+
+	if req.EndTime != nil {
+		endTime := req.EndTime.UTC().Format(time.RFC3339Nano)
+		update.EndTime = &endTime
+		hasUpdate = true
+	}
+
+	if req.Usage != nil {
+		update.PromptTokens = tokenCountPtr(req.Usage.PromptTokens)
+		update.CompletionTokens = tokenCountPtr(req.Usage.CompletionTokens)
+		update.TotalTokens = tokenCountPtr(req.Usage.TotalTokens)
+		hasUpdate = true
+	}
+
+	if !hasUpdate {
+		response.Error(w, http.StatusBadRequest, "validation_error", "no fields to update")
+		return
+	}
+
+	projectID := r.Header.Get("X-Project-ID")
+
+	err = h.temporalClient.UpdateSpan(r.Context(), projectID, traceID, update)
+	switch {
+	case errors.Is(err, temporal.ErrTraceNotFound):
+		response.Error(w, http.StatusNotFound, "trace_not_found", err.Error())
+		return
+	case errors.Is(err, temporal.ErrSpanNotFound):
+		response.Error(w, http.StatusNotFound, "span_not_found", err.Error())
+		return
+	case errors.Is(err, temporal.ErrTraceFinalized):
+		response.Error(w, http.StatusConflict, "trace_finalized", err.Error())
+		return
+	case err != nil:
+		slog.Error("failed to update span", "error", err, "trace_id", traceID, "span_id", spanID)
+		response.Error(w, http.StatusInternalServerError, "internal_error", "failed to update span")
+		return
+	}
+	slog.Info("span update signalled", "trace_id", traceID, "span_id", spanID)
+
+	resp := UpdateSpanResponse{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Success: true,
+	}
+
+	response.JSON(w, http.StatusAccepted, resp)
+}
+
+func tokenCountPtr(v *int64) *temporal.TokenCount {
+	if v == nil {
+		return nil
+	}
+	c := temporal.TokenCount(*v)
+	return &c
+}
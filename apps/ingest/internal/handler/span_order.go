@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// sortSpansByStartTime reorders spans by start time, for waterfall
+// rendering that assumes start-time order. The sort is stable; among spans
+// starting at the same instant, parents come before their children and
+// otherwise request order is kept. starts holds each span's start time.
+func sortSpansByStartTime(spans []temporal.SpanInput, starts []time.Time) {
+	order := make([]int, len(spans))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return starts[order[a]].Before(starts[order[b]])
+	})
+
+	// Runs of equal start times are put in parent-before-child order
+	for lo := 0; lo < len(order); {
+		hi := lo + 1
+		for hi < len(order) && starts[order[hi]].Equal(starts[order[lo]]) {
+			hi++
+		}
+		if hi-lo > 1 {
+			topologicalOrder(spans, order[lo:hi])
+		}
+		lo = hi
+	}
+
+	sorted := make([]temporal.SpanInput, len(spans))
+	for i, idx := range order {
+		sorted[i] = spans[idx]
+	}
+	copy(spans, sorted)
+}
+
+// topologicalOrder reorders span indexes in place so each span follows its
+// parent when both are in the group, keeping the given order otherwise.
+// A cycle of parent links is broken at the span reached first.
+func topologicalOrder(spans []temporal.SpanInput, group []int) {
+	position := make(map[string]int, len(group)) // Span ID to index in group
+	for i, idx := range group {
+		position[spans[idx].ID] = i
+	}
+
+	placed := make([]bool, len(group))
+	ordered := make([]int, 0, len(group))
+	var place func(i int, depth int)
+	place = func(i int, depth int) {
+		if placed[i] || depth > len(group) {
+			return
+		}
+		if parent, ok := position[spans[group[i]].ParentSpanID]; ok && parent != i {
+			place(parent, depth+1)
+		}
+		if !placed[i] {
+			placed[i] = true
+			ordered = append(ordered, group[i])
+		}
+	}
+	for i := range group {
+		place(i, 0)
+	}
+	copy(group, ordered)
+}
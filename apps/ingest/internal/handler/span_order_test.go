@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+func TestSortSpansByStartTime(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	type span struct {
+		id, parent string
+		offset     time.Duration
+	}
+	tests := []struct {
+		name  string
+		spans []span
+		want  []string
+	}{
+		{
+			name:  "by start time",
+			spans: []span{{id: "c", offset: 2}, {id: "a", offset: 0}, {id: "b", offset: 1}},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "ties keep request order",
+			spans: []span{{id: "x"}, {id: "y"}, {id: "z"}},
+			want:  []string{"x", "y", "z"},
+		},
+		{
+			name:  "parent first on ties",
+			spans: []span{{id: "child", parent: "root"}, {id: "root"}},
+			want:  []string{"root", "child"},
+		},
+		{
+			name:  "grandparent chain on ties",
+			spans: []span{{id: "c", parent: "b"}, {id: "b", parent: "a"}, {id: "a"}},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			// An earlier child still sorts by time; parent order only breaks ties
+			name:  "child starting before parent",
+			spans: []span{{id: "root", offset: 1}, {id: "child", parent: "root"}},
+			want:  []string{"child", "root"},
+		},
+		{
+			name:  "parent cycle",
+			spans: []span{{id: "a", parent: "b"}, {id: "b", parent: "a"}, {id: "c"}},
+			want:  []string{"b", "a", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := make([]temporal.SpanInput, len(tt.spans))
+			starts := make([]time.Time, len(tt.spans))
+			for i, s := range tt.spans {
+				spans[i] = temporal.SpanInput{ID: s.id, ParentSpanID: s.parent}
+				starts[i] = t0.Add(s.offset * time.Second)
+			}
+
+			sortSpansByStartTime(spans, starts)
+
+			got := make([]string, len(spans))
+			for i, s := range spans {
+				got[i] = s.ID
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+func TestUpdateSpan(t *testing.T) {
+	strict := func(cfg *config.Config) { cfg.StrictSpanPayloads = true }
+
+	tests := []struct {
+		name       string
+		body       string
+		mutate     func(*config.Config)
+		updateErr  error // Returned by the workflow signal
+		wantStatus int
+		wantCode   string
+		wantOutput any     // Output signalled to the workflow
+		wantLevel  *string // Level signalled to the workflow
+		wantTotal  *temporal.TokenCount
+	}{
+		{name: "object output", body: `{"output":{"text":"done"}}`, wantStatus: http.StatusAccepted, wantOutput: map[string]any{"text": "done"}},
+		{name: "string output", body: `{"output":"done"}`, wantStatus: http.StatusAccepted, wantOutput: map[string]any{"value": "done"}},
+		{name: "array output", body: `{"output":["a","b"]}`, wantStatus: http.StatusAccepted, wantOutput: map[string]any{"value": []any{"a", "b"}}},
+		{name: "string output strict", body: `{"output":"done"}`, mutate: strict, wantStatus: http.StatusBadRequest, wantCode: "validation_error"},
+		{name: "level", body: `{"level":"WARNING"}`, wantStatus: http.StatusAccepted, wantLevel: ptr("WARNING")},
+		{name: "lower-case level", body: `{"level":"error"}`, wantStatus: http.StatusAccepted, wantLevel: ptr("ERROR")},
+		{name: "unknown level", body: `{"level":"FATAL"}`, wantStatus: http.StatusBadRequest, wantCode: "validation_error"},
+		{name: "empty level", body: `{"level":""}`, wantStatus: http.StatusBadRequest, wantCode: "validation_error"},
+		{name: "usage beyond 2^53", body: `{"usage":{"total_tokens":9007199254740993}}`, wantStatus: http.StatusAccepted, wantTotal: ptr[temporal.TokenCount](9007199254740993)},
+		{name: "no fields", body: `{}`, wantStatus: http.StatusBadRequest, wantCode: "validation_error"},
+		{name: "null output only", body: `{"output":null}`, wantStatus: http.StatusBadRequest, wantCode: "validation_error"},
+		{name: "trace not found", body: `{"output":"done"}`, updateErr: temporal.ErrTraceNotFound, wantStatus: http.StatusNotFound, wantCode: "trace_not_found"},
+		{name: "span not found", body: `{"output":"done"}`, updateErr: temporal.ErrSpanNotFound, wantStatus: http.StatusNotFound, wantCode: "span_not_found"},
+		{name: "trace finalized", body: `{"output":"done"}`, updateErr: temporal.ErrTraceFinalized, wantStatus: http.StatusConflict, wantCode: "trace_finalized"},
+		{name: "signal failure", body: `{"output":"done"}`, updateErr: errors.New("unavailable"), wantStatus: http.StatusInternalServerError, wantCode: "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates []temporal.SpanUpdateInput
+			client := &temporaltest.Client{
+				UpdateSpanFunc: func(_ context.Context, projectID, traceID string, update temporal.SpanUpdateInput) error {
+					if projectID != "proj-1" || traceID != "t1" {
+						t.Errorf("signalled %s/%s, want proj-1/t1", projectID, traceID)
+					}
+					updates = append(updates, update)
+					return tt.updateErr
+				},
+			}
+			h := newTemporalTestHandler(t, client, tt.mutate)
+			router := chi.NewRouter()
+			router.Patch("/v1/traces/{traceID}/spans/{spanID}", h.UpdateSpan)
+
+			req := httptest.NewRequest(http.MethodPatch, "/v1/traces/t1/spans/s1", strings.NewReader(tt.body))
+			req.Header.Set("X-Project-ID", "proj-1")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				var errBody response.ErrorBody
+				if err := json.Unmarshal(rec.Body.Bytes(), &errBody); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if errBody.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", errBody.Code, tt.wantCode)
+				}
+			}
+			if tt.wantStatus == http.StatusBadRequest {
+				if len(updates) != 0 {
+					t.Errorf("signalled %d updates, want none", len(updates))
+				}
+				return
+			}
+			if len(updates) != 1 {
+				t.Fatalf("signalled %d updates, want 1", len(updates))
+			}
+			update := updates[0]
+			if update.SpanID != "s1" {
+				t.Errorf("SpanID = %q, want s1", update.SpanID)
+			}
+			if tt.wantOutput != nil && !reflect.DeepEqual(update.Output, tt.wantOutput) {
+				t.Errorf("Output = %#v, want %#v", update.Output, tt.wantOutput)
+			}
+			if tt.wantLevel != nil && (update.Level == nil || *update.Level != *tt.wantLevel) {
+				t.Errorf("Level = %v, want %s", update.Level, *tt.wantLevel)
+			}
+			if tt.wantTotal != nil && (update.TotalTokens == nil || *update.TotalTokens != *tt.wantTotal) {
+				t.Errorf("TotalTokens = %v, want %d", update.TotalTokens, *tt.wantTotal)
+			}
+		})
+	}
+}
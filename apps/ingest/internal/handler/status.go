@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// TraceStatusResponse represents the processing status of a trace
+type TraceStatusResponse struct {
+	TraceID        string `json:"trace_id"`
+	WorkflowID     string `json:"workflow_id"`
+	Status         string `json:"status"` // running, completed, failed, ...
+	Phase          string `json:"phase"`
+	SpansProcessed int    `json:"spans_processed"`
+	SpansTotal     int    `json:"spans_total"`
+}
+
+// GetTraceStatus handles GET /v1/traces/{traceID}/status
+func (h *Handler) GetTraceStatus(w http.ResponseWriter, r *http.Request) {
+	traceID := chi.URLParam(r, "traceID")
+	projectID := r.Header.Get("X-Project-ID")
+
+	status, err := h.temporalClient.QueryTraceProgress(r.Context(), projectID, traceID)
+	if errors.Is(err, temporal.ErrTraceNotFound) {
+		response.Error(w, http.StatusNotFound, "trace_not_found", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get trace status", "error", err, "trace_id", traceID)
+		response.Error(w, http.StatusInternalServerError, "internal_error", "failed to get trace status")
+		return
+	}
+
+	resp := TraceStatusResponse{
+		TraceID:        traceID,
+		WorkflowID:     status.WorkflowID,
+		Status:         status.Status,
+		Phase:          status.Progress.Phase,
+		SpansProcessed: status.Progress.SpansProcessed,
+		SpansTotal:     status.Progress.SpansTotal,
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// GetTrace handles GET /v1/traces/{traceID}.
+// It returns the trace exactly as the workflow received it, after
+// normalization, redaction and truncation. This is a debugging aid;
+// the CognObserve data API remains the read path for stored traces.
+func (h *Handler) GetTrace(w http.ResponseWriter, r *http.Request) {
+	traceID := chi.URLParam(r, "traceID")
+	projectID := r.Header.Get("X-Project-ID")
+
+	input, err := h.temporalClient.QueryTraceInput(r.Context(), projectID, traceID)
+	switch {
+	case errors.Is(err, temporal.ErrTraceNotFound):
+		response.Error(w, http.StatusNotFound, "trace_not_found", err.Error())
+		return
+	case errors.Is(err, temporal.ErrTraceGone):
+		response.Error(w, http.StatusGone, "trace_gone",
+			"the trace workflow no longer holds the submitted trace; read it from the CognObserve data API instead")
+		return
+	case errors.Is(err, temporal.ErrQueryUnsupported):
+		response.Error(w, http.StatusNotImplemented, "query_unsupported",
+			"the worker that processed this trace does not support reading it back")
+		return
+	case err != nil:
+		slog.Error("failed to get trace", "error", err, "trace_id", traceID)
+		response.Error(w, http.StatusInternalServerError, "internal_error", "failed to get trace")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, input)
+}
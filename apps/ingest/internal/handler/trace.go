@@ -1,101 +1,488 @@
 package handler
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
 	"github.com/cognobserve/ingest/internal/temporal"
 )
 
-// UserInfoInput represents user information in the request
-type UserInfoInput struct {
-	Name     *string        `json:"name,omitempty"`
-	Email    *string        `json:"email,omitempty"`
-	Metadata map[string]any `json:"metadata,omitempty"`
-}
-
-// IngestTraceRequest represents the incoming trace request
-// This mirrors the proto definition but uses JSON-friendly types
-type IngestTraceRequest struct {
-	TraceID   *string           `json:"trace_id,omitempty"`
-	SessionID *string           `json:"session_id,omitempty"` // External session ID for conversations
-	UserID    *string           `json:"user_id,omitempty"`    // External user ID for tracking end-users
-	User      *UserInfoInput    `json:"user,omitempty"`       // Optional user metadata
-	Name      string            `json:"name"`
-	Metadata  map[string]any    `json:"metadata,omitempty"`
-	Spans     []IngestSpanInput `json:"spans"`
-}
-
-// IngestSpanInput represents a span in the request
-type IngestSpanInput struct {
-	SpanID          *string          `json:"span_id,omitempty"`
-	ParentSpanID    *string          `json:"parent_span_id,omitempty"`
-	Name            string           `json:"name"`
-	StartTime       *time.Time       `json:"start_time,omitempty"` // Defaults to now if not provided
-	EndTime         *time.Time       `json:"end_time,omitempty"`   // Defaults to now if not provided
-	Input           map[string]any   `json:"input,omitempty"`
-	Output          map[string]any   `json:"output,omitempty"`
-	Metadata        map[string]any   `json:"metadata,omitempty"`
-	Model           *string          `json:"model,omitempty"`
-	ModelParameters map[string]any   `json:"model_parameters,omitempty"`
-	Usage           *TokenUsageInput `json:"usage,omitempty"`
-	Level           string           `json:"level,omitempty"`
-	StatusMessage   *string          `json:"status_message,omitempty"`
-}
-
-// TokenUsageInput represents token usage in the request
-type TokenUsageInput struct {
-	PromptTokens     *int32 `json:"prompt_tokens,omitempty"`
-	CompletionTokens *int32 `json:"completion_tokens,omitempty"`
-	TotalTokens      *int32 `json:"total_tokens,omitempty"`
-}
-
-// IngestTraceResponse represents the response after ingesting
-type IngestTraceResponse struct {
-	TraceID    string   `json:"trace_id"`
-	SpanIDs    []string `json:"span_ids"`
-	WorkflowID string   `json:"workflow_id,omitempty"` // Present when using Temporal
-	Success    bool     `json:"success"`
-}
+// DurationAnomalyMetadataKey marks spans with an implausible duration.
+// The value is one of the durationAnomaly* reasons.
+const DurationAnomalyMetadataKey = "_duration_anomaly"
+
+// UnknownModelMetadataKey marks spans whose model is not in ALLOWED_MODELS
+// when the allowlist is in soft mode
+const UnknownModelMetadataKey = "_unknown_model"
+
+// Trace statuses. When a request omits the status it is derived from span
+// levels: any ERROR span makes the trace an error, otherwise a success.
+const (
+	TraceStatusSuccess   = "success"
+	TraceStatusError     = "error"
+	TraceStatusCancelled = "cancelled"
+	TraceStatusUnknown   = "unknown"
+)
+
+// Span duration anomaly reasons
+const (
+	durationAnomalyNegative = "negative"
+	durationAnomalyTooLong  = "too_long"
+)
+
+// defaultProjectID receives traces without a project ID when ALLOW_DEFAULT_PROJECT is set
+const defaultProjectID = "default"
 
 // IngestTrace handles POST /v1/traces
 func (h *Handler) IngestTrace(w http.ResponseWriter, r *http.Request) {
-	var req IngestTraceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.Warn("failed to decode request", "error", err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	requested, err := warningsRequested(r)
+	if err != nil {
+		response.WriteError(w, err)
 		return
 	}
+	var warn *warnings
+	if requested {
+		warn = &warnings{}
+	}
 
-	// Validate request
-	if req.Name == "" {
-		http.Error(w, "name is required", http.StatusBadRequest)
+	wait, err := waitRequested(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	input, err := h.decodeTrace(r, warn)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	var reported []Warning
+	if warn != nil {
+		reported = *warn
+	}
+	h.startTrace(w, r, input, reported, wait)
+}
+
+// startTrace starts the workflow for a validated trace and writes the
+// response, reporting whether the trace was accepted. reported warnings are
+// included in the response as given. With wait the response is held until
+// the workflow completes (see waitRequested).
+func (h *Handler) startTrace(w http.ResponseWriter, r *http.Request, input temporal.TraceWorkflowInput, reported []Warning, wait bool) bool {
+	if h.isRepeatedSend(r.Context(), input) {
+		response.JSON(w, http.StatusAccepted, IngestTraceResponse{
+			TraceID:       input.ID,
+			SpanIDs:       spanIDs(input),
+			Deduplicated:  true,
+			Success:       true,
+			CorrelationID: input.CorrelationID,
+			Warnings:      reported,
+		})
+		return true
+	}
+
+	usage, err := h.chargeBudget(r.Context(), &input)
+	setBudgetHeaders(w, usage)
+	if err != nil {
+		h.forgetSend(r.Context(), input)
+		response.WriteError(w, err)
+		return false
+	}
+
+	// Start Temporal workflow, timed apart from our own validation and auth
+	// so slow periods can be attributed to the Temporal frontend
+	started := time.Now()
+	result, err := h.temporalClient.StartTraceWorkflow(r.Context(), input)
+	elapsed := time.Since(started)
+	metrics.WorkflowStartDuration.Observe(elapsed.Seconds())
+	slog.Debug("trace workflow start timing", "request_id", chimw.GetReqID(r.Context()), "correlation_id", input.CorrelationID, "trace_id", input.ID, "duration", elapsed, "failed", err != nil)
+	if err != nil {
+		h.refundBudget(r.Context(), usage)
+		h.forgetSend(r.Context(), input)
+		switch classifyStartError(r.Context(), err, input.ID) {
+		case startFailureClientCanceled:
+			// The client is gone; the status only shows up in access logs
+			response.Error(w, StatusClientClosedRequest, "client_closed_request", "client closed request")
+		case startFailureDeadlineExceeded:
+			response.Error(w, http.StatusGatewayTimeout, "request_timeout", "request timed out")
+		default:
+			response.Error(w, http.StatusInternalServerError, "internal_error", "failed to process trace")
+		}
+		return false
+	}
+	if result.Duplicate {
+		h.refundBudget(r.Context(), usage)
+		slog.Info("trace workflow already started", "trace_id", input.ID, "workflow_id", result.WorkflowID, "run_id", result.RunID)
+	} else {
+		slog.Info("trace workflow started", "trace_id", input.ID, "workflow_id", result.WorkflowID, "spans", len(input.Spans), "auth_method", middleware.GetAuthMethod(r.Context()))
+		recordPayloadSizes(input)
+		h.notifyIngested(r.Context(), input)
+	}
+
+	// Send response
+	resp := IngestTraceResponse{
+		TraceID:       input.ID,
+		SpanIDs:       spanIDs(input),
+		WorkflowID:    result.WorkflowID,
+		Duplicate:     result.Duplicate,
+		Success:       true,
+		CorrelationID: input.CorrelationID,
+		UsageSummary:  traceUsageSummary(input.Spans),
+		Warnings:      reported,
+	}
+	if !result.Duplicate {
+		resp.EstimatedReadyAt = h.estimateReadyAt(input)
+	}
+
+	if wait {
+		processed, err := h.waitForTrace(r.Context(), result)
+		if err != nil {
+			response.WriteError(w, err)
+			return true
+		}
+		if processed != nil {
+			resp.Result = processed
+			resp.EstimatedReadyAt = nil
+			response.JSON(w, http.StatusOK, resp)
+			return true
+		}
+	}
+
+	response.JSON(w, http.StatusAccepted, resp)
+	return true
+}
+
+// estimateReadyAt projects when a newly started trace will have been
+// processed: the time to drain the sampled backlog at the observed dispatch
+// rate, plus ESTIMATED_PROCESSING_TIME. Returns nil without a recent sample
+// and for traces routed to a project's own task queue, which isn't sampled.
+func (h *Handler) estimateReadyAt(input temporal.TraceWorkflowInput) *time.Time {
+	if !h.cfg.EstimateReadyAt || input.TaskQueue != "" {
+		return nil
+	}
+	backlog := h.watchdog.Status().Backlog
+	if backlog == nil || time.Since(backlog.SampledAt) > 3*h.cfg.TemporalHealthCheckInterval {
+		return nil
+	}
+
+	var drain time.Duration
+	if backlog.Count > 0 {
+		if backlog.DispatchRate <= 0 {
+			return nil // Stalled or idle workers; no basis for an estimate
+		}
+		drain = time.Duration(float64(backlog.Count) / backlog.DispatchRate * float64(time.Second))
+	}
+	readyAt := time.Now().UTC().Add(drain + h.cfg.EstimatedProcessingTime).Truncate(time.Second)
+	return &readyAt
+}
+
+// StatusClientClosedRequest is the non-standard 499 status (from nginx) used
+// when the client disconnects before the request completes
+const StatusClientClosedRequest = 499
+
+// Workflow start failure reasons, as reported by classifyStartError
+const (
+	startFailureClientCanceled   = "client_canceled"
+	startFailureDeadlineExceeded = "deadline_exceeded"
+	startFailureServerError      = "server_error"
+)
+
+// classifyStartError tells a workflow start that failed because the request
+// context ended (client disconnect or request timeout) apart from a genuine
+// Temporal failure. Only the latter is logged as an error.
+func classifyStartError(ctx context.Context, err error, traceID string) string {
+	reason := startFailureServerError
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		reason = startFailureClientCanceled
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		reason = startFailureDeadlineExceeded
+	}
+	metrics.WorkflowStartFailures.WithLabelValues(reason).Inc()
+
+	if reason == startFailureServerError {
+		slog.Error("failed to start trace workflow", "error", err, "trace_id", traceID)
+	} else {
+		slog.Debug("trace workflow start abandoned", "reason", reason, "error", err, "trace_id", traceID)
+	}
+	return reason
+}
+
+// isRepeatedSend reports whether the trace repeats content received within
+// the dedup window. Redis errors fail open so dedup never blocks ingestion.
+func (h *Handler) isRepeatedSend(ctx context.Context, input temporal.TraceWorkflowInput) bool {
+	if h.dedup == nil {
+		return false
+	}
+	seen, err := h.dedup.Seen(ctx, input)
+	if err != nil {
+		slog.Warn("trace dedup check failed, ingesting anyway", "error", err, "trace_id", input.ID)
+		return false
+	}
+	if seen {
+		metrics.DedupHits.Inc()
+		slog.Info("dropped repeated trace send", "trace_id", input.ID, "project_id", input.ProjectID)
+	}
+	return seen
+}
+
+// forgetSend removes the fingerprint recorded by isRepeatedSend for a trace
+// that was then not started (rejected by the budget or a failed start), so
+// the client's retry is ingested. Like refundBudget it runs even when the
+// request was canceled.
+func (h *Handler) forgetSend(ctx context.Context, input temporal.TraceWorkflowInput) {
+	if h.dedup == nil {
 		return
 	}
+	if err := h.dedup.Forget(context.WithoutCancel(ctx), input); err != nil {
+		slog.Warn("trace dedup fingerprint removal failed", "error", err, "trace_id", input.ID)
+	}
+}
+
+// decodeTrace decodes and validates a single-trace request body, JSON or,
+// for Content-Type: application/protobuf, a binary IngestTraceRequest.
+// Shared by IngestTrace and ValidateTrace so dry runs match real ingestion.
+// Non-fatal issues are added to warn when it is non-nil.
+func (h *Handler) decodeTrace(r *http.Request, warn *warnings) (temporal.TraceWorkflowInput, error) {
+	backfill, err := backfillMode(r)
+	if err != nil {
+		return temporal.TraceWorkflowInput{}, err
+	}
+
+	if err := checkContentType(r, h.cfg.AcceptedContentTypes); err != nil {
+		return temporal.TraceWorkflowInput{}, err
+	}
+
+	var req *IngestTraceRequest
+	if isProtobufBody(r) {
+		if req, err = decodeProtoTrace(r); err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+	} else {
+		req = &IngestTraceRequest{}
+		if err := h.decodeBody(r, req); err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+	}
+
+	projectID, err := h.resolveProjectID(r)
+	if err != nil {
+		return temporal.TraceWorkflowInput{}, err
+	}
+
+	input, err := h.buildTraceInput(req, projectID, backfill, warn)
+	if err != nil {
+		return temporal.TraceWorkflowInput{}, err
+	}
+	if err := h.prepareTrace(r.Context(), &input, warn); err != nil {
+		return temporal.TraceWorkflowInput{}, err
+	}
+	return input, nil
+}
+
+// prepareTrace applies per-project settings from authentication and the
+// request's deadline to a validated trace: required metadata, the
+// metadata key policy, retention, its task queue, its run timeout, its
+// correlation ID, output schema checks and client IP enrichment. Metadata
+// keys dropped by the policy are added to warn when it is non-nil.
+func (h *Handler) prepareTrace(ctx context.Context, input *temporal.TraceWorkflowInput, warn *warnings) error {
+	if err := checkRequiredMetadata(middleware.GetProjectRequiredMetadata(ctx), input.Metadata); err != nil {
+		return err
+	}
+	applyMetadataPolicy(middleware.GetProjectMetadataPolicy(ctx), input, warn)
+	if err := applyRetention(middleware.GetProjectRetention(ctx), input); err != nil {
+		return err
+	}
+	input.TaskQueue = middleware.GetProjectTaskQueue(ctx)
+	input.RunTimeout = h.workflowRunTimeout(ctx)
+	input.CorrelationID = middleware.GetCorrelationID(ctx)
+	h.checkOutputSchemas(ctx, input)
+	h.enrichClient(ctx, input)
+	return nil
+}
+
+// workflowRunTimeout derives a trace workflow's run timeout from the time
+// left on ctx's deadline, clamped to WORKFLOW_MIN_RUN_TIMEOUT and
+// WORKFLOW_MAX_RUN_TIMEOUT. Returns zero (the default timeout) unless
+// WORKFLOW_TIMEOUT_FROM_DEADLINE is set and ctx has a deadline.
+func (h *Handler) workflowRunTimeout(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !h.cfg.WorkflowTimeoutFromDeadline || !ok {
+		return 0
+	}
+	return min(max(time.Until(deadline), h.cfg.WorkflowMinRunTimeout), h.cfg.WorkflowMaxRunTimeout)
+}
 
-	// Get project ID from header (set by auth middleware)
+// checkRequiredMetadata rejects traces missing a metadata key the project
+// requires (e.g. cost_center for chargeback). A null value counts as missing.
+func checkRequiredMetadata(required []string, metadata map[string]any) error {
+	for _, key := range required {
+		if metadata[key] == nil {
+			apiErr := response.NewError(http.StatusBadRequest, "missing_required_metadata",
+				fmt.Sprintf("metadata.%s is required for this project", key))
+			apiErr.Details = map[string]any{"key": key}
+			return apiErr
+		}
+	}
+	return nil
+}
+
+// applyRetention defaults a trace's retention to the project's and rejects
+// retention beyond the project's maximum with 400 retention_too_long.
+// Without a policy (e.g. JWT auth) the trace's own retention is kept.
+func applyRetention(policy *middleware.ProjectRetention, input *temporal.TraceWorkflowInput) error {
+	if policy == nil {
+		return nil
+	}
+	if input.RetentionDays == 0 {
+		input.RetentionDays = policy.DefaultDays
+		return nil
+	}
+	if policy.MaxDays > 0 && input.RetentionDays > policy.MaxDays {
+		apiErr := response.NewError(http.StatusBadRequest, "retention_too_long",
+			fmt.Sprintf("retention_days must be at most %d for this project (got %d)", policy.MaxDays, input.RetentionDays))
+		apiErr.Details = map[string]any{"field": "retention_days", "max": policy.MaxDays}
+		return apiErr
+	}
+	return nil
+}
+
+// resolveProjectID returns the request's project ID (set by auth middleware).
+// A missing ID falls back to "default" only when ALLOW_DEFAULT_PROJECT is set,
+// so misconfigured production clients can't write into a shared bucket.
+func (h *Handler) resolveProjectID(r *http.Request) (string, error) {
 	projectID := r.Header.Get("X-Project-ID")
-	if projectID == "" {
-		projectID = "default" // For testing
+	if projectID != "" {
+		return projectID, nil
+	}
+	if h.cfg.AllowDefaultProject {
+		return defaultProjectID, nil
+	}
+	return "", response.NewError(http.StatusBadRequest, "missing_project", "Missing project ID")
+}
+
+// buildTraceInput validates a trace request and converts it into workflow input.
+// Single and batch ingestion share it so both apply identical validation.
+// backfill is set from ?backfill=true, not the body (see backfillMode).
+// Validation failures are returned as *response.APIError; corrections and
+// flags that don't reject the trace are added to warn when it is non-nil.
+func (h *Handler) buildTraceInput(req *IngestTraceRequest, projectID string, backfill bool, warn *warnings) (temporal.TraceWorkflowInput, error) {
+	// Validate request
+	if req.Name == "" {
+		return temporal.TraceWorkflowInput{}, validationError("name is required")
+	}
+	if err := checkNameLength("name", req.Name, h.cfg.MaxTraceNameLength); err != nil {
+		return temporal.TraceWorkflowInput{}, err
+	}
+
+	// An empty trace starts a workflow with nothing to process
+	if len(req.Spans) == 0 && !req.AllowEmptyTrace && !h.cfg.AllowEmptyTraces {
+		return temporal.TraceWorkflowInput{}, response.NewError(http.StatusBadRequest, "no_spans", "trace must contain at least one span (set allow_empty_trace to override)")
+	}
+	if len(req.Spans) > h.cfg.MaxSpansPerTrace {
+		apiErr := response.NewError(http.StatusBadRequest, "too_many_spans",
+			fmt.Sprintf("trace contains %d spans, maximum is %d", len(req.Spans), h.cfg.MaxSpansPerTrace))
+		apiErr.Details = map[string]any{"field": "spans", "max": h.cfg.MaxSpansPerTrace}
+		return temporal.TraceWorkflowInput{}, apiErr
+	}
+
+	// Default environment to the configured value when omitted
+	environment := h.cfg.DefaultEnvironment
+	if req.Environment != nil && *req.Environment != "" {
+		if err := validateTagValue("environment", *req.Environment); err != nil {
+			return temporal.TraceWorkflowInput{}, validationError(err.Error())
+		}
+		environment = *req.Environment
+	}
+
+	var release string
+	if req.Release != nil && *req.Release != "" {
+		if err := validateTagValue("release", *req.Release); err != nil {
+			return temporal.TraceWorkflowInput{}, validationError(err.Error())
+		}
+		release = *req.Release
+	}
+
+	tags, err := normalizeTags(req.Tags, h.cfg.MaxTagsPerTrace)
+	if err != nil {
+		return temporal.TraceWorkflowInput{}, validationError(err.Error())
+	}
+	if removed := len(req.Tags) - len(tags); removed > 0 {
+		warn.add(WarningDuplicateTags, "tags", fmt.Sprintf("duplicate tags were removed (%d)", removed))
+	}
+
+	// Session and user IDs become grouping keys downstream
+	if req.SessionID != nil {
+		if err := checkExternalID("session_id", *req.SessionID, h.cfg.MaxExternalIDLength); err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+	}
+	if req.UserID != nil {
+		if err := checkExternalID("user_id", *req.UserID, h.cfg.MaxExternalIDLength); err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+	}
+	if req.User != nil {
+		if err := checkUserMetadata(req.User.Metadata, h.cfg.MaxUserMetadataKeys, h.cfg.MaxUserMetadataBytes); err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+	}
+
+	if req.RetentionDays != nil && *req.RetentionDays < 1 {
+		apiErr := response.NewError(http.StatusBadRequest, "validation_error",
+			fmt.Sprintf("retention_days must be at least 1 (got %d)", *req.RetentionDays))
+		apiErr.Details = map[string]any{"field": "retention_days"}
+		return temporal.TraceWorkflowInput{}, apiErr
+	}
+
+	switch req.Status {
+	case "", TraceStatusSuccess, TraceStatusError, TraceStatusCancelled, TraceStatusUnknown:
+	default:
+		return temporal.TraceWorkflowInput{}, validationError(fmt.Sprintf("status must be one of success, error, cancelled, unknown (got %q)", req.Status))
 	}
 
 	// Generate trace ID if not provided
-	traceID := generateID()
+	traceID := h.ids.New()
 	if req.TraceID != nil && *req.TraceID != "" {
 		traceID = *req.TraceID
 	}
 
-	// Build workflow input
+	// Build workflow input. Metadata maps are copied without reserved keys:
+	// system keys are added to them below and the request may be shared,
+	// e.g. by OTLP conversion.
 	input := temporal.TraceWorkflowInput{
-		ID:        traceID,
-		ProjectID: projectID,
-		Name:      req.Name,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Metadata:  req.Metadata,
+		ID:          traceID,
+		ProjectID:   projectID,
+		Name:        req.Name,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Metadata:    clientMetadata("metadata", req.Metadata, warn),
+		Environment: environment,
+		Release:     release,
+		Tags:        tags,
+		Status:      req.Status,
+	}
+	if req.RetentionDays != nil {
+		input.RetentionDays = *req.RetentionDays
+	}
+
+	// Historical imports keep their original timestamps; tag them so they
+	// can be told apart from live traffic
+	if backfill {
+		if input.Metadata == nil {
+			input.Metadata = make(map[string]any, 1)
+		}
+		input.Metadata[BackfillMetadataKey] = true
 	}
 
 	if req.SessionID != nil {
@@ -117,92 +504,301 @@ func (h *Handler) IngestTrace(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert spans
-	spanIDs := make([]string, 0, len(req.Spans))
 	now := time.Now().UTC()
 	input.Spans = make([]temporal.SpanInput, len(req.Spans))
+	starts := make([]time.Time, len(req.Spans))
+	anomalies := 0
 
 	for i, s := range req.Spans {
-		spanID := generateID()
+		if err := checkNameLength(fmt.Sprintf("spans[%d].name", i), s.Name, h.cfg.MaxSpanNameLength); err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+
+		spanID := h.ids.New()
 		if s.SpanID != nil && *s.SpanID != "" {
 			spanID = *s.SpanID
 		}
-		spanIDs = append(spanIDs, spanID)
 
 		// Default start_time to now if not provided
 		startTime := now
 		if s.StartTime != nil {
-			startTime = *s.StartTime
+			startTime = s.StartTime.UTC()
+		}
+		starts[i] = startTime
+
+		if err := h.checkModelParameters(i, s.ModelParameters); err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+		spanInput, err := spanPayload(fmt.Sprintf("spans[%d].input", i), s.Input, h.cfg.StrictSpanPayloads)
+		if err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+		spanOutput, err := spanPayload(fmt.Sprintf("spans[%d].output", i), s.Output, h.cfg.StrictSpanPayloads)
+		if err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+		level := s.Level
+		if level != "" {
+			if level, err = checkSpanLevel(fmt.Sprintf("spans[%d].level", i), level); err != nil {
+				return temporal.TraceWorkflowInput{}, err
+			}
 		}
 
 		span := temporal.SpanInput{
 			ID:              spanID,
 			Name:            s.Name,
-			StartTime:       startTime.Format(time.RFC3339),
-			Input:           s.Input,
-			Output:          s.Output,
-			Metadata:        s.Metadata,
+			StartTime:       startTime.Format(time.RFC3339Nano),
+			Input:           spanInput,
+			Output:          spanOutput,
+			Metadata:        clientMetadata(fmt.Sprintf("spans[%d].metadata", i), s.Metadata, warn),
 			ModelParameters: s.ModelParameters,
-			Level:           s.Level,
+			Level:           level,
 		}
 
 		if s.ParentSpanID != nil {
 			span.ParentSpanID = *s.ParentSpanID
 		}
 
-		if s.EndTime != nil {
-			span.EndTime = s.EndTime.Format(time.RFC3339)
-		} else {
-			span.EndTime = now.Format(time.RFC3339)
+		// Credentials forwarded with config blobs must not be stored
+		if scrubbed := h.scrubModelParameters(span.ModelParameters); scrubbed > 0 {
+			if span.Metadata == nil {
+				span.Metadata = make(map[string]any, 1)
+			}
+			span.Metadata[ScrubbedKeysMetadataKey] = scrubbed
+			warn.add(WarningParametersScrubbed, fmt.Sprintf("spans[%d].model_parameters", i),
+				fmt.Sprintf("sensitive values in spans[%d].model_parameters were redacted (%d)", i, scrubbed))
+		}
+
+		endTime, err := spanEndTime(i, s, startTime, now)
+		if err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+		span.EndTime = endTime.Format(time.RFC3339Nano)
+
+		// Flag rather than reject: usually client clock skew, and the data is still useful.
+		// Backfilled spans come from other systems' clocks and aren't flagged.
+		if reason := h.durationAnomaly(endTime.Sub(startTime)); reason != "" && !backfill {
+			if span.Metadata == nil {
+				span.Metadata = make(map[string]any, 1)
+			}
+			span.Metadata[DurationAnomalyMetadataKey] = reason
+			metrics.SpanDurationAnomalies.WithLabelValues(reason).Inc()
+			anomalies++
+			warn.add(WarningDurationAnomaly, fmt.Sprintf("spans[%d]", i), h.durationAnomalyMessage(i, reason))
 		}
 
 		if s.Model != nil {
 			span.Model = *s.Model
 		}
 
+		// Catch instrumentation typos before they pollute cost reports
+		if span.Model != "" && !h.cfg.IsModelAllowed(span.Model) {
+			if !h.cfg.AllowedModelsSoft {
+				return temporal.TraceWorkflowInput{}, response.NewError(http.StatusBadRequest, "unknown_model",
+					fmt.Sprintf("spans[%d].model %q is not an allowed model", i, span.Model))
+			}
+			if span.Metadata == nil {
+				span.Metadata = make(map[string]any, 1)
+			}
+			span.Metadata[UnknownModelMetadataKey] = true
+			metrics.UnknownModels.Inc()
+			warn.add(WarningUnknownModel, fmt.Sprintf("spans[%d].model", i),
+				fmt.Sprintf("spans[%d].model %q is not an allowed model", i, span.Model))
+			slog.Warn("unknown model flagged", "project_id", projectID, "trace_id", traceID, "model", span.Model)
+		}
+
+		// Cost attribution groups by provider, which many SDKs only put in metadata
+		provider, err := spanProvider(i, s.Provider, s.Metadata, span.Model)
+		if err != nil {
+			apiErr := response.NewError(http.StatusBadRequest, "validation_error", err.Error())
+			apiErr.Details = map[string]any{"field": fmt.Sprintf("spans[%d].provider", i)}
+			return temporal.TraceWorkflowInput{}, apiErr
+		}
+		span.Provider = provider
+		if provider != "" && !isKnownProvider(provider) {
+			if span.Metadata == nil {
+				span.Metadata = make(map[string]any, 1)
+			}
+			span.Metadata[UnknownProviderMetadataKey] = true
+			metrics.UnknownProviders.Inc()
+			warn.add(WarningUnknownProvider, fmt.Sprintf("spans[%d].provider", i),
+				fmt.Sprintf("spans[%d].provider %q is not a known provider", i, provider))
+			slog.Warn("unknown provider flagged", "project_id", projectID, "trace_id", traceID, "provider", provider)
+		}
+
 		if s.StatusMessage != nil {
 			span.StatusMessage = *s.StatusMessage
 		}
 
+		attachments, err := h.buildAttachments(i, s.Attachments)
+		if err != nil {
+			return temporal.TraceWorkflowInput{}, err
+		}
+		span.Attachments = attachments
+
+		// Costs the client computed itself are authoritative; otherwise
+		// negotiated pricing replaces the price-table lookup. A span
+		// carrying both must agree on the total.
+		costOverride, err := resolveCostOverride(i, s.ModelParameters, req.Metadata)
+		if err != nil {
+			return temporal.TraceWorkflowInput{}, validationError(err.Error())
+		}
+		if s.Cost != nil {
+			total, err := resolveClientCost(i, s.Cost)
+			if err != nil {
+				apiErr := response.NewError(http.StatusBadRequest, "validation_error", err.Error())
+				apiErr.Details = map[string]any{"field": fmt.Sprintf("spans[%d].cost", i)}
+				return temporal.TraceWorkflowInput{}, apiErr
+			}
+			if costOverride != nil && *costOverride != *total {
+				apiErr := response.NewError(http.StatusBadRequest, "conflicting_costs",
+					fmt.Sprintf("spans[%d].cost total %g differs from %s %g", i, *total, CostOverrideKey, *costOverride))
+				apiErr.Details = map[string]any{"field": fmt.Sprintf("spans[%d].cost", i)}
+				return temporal.TraceWorkflowInput{}, apiErr
+			}
+			span.CostOverrideUSD = total
+			span.PromptCostUSD = s.Cost.PromptCostUSD
+			span.CompletionCostUSD = s.Cost.CompletionCostUSD
+			span.CostSource = CostSourceClient
+		} else if costOverride != nil {
+			span.CostOverrideUSD = costOverride
+			span.CostSource = CostSourceOverride
+		}
+
 		if s.Usage != nil {
 			if s.Usage.PromptTokens != nil {
-				span.PromptTokens = int(*s.Usage.PromptTokens)
+				span.PromptTokens = temporal.TokenCount(*s.Usage.PromptTokens)
 			}
 			if s.Usage.CompletionTokens != nil {
-				span.CompletionTokens = int(*s.Usage.CompletionTokens)
+				span.CompletionTokens = temporal.TokenCount(*s.Usage.CompletionTokens)
 			}
 			if s.Usage.TotalTokens != nil {
-				span.TotalTokens = int(*s.Usage.TotalTokens)
+				span.TotalTokens = temporal.TokenCount(*s.Usage.TotalTokens)
 			}
 		}
 
+		// Some providers report only a total; price it with an estimated
+		// split rather than recording zero cost
+		if span.CostOverrideUSD == nil && span.PromptTokens == 0 && span.CompletionTokens == 0 && span.TotalTokens > 0 {
+			span.EstimatedPromptTokens, span.EstimatedCompletionTokens = blendedTokenSplit(int64(span.TotalTokens), h.cfg.CostBlendedPromptRatio)
+			span.CostSource = CostSourceEstimatedBlended
+			warn.add(WarningUsageEstimated, fmt.Sprintf("spans[%d].usage", i),
+				fmt.Sprintf("spans[%d].usage has only total_tokens; prompt and completion tokens were estimated for cost", i))
+		}
+
 		input.Spans[i] = span
 	}
 
-	// Start Temporal workflow
-	workflowID, err := h.temporalClient.StartTraceWorkflow(r.Context(), input)
-	if err != nil {
-		slog.Error("failed to start trace workflow", "error", err, "trace_id", traceID)
-		http.Error(w, "failed to process trace", http.StatusInternalServerError)
-		return
+	if err := checkSpanGraph(input.Spans, h.cfg.MaxSpanDepth); err != nil {
+		return temporal.TraceWorkflowInput{}, err
 	}
-	slog.Info("trace workflow started", "trace_id", traceID, "workflow_id", workflowID, "spans", len(input.Spans))
 
-	// Send response
-	resp := IngestTraceResponse{
-		TraceID:    traceID,
-		SpanIDs:    spanIDs,
-		WorkflowID: workflowID,
-		Success:    true,
+	if input.Status == "" {
+		input.Status = derivedTraceStatus(input.Spans)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	_ = json.NewEncoder(w).Encode(resp)
+	if summary := traceUsageSummary(input.Spans); summary != nil {
+		if input.Metadata == nil {
+			input.Metadata = make(map[string]any, 1)
+		}
+		input.Metadata[UsageSummaryMetadataKey] = summary
+	}
+
+	// Opt-in, since some clients rely on insertion order
+	if h.cfg.SortSpansByStartTime {
+		sortSpansByStartTime(input.Spans, starts)
+	}
+
+	if anomalies > 0 {
+		slog.Warn("span duration anomalies flagged",
+			"project_id", projectID,
+			"trace_id", traceID,
+			"anomalies", anomalies,
+		)
+	}
+
+	return input, nil
 }
 
-// generateID generates a random ID
-func generateID() string {
-	b := make([]byte, 16)
-	_, _ = rand.Read(b)
-	return hex.EncodeToString(b)
+// durationMismatchTolerance is how far end_time and start_time plus
+// duration_ms may disagree, allowing for SDKs rounding to whole milliseconds
+const durationMismatchTolerance = time.Millisecond
+
+// spanEndTime resolves a span's end time from end_time, or from start_time
+// plus duration_ms for SDKs that only track a duration. When both are sent
+// they must agree within durationMismatchTolerance. Defaults to now.
+// An end_time before an explicit start_time is rejected with 400
+// end_before_start; a defaulted end before a future start is left to
+// the duration anomaly check, as it points at client clock skew.
+func spanEndTime(i int, s IngestSpanInput, startTime, now time.Time) (time.Time, error) {
+	if s.EndTime != nil && s.StartTime != nil && s.EndTime.Before(startTime) {
+		apiErr := response.NewError(http.StatusBadRequest, "end_before_start",
+			fmt.Sprintf("spans[%d].end_time is before its start_time", i))
+		apiErr.Details = map[string]any{"field": fmt.Sprintf("spans[%d].end_time", i)}
+		return time.Time{}, apiErr
+	}
+	if s.DurationMs == nil {
+		if s.EndTime != nil {
+			return s.EndTime.UTC(), nil
+		}
+		return now, nil
+	}
+
+	field := fmt.Sprintf("spans[%d].duration_ms", i)
+	durationError := func(message string) error {
+		apiErr := response.NewError(http.StatusBadRequest, "validation_error", message)
+		apiErr.Details = map[string]any{"field": field}
+		return apiErr
+	}
+	if *s.DurationMs < 0 || math.IsNaN(*s.DurationMs) || *s.DurationMs > float64(math.MaxInt64/int64(time.Millisecond)) {
+		return time.Time{}, durationError(field + " must be a non-negative number of milliseconds")
+	}
+
+	endTime := startTime.Add(time.Duration(*s.DurationMs * float64(time.Millisecond)))
+	if s.EndTime != nil {
+		if diff := s.EndTime.UTC().Sub(endTime).Abs(); diff > durationMismatchTolerance {
+			return time.Time{}, durationError(fmt.Sprintf("%s disagrees with end_time by %s", field, diff))
+		}
+		return s.EndTime.UTC(), nil
+	}
+	return endTime, nil
+}
+
+// derivedTraceStatus is the status of a trace submitted without one
+func derivedTraceStatus(spans []temporal.SpanInput) string {
+	for _, span := range spans {
+		if strings.EqualFold(span.Level, "ERROR") {
+			return TraceStatusError
+		}
+	}
+	return TraceStatusSuccess
+}
+
+// durationAnomaly returns the anomaly reason for a span duration, or "" if plausible
+func (h *Handler) durationAnomaly(d time.Duration) string {
+	switch {
+	case d < 0:
+		return durationAnomalyNegative
+	case d > h.cfg.MaxPlausibleSpanDuration:
+		return durationAnomalyTooLong
+	default:
+		return ""
+	}
+}
+
+// durationAnomalyMessage describes a span's duration anomaly
+func (h *Handler) durationAnomalyMessage(i int, reason string) string {
+	if reason == durationAnomalyNegative {
+		return fmt.Sprintf("spans[%d] ends before it starts", i)
+	}
+	return fmt.Sprintf("spans[%d] is longer than %s", i, h.cfg.MaxPlausibleSpanDuration)
+}
+
+// spanIDs returns the IDs of the spans in a workflow input
+func spanIDs(input temporal.TraceWorkflowInput) []string {
+	ids := make([]string, len(input.Spans))
+	for i, span := range input.Spans {
+		ids[i] = span.ID
+	}
+	return ids
 }
@@ -0,0 +1,434 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/dedup"
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+func TestBuildTraceInputCopiesMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		span     IngestSpanInput
+		backfill bool
+		traceKey string // System key expected on the trace metadata
+		spanKey  string // System key expected on the span metadata
+	}{
+		{
+			name:     "backfill flag",
+			span:     IngestSpanInput{Name: "llm"},
+			backfill: true,
+			traceKey: BackfillMetadataKey,
+		},
+		{
+			name:    "scrubbed model parameters",
+			span:    IngestSpanInput{Name: "llm", ModelParameters: map[string]any{"api_key": "sk-test"}},
+			spanKey: ScrubbedKeysMetadataKey,
+		},
+		{
+			name:    "unknown model",
+			env:     map[string]string{"ALLOWED_MODELS": "gpt-4o", "ALLOWED_MODELS_SOFT": "true"},
+			span:    IngestSpanInput{Name: "llm", Model: ptr("gpt-4o-typo")},
+			spanKey: UnknownModelMetadataKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			h := newTestHandler(t, nil)
+
+			traceMetadata := map[string]any{"team": "search"}
+			spanMetadata := map[string]any{"step": "retrieve"}
+			tt.span.Metadata = spanMetadata
+			req := &IngestTraceRequest{
+				Name:     "chat",
+				Metadata: traceMetadata,
+				Spans:    []IngestSpanInput{tt.span},
+			}
+
+			input, err := h.buildTraceInput(req, "proj-1", tt.backfill, nil)
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+
+			if _, ok := input.Metadata[tt.traceKey]; tt.traceKey != "" && !ok {
+				t.Errorf("trace metadata = %v, want %s set", input.Metadata, tt.traceKey)
+			}
+			if _, ok := input.Spans[0].Metadata[tt.spanKey]; tt.spanKey != "" && !ok {
+				t.Errorf("span metadata = %v, want %s set", input.Spans[0].Metadata, tt.spanKey)
+			}
+			// System keys are added to the workflow input only
+			if len(traceMetadata) != 1 {
+				t.Errorf("request trace metadata was modified: %v", traceMetadata)
+			}
+			if len(spanMetadata) != 1 {
+				t.Errorf("request span metadata was modified: %v", spanMetadata)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputSpanTimes(t *testing.T) {
+	tests := []struct {
+		name      string
+		span      string
+		wantStart string
+		wantEnd   string
+	}{
+		{
+			name:      "rfc3339 offset",
+			span:      `{"name":"llm","start_time":"2024-05-01T14:30:00+02:00","end_time":"2024-05-01T14:30:01.5+02:00"}`,
+			wantStart: "2024-05-01T12:30:00Z",
+			wantEnd:   "2024-05-01T12:30:01.5Z",
+		},
+		{
+			name:      "epoch seconds",
+			span:      `{"name":"llm","start_time":1714566600,"end_time":1714566601}`,
+			wantStart: "2024-05-01T12:30:00Z",
+			wantEnd:   "2024-05-01T12:30:01Z",
+		},
+		{
+			name:      "epoch milliseconds",
+			span:      `{"name":"llm","start_time":1714566600123,"end_time":1714566600456}`,
+			wantStart: "2024-05-01T12:30:00.123Z",
+			wantEnd:   "2024-05-01T12:30:00.456Z",
+		},
+		{
+			name:      "mixed formats",
+			span:      `{"name":"llm","start_time":"2024-05-01 12:30:00","end_time":1714566600250000}`,
+			wantStart: "2024-05-01T12:30:00Z",
+			wantEnd:   "2024-05-01T12:30:00.25Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			var span IngestSpanInput
+			if err := json.Unmarshal([]byte(tt.span), &span); err != nil {
+				t.Fatalf("decode span: %v", err)
+			}
+			req := &IngestTraceRequest{Name: "chat", Spans: []IngestSpanInput{span}}
+
+			input, err := h.buildTraceInput(req, "proj-1", true, nil)
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			if got := input.Spans[0].StartTime; got != tt.wantStart {
+				t.Errorf("StartTime = %q, want %q", got, tt.wantStart)
+			}
+			if got := input.Spans[0].EndTime; got != tt.wantEnd {
+				t.Errorf("EndTime = %q, want %q", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestWorkflowRunTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		deadline time.Duration // Time left on the request; zero means no deadline
+		want     time.Duration // Expected timeout, to the nearest second
+	}{
+		{name: "disabled", deadline: time.Minute},
+		{name: "no deadline", enabled: true},
+		// The default REQUEST_TIMEOUT must not be clamped to the minimum
+		{name: "request timeout", enabled: true, deadline: 30 * time.Second, want: 30 * time.Second},
+		{name: "client deadline", enabled: true, deadline: 10 * time.Second, want: 10 * time.Second},
+		{name: "wait timeout", enabled: true, deadline: 2 * time.Minute, want: 2 * time.Minute},
+		{name: "below the minimum", enabled: true, deadline: 2 * time.Second, want: 5 * time.Second},
+		{name: "above the maximum", enabled: true, deadline: time.Hour, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Default WORKFLOW_MIN_RUN_TIMEOUT and WORKFLOW_MAX_RUN_TIMEOUT
+			h := newTestHandler(t, func(cfg *config.Config) { cfg.WorkflowTimeoutFromDeadline = tt.enabled })
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			if got := h.workflowRunTimeout(ctx).Round(time.Second); got != tt.want {
+				t.Errorf("workflowRunTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputSpanDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		span      string
+		wantEnd   string
+		wantError string // Substring of the spans[0].duration_ms error; empty expects success
+	}{
+		{
+			name:    "duration only",
+			span:    `{"name":"llm","start_time":"2024-05-01T12:30:00Z","duration_ms":1500}`,
+			wantEnd: "2024-05-01T12:30:01.5Z",
+		},
+		{
+			name:    "fractional duration",
+			span:    `{"name":"llm","start_time":"2024-05-01T12:30:00Z","duration_ms":0.25}`,
+			wantEnd: "2024-05-01T12:30:00.00025Z",
+		},
+		{
+			name:    "end time only",
+			span:    `{"name":"llm","start_time":"2024-05-01T12:30:00Z","end_time":"2024-05-01T12:30:02Z"}`,
+			wantEnd: "2024-05-01T12:30:02Z",
+		},
+		{
+			// Within the rounding tolerance, end_time wins
+			name:    "both agree",
+			span:    `{"name":"llm","start_time":"2024-05-01T12:30:00Z","end_time":"2024-05-01T12:30:01.0004Z","duration_ms":1000}`,
+			wantEnd: "2024-05-01T12:30:01.0004Z",
+		},
+		{
+			name:      "both disagree",
+			span:      `{"name":"llm","start_time":"2024-05-01T12:30:00Z","end_time":"2024-05-01T12:30:02Z","duration_ms":1000}`,
+			wantError: "spans[0].duration_ms disagrees with end_time by 1s",
+		},
+		{
+			name:      "negative",
+			span:      `{"name":"llm","start_time":"2024-05-01T12:30:00Z","duration_ms":-1}`,
+			wantError: "spans[0].duration_ms must be a non-negative number",
+		},
+		{
+			name:      "too large",
+			span:      `{"name":"llm","start_time":"2024-05-01T12:30:00Z","duration_ms":1e300}`,
+			wantError: "spans[0].duration_ms must be a non-negative number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			var span IngestSpanInput
+			if err := json.Unmarshal([]byte(tt.span), &span); err != nil {
+				t.Fatalf("decode span: %v", err)
+			}
+			req := &IngestTraceRequest{Name: "chat", Spans: []IngestSpanInput{span}}
+
+			input, err := h.buildTraceInput(req, "proj-1", false, nil)
+			if tt.wantError != "" {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || !strings.Contains(apiErr.Message, tt.wantError) {
+					t.Fatalf("buildTraceInput() error = %v, want %q", err, tt.wantError)
+				}
+				if field := apiErr.Detail().Field; field != "spans[0].duration_ms" {
+					t.Errorf("error field = %q, want spans[0].duration_ms", field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			if got := input.Spans[0].EndTime; got != tt.wantEnd {
+				t.Errorf("EndTime = %q, want %q", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    *middleware.ProjectRetention
+		requested int // Trace's retention_days; zero means omitted
+		want      int
+		wantErr   bool
+	}{
+		{name: "no policy", requested: 400, want: 400},
+		{name: "no policy, omitted"},
+		{name: "project default", policy: &middleware.ProjectRetention{DefaultDays: 30, MaxDays: 90}, want: 30},
+		{name: "requested", policy: &middleware.ProjectRetention{DefaultDays: 30, MaxDays: 90}, requested: 7, want: 7},
+		{name: "at the maximum", policy: &middleware.ProjectRetention{DefaultDays: 30, MaxDays: 90}, requested: 90, want: 90},
+		{name: "over the maximum", policy: &middleware.ProjectRetention{DefaultDays: 30, MaxDays: 90}, requested: 91, wantErr: true},
+		{name: "no maximum", policy: &middleware.ProjectRetention{DefaultDays: 30}, requested: 3650, want: 3650},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := temporal.TraceWorkflowInput{ID: "t1", RetentionDays: tt.requested}
+			err := applyRetention(tt.policy, &input)
+			if tt.wantErr {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != "retention_too_long" || apiErr.Detail().Field != "retention_days" {
+					t.Fatalf("applyRetention() error = %v, want retention_too_long for retention_days", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyRetention: %v", err)
+			}
+			if input.RetentionDays != tt.want {
+				t.Errorf("RetentionDays = %d, want %d", input.RetentionDays, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputRetentionDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    int
+		wantErr bool
+	}{
+		{name: "positive", days: 14},
+		{name: "zero", days: 0, wantErr: true},
+		{name: "negative", days: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			req := &IngestTraceRequest{Name: "chat", RetentionDays: &tt.days, Spans: []IngestSpanInput{{Name: "llm"}}}
+
+			input, err := h.buildTraceInput(req, "proj-1", false, nil)
+			if tt.wantErr {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != "validation_error" || apiErr.Detail().Field != "retention_days" {
+					t.Fatalf("buildTraceInput() error = %v, want validation_error for retention_days", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			if input.RetentionDays != tt.days {
+				t.Errorf("RetentionDays = %d, want %d", input.RetentionDays, tt.days)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputSpanLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		want    string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "upper-case", level: "WARNING", want: "WARNING"},
+		{name: "lower-case", level: "debug", want: "DEBUG"},
+		{name: "unknown", level: "FATAL", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			req := &IngestTraceRequest{Name: "chat", Spans: []IngestSpanInput{{Name: "llm", Level: tt.level}}}
+
+			input, err := h.buildTraceInput(req, "proj-1", false, nil)
+			if tt.wantErr {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != "validation_error" || apiErr.Detail().Field != "spans[0].level" {
+					t.Fatalf("buildTraceInput() error = %v, want validation_error for spans[0].level", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			if got := input.Spans[0].Level; got != tt.want {
+				t.Errorf("Level = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngestTraceDedup(t *testing.T) {
+	// Client-supplied times, so a retry fingerprints the same
+	const body = `{"name":"chat","spans":[{"name":"llm","start_time":"2024-05-01T12:00:00Z","end_time":"2024-05-01T12:00:01Z"}]}`
+
+	tests := []struct {
+		name        string
+		redisDown   bool
+		firstFails  bool // The first send's workflow start fails
+		wantStatus  int  // Status of the second send
+		wantDeduped bool
+		wantStarted int
+	}{
+		{name: "repeat dropped", wantStatus: http.StatusAccepted, wantDeduped: true, wantStarted: 1},
+		// The failed send's fingerprint is forgotten, so the retry is ingested
+		{name: "retry after a failed start", firstFails: true, wantStatus: http.StatusAccepted, wantStarted: 1},
+		{name: "redis down fails open", redisDown: true, wantStatus: http.StatusAccepted, wantStarted: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			deduplicator, err := dedup.New("redis://"+mr.Addr(), time.Minute)
+			if err != nil {
+				t.Fatalf("dedup.New: %v", err)
+			}
+			t.Cleanup(func() { _ = deduplicator.Close() })
+			if tt.redisDown {
+				mr.SetError("LOADING Redis is loading the dataset in memory")
+			}
+
+			sends := 0
+			client := &temporaltest.Client{
+				StartTraceWorkflowFunc: func(_ context.Context, input temporal.TraceWorkflowInput) (*temporal.StartResult, error) {
+					sends++
+					if tt.firstFails && sends == 1 {
+						return nil, errors.New("frontend unavailable")
+					}
+					return &temporal.StartResult{WorkflowID: "trace-" + input.ID}, nil
+				},
+			}
+			watchdog := temporal.NewWatchdog(client, time.Minute, 3, false)
+			h := New(testConfig(t, nil), client, watchdog, nil, deduplicator, nil, nil, nil, time.Now())
+
+			send := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(body))
+				req.Header.Set("X-Project-ID", "proj-1")
+				rec := httptest.NewRecorder()
+				h.IngestTrace(rec, req)
+				return rec
+			}
+			send()
+			rec := send()
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var resp IngestTraceResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if resp.Deduplicated != tt.wantDeduped {
+				t.Errorf("deduplicated = %v, want %v", resp.Deduplicated, tt.wantDeduped)
+			}
+			if tt.wantDeduped && resp.WorkflowID != "" {
+				t.Errorf("workflow_id = %q, want none for a dropped send", resp.WorkflowID)
+			}
+			if got := len(client.Started()); got != tt.wantStarted {
+				t.Errorf("started %d workflows, want %d", got, tt.wantStarted)
+			}
+		})
+	}
+}
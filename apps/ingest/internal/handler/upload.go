@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/upload"
+)
+
+// UploadSessionResponse represents a newly opened upload session
+type UploadSessionResponse struct {
+	SessionID string    `json:"session_id"`
+	TraceID   string    `json:"trace_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UploadChunkRequest carries one batch of spans for an upload session
+type UploadChunkRequest struct {
+	Spans []IngestSpanInput `json:"spans"`
+}
+
+// UploadChunkResponse represents the response after storing a chunk
+type UploadChunkResponse struct {
+	SessionID string `json:"session_id"`
+	Chunks    int64  `json:"chunks"` // Chunks received so far, including this one
+}
+
+// CreateUploadSession handles POST /v1/traces/sessions
+// The body is a trace without spans; spans follow as chunks and the
+// trace is validated and started as one workflow on commit. A trace ID
+// is assigned here when the client didn't send one, so retried commits
+// start the same workflow.
+func (h *Handler) CreateUploadSession(w http.ResponseWriter, r *http.Request) {
+	var req IngestTraceRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		response.WriteError(w, err)
+		return
+	}
+	if len(req.Spans) > 0 {
+		response.Error(w, http.StatusBadRequest, "validation_error", "spans must be sent as chunks, not with the session")
+		return
+	}
+
+	projectID, err := h.resolveProjectID(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	if req.TraceID == nil || *req.TraceID == "" {
+		traceID := h.ids.New()
+		req.TraceID = &traceID
+	}
+	header, err := json.Marshal(req)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "internal_error", "failed to encode trace")
+		return
+	}
+	session, err := h.uploads.Create(r.Context(), projectID, header)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	slog.Info("upload session created", "session_id", session.ID, "project_id", projectID)
+
+	response.JSON(w, http.StatusCreated, UploadSessionResponse{SessionID: session.ID, TraceID: *req.TraceID, ExpiresAt: session.ExpiresAt.UTC()})
+}
+
+// AppendUploadChunk handles POST /v1/traces/sessions/{sessionID}/chunk
+// Chunks are stored in arrival order and only checked for shape here.
+// Spans without an ID are given one before storing, for the same reason
+// as the trace ID.
+func (h *Handler) AppendUploadChunk(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+
+	var req UploadChunkRequest
+	if err := h.decodeBody(r, &req); err != nil {
+		response.WriteError(w, err)
+		return
+	}
+	if len(req.Spans) == 0 {
+		response.Error(w, http.StatusBadRequest, "validation_error", "spans must not be empty")
+		return
+	}
+
+	projectID, err := h.resolveProjectID(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	for i := range req.Spans {
+		if req.Spans[i].SpanID == nil || *req.Spans[i].SpanID == "" {
+			spanID := h.ids.New()
+			req.Spans[i].SpanID = &spanID
+		}
+	}
+	chunk, err := json.Marshal(req.Spans)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "internal_error", "failed to encode spans")
+		return
+	}
+	chunks, err := h.uploads.Append(r.Context(), projectID, sessionID, chunk)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, UploadChunkResponse{SessionID: sessionID, Chunks: chunks})
+}
+
+// CommitUploadSession handles POST /v1/traces/sessions/{sessionID}/commit
+// The chunks are assembled into one trace, validated like POST /v1/traces
+// and started as a single workflow. The session is removed once accepted;
+// a trace that fails validation is left to expire. If the session
+// outlives its commit, e.g. the process stops before deleting it, a
+// repeated commit finds the workflow started and reports a duplicate.
+func (h *Handler) CommitUploadSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+
+	backfill, err := backfillMode(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	projectID, err := h.resolveProjectID(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	header, chunks, err := h.uploads.Load(r.Context(), projectID, sessionID)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	var req IngestTraceRequest
+	if err := h.unmarshalJSON(header, &req); err != nil {
+		slog.Error("failed to decode upload session", "error", err, "session_id", sessionID)
+		response.Error(w, http.StatusInternalServerError, "internal_error", "failed to read upload session")
+		return
+	}
+	for i, chunk := range chunks {
+		var spans []IngestSpanInput
+		if err := h.unmarshalJSON(chunk, &spans); err != nil {
+			slog.Error("failed to decode upload chunk", "error", err, "session_id", sessionID, "chunk", i)
+			response.Error(w, http.StatusInternalServerError, "internal_error", "failed to read upload session")
+			return
+		}
+		req.Spans = append(req.Spans, spans...)
+	}
+
+	input, err := h.buildTraceInput(&req, projectID, backfill, nil)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+	if err := h.prepareTrace(r.Context(), &input, nil); err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	if !h.startTrace(w, r, input, nil, false) {
+		return
+	}
+	if err := h.uploads.Delete(r.Context(), sessionID); err != nil {
+		slog.Warn("failed to delete committed upload session", "error", err, "session_id", sessionID)
+	}
+}
+
+// writeUploadError maps upload store errors to API errors
+func writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, upload.ErrSessionNotFound):
+		response.Error(w, http.StatusNotFound, "upload_session_not_found", err.Error())
+	case errors.Is(err, upload.ErrSessionTooLarge):
+		response.Error(w, http.StatusRequestEntityTooLarge, "upload_too_large", err.Error())
+	default:
+		slog.Error("upload session failed", "error", err)
+		response.Error(w, http.StatusInternalServerError, "internal_error", "upload session failed")
+	}
+}
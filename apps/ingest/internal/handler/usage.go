@@ -0,0 +1,31 @@
+package handler
+
+import "github.com/cognobserve/ingest/internal/temporal"
+
+// UsageSummaryMetadataKey holds the trace's aggregated token usage, so
+// downstream consumers don't have to sum the spans again
+const UsageSummaryMetadataKey = "_usage_summary"
+
+// traceUsageSummary sums token usage across spans, or returns nil when no
+// span reported usage. Spans may report any subset of the counts; a span
+// without a total contributes its prompt plus completion tokens to the total.
+func traceUsageSummary(spans []temporal.SpanInput) *UsageSummary {
+	var summary UsageSummary
+	for _, span := range spans {
+		if span.PromptTokens == 0 && span.CompletionTokens == 0 && span.TotalTokens == 0 {
+			continue
+		}
+		summary.SpansWithUsage++
+		summary.PromptTokens += int64(span.PromptTokens)
+		summary.CompletionTokens += int64(span.CompletionTokens)
+		if span.TotalTokens > 0 {
+			summary.TotalTokens += int64(span.TotalTokens)
+		} else {
+			summary.TotalTokens += int64(span.PromptTokens + span.CompletionTokens)
+		}
+	}
+	if summary.SpansWithUsage == 0 {
+		return nil
+	}
+	return &summary
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+func TestTraceUsageSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		spans []temporal.SpanInput
+		want  *UsageSummary
+	}{
+		{name: "no spans"},
+		{name: "no usage", spans: []temporal.SpanInput{{Name: "a"}, {Name: "b"}}},
+		{
+			name: "full usage",
+			spans: []temporal.SpanInput{
+				{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+				{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+			},
+			want: &UsageSummary{PromptTokens: 30, CompletionTokens: 15, TotalTokens: 45, SpansWithUsage: 2},
+		},
+		{
+			name: "total derived from breakdown",
+			spans: []temporal.SpanInput{
+				{PromptTokens: 10, CompletionTokens: 5},
+				{Name: "retriever"},
+			},
+			want: &UsageSummary{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, SpansWithUsage: 1},
+		},
+		{
+			name: "total only",
+			spans: []temporal.SpanInput{
+				{TotalTokens: 40},
+				{PromptTokens: 3},
+			},
+			want: &UsageSummary{PromptTokens: 3, TotalTokens: 43, SpansWithUsage: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := traceUsageSummary(tt.spans); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("traceUsageSummary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputUsageSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *UsageSummary
+	}{
+		{name: "no usage", body: `{"name":"chat","spans":[{"name":"llm"}]}`},
+		{
+			name: "usage",
+			body: `{"name":"chat","spans":[{"name":"llm","usage":{"prompt_tokens":12,"completion_tokens":8}}]}`,
+			want: &UsageSummary{PromptTokens: 12, CompletionTokens: 8, TotalTokens: 20, SpansWithUsage: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, nil)
+			var req IngestTraceRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+
+			input, err := h.buildTraceInput(&req, "proj-1", false, nil)
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			got, ok := input.Metadata[UsageSummaryMetadataKey]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("metadata[%s] = %+v, want it omitted", UsageSummaryMetadataKey, got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metadata[%s] = %+v, want %+v", UsageSummaryMetadataKey, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// MaxTagValueLength is the maximum length of environment, release and tag values
+const MaxTagValueLength = 128
+
+// tagValuePattern restricts environment/release/tag values to a charset that is
+// safe to use as a Temporal search attribute and in downstream filters
+var tagValuePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._\-+/]*$`)
+
+// validateTagValue checks an optional environment/release style value
+func validateTagValue(field, value string) error {
+	if len(value) > MaxTagValueLength {
+		return fmt.Errorf("%s must be at most %d characters", field, MaxTagValueLength)
+	}
+	if !tagValuePattern.MatchString(value) {
+		return fmt.Errorf("%s may only contain letters, digits, '.', '_', '-', '+' and '/'", field)
+	}
+	return nil
+}
+
+// normalizeTags validates trace tags and removes duplicates, keeping first-seen order.
+// The limit applies to distinct tags.
+func normalizeTags(tags []string, maxTags int) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	unique := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		if err := validateTagValue(fmt.Sprintf("tags[%d]", i), tag); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		unique = append(unique, tag)
+	}
+
+	if len(unique) > maxTags {
+		return nil, fmt.Errorf("tags must contain at most %d distinct values (got %d)", maxTags, len(unique))
+	}
+	return unique, nil
+}
+
+// nameExcerptLength is how much of an over-long name is echoed back in errors
+const nameExcerptLength = 64
+
+// checkNameLength rejects names longer than maxLength bytes with 400 name_too_long.
+// Only a short excerpt of the name is included in the message.
+func checkNameLength(field, name string, maxLength int) error {
+	if len(name) <= maxLength {
+		return nil
+	}
+	return response.NewError(http.StatusBadRequest, "name_too_long",
+		fmt.Sprintf("%s must be at most %d bytes (got %d): %q", field, maxLength, len(name), truncate(name, nameExcerptLength)+"..."))
+}
+
+// checkExternalID validates a client-supplied grouping key such as
+// session_id or user_id. Any printable UTF-8 is allowed; control characters
+// (including newlines) would corrupt session/user aggregation downstream.
+func checkExternalID(field, value string, maxLength int) error {
+	var apiErr *response.APIError
+	switch {
+	case len(value) > maxLength:
+		apiErr = response.NewError(http.StatusBadRequest, "validation_error",
+			fmt.Sprintf("%s must be at most %d bytes (got %d)", field, maxLength, len(value)))
+	case !utf8.ValidString(value):
+		apiErr = response.NewError(http.StatusBadRequest, "validation_error", field+" must be valid UTF-8")
+	case strings.ContainsFunc(value, unicode.IsControl):
+		apiErr = response.NewError(http.StatusBadRequest, "validation_error", field+" must not contain control characters or newlines")
+	default:
+		return nil
+	}
+	apiErr.Details = map[string]any{"field": field}
+	return apiErr
+}
+
+// spanLevels are the span levels the worker can store
+var spanLevels = []string{"DEBUG", "DEFAULT", "WARNING", "ERROR"}
+
+// checkSpanLevel validates a span level in any case and returns it
+// upper-cased, as the worker stores it. Unknown levels are rejected with
+// 400 rather than failing the workflow's insert.
+func checkSpanLevel(field, level string) (string, error) {
+	upper := strings.ToUpper(level)
+	if slices.Contains(spanLevels, upper) {
+		return upper, nil
+	}
+	apiErr := response.NewError(http.StatusBadRequest, "validation_error",
+		fmt.Sprintf("%s must be one of %s (got %q)", field, strings.Join(spanLevels, ", "), level))
+	apiErr.Details = map[string]any{"field": field}
+	return "", apiErr
+}
+
+// checkUserMetadata bounds user.metadata, which is replayed on every trace
+// for that user, by key count and serialized size. Oversized metadata is
+// rejected with 400 user_metadata_too_large.
+func checkUserMetadata(metadata map[string]any, maxKeys, maxBytes int) error {
+	var msg string
+	if len(metadata) > maxKeys {
+		msg = fmt.Sprintf("user.metadata must have at most %d keys (got %d)", maxKeys, len(metadata))
+	} else if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return validationError("user.metadata must be JSON-serializable")
+		}
+		if len(encoded) > maxBytes {
+			msg = fmt.Sprintf("user.metadata must be at most %d bytes serialized (got %d)", maxBytes, len(encoded))
+		}
+	}
+	if msg == "" {
+		return nil
+	}
+	apiErr := response.NewError(http.StatusBadRequest, "user_metadata_too_large", msg)
+	apiErr.Details = map[string]any{"field": "user.metadata", "max_keys": maxKeys, "max_bytes": maxBytes}
+	return apiErr
+}
+
+// spanPayload normalizes a span input or output to a JSON object. Clients
+// that log a raw prompt send a string, number or array; such values are
+// wrapped as {"value": <x>}, or rejected when STRICT_SPAN_PAYLOADS is set.
+func spanPayload(field string, v any, strict bool) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		if v == nil {
+			return nil, nil
+		}
+		return v, nil
+	}
+	if strict {
+		apiErr := response.NewError(http.StatusBadRequest, "validation_error",
+			fmt.Sprintf("%s must be a JSON object (got %s)", field, jsonValueType(v)))
+		apiErr.Details = map[string]any{"field": field}
+		return nil, apiErr
+	}
+	return map[string]any{"value": v}, nil
+}
+
+// jsonValueType names the JSON type of a decoded value
+func jsonValueType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// checkSpanGraph rejects span parent links that can't form a tree with
+// 400 invalid_span_graph: duplicate span IDs, spans that are their own
+// parent and parent cycles. Parents outside the trace are allowed. Trees
+// deeper than maxDepth levels are rejected with 422 span_tree_too_deep.
+// spans must be in request order so the reported field index matches the request.
+func checkSpanGraph(spans []temporal.SpanInput, maxDepth int) error {
+	graphError := func(field, message string) error {
+		apiErr := response.NewError(http.StatusBadRequest, "invalid_span_graph", message)
+		apiErr.Details = map[string]any{"field": field}
+		return apiErr
+	}
+
+	index := make(map[string]int, len(spans))
+	for i, span := range spans {
+		if first, ok := index[span.ID]; ok {
+			return graphError(fmt.Sprintf("spans[%d].span_id", i),
+				fmt.Sprintf("spans[%d] and spans[%d] share span_id %q", first, i, span.ID))
+		}
+		index[span.ID] = i
+	}
+
+	// Walk each span's ancestors up to a root or a span of known depth,
+	// then assign depths back down the path. A root is at depth 1.
+	depths := make([]int, len(spans)) // 0 until known
+	deepest := 0
+	for i := range spans {
+		var path []int
+		onPath := make(map[int]bool)
+		depth := 0
+		for j := i; ; {
+			if depths[j] > 0 {
+				depth = depths[j]
+				break
+			}
+			if onPath[j] {
+				return graphError(fmt.Sprintf("spans[%d].parent_span_id", j),
+					fmt.Sprintf("spans[%d] is its own ancestor through parent_span_id", j))
+			}
+			onPath[j] = true
+			path = append(path, j)
+			parent, ok := index[spans[j].ParentSpanID]
+			if spans[j].ParentSpanID == "" || !ok {
+				break
+			}
+			j = parent
+		}
+		for k := len(path) - 1; k >= 0; k-- {
+			depth++
+			depths[path[k]] = depth
+		}
+		deepest = max(deepest, depth)
+	}
+
+	if deepest > maxDepth {
+		apiErr := response.NewError(http.StatusUnprocessableEntity, "span_tree_too_deep",
+			fmt.Sprintf("span tree is %d levels deep, maximum is %d", deepest, maxDepth))
+		apiErr.Details = map[string]any{"field": "spans", "depth": deepest, "max": maxDepth}
+		return apiErr
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// validationError creates a 400 validation_error
+func validationError(message string) error {
+	return response.NewError(http.StatusBadRequest, "validation_error", message)
+}
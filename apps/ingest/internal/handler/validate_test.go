@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		maxTags int
+		want    []string
+		wantErr string
+	}{
+		{name: "none", maxTags: 2},
+		{name: "kept in order", tags: []string{"billing", "beta-feature"}, maxTags: 2, want: []string{"billing", "beta-feature"}},
+		{name: "duplicates removed", tags: []string{"a", "b", "a"}, maxTags: 5, want: []string{"a", "b"}},
+		// Duplicates don't count towards the limit
+		{name: "limit counts distinct tags", tags: []string{"a", "a", "b"}, maxTags: 2, want: []string{"a", "b"}},
+		{name: "too many", tags: []string{"a", "b", "c"}, maxTags: 2, wantErr: "at most 2 distinct values (got 3)"},
+		{name: "invalid charset", tags: []string{"ok", "no spaces"}, maxTags: 5, wantErr: "tags[1] may only contain"},
+		{name: "empty", tags: []string{""}, maxTags: 5, wantErr: "tags[0] may only contain"},
+		{name: "too long", tags: []string{strings.Repeat("a", MaxTagValueLength+1)}, maxTags: 5, wantErr: "tags[0] must be at most"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTags(tt.tags, tt.maxTags)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("normalizeTags() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeTags: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("normalizeTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputExternalIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		sessionID *string
+		userID    *string
+		wantField string // Field named in the validation error; empty expects success
+	}{
+		{name: "absent"},
+		{name: "printable unicode", sessionID: ptr("sess ünïcode"), userID: ptr("user@example.com")},
+		{name: "at the limit", userID: ptr(strings.Repeat("u", 16))},
+		{name: "too long", userID: ptr(strings.Repeat("u", 17)), wantField: "user_id"},
+		{name: "newline", sessionID: ptr("sess-1\nforged"), wantField: "session_id"},
+		{name: "control character", userID: ptr("user\x00"), wantField: "user_id"},
+		{name: "invalid utf-8", sessionID: ptr("sess-\xff"), wantField: "session_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, func(cfg *config.Config) { cfg.MaxExternalIDLength = 16 })
+			req := &IngestTraceRequest{
+				Name:      "chat",
+				SessionID: tt.sessionID,
+				UserID:    tt.userID,
+				Spans:     []IngestSpanInput{{Name: "llm"}},
+			}
+
+			_, err := h.buildTraceInput(req, "proj-1", false, nil)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("buildTraceInput: %v", err)
+				}
+				return
+			}
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != "validation_error" {
+				t.Fatalf("buildTraceInput() error = %v, want validation_error", err)
+			}
+			if field := apiErr.Detail().Field; field != tt.wantField {
+				t.Errorf("error field = %q, want %q", field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputSpanPayloads(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string // JSON value of spans[0].input
+		strict    bool
+		want      any
+		wantError string
+	}{
+		{name: "object", input: `{"prompt":"hi"}`, want: map[string]any{"prompt": "hi"}},
+		{name: "null", input: `null`},
+		{name: "string", input: `"hi"`, want: map[string]any{"value": "hi"}},
+		{name: "number", input: `42`, want: map[string]any{"value": 42.0}},
+		{name: "boolean", input: `true`, want: map[string]any{"value": true}},
+		{name: "array", input: `[{"role":"user"}]`, want: map[string]any{"value": []any{map[string]any{"role": "user"}}}},
+		{name: "strict object", input: `{"prompt":"hi"}`, strict: true, want: map[string]any{"prompt": "hi"}},
+		{name: "strict string", input: `"hi"`, strict: true, wantError: "spans[0].input must be a JSON object (got string)"},
+		{name: "strict array", input: `[]`, strict: true, wantError: "spans[0].input must be a JSON object (got array)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, func(cfg *config.Config) { cfg.StrictSpanPayloads = tt.strict })
+			var req IngestTraceRequest
+			body := `{"name":"chat","spans":[{"name":"llm","input":` + tt.input + `}]}`
+			if err := json.Unmarshal([]byte(body), &req); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+
+			input, err := h.buildTraceInput(&req, "proj-1", false, nil)
+			if tt.wantError != "" {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Message != tt.wantError {
+					t.Fatalf("buildTraceInput() error = %v, want %q", err, tt.wantError)
+				}
+				if field := apiErr.Detail().Field; field != "spans[0].input" {
+					t.Errorf("error field = %q, want spans[0].input", field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTraceInput: %v", err)
+			}
+			if got := input.Spans[0].Input; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Input = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSpanGraph(t *testing.T) {
+	tests := []struct {
+		name      string
+		spans     []temporal.SpanInput
+		wantField string // Field named in the invalid_span_graph error; empty expects success
+	}{
+		{name: "empty"},
+		{
+			name:  "tree",
+			spans: []temporal.SpanInput{{ID: "a"}, {ID: "b", ParentSpanID: "a"}, {ID: "c", ParentSpanID: "b"}, {ID: "d", ParentSpanID: "a"}},
+		},
+		{name: "child before parent", spans: []temporal.SpanInput{{ID: "b", ParentSpanID: "a"}, {ID: "a"}}},
+		// The parent may have been sent in another request
+		{name: "parent outside the trace", spans: []temporal.SpanInput{{ID: "a", ParentSpanID: "elsewhere"}}},
+		{
+			name:      "duplicate span ID",
+			spans:     []temporal.SpanInput{{ID: "a"}, {ID: "b"}, {ID: "a"}},
+			wantField: "spans[2].span_id",
+		},
+		{
+			name:      "own parent",
+			spans:     []temporal.SpanInput{{ID: "a"}, {ID: "b", ParentSpanID: "b"}},
+			wantField: "spans[1].parent_span_id",
+		},
+		{
+			name:      "cycle",
+			spans:     []temporal.SpanInput{{ID: "a", ParentSpanID: "c"}, {ID: "b", ParentSpanID: "a"}, {ID: "c", ParentSpanID: "b"}},
+			wantField: "spans[0].parent_span_id",
+		},
+		{
+			name:      "cycle below a rooted span",
+			spans:     []temporal.SpanInput{{ID: "root"}, {ID: "x", ParentSpanID: "y"}, {ID: "y", ParentSpanID: "x"}},
+			wantField: "spans[1].parent_span_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSpanGraph(tt.spans, 100)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("checkSpanGraph: %v", err)
+				}
+				return
+			}
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != "invalid_span_graph" {
+				t.Fatalf("checkSpanGraph() error = %v, want invalid_span_graph", err)
+			}
+			if field := apiErr.Detail().Field; field != tt.wantField {
+				t.Errorf("error field = %q, want %q", field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestBuildTraceInputMaxSpans(t *testing.T) {
+	tests := []struct {
+		name     string
+		spans    int
+		wantCode string
+	}{
+		{name: "at the limit", spans: 3},
+		{name: "over the limit", spans: 4, wantCode: "too_many_spans"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, func(cfg *config.Config) { cfg.MaxSpansPerTrace = 3 })
+			req := IngestTraceRequest{Name: "chat"}
+			for range tt.spans {
+				req.Spans = append(req.Spans, IngestSpanInput{Name: "llm"})
+			}
+
+			_, err := h.buildTraceInput(&req, "proj-1", false, nil)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("buildTraceInput: %v", err)
+				}
+				return
+			}
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.wantCode {
+				t.Fatalf("buildTraceInput() error = %v, want %s", err, tt.wantCode)
+			}
+			if field := apiErr.Detail().Field; field != "spans" {
+				t.Errorf("error field = %q, want spans", field)
+			}
+		})
+	}
+}
+
+func TestCheckUserMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]any
+		wantErr  string // Substring of the user_metadata_too_large message; empty expects success
+	}{
+		{name: "none"},
+		{name: "within limits", metadata: map[string]any{"plan": "pro", "seats": 3}},
+		{
+			name:     "too many keys",
+			metadata: map[string]any{"a": 1, "b": 2, "c": 3, "d": 4},
+			wantErr:  "user.metadata must have at most 3 keys (got 4)",
+		},
+		{
+			name:     "too large",
+			metadata: map[string]any{"bio": strings.Repeat("x", 64)},
+			wantErr:  "user.metadata must be at most 32 bytes serialized (got 74)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUserMetadata(tt.metadata, 3, 32)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkUserMetadata: %v", err)
+				}
+				return
+			}
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != "user_metadata_too_large" {
+				t.Fatalf("checkUserMetadata() error = %v, want user_metadata_too_large", err)
+			}
+			if !strings.Contains(apiErr.Message, tt.wantErr) {
+				t.Errorf("message = %q, want it to contain %q", apiErr.Message, tt.wantErr)
+			}
+			if field := apiErr.Detail().Field; field != "user.metadata" {
+				t.Errorf("error field = %q, want user.metadata", field)
+			}
+		})
+	}
+}
+
+func TestCheckSpanGraphDepth(t *testing.T) {
+	// chain returns n spans, each the parent of the next, listed leaf first
+	chain := func(n int) []temporal.SpanInput {
+		spans := make([]temporal.SpanInput, n)
+		for i := range spans {
+			spans[i] = temporal.SpanInput{ID: fmt.Sprintf("s%d", n-1-i)}
+			if i < n-1 {
+				spans[i].ParentSpanID = fmt.Sprintf("s%d", n-2-i)
+			}
+		}
+		return spans
+	}
+	// A root with two branches of depth 3 and 4
+	branches := []temporal.SpanInput{
+		{ID: "root"},
+		{ID: "a1", ParentSpanID: "root"}, {ID: "a2", ParentSpanID: "a1"},
+		{ID: "b3", ParentSpanID: "b2"}, {ID: "b2", ParentSpanID: "b1"}, {ID: "b1", ParentSpanID: "root"},
+	}
+
+	tests := []struct {
+		name      string
+		spans     []temporal.SpanInput
+		maxDepth  int
+		wantDepth int // Depth reported by span_tree_too_deep; zero expects success
+	}{
+		{name: "single root", spans: chain(1), maxDepth: 1},
+		{name: "chain at the limit", spans: chain(100), maxDepth: 100},
+		{name: "chain over the limit", spans: chain(101), maxDepth: 100, wantDepth: 101},
+		{name: "branches at the limit", spans: branches, maxDepth: 4},
+		{name: "branches over the limit", spans: branches, maxDepth: 3, wantDepth: 4},
+		// A parent outside the trace makes the span a root of this trace
+		{name: "external parent", spans: []temporal.SpanInput{{ID: "a", ParentSpanID: "x"}, {ID: "b", ParentSpanID: "a"}}, maxDepth: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSpanGraph(tt.spans, tt.maxDepth)
+			if tt.wantDepth == 0 {
+				if err != nil {
+					t.Fatalf("checkSpanGraph: %v", err)
+				}
+				return
+			}
+			var apiErr *response.APIError
+			if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnprocessableEntity || apiErr.Code != "span_tree_too_deep" {
+				t.Fatalf("checkSpanGraph() error = %v, want 422 span_tree_too_deep", err)
+			}
+			if got := apiErr.Details["depth"]; got != tt.wantDepth {
+				t.Errorf("details[depth] = %v, want %d", got, tt.wantDepth)
+			}
+			if got := apiErr.Details["max"]; got != tt.maxDepth {
+				t.Errorf("details[max] = %v, want %d", got, tt.maxDepth)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// ValidateTraceResponse summarizes a dry-run validation
+type ValidateTraceResponse struct {
+	Valid    bool     `json:"valid"`
+	TraceID  string   `json:"trace_id"`
+	SpanIDs  []string `json:"span_ids"`
+	Warnings []string `json:"warnings"`
+
+	// WarningDetails are the warnings with their codes and paths, reported
+	// with ?warnings=true
+	WarningDetails []Warning `json:"warning_details,omitempty"`
+}
+
+// ValidateTrace handles POST /v1/traces/validate
+// It runs the same decoding and validation as IngestTrace without starting
+// a workflow. Invalid payloads get the same 400 errors as real ingestion.
+func (h *Handler) ValidateTrace(w http.ResponseWriter, r *http.Request) {
+	requested, err := warningsRequested(r)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	warn := warnings{}
+	input, err := h.decodeTrace(r, &warn)
+	if err != nil {
+		response.WriteError(w, err)
+		return
+	}
+
+	resp := ValidateTraceResponse{
+		Valid:    true,
+		TraceID:  input.ID,
+		SpanIDs:  spanIDs(input),
+		Warnings: warn.messages(),
+	}
+	if requested {
+		resp.WarningDetails = warn
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
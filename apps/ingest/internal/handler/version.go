@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/config"
+)
+
+type VersionResponse struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	BuildTime  string `json:"build_time"`
+	APIVersion string `json:"api_version"`
+}
+
+// Version handles GET /v1/version
+func (h *Handler) Version(w http.ResponseWriter, r *http.Request) {
+	resp := VersionResponse{
+		Version:    config.Version,
+		Commit:     config.Commit,
+		BuildTime:  config.BuildTime,
+		APIVersion: config.APIVersion,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// Options handles OPTIONS for read-only endpoints.
+// CORS preflight requests are answered earlier by the CORS middleware.
+func (h *Handler) Options(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+	w.WriteHeader(http.StatusNoContent)
+}
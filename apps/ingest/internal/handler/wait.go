@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// waitRequested reports whether the request asked for ?wait=true, which
+// holds the response until the trace workflow completes. Only interactive
+// users (JWT auth) may wait: a blocked SDK flush would stall the
+// instrumented application, and long waits tie up request slots.
+func waitRequested(r *http.Request) (bool, error) {
+	wait, err := middleware.ParseWait(r)
+	if err != nil {
+		return false, validationError("wait must be true or false")
+	}
+	if wait && middleware.GetAuthMethod(r.Context()) != middleware.AuthMethodJWT {
+		return false, response.NewError(http.StatusForbidden, "wait_not_allowed",
+			"wait=true is only available to users signed in with a JWT")
+	}
+	return wait, nil
+}
+
+// waitForTrace blocks until the trace workflow run finishes and returns
+// its result. nil is returned without an error when the request deadline
+// or client disconnect ends the wait first: the trace was still accepted.
+func (h *Handler) waitForTrace(ctx context.Context, result *temporal.StartResult) (*TraceResult, error) {
+	processed, err := h.temporalClient.WaitTraceWorkflow(ctx, result.WorkflowID, result.RunID)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			slog.Debug("trace wait abandoned", "workflow_id", result.WorkflowID, "error", err)
+			return nil, nil
+		}
+		slog.Error("trace workflow failed", "error", err, "workflow_id", result.WorkflowID)
+		return nil, response.NewError(http.StatusInternalServerError, "trace_processing_failed", "trace was accepted but processing failed")
+	}
+	return &TraceResult{
+		SpanCount:       processed.SpanCount,
+		CostsProvided:   processed.CostsProvided,
+		CostsCalculated: processed.CostsCalculated,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestWaitRequested(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		authMethod string
+		want       bool
+		wantStatus int // Zero when no error is expected
+	}{
+		{name: "not requested", query: "", authMethod: middleware.AuthMethodAPIKey, want: false},
+		{name: "jwt user", query: "?wait=true", authMethod: middleware.AuthMethodJWT, want: true},
+		{name: "jwt user opts out", query: "?wait=false", authMethod: middleware.AuthMethodJWT, want: false},
+		{name: "api key", query: "?wait=true", authMethod: middleware.AuthMethodAPIKey, wantStatus: http.StatusForbidden},
+		{name: "upstream gateway", query: "?wait=true", authMethod: middleware.AuthMethodUpstream, wantStatus: http.StatusForbidden},
+		{name: "invalid value", query: "?wait=soon", authMethod: middleware.AuthMethodJWT, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/traces"+tt.query, nil)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.AuthMethodContextKey, tt.authMethod))
+
+			got, err := waitRequested(r)
+			if tt.wantStatus != 0 {
+				var apiErr *response.APIError
+				if !errors.As(err, &apiErr) || apiErr.Status != tt.wantStatus {
+					t.Fatalf("error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("waitRequested() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
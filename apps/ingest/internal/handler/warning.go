@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Warning codes
+const (
+	WarningDuplicateTags       = "duplicate_tags"
+	WarningParametersScrubbed  = "model_parameters_scrubbed"
+	WarningDurationAnomaly     = "duration_anomaly"
+	WarningUnknownModel        = "unknown_model"
+	WarningUnknownProvider     = "unknown_provider"
+	WarningUsageEstimated      = "usage_estimated"
+	WarningMetadataKeysDropped = "metadata_keys_dropped"
+)
+
+// warnings accumulates Warnings during conversion. Adding to a nil
+// *warnings is a no-op, for callers that don't report them.
+type warnings []Warning
+
+func (ws *warnings) add(code, path, message string) {
+	if ws == nil {
+		return
+	}
+	*ws = append(*ws, Warning{Code: code, Message: message, Path: path})
+}
+
+// messages returns the warning messages alone
+func (ws warnings) messages() []string {
+	out := make([]string, len(ws))
+	for i, w := range ws {
+		out[i] = w.Message
+	}
+	return out
+}
+
+// warningsRequested reports whether the request asked for ?warnings=true
+func warningsRequested(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("warnings")
+	if raw == "" {
+		return false, nil
+	}
+	requested, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, validationError("warnings must be true or false")
+	}
+	return requested, nil
+}
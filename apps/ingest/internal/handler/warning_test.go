@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+func TestIngestTraceWarnings(t *testing.T) {
+	body := `{"name":"chat","tags":["a","b","a"],"spans":[{"name":"llm","provider":"together","usage":{"total_tokens":100}}]}`
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []Warning
+	}{
+		{name: "not requested", wantStatus: http.StatusAccepted},
+		{name: "opted out", query: "?warnings=false", wantStatus: http.StatusAccepted},
+		{
+			name:       "requested",
+			query:      "?warnings=true",
+			wantStatus: http.StatusAccepted,
+			want: []Warning{
+				{Code: WarningDuplicateTags, Path: "tags"},
+				{Code: WarningUnknownProvider, Path: "spans[0].provider"},
+				{Code: WarningUsageEstimated, Path: "spans[0].usage"},
+			},
+		},
+		{name: "invalid value", query: "?warnings=maybe", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTemporalTestHandler(t, &temporaltest.Client{}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces"+tt.query, strings.NewReader(body))
+			req.Header.Set("X-Project-ID", "proj-1")
+			rec := httptest.NewRecorder()
+			h.IngestTrace(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+			var resp IngestTraceResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+
+			// Messages are for people; compare codes and paths
+			var got []Warning
+			for _, w := range resp.Warnings {
+				if w.Message == "" {
+					t.Errorf("warning %s has no message", w.Code)
+				}
+				got = append(got, Warning{Code: w.Code, Path: w.Path})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("warnings = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+)
+
+// PayloadHash returns the SHA-256 of a request's query parameters and
+// canonicalized JSON body, so that formatting, key order and parameter
+// order don't make identical requests look different. Bodies that aren't
+// valid JSON are hashed as-is.
+func PayloadHash(query url.Values, body []byte) string {
+	canonical := body
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // Keep numbers exactly as sent
+	var v any
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		// encoding/json sorts map keys, giving a stable encoding
+		if b, err := json.Marshal(v); err == nil {
+			canonical = b
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(query.Encode())) // Encode sorts by key
+	h.Write([]byte{0})
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,50 @@
+package idempotency
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestPayloadHash(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string // Bodies
+		queryA    string
+		queryB    string
+		wantEqual bool
+	}{
+		{name: "identical", a: `{"name":"chat"}`, b: `{"name":"chat"}`, wantEqual: true},
+		{name: "key order", a: `{"name":"chat","tags":["a"]}`, b: `{"tags":["a"],"name":"chat"}`, wantEqual: true},
+		{name: "whitespace", a: `{"name":"chat"}`, b: "{\n  \"name\": \"chat\"\n}\n", wantEqual: true},
+		{name: "nested key order", a: `{"metadata":{"a":1,"b":2}}`, b: `{"metadata":{"b":2,"a":1}}`, wantEqual: true},
+		{name: "different value", a: `{"name":"chat"}`, b: `{"name":"chat2"}`},
+		// 2^53+1 and 2^53 are the same float64; numbers must compare as written
+		{name: "large integers", a: `{"n":9007199254740993}`, b: `{"n":9007199254740992}`},
+		{name: "number spelling", a: `{"n":1}`, b: `{"n":1.0}`},
+		{name: "invalid json", a: `{"name":`, b: `{"name":`, wantEqual: true},
+		{name: "invalid json whitespace", a: `{"name":`, b: `{ "name":`},
+		{name: "trailing value", a: `{"a":1}`, b: `{"a":1} {"b":2}`},
+		{name: "query order", a: `{}`, b: `{}`, queryA: "wait=true&warnings=true", queryB: "warnings=true&wait=true", wantEqual: true},
+		{name: "query added", a: `{}`, b: `{}`, queryB: "wait=true"},
+		{name: "query value", a: `{}`, b: `{}`, queryA: "backfill=true", queryB: "backfill=false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryA, err := url.ParseQuery(tt.queryA)
+			if err != nil {
+				t.Fatal(err)
+			}
+			queryB, err := url.ParseQuery(tt.queryB)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := PayloadHash(queryA, []byte(tt.a))
+			b := PayloadHash(queryB, []byte(tt.b))
+			if (a == b) != tt.wantEqual {
+				t.Errorf("hashes equal = %v, want %v", a == b, tt.wantEqual)
+			}
+		})
+	}
+}
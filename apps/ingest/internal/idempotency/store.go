@@ -0,0 +1,123 @@
+package idempotency
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is the state recorded for an idempotency key
+type Entry struct {
+	PayloadHash string // SHA-256 of the query and canonicalized request body
+	Pending     bool   // The first request is still being processed
+
+	// Cached response, set once the first request completes
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type storedEntry struct {
+	Entry
+	key       string
+	expiresAt time.Time
+	elem      *list.Element
+}
+
+// Store is a bounded in-memory idempotency store.
+// Entries expire after the TTL; when full, the oldest completed entry is
+// evicted. Pending entries are never evicted, so a concurrent retry always
+// sees its key in progress.
+type Store struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*storedEntry
+	order      *list.List       // Keys, oldest first
+	now        func() time.Time // Replaced in tests
+}
+
+// NewStore creates a Store holding at most maxEntries keys for ttl each
+func NewStore(ttl time.Duration, maxEntries int) *Store {
+	return &Store{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*storedEntry),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Begin reserves key for a request with payloadHash.
+// If the key is already known, its entry is returned and nothing is reserved.
+func (s *Store) Begin(key, payloadHash string) (existing Entry, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok {
+		if now.Before(e.expiresAt) {
+			return e.Entry, true
+		}
+		s.remove(e)
+	}
+
+	s.evict(now)
+
+	e := &storedEntry{
+		Entry:     Entry{PayloadHash: payloadHash, Pending: true},
+		key:       key,
+		expiresAt: now.Add(s.ttl),
+	}
+	e.elem = s.order.PushBack(e)
+	s.entries[key] = e
+	return Entry{}, false
+}
+
+// Complete stores the response for a key reserved by Begin
+func (s *Store) Complete(key string, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.Pending {
+		e.Pending = false
+		e.Status = status
+		e.Header = header
+		e.Body = body
+	}
+}
+
+// Release drops a pending reservation so the request can be retried
+func (s *Store) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.Pending {
+		s.remove(e)
+	}
+}
+
+// evict makes room for one entry, dropping expired entries first.
+// Pending entries are skipped; they are bounded by the requests in flight,
+// so the store can briefly hold more than maxEntries keys.
+// Must be called with s.mu held.
+func (s *Store) evict(now time.Time) {
+	next := s.order.Front()
+	for elem := next; elem != nil; elem = next {
+		next = elem.Next()
+		e := elem.Value.(*storedEntry)
+		if now.Before(e.expiresAt) && len(s.entries) < s.maxEntries {
+			return
+		}
+		if !e.Pending {
+			s.remove(e)
+		}
+	}
+}
+
+// remove deletes an entry. Must be called with s.mu held.
+func (s *Store) remove(e *storedEntry) {
+	s.order.Remove(e.elem)
+	delete(s.entries, e.key)
+}
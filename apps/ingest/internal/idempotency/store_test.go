@@ -0,0 +1,166 @@
+package idempotency
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	const ttl = time.Minute
+
+	// Each op reserves or settles a key; begin checks what Begin reports
+	type op struct {
+		action    string        // "begin", "complete", "release" or "wait"
+		key       string        // Key for begin, complete and release
+		wait      time.Duration // Clock advance for "wait"
+		wantFound bool          // begin: the key was already known
+		wantState string        // begin with wantFound: "pending" or "completed"
+	}
+
+	tests := []struct {
+		name       string
+		maxEntries int
+		ops        []op
+	}{
+		{
+			name:       "new key",
+			maxEntries: 10,
+			ops:        []op{{action: "begin", key: "a"}},
+		},
+		{
+			name:       "in progress",
+			maxEntries: 10,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "begin", key: "a", wantFound: true, wantState: "pending"},
+			},
+		},
+		{
+			name:       "completed",
+			maxEntries: 10,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "complete", key: "a"},
+				{action: "begin", key: "a", wantFound: true, wantState: "completed"},
+			},
+		},
+		{
+			name:       "released",
+			maxEntries: 10,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "release", key: "a"},
+				{action: "begin", key: "a"},
+			},
+		},
+		{
+			name:       "release after complete keeps the response",
+			maxEntries: 10,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "complete", key: "a"},
+				{action: "release", key: "a"},
+				{action: "begin", key: "a", wantFound: true, wantState: "completed"},
+			},
+		},
+		{
+			name:       "within ttl",
+			maxEntries: 10,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "complete", key: "a"},
+				{action: "wait", wait: ttl - time.Second},
+				{action: "begin", key: "a", wantFound: true, wantState: "completed"},
+			},
+		},
+		{
+			name:       "expired",
+			maxEntries: 10,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "complete", key: "a"},
+				{action: "wait", wait: ttl},
+				{action: "begin", key: "a"},
+			},
+		},
+		{
+			name:       "full evicts the oldest",
+			maxEntries: 2,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "complete", key: "a"},
+				{action: "begin", key: "b"},
+				{action: "complete", key: "b"},
+				{action: "begin", key: "c"},
+				{action: "begin", key: "b", wantFound: true, wantState: "completed"},
+				{action: "begin", key: "a"},
+			},
+		},
+		{
+			name:       "full skips pending",
+			maxEntries: 2,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "begin", key: "b"},
+				{action: "complete", key: "b"},
+				{action: "begin", key: "c"},
+				// a is older than b but still running, so b was evicted
+				{action: "begin", key: "a", wantFound: true, wantState: "pending"},
+				{action: "begin", key: "b"},
+			},
+		},
+		{
+			name:       "full of pending",
+			maxEntries: 2,
+			ops: []op{
+				{action: "begin", key: "a"},
+				{action: "begin", key: "b"},
+				{action: "begin", key: "c"},
+				{action: "begin", key: "a", wantFound: true, wantState: "pending"},
+				{action: "begin", key: "b", wantFound: true, wantState: "pending"},
+				{action: "begin", key: "c", wantFound: true, wantState: "pending"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			s := NewStore(ttl, tt.maxEntries)
+			s.now = func() time.Time { return now }
+
+			for i, op := range tt.ops {
+				switch op.action {
+				case "begin":
+					existing, found := s.Begin(op.key, "hash-"+op.key)
+					if found != op.wantFound {
+						t.Fatalf("op %d: Begin(%q) found = %v, want %v", i, op.key, found, op.wantFound)
+					}
+					if !found {
+						continue
+					}
+					if existing.PayloadHash != "hash-"+op.key {
+						t.Errorf("op %d: PayloadHash = %q, want hash-%s", i, existing.PayloadHash, op.key)
+					}
+					state := "completed"
+					if existing.Pending {
+						state = "pending"
+					}
+					if state != op.wantState {
+						t.Errorf("op %d: Begin(%q) state = %s, want %s", i, op.key, state, op.wantState)
+					}
+					if state == "completed" && (existing.Status != http.StatusAccepted || string(existing.Body) != op.key) {
+						t.Errorf("op %d: stored response = %d %q, want 202 %q", i, existing.Status, existing.Body, op.key)
+					}
+				case "complete":
+					s.Complete(op.key, http.StatusAccepted, http.Header{}, []byte(op.key))
+				case "release":
+					s.Release(op.key)
+				case "wait":
+					now = now.Add(op.wait)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// ID strategies
+const (
+	// StrategyRandomHex generates hex-encoded random bytes
+	StrategyRandomHex = "random-hex"
+
+	// StrategyUUIDv7 generates time-ordered RFC 9562 UUIDv7s, which keep
+	// downstream index inserts localized
+	StrategyUUIDv7 = "uuidv7"
+)
+
+// Bounds for the random-hex byte length
+const (
+	MinRandomBytes = 16
+	MaxRandomBytes = 64
+)
+
+// Generator creates trace, span and score IDs
+type Generator struct {
+	strategy string
+	length   int // Random bytes for StrategyRandomHex
+}
+
+// New creates a Generator. strategy and length are expected to be validated
+// by config; an unknown strategy falls back to StrategyRandomHex.
+func New(strategy string, length int) *Generator {
+	return &Generator{strategy: strategy, length: length}
+}
+
+// New returns a new ID
+func (g *Generator) New() string {
+	if g.strategy == StrategyUUIDv7 {
+		return uuidV7(time.Now())
+	}
+	b := make([]byte, g.length)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// uuidV7 builds a UUIDv7: a 48-bit Unix millisecond timestamp followed by
+// random bits, with the version and variant fields set
+func uuidV7(now time.Time) string {
+	var u [16]byte
+	_, _ = rand.Read(u[6:])
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(now.UnixMilli()))
+	copy(u[:6], ts[2:])
+
+	u[6] = (u[6] & 0x0f) | 0x70 // Version 7
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 9562 variant
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,59 @@
+package idgen
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestGeneratorNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		length   int
+		pattern  *regexp.Regexp
+	}{
+		{name: "random hex", strategy: StrategyRandomHex, length: 16, pattern: regexp.MustCompile(`^[0-9a-f]{32}$`)},
+		{name: "random hex max length", strategy: StrategyRandomHex, length: MaxRandomBytes, pattern: regexp.MustCompile(`^[0-9a-f]{128}$`)},
+		{name: "uuidv7", strategy: StrategyUUIDv7, pattern: uuidV7Pattern},
+		{name: "unknown falls back to random hex", strategy: "ulid", length: 16, pattern: regexp.MustCompile(`^[0-9a-f]{32}$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(tt.strategy, tt.length)
+			seen := make(map[string]bool)
+			for i := 0; i < 100; i++ {
+				id := g.New()
+				if !tt.pattern.MatchString(id) {
+					t.Fatalf("New() = %q, want match for %s", id, tt.pattern)
+				}
+				if seen[id] {
+					t.Fatalf("New() repeated %q", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestUUIDv7Ordering(t *testing.T) {
+	start := time.UnixMilli(1714566600000)
+
+	// IDs from later milliseconds sort after earlier ones
+	prev := uuidV7(start)
+	for i := 1; i <= 10; i++ {
+		id := uuidV7(start.Add(time.Duration(i) * time.Millisecond))
+		if id <= prev {
+			t.Fatalf("uuidV7 at +%dms = %q, not after %q", i, id, prev)
+		}
+		prev = id
+	}
+
+	// The first 48 bits hold the Unix millisecond timestamp
+	if got := uuidV7(start)[:13]; got != "018f3422-1540" {
+		t.Errorf("timestamp prefix = %q, want 018f3422-1540", got)
+	}
+}
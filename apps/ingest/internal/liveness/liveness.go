@@ -0,0 +1,72 @@
+package liveness
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat detects a wedged process, as opposed to a down dependency.
+// A goroutine records a heartbeat every interval and answers pings; when
+// it stops doing either (e.g. the runtime is stalled or a deadlock starves
+// it), Check reports the process as not alive so it can be restarted.
+type Heartbeat struct {
+	interval time.Duration
+	last     atomic.Int64 // Unix nanoseconds of the latest beat
+	ping     chan chan struct{}
+}
+
+// New creates a Heartbeat beating every interval. Call Run to start it.
+func New(interval time.Duration) *Heartbeat {
+	h := &Heartbeat{
+		interval: interval,
+		ping:     make(chan chan struct{}),
+	}
+	h.beat()
+	return h
+}
+
+// Run beats and answers pings until ctx is cancelled
+func (h *Heartbeat) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.beat()
+		case reply := <-h.ping:
+			h.beat()
+			close(reply)
+		}
+	}
+}
+
+// Check round-trips a ping through the heartbeat goroutine, waiting at most
+// staleAfter, and returns the age of the latest beat. The process is alive
+// when the ping was answered and the beat is no older than staleAfter.
+func (h *Heartbeat) Check(ctx context.Context, staleAfter time.Duration) (age time.Duration, alive bool) {
+	ctx, cancel := context.WithTimeout(ctx, staleAfter)
+	defer cancel()
+
+	reply := make(chan struct{})
+	answered := false
+	select {
+	case h.ping <- reply:
+		select {
+		case <-reply:
+			answered = true
+		case <-ctx.Done():
+		}
+	case <-ctx.Done():
+	}
+
+	age = time.Since(time.Unix(0, h.last.Load()))
+	return age, answered && age <= staleAfter
+}
+
+func (h *Heartbeat) beat() {
+	h.last.Store(time.Now().UnixNano())
+}
@@ -0,0 +1,42 @@
+package liveness
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := New(10 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+
+	if age, alive := h.Check(context.Background(), time.Second); !alive {
+		t.Fatalf("Check() alive = false (age %v), want true while running", age)
+	}
+
+	// A stopped heartbeat goroutine no longer answers pings
+	cancel()
+	<-done
+	if age, alive := h.Check(context.Background(), 20*time.Millisecond); alive {
+		t.Errorf("Check() alive = true (age %v), want false after Run returned", age)
+	}
+}
+
+func TestHeartbeatCheckStaleBeat(t *testing.T) {
+	h := New(time.Hour)
+	h.last.Store(time.Now().Add(-time.Minute).UnixNano())
+
+	// Nothing answers pings, so Check waits out staleAfter and reports the old beat
+	age, alive := h.Check(context.Background(), 10*time.Millisecond)
+	if alive {
+		t.Error("Check() alive = true, want false for an unanswered ping")
+	}
+	if age < time.Minute {
+		t.Errorf("Check() age = %v, want at least 1m", age)
+	}
+}
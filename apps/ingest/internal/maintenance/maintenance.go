@@ -0,0 +1,67 @@
+package maintenance
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// Mode tracks whether the service is in maintenance, when ingest is
+// rejected with a retryable 503 (e.g. during Temporal upgrades).
+//
+// The environment of a running process can't change, so the mode is
+// reloaded from a flag file: on Reload, maintenance is on while the file
+// exists.
+type Mode struct {
+	enabled atomic.Bool
+	file    string
+}
+
+// New creates a Mode starting in the initial state.
+// file is optional; without it Reload keeps the current state.
+func New(initial bool, file string) *Mode {
+	m := &Mode{file: file}
+	m.enabled.Store(initial)
+	if initial {
+		slog.Warn("maintenance mode enabled at startup")
+	}
+	return m
+}
+
+// Enabled reports whether maintenance mode is on. A nil Mode is never enabled.
+func (m *Mode) Enabled() bool {
+	return m != nil && m.enabled.Load()
+}
+
+// Set switches maintenance mode, logging transitions
+func (m *Mode) Set(enabled bool) {
+	if m.enabled.Swap(enabled) == enabled {
+		return
+	}
+	if enabled {
+		slog.Warn("maintenance mode enabled, rejecting ingest")
+	} else {
+		slog.Info("maintenance mode disabled, accepting ingest")
+	}
+}
+
+// Reload re-reads the flag file, e.g. on SIGHUP
+func (m *Mode) Reload() {
+	if m.file == "" {
+		slog.Warn("maintenance mode reload requested but MAINTENANCE_FILE is not set")
+		return
+	}
+
+	_, err := os.Stat(m.file)
+	switch {
+	case err == nil:
+		m.Set(true)
+	case errors.Is(err, fs.ErrNotExist):
+		m.Set(false)
+	default:
+		// Keep the current state rather than guessing
+		slog.Error("failed to read maintenance file", "error", err, "file", m.file)
+	}
+}
@@ -0,0 +1,52 @@
+package maintenance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name     string
+		initial  bool
+		noFile   bool // MAINTENANCE_FILE not configured
+		flagFile bool // The flag file exists
+		want     bool
+	}{
+		{name: "file appears", flagFile: true, want: true},
+		{name: "file removed", initial: true},
+		{name: "file kept", initial: true, flagFile: true, want: true},
+		{name: "still absent"},
+		// Without a file to read, the startup state stands
+		{name: "no file configured on", initial: true, noFile: true, want: true},
+		{name: "no file configured off", noFile: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "maintenance")
+			if tt.flagFile {
+				if err := os.WriteFile(file, nil, 0o600); err != nil {
+					t.Fatalf("write flag file: %v", err)
+				}
+			}
+			if tt.noFile {
+				file = ""
+			}
+
+			m := New(tt.initial, file)
+			m.Reload()
+			if got := m.Enabled(); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilModeDisabled(t *testing.T) {
+	var m *Mode
+	if m.Enabled() {
+		t.Error("nil Mode is enabled")
+	}
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cognobserve_ingest"
+
+// Request handling metrics
+var (
+	InFlightRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "in_flight_requests",
+		Help:      "Number of API requests currently being processed.",
+	})
+
+	ConcurrencyRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "concurrency_rejections_total",
+		Help:      "Requests rejected because the concurrency limit was reached.",
+	})
+)
+
+// Authentication metrics
+var (
+	AuthDenials = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_denials_total",
+		Help:      "Requests denied by authentication or authorization, by reason.",
+	}, []string{"reason"})
+
+	AuditEventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_events_dropped_total",
+		Help:      "Auth audit events lost because the buffer was full, the sink failed or the shutdown flush timed out, by reason.",
+	}, []string{"reason"})
+)
+
+// Ingestion metrics
+var (
+	SpanDurationAnomalies = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "span_duration_anomalies_total",
+		Help:      "Ingested spans flagged with an implausible duration, by reason.",
+	}, []string{"reason"})
+
+	WorkflowStartFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "workflow_start_failures_total",
+		Help:      "Trace workflow starts that failed, by reason (client_canceled, deadline_exceeded, server_error).",
+	}, []string{"reason"})
+
+	WorkflowStartDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "workflow_start_duration_seconds",
+		Help:      "Time spent in the Temporal StartWorkflow call for HTTP trace ingest, including failed starts.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14), // 1ms to ~8s
+	})
+
+	DedupHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trace_dedup_hits_total",
+		Help:      "Traces dropped because identical content arrived within the dedup window.",
+	})
+
+	OutputSchemaChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "output_schema_checks_total",
+		Help:      "Span outputs checked against a registered output schema, by result (valid, invalid).",
+	}, []string{"result"})
+
+	UnknownModels = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unknown_models_total",
+		Help:      "Ingested spans referencing a model outside ALLOWED_MODELS.",
+	})
+
+	UnknownProviders = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unknown_providers_total",
+		Help:      "Ingested spans whose provider is not a known provider.",
+	})
+
+	FilteredMetadataKeys = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "filtered_metadata_keys_total",
+		Help:      "Trace and span metadata keys dropped by a project's metadata policy.",
+	})
+
+	ScrubbedModelParameters = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scrubbed_model_parameters_total",
+		Help:      "Span model_parameters values redacted for matching SENSITIVE_KEY_PATTERN.",
+	})
+
+	SpanPayloadBytes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "span_payload_bytes",
+		Help:      "JSON size of ingested span input and output payloads, by field (input, output).",
+		Buckets:   prometheus.ExponentialBuckets(64, 4, 10), // 64B to 16MiB
+	}, []string{"field"})
+
+	IngestedPayloadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingested_payload_bytes_total",
+		Help:      "Total JSON size of span input and output payloads ingested.",
+	})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Trace ingest webhook deliveries, by result (success, failure after retries, dropped on a full queue or shutdown).",
+	}, []string{"result"})
+)
+
+// Handler returns the HTTP handler exposing metrics in Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
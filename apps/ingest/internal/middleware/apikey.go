@@ -10,106 +10,202 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/cognobserve/ingest/internal/audit"
 	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/webapi"
 )
 
 const (
 	// APIKeyHeader is the header used for API key authentication
 	APIKeyHeader = "X-API-Key"
 
-	// APIKeyPrefix is the expected prefix for API keys
+	// APIKeyPrefix is the default API key prefix; keys are checked against API_KEY_PREFIX
 	APIKeyPrefix = "co_sk_"
 
 	// ProjectIDHeader is the header set after successful API key validation
 	ProjectIDHeader = "X-Project-ID"
 
 	// InternalSecretHeader is the header used for internal API authentication
-	InternalSecretHeader = "X-Internal-Secret"
+	InternalSecretHeader = webapi.InternalSecretHeader
 
 	// MinResponseTime is the minimum response time to prevent timing attacks
 	MinResponseTime = 50 * time.Millisecond
 )
 
-// APIKeyContextKey is the context key for API key authentication status
-const APIKeyContextKey contextKey = "api_key_auth"
-
 // APIKeyProjectIDKey is the context key for the validated project ID from API key auth
 const APIKeyProjectIDKey contextKey = "api_key_project_id"
 
+// APIKeyScopesKey is the context key for the scopes granted to the API key
+const APIKeyScopesKey contextKey = "api_key_scopes"
+
+// ProjectFeaturesKey is the context key for the key's project feature flags
+const ProjectFeaturesKey contextKey = "project_features"
+
+// ProjectWebhookKey is the context key for the key's project ingest webhook
+const ProjectWebhookKey contextKey = "project_webhook"
+
+// ProjectRequiredMetadataKey is the context key for the trace metadata keys the key's project requires
+const ProjectRequiredMetadataKey contextKey = "project_required_metadata"
+
+// ProjectTaskQueueKey is the context key for the key's project trace task queue
+const ProjectTaskQueueKey contextKey = "project_task_queue"
+
+// ProjectRetentionKey is the context key for the key's project trace retention policy
+const ProjectRetentionKey contextKey = "project_retention"
+
+// ProjectMetadataPolicyKey is the context key for the key's project metadata key policy
+const ProjectMetadataPolicyKey contextKey = "project_metadata_policy"
+
+// ProjectDailyBudgetKey is the context key for the key's project daily cost budget
+const ProjectDailyBudgetKey contextKey = "project_daily_budget"
+
+// taskQueuePattern is the accepted format for per-project task queue names
+var taskQueuePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._\-]{0,199}$`)
+
 type validateKeyRequest struct {
 	HashedKey string `json:"hashedKey"`
 }
 
+// ProjectWebhook is a project's ingest webhook endpoint and signing secret
+type ProjectWebhook struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// ProjectRetention is a project's trace retention policy, in days
+type ProjectRetention struct {
+	DefaultDays int `json:"defaultDays"`       // Applied to traces that don't set retention_days
+	MaxDays     int `json:"maxDays,omitempty"` // Longest retention a trace may request; 0 means no limit
+}
+
+// Metadata policy modes
+const (
+	MetadataPolicyAllow = "allow" // Keep only the listed keys
+	MetadataPolicyBlock = "block" // Drop the listed keys
+)
+
+// ProjectMetadataPolicy restricts the trace and span metadata keys a
+// project stores, for data governance
+type ProjectMetadataPolicy struct {
+	Mode string   `json:"mode"` // MetadataPolicyAllow or MetadataPolicyBlock
+	Keys []string `json:"keys"` // Top-level metadata keys
+}
+
 type validateKeyResponse struct {
-	Valid     bool   `json:"valid"`
-	ProjectID string `json:"projectId,omitempty"`
-	Error     string `json:"error,omitempty"`
+	Valid            bool                   `json:"valid"`
+	ProjectID        string                 `json:"projectId,omitempty"`
+	Scopes           []string               `json:"scopes,omitempty"`           // Omitted for keys predating scopes, which hold every scope
+	Features         map[string]bool        `json:"features,omitempty"`         // Project feature flags; absent features are enabled
+	Webhook          *ProjectWebhook        `json:"webhook,omitempty"`          // Where to notify the project of ingested traces
+	TaskQueue        string                 `json:"taskQueue,omitempty"`        // Dedicated trace task queue; empty uses TEMPORAL_TASK_QUEUE
+	RequiredMetadata []string               `json:"requiredMetadata,omitempty"` // Trace metadata keys every trace must carry
+	DailyBudgetUSD   *float64               `json:"dailyBudgetUsd,omitempty"`   // Daily cost budget; absent uses COST_BUDGET_DAILY_USD
+	Retention        *ProjectRetention      `json:"retention,omitempty"`        // Trace retention policy
+	MetadataPolicy   *ProjectMetadataPolicy `json:"metadataPolicy,omitempty"`   // Metadata keys to keep or drop
+	Error            string                 `json:"error,omitempty"`
 }
 
-// APIKeyAuth validates X-API-Key header by calling internal web API
-func APIKeyAuth(cfg *config.Config) func(http.Handler) http.Handler {
+// APIKeyAuth validates X-API-Key header by calling internal web API.
+// Each decision is recorded to auditLog.
+func APIKeyAuth(cfg *config.Config, auditLog *audit.Logger) func(http.Handler) http.Handler {
+	client := webapi.NewHTTPClient(cfg)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			startTime := time.Now()
 
 			apiKey := r.Header.Get(APIKeyHeader)
 
-			// If no API key, fall through to JWT auth
-			if apiKey == "" {
+			// If no API key, fall through to JWT auth; a trusted gateway
+			// already authenticated the caller
+			if apiKey == "" || GetAuthMethod(r.Context()) == AuthMethodUpstream {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Validate format using constant-time comparison for prefix
-			if !hasPrefixConstantTime(apiKey, APIKeyPrefix) {
-				delayAndRespond(w, startTime, http.StatusUnauthorized, "Invalid API key format")
-				return
+			key, apiErr := authenticateAPIKey(r.Context(), cfg, client, apiKey)
+			event := audit.Event{
+				Method:    AuthMethodAPIKey,
+				ProjectID: key.ProjectID,
+				KeyPrefix: hashedKeyPrefix(apiKey),
 			}
-
-			// Minimum length validation
-			if len(apiKey) < len(APIKeyPrefix)+32 {
-				delayAndRespond(w, startTime, http.StatusUnauthorized, "Invalid API key")
+			if apiErr != nil {
+				event.Outcome = audit.OutcomeFailure
+				event.ProjectID = r.Header.Get(ProjectIDHeader)
+				event.Reason = apiErr.Code
+				auditHTTP(auditLog, r, event)
+				delayAndRespond(w, startTime, apiErr.Status, apiErr.Code, apiErr.Message)
 				return
 			}
 
-			// Hash the key using SHA-256
-			hash := sha256.Sum256([]byte(apiKey))
-			hashedKey := hex.EncodeToString(hash[:])
-
-			// Validate via internal API
-			projectID, err := validateKeyViaAPI(r.Context(), cfg, hashedKey)
-			if err != nil {
-				// Log only the hash prefix, never the raw key
-				slog.Warn("API key validation failed",
-					"error", err.Error(),
-					"hashedKeyPrefix", hashedKey[:16],
-				)
-				delayAndRespond(w, startTime, http.StatusUnauthorized, "Invalid or expired API key")
-				return
-			}
+			event.Outcome = audit.OutcomeSuccess
+			auditHTTP(auditLog, r, event)
 
 			// Set project ID header for downstream handlers
-			r.Header.Set(ProjectIDHeader, projectID)
+			r.Header.Set(ProjectIDHeader, key.ProjectID)
 
 			// Mark that API key auth was used and store the validated project ID
 			// The project ID in context is authoritative - prevents header tampering
-			ctx := context.WithValue(r.Context(), APIKeyContextKey, true)
-			ctx = context.WithValue(ctx, APIKeyProjectIDKey, projectID)
-
-			// Log only the hash prefix for debugging, never the raw key
-			slog.Info("API key validated",
-				"projectId", projectID,
-				"hashedKeyPrefix", hashedKey[:16],
-			)
+			ctx := withAPIKey(r.Context(), key)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// authenticateAPIKey validates the key format and resolves the key's project, scopes
+// and project features via the web API. On failure the returned key is empty, not nil.
+// Shared by the HTTP middleware and the gRPC interceptor; callers apply MinResponseTime on failure.
+func authenticateAPIKey(ctx context.Context, cfg *config.Config, client *http.Client, apiKey string) (*validateKeyResponse, *response.APIError) {
+	// Validate format using constant-time comparison for prefix
+	if !hasPrefixConstantTime(apiKey, cfg.APIKeyPrefix) {
+		return &validateKeyResponse{}, response.NewError(http.StatusUnauthorized, "invalid_api_key", "Invalid API key format")
+	}
+
+	// Shorter keys can't have been generated with the configured byte length
+	if len(apiKey) < cfg.APIKeyMinLength() {
+		return &validateKeyResponse{}, response.NewError(http.StatusUnauthorized, "invalid_api_key", "Invalid API key")
+	}
+
+	// Hash the key using SHA-256
+	hash := sha256.Sum256([]byte(apiKey))
+	hashedKey := hex.EncodeToString(hash[:])
+
+	// Validate via internal API
+	result, err := validateKeyViaAPI(ctx, cfg, client, hashedKey)
+	if err != nil {
+		// Log only the hash prefix, never the raw key
+		slog.Warn("API key validation failed",
+			"error", err.Error(),
+			"hashedKeyPrefix", hashedKey[:16],
+		)
+		return &validateKeyResponse{}, response.NewError(http.StatusUnauthorized, "invalid_api_key", "Invalid or expired API key")
+	}
+
+	// A malformed queue would strand the project's traces; fall back to the default
+	if result.TaskQueue != "" && !taskQueuePattern.MatchString(result.TaskQueue) {
+		slog.Warn("ignoring invalid project task queue", "projectId", result.ProjectID, "taskQueue", result.TaskQueue)
+		result.TaskQueue = ""
+	}
+	if result.DailyBudgetUSD != nil && *result.DailyBudgetUSD < 0 {
+		slog.Warn("ignoring invalid project daily budget", "projectId", result.ProjectID, "dailyBudgetUsd", *result.DailyBudgetUSD)
+		result.DailyBudgetUSD = nil
+	}
+
+	// Log only the hash prefix for debugging, never the raw key
+	slog.Info("API key validated",
+		"projectId", result.ProjectID,
+		"hashedKeyPrefix", hashedKey[:16],
+	)
+
+	return result, nil
+}
+
 // hasPrefixConstantTime checks prefix using constant-time comparison
 func hasPrefixConstantTime(s, prefix string) bool {
 	if len(s) < len(prefix) {
@@ -119,66 +215,76 @@ func hasPrefixConstantTime(s, prefix string) bool {
 }
 
 // delayAndRespond ensures minimum response time to prevent timing attacks
-func delayAndRespond(w http.ResponseWriter, startTime time.Time, status int, message string) {
+func delayAndRespond(w http.ResponseWriter, startTime time.Time, status int, code, message string) {
+	waitMinResponseTime(startTime)
+	response.Error(w, status, code, message)
+}
+
+// waitMinResponseTime sleeps until MinResponseTime has elapsed since startTime
+func waitMinResponseTime(startTime time.Time) {
 	elapsed := time.Since(startTime)
 	if elapsed < MinResponseTime {
 		time.Sleep(MinResponseTime - elapsed)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
-		slog.Error("failed to encode error response", "error", err)
-	}
 }
 
 // validateKeyViaAPI calls the internal validation endpoint
-func validateKeyViaAPI(ctx context.Context, cfg *config.Config, hashedKey string) (string, error) {
-	url := strings.TrimSuffix(cfg.WebAPIURL, "/") + "/api/internal/validate-key"
+func validateKeyViaAPI(ctx context.Context, cfg *config.Config, client *http.Client, hashedKey string) (*validateKeyResponse, error) {
+	url := strings.TrimSuffix(cfg.WebAPIURL, "/") + cfg.InternalValidateKeyPath
 
 	reqBody := validateKeyRequest{HashedKey: hashedKey}
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set(InternalSecretHeader, cfg.InternalAPISecret)
+	req.Header.Set(InternalSecretHeader, cfg.InternalAPISecret())
 
-	// Use a client with timeout
-	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("validation request failed: %w", err)
+		return nil, fmt.Errorf("validation request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	var result validateKeyResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if !result.Valid {
 		if result.Error != "" {
-			return "", fmt.Errorf(result.Error)
+			return nil, fmt.Errorf(result.Error)
 		}
-		return "", fmt.Errorf("invalid API key")
+		return nil, fmt.Errorf("invalid API key")
 	}
 
-	return result.ProjectID, nil
+	return &result, nil
+}
+
+// withAPIKey marks ctx as API-key-authenticated for the key's project,
+// with its scopes and project features
+func withAPIKey(ctx context.Context, key *validateKeyResponse) context.Context {
+	ctx = context.WithValue(ctx, AuthMethodContextKey, AuthMethodAPIKey)
+	ctx = context.WithValue(ctx, APIKeyScopesKey, key.Scopes)
+	ctx = context.WithValue(ctx, ProjectFeaturesKey, key.Features)
+	ctx = context.WithValue(ctx, ProjectWebhookKey, key.Webhook)
+	ctx = context.WithValue(ctx, ProjectTaskQueueKey, key.TaskQueue)
+	ctx = context.WithValue(ctx, ProjectRequiredMetadataKey, key.RequiredMetadata)
+	ctx = context.WithValue(ctx, ProjectDailyBudgetKey, key.DailyBudgetUSD)
+	ctx = context.WithValue(ctx, ProjectRetentionKey, key.Retention)
+	ctx = context.WithValue(ctx, ProjectMetadataPolicyKey, key.MetadataPolicy)
+	return context.WithValue(ctx, APIKeyProjectIDKey, key.ProjectID)
 }
 
 // IsAPIKeyAuthenticated checks if the request was authenticated via API key
 func IsAPIKeyAuthenticated(ctx context.Context) bool {
-	if authenticated, ok := ctx.Value(APIKeyContextKey).(bool); ok {
-		return authenticated
-	}
-	return false
+	return GetAuthMethod(ctx) == AuthMethodAPIKey
 }
 
 // GetAPIKeyProjectID returns the validated project ID from API key authentication
@@ -189,3 +295,48 @@ func GetAPIKeyProjectID(ctx context.Context) string {
 	}
 	return ""
 }
+
+// GetProjectWebhook returns the project's ingest webhook from API key
+// authentication, or nil if the project has none
+func GetProjectWebhook(ctx context.Context) *ProjectWebhook {
+	webhook, _ := ctx.Value(ProjectWebhookKey).(*ProjectWebhook)
+	return webhook
+}
+
+// GetProjectTaskQueue returns the project's trace task queue from API key
+// authentication, or "" to use the default queue
+func GetProjectTaskQueue(ctx context.Context) string {
+	taskQueue, _ := ctx.Value(ProjectTaskQueueKey).(string)
+	return taskQueue
+}
+
+// GetProjectRetention returns the project's trace retention policy from
+// API key authentication, or nil when it has none
+func GetProjectRetention(ctx context.Context) *ProjectRetention {
+	retention, _ := ctx.Value(ProjectRetentionKey).(*ProjectRetention)
+	return retention
+}
+
+// GetProjectMetadataPolicy returns the project's metadata key policy from
+// API key authentication, or nil when metadata is not filtered
+func GetProjectMetadataPolicy(ctx context.Context) *ProjectMetadataPolicy {
+	policy, _ := ctx.Value(ProjectMetadataPolicyKey).(*ProjectMetadataPolicy)
+	return policy
+}
+
+// GetProjectDailyBudget returns the project's daily cost budget in USD from
+// API key authentication; ok is false when the project has none of its own
+func GetProjectDailyBudget(ctx context.Context) (budgetUSD float64, ok bool) {
+	budget, _ := ctx.Value(ProjectDailyBudgetKey).(*float64)
+	if budget == nil {
+		return 0, false
+	}
+	return *budget, true
+}
+
+// GetProjectRequiredMetadata returns the trace metadata keys the project
+// requires, from API key authentication
+func GetProjectRequiredMetadata(ctx context.Context) []string {
+	keys, _ := ctx.Value(ProjectRequiredMetadataKey).([]string)
+	return keys
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/config"
+)
+
+func TestAuthenticateAPIKeyFormat(t *testing.T) {
+	// Keys of the minimum length reach the web API, which accepts them
+	var lookups int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		_ = json.NewEncoder(w).Encode(validateKeyResponse{Valid: true, ProjectID: "proj-1"})
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		prefix      string
+		randomBytes int
+		key         string
+		wantErr     bool
+	}{
+		{name: "default length", prefix: "co_sk_", randomBytes: 32, key: "co_sk_" + strings.Repeat("a", 43)},
+		{name: "one short of default", prefix: "co_sk_", randomBytes: 32, key: "co_sk_" + strings.Repeat("a", 42), wantErr: true},
+		{name: "shorter configured length", prefix: "co_sk_", randomBytes: 16, key: "co_sk_" + strings.Repeat("a", 22)},
+		{name: "longer configured length", prefix: "co_sk_", randomBytes: 64, key: "co_sk_" + strings.Repeat("a", 85), wantErr: true},
+		{name: "custom prefix", prefix: "acme_", randomBytes: 32, key: "acme_" + strings.Repeat("a", 43)},
+		{name: "default prefix with custom configured", prefix: "acme_", randomBytes: 32, key: "co_sk_" + strings.Repeat("a", 43), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				APIKeyPrefix:            tt.prefix,
+				APIKeyRandomBytesLength: tt.randomBytes,
+				WebAPIURL:               srv.URL,
+				InternalValidateKeyPath: "/api/internal/validate-key",
+			}
+			before := lookups
+
+			_, apiErr := authenticateAPIKey(context.Background(), cfg, srv.Client(), tt.key)
+			if (apiErr != nil) != tt.wantErr {
+				t.Fatalf("authenticateAPIKey() error = %v, wantErr %v", apiErr, tt.wantErr)
+			}
+			// Malformed keys are rejected without a web API round trip
+			if tt.wantErr && lookups != before {
+				t.Error("malformed key was looked up")
+			}
+		})
+	}
+}
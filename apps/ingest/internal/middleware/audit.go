@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/cognobserve/ingest/internal/audit"
+)
+
+// auditHTTP records an auth decision for an HTTP request.
+// RemoteAddr has already been rewritten to the client IP by chi's RealIP.
+func auditHTTP(l *audit.Logger, r *http.Request, e audit.Event) {
+	e.Transport = audit.TransportHTTP
+	e.IP = hostOnly(r.RemoteAddr)
+	l.Log(e)
+}
+
+// auditGRPC records an auth decision for a gRPC call
+func auditGRPC(l *audit.Logger, ctx context.Context, e audit.Event) {
+	e.Transport = audit.TransportGRPC
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.IP = hostOnly(p.Addr.String())
+	}
+	l.Log(e)
+}
+
+// hashedKeyPrefix returns the SHA-256 prefix used to identify an API key
+// in logs and audit events; the raw key is never recorded.
+func hashedKeyPrefix(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// hostOnly strips the port from addr, if present
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
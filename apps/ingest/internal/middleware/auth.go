@@ -2,18 +2,32 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
 	"net/http"
-	"os"
-	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/cognobserve/ingest/internal/audit"
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/response"
 )
 
 type contextKey string
 
 const (
-	UserContextKey     contextKey = "user"
-	ProjectsContextKey contextKey = "projects"
+	UserContextKey       contextKey = "user"
+	ProjectsContextKey   contextKey = "projects"
+	AuthMethodContextKey contextKey = "auth_method"
+)
+
+// Authentication methods reported by GetAuthMethod
+const (
+	AuthMethodAPIKey   = "apikey"
+	AuthMethodJWT      = "jwt"
+	AuthMethodUpstream = "upstream" // Trusted API gateway, see UpstreamAuth
+	AuthMethodNone     = "none"
 )
 
 type ProjectAccess struct {
@@ -24,181 +38,257 @@ type ProjectAccess struct {
 type UserClaims struct {
 	jwt.RegisteredClaims
 	Email    string          `json:"email"`
-	Projects []ProjectAccess `json:"projects"`
-}
-
-// JWTAuth validates Bearer tokens from NextAuth (required)
-func JWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error":"Missing authorization header"}`, http.StatusUnauthorized)
-			return
-		}
+	Projects []ProjectAccess `json:"-"` // Read from JWT_PROJECTS_CLAIM by the TokenVerifier
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, `{"error":"Invalid authorization header format"}`, http.StatusUnauthorized)
-			return
-		}
+	raw map[string]any // Every claim, for resolving JWT_PROJECTS_CLAIM
+}
 
-		tokenString := parts[1]
+// UnmarshalJSON decodes the registered claims and keeps the full claim set
+func (c *UserClaims) UnmarshalJSON(data []byte) error {
+	type plain UserClaims
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.raw)
+}
 
-		// Parse and validate token
-		secret := []byte(os.Getenv("JWT_SHARED_SECRET"))
-		token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+// JWTAuth validates Bearer tokens from NextAuth (required)
+func JWTAuth(verifier *TokenVerifier, auditLog *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Extract token from Authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				auditHTTP(auditLog, r, audit.Event{
+					Outcome:   audit.OutcomeFailure,
+					Method:    AuthMethodJWT,
+					ProjectID: r.Header.Get(ProjectIDHeader),
+					Reason:    "missing_authorization",
+				})
+				response.Error(w, http.StatusUnauthorized, "missing_authorization", "Missing authorization header")
+				return
 			}
-			return secret, nil
-		})
-
-		if err != nil || !token.Valid {
-			http.Error(w, `{"error":"Invalid token"}`, http.StatusUnauthorized)
-			return
-		}
 
-		claims, ok := token.Claims.(*UserClaims)
-		if !ok {
-			http.Error(w, `{"error":"Invalid token claims"}`, http.StatusUnauthorized)
-			return
-		}
+			claims, apiErr := verifier.verifyBearer(authHeader)
+			auditJWT(auditLog, r, claims, apiErr)
+			if apiErr != nil {
+				response.WriteError(w, apiErr)
+				return
+			}
 
-		// Add claims to context
-		ctx := context.WithValue(r.Context(), UserContextKey, claims.Subject)
-		ctx = context.WithValue(ctx, ProjectsContextKey, claims.Projects)
+			// Add claims to context
+			ctx := withJWTClaims(r.Context(), claims)
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 // OptionalJWTAuth validates Bearer tokens if present, but doesn't require them
 // Used when API key auth is also an option
-func OptionalJWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// If already authenticated via API key, skip JWT auth
-		if IsAPIKeyAuthenticated(r.Context()) {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Extract token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			// No JWT token, continue without auth (RequireAuth will check later)
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, `{"error":"Invalid authorization header format"}`, http.StatusUnauthorized)
-			return
-		}
-
-		tokenString := parts[1]
+func OptionalJWTAuth(verifier *TokenVerifier, auditLog *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// If already authenticated via API key or gateway, skip JWT auth
+			if GetAuthMethod(r.Context()) != AuthMethodNone {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		// Parse and validate token
-		secret := []byte(os.Getenv("JWT_SHARED_SECRET"))
-		token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+			// Extract token from Authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				// No JWT token, continue without auth (RequireAuth will check later)
+				next.ServeHTTP(w, r)
+				return
 			}
-			return secret, nil
-		})
 
-		if err != nil || !token.Valid {
-			http.Error(w, `{"error":"Invalid token"}`, http.StatusUnauthorized)
-			return
-		}
+			claims, apiErr := verifier.verifyBearer(authHeader)
+			auditJWT(auditLog, r, claims, apiErr)
+			if apiErr != nil {
+				response.WriteError(w, apiErr)
+				return
+			}
 
-		claims, ok := token.Claims.(*UserClaims)
-		if !ok {
-			http.Error(w, `{"error":"Invalid token claims"}`, http.StatusUnauthorized)
-			return
-		}
+			// Add claims to context
+			ctx := withJWTClaims(r.Context(), claims)
 
-		// Add claims to context
-		ctx := context.WithValue(r.Context(), UserContextKey, claims.Subject)
-		ctx = context.WithValue(ctx, ProjectsContextKey, claims.Projects)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// auditJWT records the outcome of verifying a request's Bearer token
+func auditJWT(auditLog *audit.Logger, r *http.Request, claims *UserClaims, apiErr *response.APIError) {
+	event := audit.Event{
+		Outcome:   audit.OutcomeSuccess,
+		Method:    AuthMethodJWT,
+		ProjectID: r.Header.Get(ProjectIDHeader),
+	}
+	if apiErr != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.Reason = apiErr.Code
+	} else {
+		event.UserID = claims.Subject
+	}
+	auditHTTP(auditLog, r, event)
 }
 
-// RequireAuth ensures at least one authentication method was used (API key or JWT)
-func RequireAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check if API key auth was used
-		if IsAPIKeyAuthenticated(r.Context()) {
-			next.ServeHTTP(w, r)
-			return
-		}
+// withJWTClaims marks ctx as JWT-authenticated and stores the user's claims
+func withJWTClaims(ctx context.Context, claims *UserClaims) context.Context {
+	ctx = context.WithValue(ctx, AuthMethodContextKey, AuthMethodJWT)
+	ctx = context.WithValue(ctx, UserContextKey, claims.Subject)
+	return context.WithValue(ctx, ProjectsContextKey, claims.Projects)
+}
 
-		// Check if JWT auth was used (user ID in context)
-		if GetUserID(r.Context()) != "" {
-			next.ServeHTTP(w, r)
-			return
-		}
+// RequireAuth ensures at least one authentication method was used (API key, JWT or trusted gateway)
+func RequireAuth(auditLog *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetAuthMethod(r.Context()) != AuthMethodNone {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		http.Error(w, `{"error":"Authentication required"}`, http.StatusUnauthorized)
-	})
+			auditHTTP(auditLog, r, audit.Event{
+				Outcome:   audit.OutcomeFailure,
+				Method:    AuthMethodNone,
+				ProjectID: r.Header.Get(ProjectIDHeader),
+				Reason:    "authentication_required",
+			})
+			response.Error(w, http.StatusUnauthorized, "authentication_required", "Authentication required")
+		})
+	}
 }
 
 // RequireProjectAccess checks if user has access to the specified project
 // For API key auth: validates that the requested project matches the key's bound project
-// For JWT auth: project membership is checked from the token claims
-func RequireProjectAccess(projectIDHeader string) func(http.Handler) http.Handler {
+// For JWT auth: the header format is validated, then membership is checked from the token claims
+// Writes (methods other than GET, HEAD and OPTIONS, bar the routes in routeRoles)
+// need the MEMBER role, or the traces:write scope for scoped API keys
+// Denials are recorded to auditLog.
+func RequireProjectAccess(cfg *config.Config, projectIDHeader string, auditLog *audit.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			projectID := r.Header.Get(projectIDHeader)
 			if projectID == "" {
-				http.Error(w, `{"error":"Missing project ID"}`, http.StatusBadRequest)
+				response.Error(w, http.StatusBadRequest, "missing_project_id", "Missing project ID")
 				return
 			}
 
-			// If authenticated via API key, verify the requested project matches the key's project
-			// This prevents header tampering attacks where an attacker uses a valid key
-			// but tries to access a different project by manipulating the header
-			if IsAPIKeyAuthenticated(r.Context()) {
-				validatedProjectID := GetAPIKeyProjectID(r.Context())
-				if validatedProjectID == "" {
-					http.Error(w, `{"error":"Invalid API key context"}`, http.StatusInternalServerError)
-					return
-				}
-				if validatedProjectID != projectID {
-					http.Error(w, `{"error":"API key not authorized for this project"}`, http.StatusForbidden)
-					return
-				}
-				next.ServeHTTP(w, r)
+			if apiErr := authorizeProject(r.Context(), cfg, projectID, requiredRole(r)); apiErr != nil {
+				auditHTTP(auditLog, r, audit.Event{
+					Outcome:   audit.OutcomeFailure,
+					Method:    GetAuthMethod(r.Context()),
+					ProjectID: projectID,
+					UserID:    GetUserID(r.Context()),
+					Reason:    apiErr.Code,
+				})
+				response.WriteError(w, apiErr)
 				return
 			}
 
-			// For JWT auth, check project membership
-			projects, ok := r.Context().Value(ProjectsContextKey).([]ProjectAccess)
-			if !ok {
-				http.Error(w, `{"error":"Invalid context"}`, http.StatusInternalServerError)
-				return
-			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-			// Check if user has access to this project
-			hasAccess := false
-			for _, p := range projects {
-				if p.ID == projectID {
-					hasAccess = true
-					break
-				}
-			}
+// authorizeProject checks that the authenticated caller may access projectID
+// with at least the role required.
+// Shared by the HTTP middleware and the gRPC interceptor.
+func authorizeProject(ctx context.Context, cfg *config.Config, projectID, required string) *response.APIError {
+	// If authenticated via API key, verify the requested project matches the key's project
+	// This prevents header tampering attacks where an attacker uses a valid key
+	// but tries to access a different project by manipulating the header
+	if IsAPIKeyAuthenticated(ctx) {
+		validatedProjectID := GetAPIKeyProjectID(ctx)
+		if validatedProjectID == "" {
+			return response.NewError(http.StatusInternalServerError, "internal_error", "Invalid API key context")
+		}
+		if validatedProjectID != projectID {
+			// Usually a misconfigured client sending the wrong project header
+			slog.Warn("API key project mismatch",
+				"keyProjectId", validatedProjectID,
+				"requestedProjectId", projectID,
+			)
+			metrics.AuthDenials.WithLabelValues("api_key_project_mismatch").Inc()
+			return response.NewError(http.StatusForbidden, "api_key_project_mismatch", "API key not authorized for this project")
+		}
+		// Keys are write-capable unless scoped without traces:write
+		if required != RoleViewer && !HasScope(ctx, ScopeTracesWrite) {
+			metrics.AuthDenials.WithLabelValues("insufficient_scope").Inc()
+			return response.NewError(http.StatusForbidden, "insufficient_scope", "API key lacks the "+ScopeTracesWrite+" scope")
+		}
+		return nil
+	}
 
-			if !hasAccess {
-				http.Error(w, `{"error":"Access denied to project"}`, http.StatusForbidden)
-				return
-			}
+	// A trusted gateway authorized the caller for the forwarded project only
+	if GetAuthMethod(ctx) == AuthMethodUpstream {
+		if GetUpstreamProjectID(ctx) != projectID {
+			metrics.AuthDenials.WithLabelValues("upstream_project_mismatch").Inc()
+			return response.NewError(http.StatusForbidden, "upstream_project_mismatch", "Forwarded project does not match the requested project")
+		}
+		return nil
+	}
 
-			next.ServeHTTP(w, r)
-		})
+	// Defensive: RequireAuth should have rejected unauthenticated callers already
+	if GetAuthMethod(ctx) != AuthMethodJWT {
+		return response.NewError(http.StatusUnauthorized, "authentication_required", "Authentication required")
+	}
+
+	// For JWT auth the header is client-controlled; reject malformed values
+	// before they reach membership checks or downstream workflow IDs
+	if !cfg.ProjectIDRegexp.MatchString(projectID) {
+		return response.NewError(http.StatusBadRequest, "invalid_project_id", "Invalid project ID format")
+	}
+
+	// For JWT auth, check project membership
+	projects, ok := ctx.Value(ProjectsContextKey).([]ProjectAccess)
+	if !ok {
+		return response.NewError(http.StatusInternalServerError, "internal_error", "Invalid context")
+	}
+
+	// Check if user has access to this project
+	var access *ProjectAccess
+	for i := range projects {
+		if projects[i].ID == projectID {
+			access = &projects[i]
+			break
+		}
+	}
+
+	if access == nil {
+		// A genuine access-control event: the user isn't a member of the project
+		slog.Warn("project membership denied",
+			"userId", GetUserID(ctx),
+			"requestedProjectId", projectID,
+		)
+		metrics.AuthDenials.WithLabelValues("project_membership_denied").Inc()
+		return response.NewError(http.StatusForbidden, "project_membership_denied", "Access denied to project")
+	}
+
+	// Members can be read-only (VIEWER)
+	if apiErr := checkRole(access.Role, required); apiErr != nil {
+		slog.Warn("project role denied",
+			"userId", GetUserID(ctx),
+			"requestedProjectId", projectID,
+			"role", access.Role,
+			"requiredRole", required,
+		)
+		metrics.AuthDenials.WithLabelValues("insufficient_role").Inc()
+		return apiErr
+	}
+
+	return nil
+}
+
+// GetAuthMethod returns how the request was authenticated:
+// AuthMethodAPIKey, AuthMethodJWT, AuthMethodUpstream or AuthMethodNone
+func GetAuthMethod(ctx context.Context) string {
+	if method, ok := ctx.Value(AuthMethodContextKey).(string); ok {
+		return method
 	}
+	return AuthMethodNone
 }
 
 // GetUserID gets the user ID from context
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestRequireProjectAccessForbiddenReasons(t *testing.T) {
+	cfg := &config.Config{ProjectIDRegexp: regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)}
+
+	tests := []struct {
+		name     string
+		ctx      func(context.Context) context.Context
+		wantCode string
+	}{
+		{
+			name: "api key bound to another project",
+			ctx: func(ctx context.Context) context.Context {
+				return withAPIKey(ctx, &validateKeyResponse{Valid: true, ProjectID: "proj-other"})
+			},
+			wantCode: "api_key_project_mismatch",
+		},
+		{
+			name: "user not a member",
+			ctx: func(ctx context.Context) context.Context {
+				ctx = context.WithValue(ctx, AuthMethodContextKey, AuthMethodJWT)
+				ctx = context.WithValue(ctx, UserContextKey, "user-1")
+				return context.WithValue(ctx, ProjectsContextKey, []ProjectAccess{{ID: "proj-other", Role: RoleOwner}})
+			},
+			wantCode: "project_membership_denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denials := metrics.AuthDenials.WithLabelValues(tt.wantCode)
+			before := testutil.ToFloat64(denials)
+
+			called := false
+			handler := RequireProjectAccess(cfg, "X-Project-ID", nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", nil)
+			req.Header.Set("X-Project-ID", "proj-1")
+			req = req.WithContext(tt.ctx(req.Context()))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if called {
+				t.Fatal("next handler was called")
+			}
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+			}
+			var body response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			if body.Error == "" || body.Error != body.Message {
+				t.Errorf("error = %q, want the message %q", body.Error, body.Message)
+			}
+			if got := testutil.ToFloat64(denials) - before; got != 1 {
+				t.Errorf("%s denials increased by %v, want 1", tt.wantCode, got)
+			}
+		})
+	}
+}
+
+func TestGetAuthMethod(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func(context.Context) context.Context
+		want string
+	}{
+		{
+			name: "api key",
+			ctx: func(ctx context.Context) context.Context {
+				return withAPIKey(ctx, &validateKeyResponse{Valid: true, ProjectID: "proj-1"})
+			},
+			want: AuthMethodAPIKey,
+		},
+		{
+			name: "jwt",
+			ctx: func(ctx context.Context) context.Context {
+				return withJWTClaims(ctx, &UserClaims{Projects: []ProjectAccess{{ID: "proj-1", Role: RoleOwner}}})
+			},
+			want: AuthMethodJWT,
+		},
+		{
+			name: "upstream gateway",
+			ctx: func(ctx context.Context) context.Context {
+				return context.WithValue(ctx, AuthMethodContextKey, AuthMethodUpstream)
+			},
+			want: AuthMethodUpstream,
+		},
+		{
+			name: "unauthenticated",
+			ctx:  func(ctx context.Context) context.Context { return ctx },
+			want: AuthMethodNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx(context.Background())
+			if got := GetAuthMethod(ctx); got != tt.want {
+				t.Errorf("GetAuthMethod() = %q, want %q", got, tt.want)
+			}
+			if got := IsAPIKeyAuthenticated(ctx); got != (tt.want == AuthMethodAPIKey) {
+				t.Errorf("IsAPIKeyAuthenticated() = %v, want %v", got, tt.want == AuthMethodAPIKey)
+			}
+		})
+	}
+}
+
+func TestRequireProjectAccessProjectIDFormat(t *testing.T) {
+	cfg := &config.Config{ProjectIDRegexp: regexp.MustCompile(`^(?:[A-Za-z0-9_-]{1,64})$`)}
+	jwtCtx := func(ctx context.Context, projectID string) context.Context {
+		ctx = context.WithValue(ctx, AuthMethodContextKey, AuthMethodJWT)
+		ctx = context.WithValue(ctx, UserContextKey, "user-1")
+		return context.WithValue(ctx, ProjectsContextKey, []ProjectAccess{{ID: projectID, Role: RoleOwner}})
+	}
+
+	tests := []struct {
+		name       string
+		projectID  string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "cuid", projectID: "clx1a2b3c0000abcd1234efgh", wantStatus: http.StatusOK},
+		{name: "uuid", projectID: "8f14e45f-ceea-467f-a0e6-3f2b1c9d7e10", wantStatus: http.StatusOK},
+		{name: "path traversal", projectID: "../proj-1", wantStatus: http.StatusBadRequest, wantCode: "invalid_project_id"},
+		{name: "whitespace", projectID: "proj 1", wantStatus: http.StatusBadRequest, wantCode: "invalid_project_id"},
+		{name: "too long", projectID: strings.Repeat("a", 65), wantStatus: http.StatusBadRequest, wantCode: "invalid_project_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := RequireProjectAccess(cfg, "X-Project-ID", nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", nil)
+			req.Header.Set("X-Project-ID", tt.projectID)
+			// The user is a member, so only the format check can reject
+			req = req.WithContext(jwtCtx(req.Context(), tt.projectID))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("next handler called = %v", called)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var body response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// LimitRequestBody caps the bytes read from the request body as sent on
+// the wire, before any decompression. Bodies declaring a larger
+// Content-Length are rejected up front; others fail with 413 once reading
+// passes the limit. This bounds what a client can make the server read,
+// separately from how large a payload may be once inflated.
+func LimitRequestBody(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				response.WriteError(w, requestTooLarge(limit))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BodyTooLarge returns the 413 response for a body read that failed on
+// LimitRequestBody's cap, or nil for any other error
+func BodyTooLarge(err error) *response.APIError {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return nil
+	}
+	return requestTooLarge(maxErr.Limit)
+}
+
+func requestTooLarge(limit int64) *response.APIError {
+	apiErr := response.NewError(http.StatusRequestEntityTooLarge, "request_too_large",
+		fmt.Sprintf("request body exceeds %d bytes", limit))
+	apiErr.Details = map[string]any{"max_bytes": limit}
+	return apiErr
+}
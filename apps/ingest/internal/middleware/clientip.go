@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+
+	"google.golang.org/grpc/peer"
+)
+
+// ClientIPKey is the context key for the client's IP address
+const ClientIPKey contextKey = "client_ip"
+
+// ClientIP records the client's IP address in the request context.
+// It must run after chi's RealIP so proxies' forwarding headers are honoured.
+func ClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, err := netip.ParseAddr(hostOnly(r.RemoteAddr)); err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), ClientIPKey, addr.Unmap()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetClientIP returns the client's IP address, from ClientIP for HTTP
+// requests or the peer address for gRPC calls
+func GetClientIP(ctx context.Context) (netip.Addr, bool) {
+	if addr, ok := ctx.Value(ClientIPKey).(netip.Addr); ok {
+		return addr, true
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if addr, err := netip.ParseAddr(hostOnly(p.Addr.String())); err == nil {
+			return addr.Unmap(), true
+		}
+	}
+	return netip.Addr{}, false
+}
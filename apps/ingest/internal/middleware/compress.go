@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// Compress gzips responses for clients that send Accept-Encoding: gzip.
+// Bodies are buffered until they reach minSize bytes; smaller responses
+// (single-trace acks, error envelopes) are sent uncompressed, since gzip
+// would only add overhead.
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, minSize: minSize}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// Decompress inflates request bodies sent with Content-Encoding: gzip, so
+// SDKs can compress large trace payloads. Other encodings are rejected
+// with 415 unsupported_content_encoding; identity bodies pass through.
+// Inflated bodies are capped at limit bytes, failing with 413 like
+// LimitRequestBody: that cap only counts compressed bytes, and a small
+// gzip body can expand to gigabytes.
+func Decompress(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch encoding := strings.TrimSpace(r.Header.Get("Content-Encoding")); {
+			case encoding == "" || strings.EqualFold(encoding, "identity"):
+				next.ServeHTTP(w, r)
+			case strings.EqualFold(encoding, "gzip"):
+				gz, err := gzip.NewReader(r.Body)
+				if apiErr := BodyTooLarge(err); apiErr != nil {
+					response.WriteError(w, apiErr)
+					return
+				}
+				if err != nil {
+					response.Error(w, http.StatusBadRequest, "invalid_request_body", "request body is not valid gzip")
+					return
+				}
+				defer gz.Close()
+
+				r.Body = http.MaxBytesReader(w, gz, limit)
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+				next.ServeHTTP(w, r)
+			default:
+				response.Error(w, http.StatusUnsupportedMediaType, "unsupported_content_encoding",
+					fmt.Sprintf("Content-Encoding %q is not supported, use gzip or identity", encoding))
+			}
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding value allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// gzip;q=0 explicitly refuses the encoding
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(key) == "q" {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressWriter buffers the start of a response to decide whether to gzip it
+type compressWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status      int
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	wroteHeader bool // Header has been sent downstream
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.gz != nil {
+		return cw.gz.Write(p)
+	}
+	if cw.wroteHeader {
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf.Write(p)
+	if cw.buf.Len() >= cw.minSize && cw.compressible() {
+		if err := cw.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends buffered data, committing to the current encoding
+func (cw *compressWriter) Flush() {
+	if cw.gz != nil {
+		_ = cw.gz.Flush()
+	} else if !cw.wroteHeader {
+		_ = cw.writePlain()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// compressible reports whether the response may be re-encoded
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	return h.Get("Content-Encoding") == "" &&
+		cw.status != http.StatusNoContent &&
+		cw.status != http.StatusNotModified
+}
+
+func (cw *compressWriter) startGzip() error {
+	h := cw.Header()
+	// Sniff from the plain bytes; net/http would otherwise sniff the gzip stream
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(cw.buf.Bytes()))
+	}
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	cw.ResponseWriter.WriteHeader(cw.status)
+	cw.wroteHeader = true
+
+	cw.gz = gzipWriterPool.Get().(*gzip.Writer)
+	cw.gz.Reset(cw.ResponseWriter)
+
+	_, err := cw.gz.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+func (cw *compressWriter) writePlain() error {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	cw.wroteHeader = true
+
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+// close completes the response once the handler returns
+func (cw *compressWriter) close() {
+	if cw.gz != nil {
+		_ = cw.gz.Close()
+		gzipWriterPool.Put(cw.gz)
+		cw.gz = nil
+		return
+	}
+	if !cw.wroteHeader && cw.status != 0 {
+		_ = cw.writePlain()
+	}
+}
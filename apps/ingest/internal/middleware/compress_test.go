@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"trace_id":"t1","success":true}`, 64)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		status         int
+		body           string
+		wantGzip       bool
+	}{
+		{name: "large body", acceptEncoding: "gzip", status: http.StatusOK, body: large, wantGzip: true},
+		{name: "small body", acceptEncoding: "gzip", status: http.StatusOK, body: `{"success":true}`},
+		{name: "not accepted", status: http.StatusOK, body: large},
+		{name: "refused by q=0", acceptEncoding: "br, gzip;q=0", status: http.StatusOK, body: large},
+		{name: "weighted", acceptEncoding: "br;q=1.0, gzip;q=0.5", status: http.StatusOK, body: large, wantGzip: true},
+		{name: "error status keeps the status", acceptEncoding: "gzip", status: http.StatusBadRequest, body: large, wantGzip: true},
+		{name: "empty body", acceptEncoding: "gzip", status: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Compress(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				// Several writes cross the threshold part-way through
+				for i := 0; i < len(tt.body); i += 100 {
+					_, _ = io.WriteString(w, tt.body[i:min(i+100, len(tt.body))])
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/traces", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+
+			gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("gzipped = %v, want %v", gzipped, tt.wantGzip)
+			}
+			body := rec.Body.Bytes()
+			if gzipped {
+				gz, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("gzip reader: %v", err)
+				}
+				if body, err = io.ReadAll(gz); err != nil {
+					t.Fatalf("decompress: %v", err)
+				}
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	const rawLimit, inflatedLimit = 4 << 10, 64 << 10
+
+	gzipped := func(body []byte) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, _ = gz.Write(body)
+		_ = gz.Close()
+		return buf.Bytes()
+	}
+	payload := []byte(`{"trace_id":"t1","spans":[]}`)
+	// A megabyte of zeros gzips to about a kilobyte, well within the raw cap
+	bomb := gzipped(make([]byte, 1<<20))
+	if len(bomb) > rawLimit {
+		t.Fatalf("compressed bomb is %d bytes, want it within the raw cap", len(bomb))
+	}
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		wantStatus int
+		wantCode   string
+		wantBody   []byte
+	}{
+		{name: "identity", body: payload, wantStatus: http.StatusOK, wantBody: payload},
+		{name: "gzip", encoding: "gzip", body: gzipped(payload), wantStatus: http.StatusOK, wantBody: payload},
+		{name: "gzip at the inflated cap", encoding: "gzip", body: gzipped(make([]byte, inflatedLimit)), wantStatus: http.StatusOK, wantBody: make([]byte, inflatedLimit)},
+		{name: "gzip bomb", encoding: "gzip", body: bomb, wantStatus: http.StatusRequestEntityTooLarge, wantCode: "request_too_large"},
+		{name: "invalid gzip", encoding: "gzip", body: payload, wantStatus: http.StatusBadRequest, wantCode: "invalid_request_body"},
+		{name: "unsupported encoding", encoding: "br", body: payload, wantStatus: http.StatusUnsupportedMediaType, wantCode: "unsupported_content_encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Buffers the whole body, as Idempotency and the decoders do
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if apiErr := BodyTooLarge(err); apiErr != nil {
+					response.WriteError(w, apiErr)
+					return
+				}
+				if err != nil {
+					t.Fatalf("read body: %v", err)
+				}
+				_, _ = w.Write(body)
+			})
+			handler := LimitRequestBody(rawLimit)(Decompress(inflatedLimit)(next))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode == "" {
+				if !bytes.Equal(rec.Body.Bytes(), tt.wantBody) {
+					t.Errorf("body is %d bytes, want %d", rec.Body.Len(), len(tt.wantBody))
+				}
+				return
+			}
+			var body response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", rec.Body, err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			// The inflated cap is reported, not the raw one
+			if tt.wantStatus == http.StatusRequestEntityTooLarge && body.Details["max_bytes"] != float64(inflatedLimit) {
+				t.Errorf("max_bytes = %v, want %d", body.Details["max_bytes"], inflatedLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang.org/x/sync/semaphore"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// ConcurrencyRetryAfter is the Retry-After hint (seconds) sent when saturated
+const ConcurrencyRetryAfter = 1
+
+// ConcurrencyLimit caps the number of requests processed at once.
+// Requests beyond the limit are rejected immediately with 503 instead of
+// queueing, so a retry storm can't pile up unbounded goroutines.
+func ConcurrencyLimit(maxConcurrent int) func(http.Handler) http.Handler {
+	sem := semaphore.NewWeighted(int64(maxConcurrent))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !sem.TryAcquire(1) {
+				metrics.ConcurrencyRejections.Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(ConcurrencyRetryAfter))
+				response.Error(w, http.StatusServiceUnavailable, "server_busy", "Server busy, retry later")
+				return
+			}
+			defer sem.Release(1)
+
+			metrics.InFlightRequests.Inc()
+			defer metrics.InFlightRequests.Dec()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
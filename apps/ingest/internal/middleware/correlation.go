@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// CorrelationIDHeader carries a client-supplied ID shared between the
+	// client's logs and ours. It is echoed on every response.
+	CorrelationIDHeader = "X-Correlation-ID"
+
+	// correlationIDMetadataKey is the gRPC metadata equivalent of CorrelationIDHeader
+	correlationIDMetadataKey = "x-correlation-id"
+)
+
+// CorrelationIDKey is the context key for the request's correlation ID
+const CorrelationIDKey contextKey = "correlation_id"
+
+// correlationIDPattern bounds client correlation IDs to a log-safe charset
+var correlationIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/\-]{0,127}$`)
+
+// CorrelationID records a valid X-Correlation-ID header as the request's
+// correlation ID, or the generated request ID without one (or with an
+// invalid one). The request ID itself is left alone so it stays unique
+// in the access log; request-scoped logs carry both. It must run after
+// chi's RequestID.
+func CorrelationID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		requestID := chimw.GetReqID(ctx)
+		id := requestID
+		if supplied := r.Header.Get(CorrelationIDHeader); supplied != "" {
+			if correlationIDPattern.MatchString(supplied) {
+				id = supplied
+				slog.Debug("adopted client correlation ID", "request_id", requestID, "correlation_id", id)
+			} else {
+				slog.Debug("ignoring invalid correlation ID", "request_id", requestID)
+			}
+		}
+		if id != "" {
+			w.Header().Set(CorrelationIDHeader, id)
+			ctx = context.WithValue(ctx, CorrelationIDKey, id)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetCorrelationID returns the request's correlation ID: the one set by
+// CorrelationID for HTTP requests, or valid x-correlation-id metadata on
+// gRPC calls. Returns "" when there is none.
+func GetCorrelationID(ctx context.Context) string {
+	if id, ok := ctx.Value(CorrelationIDKey).(string); ok {
+		return id
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if id := firstMetadata(md, correlationIDMetadataKey); correlationIDPattern.MatchString(id) {
+		return id
+	}
+	return ""
+}
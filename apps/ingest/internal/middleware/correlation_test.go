@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestCorrelationID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantSelf bool // Correlation ID falls back to the request ID
+	}{
+		{name: "no header", wantSelf: true},
+		{name: "valid header", header: "client-trace.42"},
+		{name: "invalid header", header: "bad id\n", wantSelf: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requestID, correlationID string
+			handler := chimw.RequestID(CorrelationID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestID = chimw.GetReqID(r.Context())
+				correlationID = GetCorrelationID(r.Context())
+			})))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", nil)
+			if tt.header != "" {
+				req.Header.Set(CorrelationIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			want := tt.header
+			if tt.wantSelf {
+				want = requestID
+			}
+			if correlationID != want {
+				t.Errorf("correlation ID = %q, want %q", correlationID, want)
+			}
+			if got := rec.Header().Get(CorrelationIDHeader); got != want {
+				t.Errorf("%s header = %q, want %q", CorrelationIDHeader, got, want)
+			}
+			if requestID == "" || requestID == tt.header {
+				t.Errorf("request ID = %q, want the generated ID", requestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// Per-project features, reported by the web API when validating API keys.
+// Synchronous waits (?wait=true) need no feature: they're limited to JWT
+// callers, who have every feature.
+const (
+	FeatureStreaming        = "streaming"         // NDJSON ingestion and upload sessions
+	FeatureSchemaValidation = "schema_validation" // Output schema checks on generation spans
+)
+
+// GetProjectFeature reports whether feature is enabled for the caller's project.
+// Features are enabled unless the web API explicitly disabled them, so keys
+// validated before feature flags existed, and JWT users, keep every feature.
+func GetProjectFeature(ctx context.Context, feature string) bool {
+	features, _ := ctx.Value(ProjectFeaturesKey).(map[string]bool)
+	enabled, ok := features[feature]
+	return !ok || enabled
+}
+
+// RequireFeature gates a route on a project feature, rejecting projects
+// with it disabled with 403 feature_not_enabled
+func RequireFeature(feature string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !GetProjectFeature(r.Context(), feature) {
+				response.Error(w, http.StatusForbidden, "feature_not_enabled",
+					fmt.Sprintf("the %s feature is not enabled for this project", feature))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
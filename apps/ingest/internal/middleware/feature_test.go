@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireFeature(t *testing.T) {
+	tests := []struct {
+		name       string
+		features   map[string]bool
+		wantStatus int
+	}{
+		{name: "no feature flags", features: nil, wantStatus: http.StatusOK},
+		{name: "feature not reported", features: map[string]bool{FeatureSchemaValidation: false}, wantStatus: http.StatusOK},
+		{name: "feature enabled", features: map[string]bool{FeatureStreaming: true}, wantStatus: http.StatusOK},
+		{name: "feature disabled", features: map[string]bool{FeatureStreaming: false}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireFeature(FeatureStreaming)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces/ndjson", nil)
+			if tt.features != nil {
+				req = req.WithContext(context.WithValue(req.Context(), ProjectFeaturesKey, tt.features))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/cognobserve/ingest/internal/audit"
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/webapi"
+)
+
+// gRPC metadata keys mirroring the HTTP headers (metadata keys are lowercase)
+const (
+	apiKeyMetadataKey        = "x-api-key"
+	authorizationMetadataKey = "authorization"
+	projectIDMetadataKey     = "x-project-id"
+)
+
+// ProjectIDContextKey is the context key for the authorized project ID on gRPC calls
+const ProjectIDContextKey contextKey = "project_id"
+
+// GRPCAuth authenticates unary gRPC calls with the same rules as the HTTP chain
+// (APIKeyAuth, OptionalJWTAuth, RequireAuth and RequireProjectAccess).
+// An API key takes precedence over a Bearer token; with an API key the
+// x-project-id metadata is optional and defaults to the key's project.
+// Each decision is recorded to auditLog.
+func GRPCAuth(cfg *config.Config, verifier *TokenVerifier, auditLog *audit.Logger) grpc.UnaryServerInterceptor {
+	client := webapi.NewHTTPClient(cfg)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		projectID := firstMetadata(md, projectIDMetadataKey)
+
+		if apiKey := firstMetadata(md, apiKeyMetadataKey); apiKey != "" {
+			startTime := time.Now()
+			key, apiErr := authenticateAPIKey(ctx, cfg, client, apiKey)
+			if apiErr != nil {
+				auditGRPC(auditLog, ctx, audit.Event{
+					Outcome:   audit.OutcomeFailure,
+					Method:    AuthMethodAPIKey,
+					ProjectID: projectID,
+					KeyPrefix: hashedKeyPrefix(apiKey),
+					Reason:    apiErr.Code,
+				})
+				waitMinResponseTime(startTime)
+				return nil, apiErr
+			}
+			auditGRPC(auditLog, ctx, audit.Event{
+				Outcome:   audit.OutcomeSuccess,
+				Method:    AuthMethodAPIKey,
+				ProjectID: key.ProjectID,
+				KeyPrefix: hashedKeyPrefix(apiKey),
+			})
+
+			ctx = withAPIKey(ctx, key)
+			if projectID == "" {
+				projectID = key.ProjectID
+			}
+		} else if authHeader := firstMetadata(md, authorizationMetadataKey); authHeader != "" {
+			claims, apiErr := verifier.verifyBearer(authHeader)
+			if apiErr != nil {
+				auditGRPC(auditLog, ctx, audit.Event{
+					Outcome:   audit.OutcomeFailure,
+					Method:    AuthMethodJWT,
+					ProjectID: projectID,
+					Reason:    apiErr.Code,
+				})
+				return nil, apiErr
+			}
+			auditGRPC(auditLog, ctx, audit.Event{
+				Outcome:   audit.OutcomeSuccess,
+				Method:    AuthMethodJWT,
+				ProjectID: projectID,
+				UserID:    claims.Subject,
+			})
+
+			ctx = withJWTClaims(ctx, claims)
+		} else {
+			auditGRPC(auditLog, ctx, audit.Event{
+				Outcome:   audit.OutcomeFailure,
+				Method:    AuthMethodNone,
+				ProjectID: projectID,
+				Reason:    "authentication_required",
+			})
+			return nil, response.NewError(http.StatusUnauthorized, "authentication_required", "Authentication required")
+		}
+
+		if projectID == "" {
+			return nil, response.NewError(http.StatusBadRequest, "missing_project_id", "Missing project ID")
+		}
+		// Every IngestService and OTLP RPC writes traces
+		if apiErr := authorizeProject(ctx, cfg, projectID, RoleMember); apiErr != nil {
+			auditGRPC(auditLog, ctx, audit.Event{
+				Outcome:   audit.OutcomeFailure,
+				Method:    GetAuthMethod(ctx),
+				ProjectID: projectID,
+				UserID:    GetUserID(ctx),
+				Reason:    apiErr.Code,
+			})
+			return nil, apiErr
+		}
+
+		ctx = context.WithValue(ctx, ProjectIDContextKey, projectID)
+		return handler(ctx, req)
+	}
+}
+
+// GetProjectID returns the project ID authorized by GRPCAuth
+func GetProjectID(ctx context.Context) string {
+	if projectID, ok := ctx.Value(ProjectIDContextKey).(string); ok {
+		return projectID
+	}
+	return ""
+}
+
+// firstMetadata returns the first value for key, or "" if absent
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
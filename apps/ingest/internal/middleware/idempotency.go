@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cognobserve/ingest/internal/idempotency"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-chosen idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// MaxIdempotencyKeyLength bounds the accepted key length
+	MaxIdempotencyKeyLength = 255
+)
+
+// Idempotency replays the stored response when a POST is retried with the
+// same Idempotency-Key, query and payload. Reusing a key with a different
+// payload or query (e.g. adding ?wait=true) is rejected with 409
+// idempotency_conflict. Keys are scoped per project
+// (read from projectIDHeader, so this must run after RequireProjectAccess)
+// and per canonical path, so a retry that adds a trailing slash replays.
+// Server errors are not stored, so the request can be retried.
+func Idempotency(store *idempotency.Store, projectIDHeader string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > MaxIdempotencyKeyLength {
+				response.Error(w, http.StatusBadRequest, "invalid_idempotency_key",
+					"Idempotency-Key must be at most "+strconv.Itoa(MaxIdempotencyKeyLength)+" characters")
+				return
+			}
+
+			// Buffer the body so it can be hashed and still read by the handler
+			body, err := io.ReadAll(r.Body)
+			if apiErr := BodyTooLarge(err); apiErr != nil {
+				response.WriteError(w, apiErr)
+				return
+			}
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "invalid_request_body", "invalid request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := r.Header.Get(projectIDHeader) + "\x00" + canonicalPath(r.URL.Path) + "\x00" + key
+			payloadHash := idempotency.PayloadHash(r.URL.Query(), body)
+
+			if existing, found := store.Begin(storeKey, payloadHash); found {
+				switch {
+				case existing.PayloadHash != payloadHash:
+					slog.Warn("idempotency key reused with different payload", "path", r.URL.Path)
+					response.Error(w, http.StatusConflict, "idempotency_conflict",
+						"Idempotency-Key was already used with a different request payload or query")
+				case existing.Pending:
+					w.Header().Set("Retry-After", strconv.Itoa(ConcurrencyRetryAfter))
+					response.Error(w, http.StatusConflict, "idempotency_in_progress",
+						"A request with this Idempotency-Key is still being processed")
+				default:
+					replay(w, existing)
+				}
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					store.Release(storeKey)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			// Server errors and client disconnects (499) are transient; let a retry run again
+			if rec.status < http.StatusInternalServerError && rec.status != statusClientClosedRequest {
+				store.Complete(storeKey, rec.status, rec.header, rec.body.Bytes())
+				completed = true
+			}
+		})
+	}
+}
+
+// statusClientClosedRequest is the non-standard 499 written when the client disconnected
+const statusClientClosedRequest = 499
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, entry idempotency.Entry) {
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(entry.Status)
+	_, _ = w.Write(entry.Body)
+}
+
+// responseRecorder passes a response through while keeping a copy.
+// Headers are snapshotted when the response starts, before outer
+// middleware (e.g. Compress) adds transport-level headers.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.header == nil {
+		rr.status = status
+		rr.header = rr.Header().Clone()
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.header == nil {
+		rr.header = rr.Header().Clone()
+	}
+	rr.body.Write(p)
+	return rr.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/idempotency"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestIdempotency(t *testing.T) {
+	const body = `{"name":"chat","tags":["a"]}`
+
+	type request struct {
+		target    string
+		projectID string
+		key       string
+		body      string
+	}
+	first := request{target: "/v1/traces", projectID: "proj-1", key: "key-1", body: body}
+
+	tests := []struct {
+		name         string
+		firstStatus  int // Status the handler answers the first request with
+		retry        request
+		wantStatus   int
+		wantCode     string // Error code of the retry, if any
+		wantReplayed bool
+		wantCalls    int // Handler calls across both requests
+	}{
+		{
+			name:         "replay",
+			firstStatus:  http.StatusAccepted,
+			retry:        first,
+			wantStatus:   http.StatusAccepted,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:         "replay reformatted payload",
+			firstStatus:  http.StatusAccepted,
+			retry:        request{target: "/v1/traces", projectID: "proj-1", key: "key-1", body: `{ "tags": ["a"], "name": "chat" }`},
+			wantStatus:   http.StatusAccepted,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:         "replay client error",
+			firstStatus:  http.StatusBadRequest,
+			retry:        first,
+			wantStatus:   http.StatusBadRequest,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:        "different payload",
+			firstStatus: http.StatusAccepted,
+			retry:       request{target: "/v1/traces", projectID: "proj-1", key: "key-1", body: `{"name":"other"}`},
+			wantStatus:  http.StatusConflict,
+			wantCode:    "idempotency_conflict",
+			wantCalls:   1,
+		},
+		{
+			name:        "query added",
+			firstStatus: http.StatusAccepted,
+			retry:       request{target: "/v1/traces?wait=true", projectID: "proj-1", key: "key-1", body: body},
+			wantStatus:  http.StatusConflict,
+			wantCode:    "idempotency_conflict",
+			wantCalls:   1,
+		},
+		{
+			name:        "other key",
+			firstStatus: http.StatusAccepted,
+			retry:       request{target: "/v1/traces", projectID: "proj-1", key: "key-2", body: body},
+			wantStatus:  http.StatusAccepted,
+			wantCalls:   2,
+		},
+		{
+			name:        "other project",
+			firstStatus: http.StatusAccepted,
+			retry:       request{target: "/v1/traces", projectID: "proj-2", key: "key-1", body: body},
+			wantStatus:  http.StatusAccepted,
+			wantCalls:   2,
+		},
+		{
+			name:        "other path",
+			firstStatus: http.StatusAccepted,
+			retry:       request{target: "/v1/traces/validate", projectID: "proj-1", key: "key-1", body: body},
+			wantStatus:  http.StatusAccepted,
+			wantCalls:   2,
+		},
+		{
+			name:        "server error runs again",
+			firstStatus: http.StatusServiceUnavailable,
+			retry:       first,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCalls:   2,
+		},
+		{
+			name:        "key too long",
+			firstStatus: http.StatusAccepted,
+			retry:       request{target: "/v1/traces", projectID: "proj-1", key: strings.Repeat("k", MaxIdempotencyKeyLength+1), body: body},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invalid_idempotency_key",
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := Idempotency(idempotency.NewStore(time.Hour, 100), "X-Project-ID")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("X-Call", strconv.Itoa(calls))
+				w.WriteHeader(tt.firstStatus)
+				_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `}`))
+			}))
+			send := func(req request) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(http.MethodPost, req.target, strings.NewReader(req.body))
+				r.Header.Set("X-Project-ID", req.projectID)
+				r.Header.Set(IdempotencyKeyHeader, req.key)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, r)
+				return rec
+			}
+
+			firstRec := send(first)
+			rec := send(tt.retry)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if replayed := rec.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed {
+				if rec.Body.String() != firstRec.Body.String() || rec.Header().Get("X-Call") != "1" {
+					t.Errorf("replayed %s %q, want the first response %s", rec.Header().Get("X-Call"), rec.Body, firstRec.Body)
+				}
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var errBody response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &errBody); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if errBody.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", errBody.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := Idempotency(idempotency.NewStore(time.Hour, 100), "X-Project-ID")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/traces", strings.NewReader(`{"name":"chat"}`))
+		r.Header.Set("X-Project-ID", "proj-1")
+		r.Header.Set(IdempotencyKeyHeader, "key-1")
+		return r
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest())
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != strconv.Itoa(ConcurrencyRetryAfter) {
+		t.Errorf("Retry-After = %q, want %d", got, ConcurrencyRetryAfter)
+	}
+	var errBody response.ErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &errBody); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if errBody.Code != "idempotency_in_progress" {
+		t.Errorf("code = %q, want idempotency_in_progress", errBody.Code)
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// RequireInternalSecret restricts a route to internal callers presenting
+// an accepted INTERNAL_API_SECRET in the X-Internal-Secret header
+func RequireInternalSecret(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := r.Header.Get(InternalSecretHeader)
+			if secret == "" || !cfg.IsValidInternalSecret(secret) {
+				metrics.AuthDenials.WithLabelValues("invalid_internal_secret").Inc()
+				response.Error(w, http.StatusUnauthorized, "invalid_internal_secret", "Invalid internal secret")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksFetchTimeout bounds a single JWKS download
+	jwksFetchTimeout = 5 * time.Second
+
+	// jwksMinRefreshInterval rate-limits refreshes triggered by unknown key IDs,
+	// so tokens with random kids can't hammer the identity provider
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// ErrJWKSKeyNotFound is returned when no cached or freshly fetched key matches a kid
+var ErrJWKSKeyNotFound = errors.New("no matching JWKS key")
+
+// JWKS caches the public keys published at a JWKS URL.
+// Keys are refreshed periodically by Run and on demand when a token
+// references an unknown key ID. Lookups fail closed: if the keys can't be
+// fetched and none is cached for the kid, verification fails.
+type JWKS struct {
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]jwk
+	lastAttempt time.Time
+
+	refreshMu sync.Mutex // Serializes fetches
+}
+
+// jwk is a parsed public key with its optional algorithm constraint
+type jwk struct {
+	alg string
+	key any // *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+// NewJWKS creates a key cache for url, refreshed every refreshInterval
+func NewJWKS(url string, refreshInterval time.Duration) *JWKS {
+	return &JWKS{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		keys:            make(map[string]jwk),
+	}
+}
+
+// Run fetches the keys immediately and then on every refresh interval until ctx is done
+func (j *JWKS) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.refresh(ctx, false); err != nil {
+			slog.Warn("JWKS refresh failed", "url", j.url, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Key returns the public key for kid, refreshing once if it isn't cached.
+// alg is the token's signing algorithm; keys pinned to another algorithm don't match.
+func (j *JWKS) Key(kid, alg string) (any, error) {
+	if key, ok := j.lookup(kid, alg); ok {
+		return key, nil
+	}
+
+	// Unknown kid: the provider may have rotated keys
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	if err := j.refresh(ctx, true); err != nil {
+		slog.Warn("JWKS refresh failed", "url", j.url, "error", err)
+	}
+
+	if key, ok := j.lookup(kid, alg); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w for kid %q", ErrJWKSKeyNotFound, kid)
+}
+
+func (j *JWKS) lookup(kid, alg string) (any, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	k, ok := j.keys[kid]
+	if !ok || (k.alg != "" && k.alg != alg) {
+		return nil, false
+	}
+	return k.key, true
+}
+
+// refresh downloads and replaces the key set. On-demand refreshes are
+// skipped if another fetch happened within jwksMinRefreshInterval.
+// On failure the previously cached keys are kept.
+func (j *JWKS) refresh(ctx context.Context, onDemand bool) error {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+
+	j.mu.RLock()
+	recent := time.Since(j.lastAttempt) < jwksMinRefreshInterval
+	j.mu.RUnlock()
+	if onDemand && recent {
+		return nil
+	}
+
+	j.mu.Lock()
+	j.lastAttempt = time.Now()
+	j.mu.Unlock()
+
+	keys, err := j.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.mu.Unlock()
+	return nil
+}
+
+type jwkSet struct {
+	Keys []jwkJSON `json:"keys"`
+}
+
+type jwkJSON struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch downloads and parses the key set, skipping keys it can't use
+func (j *JWKS) fetch(ctx context.Context) (map[string]jwk, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("JWKS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]jwk, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			slog.Warn("skipping unusable JWKS key", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = jwk{alg: k.Alg, key: key}
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA or EC public key
+func (k jwkJSON) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		return ecPublicKey(k.Crv, k.X, k.Y)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// ecPublicKey decodes an EC point, rejecting points not on the curve
+func ecPublicKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+
+	xBytes, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+
+	// Validate via crypto/ecdh, which checks the point is on the curve
+	size := (curve.Params().BitSize + 7) / 8
+	if len(xBytes) != size || len(yBytes) != size {
+		return nil, errors.New("invalid coordinate length")
+	}
+	point := append([]byte{4}, append(xBytes, yBytes...)...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid point: %w", err)
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/cognobserve/ingest/internal/config"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid, alg string, key *rsa.PublicKey) jwkJSON {
+	return jwkJSON{Kid: kid, Kty: "RSA", Alg: alg, Use: "sig", N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jwkJSON {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return jwkJSON{Kid: kid, Kty: "EC", Crv: key.Curve.Params().Name, X: b64(key.X.FillBytes(make([]byte, size))), Y: b64(key.Y.FillBytes(make([]byte, size)))}
+}
+
+// serveJWKS publishes keys and counts how often they are fetched
+func serveJWKS(t *testing.T, keys *atomic.Value, fetches *atomic.Int32) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(jwkSet{Keys: keys.Load().([]jwkJSON)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key crypto.Signer) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestTokenVerifierJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		wantErr bool
+	}{
+		{name: "rsa", token: func(t *testing.T) string { return signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey) }},
+		{name: "ecdsa", token: func(t *testing.T) string { return signToken(t, jwt.SigningMethodES256, "ec-1", ecKey) }},
+		{
+			name:    "unknown kid",
+			token:   func(t *testing.T) string { return signToken(t, jwt.SigningMethodRS256, "rsa-2", rsaKey) },
+			wantErr: true,
+		},
+		{
+			name:    "missing kid",
+			token:   func(t *testing.T) string { return signToken(t, jwt.SigningMethodRS256, "", rsaKey) },
+			wantErr: true,
+		},
+		{
+			name:    "wrong signing key",
+			token:   func(t *testing.T) string { return signToken(t, jwt.SigningMethodRS256, "rsa-1", otherKey) },
+			wantErr: true,
+		},
+		{
+			// rsa-1 is pinned to RS256
+			name:    "algorithm not pinned to the key",
+			token:   func(t *testing.T) string { return signToken(t, jwt.SigningMethodRS384, "rsa-1", rsaKey) },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys atomic.Value
+			var fetches atomic.Int32
+			keys.Store([]jwkJSON{rsaJWK("rsa-1", "RS256", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey)})
+
+			v := NewTokenVerifier(&config.Config{
+				JWTSharedSecret:        "test-jwt-secret-0123456789abcdef0123",
+				JWTAllowedAlgorithms:   []string{"RS256", "RS384", "ES256"},
+				JWTJWKSURL:             serveJWKS(t, &keys, &fetches),
+				JWTJWKSRefreshInterval: time.Hour,
+				JWTProjectsClaim:       "projects",
+			})
+
+			claims, err := v.Verify(tt.token(t))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Verify() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "user-1" {
+				t.Errorf("Subject = %q, want user-1", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestJWKSKeyRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys atomic.Value
+	var fetches atomic.Int32
+	keys.Store([]jwkJSON{rsaJWK("old", "", &oldKey.PublicKey)})
+	j := NewJWKS(serveJWKS(t, &keys, &fetches), time.Hour)
+
+	if _, err := j.Key("old", "RS256"); err != nil {
+		t.Fatalf("Key(old): %v", err)
+	}
+
+	// A recent fetch rate-limits on-demand refreshes, so a rotated key
+	// isn't seen until the next periodic refresh
+	keys.Store([]jwkJSON{rsaJWK("new", "", &newKey.PublicKey)})
+	if _, err := j.Key("new", "RS256"); err == nil {
+		t.Fatal("Key(new) succeeded within the refresh rate limit")
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetched %d times, want 1", got)
+	}
+
+	j.mu.Lock()
+	j.lastAttempt = time.Now().Add(-jwksMinRefreshInterval)
+	j.mu.Unlock()
+	if _, err := j.Key("new", "RS256"); err != nil {
+		t.Fatalf("Key(new) after the rate limit: %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetched %d times, want 2", got)
+	}
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offCurve := ecJWK("ec", &ecKey.PublicKey)
+	offCurve.Y = offCurve.X
+
+	tests := []struct {
+		name    string
+		key     jwkJSON
+		wantErr bool
+	}{
+		{name: "ec", key: ecJWK("ec", &ecKey.PublicKey)},
+		{name: "point off the curve", key: offCurve, wantErr: true},
+		{name: "unsupported curve", key: jwkJSON{Kty: "EC", Crv: "P-192"}, wantErr: true},
+		{name: "empty rsa exponent", key: jwkJSON{Kty: "RSA", N: b64([]byte{1, 2, 3})}, wantErr: true},
+		{name: "unsupported key type", key: jwkJSON{Kty: "oct"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.key.publicKey()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("publicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cognobserve/ingest/internal/maintenance"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// Maintenance rejects requests with 503 maintenance and a Retry-After hint
+// while maintenance mode is on, so clients back off and retry
+func Maintenance(mode *maintenance.Mode, retryAfter time.Duration) func(http.Handler) http.Handler {
+	retryAfterSeconds := strconv.Itoa(int(retryAfter.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if mode.Enabled() {
+				w.Header().Set("Retry-After", retryAfterSeconds)
+				response.Error(w, http.StatusServiceUnavailable, "maintenance", "Service under maintenance, retry later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GRPCMaintenance rejects calls with Unavailable while maintenance mode is on;
+// gRPC clients retry Unavailable by default
+func GRPCMaintenance(mode *maintenance.Mode) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if mode.Enabled() {
+			return nil, status.Error(codes.Unavailable, "service under maintenance, retry later")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// Project roles carried in JWT claims, matching the ProjectRole enum in the web app
+const (
+	RoleViewer = "VIEWER"
+	RoleMember = "MEMBER"
+	RoleAdmin  = "ADMIN"
+	RoleOwner  = "OWNER"
+)
+
+// roleRanks orders roles; each role holds the permissions of those below it
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// routeRoles sets the project role for routes whose method doesn't say
+// whether they write, keyed by method and canonical path
+var routeRoles = map[string]string{
+	// Validation checks a payload without ingesting it
+	http.MethodPost + " /v1/traces/validate": RoleViewer,
+}
+
+// requiredRole is the project role needed for a request: the route's entry in
+// routeRoles, else VIEWER for reads and MEMBER for anything that writes
+func requiredRole(r *http.Request) string {
+	if role, ok := routeRoles[r.Method+" "+canonicalPath(r.URL.Path)]; ok {
+		return role
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RoleViewer
+	default:
+		return RoleMember
+	}
+}
+
+// checkRole rejects a project role below required with 403 insufficient_role.
+// Unknown roles rank below every known role.
+func checkRole(actual, required string) *response.APIError {
+	if roleRanks[strings.ToUpper(actual)] >= roleRanks[required] {
+		return nil
+	}
+	apiErr := response.NewError(http.StatusForbidden, "insufficient_role",
+		fmt.Sprintf("this request requires the %s role on the project (have %s)", required, actual))
+	apiErr.Details = map[string]any{"required_role": required, "actual_role": actual}
+	return apiErr
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestRequireProjectAccessRoles(t *testing.T) {
+	cfg := &config.Config{ProjectIDRegexp: regexp.MustCompile(`^(?:[A-Za-z0-9_-]{1,64})$`)}
+	member := func(role string) func(context.Context) context.Context {
+		return func(ctx context.Context) context.Context {
+			ctx = context.WithValue(ctx, AuthMethodContextKey, AuthMethodJWT)
+			ctx = context.WithValue(ctx, UserContextKey, "user-1")
+			return context.WithValue(ctx, ProjectsContextKey, []ProjectAccess{{ID: "proj-1", Role: role}})
+		}
+	}
+	apiKey := func(scopes []string) func(context.Context) context.Context {
+		return func(ctx context.Context) context.Context {
+			return withAPIKey(ctx, &validateKeyResponse{Valid: true, ProjectID: "proj-1", Scopes: scopes})
+		}
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string // Defaults to /v1/traces
+		ctx      func(context.Context) context.Context
+		wantCode string // Empty when access is granted
+	}{
+		{name: "viewer reads", method: http.MethodGet, ctx: member(RoleViewer)},
+		{name: "viewer writes", method: http.MethodPost, ctx: member(RoleViewer), wantCode: "insufficient_role"},
+		{name: "member writes", method: http.MethodPost, ctx: member(RoleMember)},
+		{name: "owner patches", method: http.MethodPatch, ctx: member(RoleOwner)},
+		{name: "lower-case role", method: http.MethodPost, ctx: member("admin")},
+		{name: "unknown role reads", method: http.MethodGet, ctx: member("GUEST"), wantCode: "insufficient_role"},
+		{name: "unscoped key writes", method: http.MethodPost, ctx: apiKey(nil)},
+		{name: "read-only key reads", method: http.MethodGet, ctx: apiKey([]string{ScopeTracesRead})},
+		{name: "read-only key writes", method: http.MethodPost, ctx: apiKey([]string{ScopeTracesRead}), wantCode: "insufficient_scope"},
+		// Validation ingests nothing, so it is a read whatever the method
+		{name: "viewer validates", method: http.MethodPost, path: "/v1/traces/validate", ctx: member(RoleViewer)},
+		{name: "viewer validates with slash", method: http.MethodPost, path: "/v1/traces/validate/", ctx: member(RoleViewer)},
+		{name: "read-only key validates", method: http.MethodPost, path: "/v1/traces/validate", ctx: apiKey([]string{ScopeTracesRead})},
+		{name: "unknown role validates", method: http.MethodPost, path: "/v1/traces/validate", ctx: member("GUEST"), wantCode: "insufficient_role"},
+		{name: "viewer posts batch", method: http.MethodPost, path: "/v1/traces/batch", ctx: member(RoleViewer), wantCode: "insufficient_role"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := RequireProjectAccess(cfg, "X-Project-ID", nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				called = true
+			}))
+
+			path := tt.path
+			if path == "" {
+				path = "/v1/traces"
+			}
+			req := httptest.NewRequest(tt.method, path, nil)
+			req.Header.Set("X-Project-ID", "proj-1")
+			req = req.WithContext(tt.ctx(req.Context()))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantCode == "" {
+				if !called {
+					t.Fatalf("access denied: %d %s", rec.Code, rec.Body)
+				}
+				return
+			}
+			if called {
+				t.Fatal("next handler was called")
+			}
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+			}
+			var body response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
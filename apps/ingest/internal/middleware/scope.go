@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/audit"
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// API key scopes checked by RequireScope and HasScope
+const (
+	ScopeTracesRead  = "traces:read"
+	ScopeTracesWrite = "traces:write"
+	ScopeBackfill    = "backfill"
+)
+
+// RequireScope rejects API keys that weren't granted scope with 403 insufficient_scope.
+// Keys validated without a scope list predate scopes and hold every scope.
+// JWT users are limited by project membership alone (see RequireProjectAccess).
+// Denials are recorded to auditLog.
+func RequireScope(scope string, auditLog *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if HasScope(r.Context(), scope) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			auditHTTP(auditLog, r, audit.Event{
+				Outcome:   audit.OutcomeFailure,
+				Method:    GetAuthMethod(r.Context()),
+				ProjectID: GetAPIKeyProjectID(r.Context()),
+				Reason:    "insufficient_scope",
+			})
+			metrics.AuthDenials.WithLabelValues("insufficient_scope").Inc()
+			response.Error(w, http.StatusForbidden, "insufficient_scope", fmt.Sprintf("API key lacks the %s scope", scope))
+		})
+	}
+}
+
+// HasScope reports whether the caller may use scope
+func HasScope(ctx context.Context, scope string) bool {
+	if !IsAPIKeyAuthenticated(ctx) {
+		return true
+	}
+	scopes, _ := ctx.Value(APIKeyScopesKey).([]string)
+	if scopes == nil {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RedirectSlashes redirects paths with a trailing slash to the canonical
+// path. Unlike chi's RedirectSlashes it answers 308, so clients repeat
+// POST and PATCH requests with their body instead of switching to GET.
+func RedirectSlashes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if len(path) <= 1 || !strings.HasSuffix(path, "/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Keep the redirect on this host: "//evil.example/" must not become
+		// a protocol-relative URL
+		target := "/" + strings.TrimLeft(strings.TrimRight(path, "/"), "/")
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
+
+// canonicalPath trims trailing slashes from a request path. chi's
+// StripSlashes only rewrites the routing path, so middleware comparing
+// r.URL.Path against a route must canonicalize it first.
+func canonicalPath(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return path
+}
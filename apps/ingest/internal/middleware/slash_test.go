@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectSlashes(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		wantLocation string // Empty when the request passes through
+	}{
+		{name: "canonical", target: "/v1/traces"},
+		{name: "root", target: "/"},
+		{name: "trailing slash", target: "/v1/traces/", wantLocation: "/v1/traces"},
+		{name: "query kept", target: "/v1/traces/?wait=true", wantLocation: "/v1/traces?wait=true"},
+		{name: "several slashes", target: "/v1/traces///", wantLocation: "/v1/traces"},
+		// Must not become the protocol-relative //evil.example
+		{name: "leading double slash", target: "//evil.example/", wantLocation: "/evil.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := RedirectSlashes(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "http://ingest.local"+tt.target, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantLocation == "" {
+				if !called {
+					t.Errorf("next handler not called, status %d", rec.Code)
+				}
+				return
+			}
+			if called {
+				t.Error("next handler was called")
+			}
+			// 308 keeps the method and body
+			if rec.Code != http.StatusPermanentRedirect {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusPermanentRedirect)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// SlowRequests logs a warning for each request taking longer than
+// threshold, so tail latency shows up in log-based alerting without
+// logging every request. The project is read from the X-Project-ID
+// header, as authentication runs further down the chain. A threshold
+// <= 0 disables the log.
+func SlowRequests(threshold time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if threshold <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			started := time.Now()
+			next.ServeHTTP(ww, r)
+
+			elapsed := time.Since(started)
+			if elapsed <= threshold {
+				return
+			}
+			slog.Warn("slow request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"project_id", r.Header.Get(ProjectIDHeader),
+				"status", ww.Status(),
+				"duration", elapsed,
+				"threshold", threshold,
+				"request_id", chimw.GetReqID(r.Context()),
+				"correlation_id", GetCorrelationID(r.Context()),
+			)
+		})
+	}
+}
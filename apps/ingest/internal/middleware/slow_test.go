@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlowRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		delay     time.Duration
+		wantLog   bool
+	}{
+		{name: "disabled", threshold: 0, delay: 20 * time.Millisecond},
+		{name: "fast", threshold: time.Second},
+		{name: "slow", threshold: 10 * time.Millisecond, delay: 20 * time.Millisecond, wantLog: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			previous := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+			t.Cleanup(func() { slog.SetDefault(previous) })
+
+			handler := SlowRequests(tt.threshold)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				time.Sleep(tt.delay)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", nil)
+			req.Header.Set(ProjectIDHeader, "proj-1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusAccepted {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusAccepted)
+			}
+			if !tt.wantLog {
+				if logs.Len() != 0 {
+					t.Errorf("logged %q, want nothing", logs.String())
+				}
+				return
+			}
+
+			var line map[string]any
+			if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+				t.Fatalf("decode log line %q: %v", logs.String(), err)
+			}
+			want := map[string]any{
+				"level":      "WARN",
+				"msg":        "slow request",
+				"method":     http.MethodPost,
+				"path":       "/v1/traces",
+				"project_id": "proj-1",
+				"status":     float64(http.StatusAccepted),
+			}
+			for key, value := range want {
+				if line[key] != value {
+					t.Errorf("%s = %v, want %v", key, line[key], value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// RequestTimeoutHeader carries a client's own timeout for the request, in
+// milliseconds, e.g. the time left on its context
+const RequestTimeoutHeader = "X-Request-Timeout-Ms"
+
+// timeoutBody is the error envelope sent when a request times out
+var timeoutBody = func() string {
+	body, _ := json.Marshal(response.NewErrorBody(response.ErrorDetail{
+		Code:    "request_timeout",
+		Message: "Request timed out",
+	}))
+	return string(body)
+}()
+
+// RequestTimeout bounds request handling with http.TimeoutHandler, which
+// answers 503 request_timeout even if the handler ignores its context.
+// Requests with ?wait=true get the longer wait timeout, since they block
+// until their workflow finishes. A timeout <= 0 disables the bound.
+// Requests to exemptPaths, with or without a trailing slash, pass
+// through unbounded: TimeoutHandler buffers the response and can't flush,
+// so streaming routes bound themselves.
+func RequestTimeout(standard, wait time.Duration, exemptPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		standardHandler := http.TimeoutHandler(next, standard, timeoutBody)
+		waitHandler := http.TimeoutHandler(next, wait, timeoutBody)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h, d := standardHandler, standard
+			if waiting, _ := ParseWait(r); waiting {
+				h, d = waitHandler, wait
+			}
+			if d <= 0 || slices.Contains(exemptPaths, canonicalPath(r.URL.Path)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.ServeHTTP(timeoutWriter{w}, r)
+		})
+	}
+}
+
+// ClientDeadline applies the client's X-Request-Timeout-Ms to the request
+// context, so work bounded by the context, such as trace workflow runs
+// with WORKFLOW_TIMEOUT_FROM_DEADLINE, stops when the client stops
+// waiting. A tighter server deadline from RequestTimeout still applies.
+// A value that isn't a positive integer is rejected.
+func ClientDeadline() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := r.Header.Get(RequestTimeoutHeader)
+			if raw == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ms, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || ms <= 0 || ms > int64(time.Duration(math.MaxInt64)/time.Millisecond) {
+				response.Error(w, http.StatusBadRequest, "invalid_request_timeout",
+					RequestTimeoutHeader+" must be a positive number of milliseconds")
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(ms)*time.Millisecond)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// timeoutWriter labels TimeoutHandler's timeout body as JSON. Handler
+// responses already carry their own Content-Type and are left alone.
+type timeoutWriter struct {
+	http.ResponseWriter
+}
+
+func (w timeoutWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// ParseWait parses the ?wait query parameter with strconv.ParseBool, so
+// the timeout choice and the handler agree on which requests wait. An
+// absent parameter is false; an invalid one returns the parse error.
+func ParseWait(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("wait")
+	if raw == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(raw)
+}
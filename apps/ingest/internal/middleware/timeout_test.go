@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	const short, long = 20 * time.Millisecond, time.Second
+
+	tests := []struct {
+		name          string
+		standard      time.Duration
+		target        string
+		work          time.Duration // How long the handler takes
+		wantStatus    int
+		wantFlushable bool
+	}{
+		{name: "within timeout", standard: long, target: "/v1/traces", wantStatus: http.StatusOK},
+		{name: "timed out", standard: short, target: "/v1/traces", work: 10 * short, wantStatus: http.StatusServiceUnavailable},
+		{name: "wait uses the longer timeout", standard: short, target: "/v1/traces?wait=true", work: 2 * short, wantStatus: http.StatusOK},
+		// Any value strconv.ParseBool accepts, as the handler does
+		{name: "wait=1 uses the longer timeout", standard: short, target: "/v1/traces?wait=1", work: 2 * short, wantStatus: http.StatusOK},
+		{name: "wait=false uses the standard timeout", standard: short, target: "/v1/traces?wait=false", work: 10 * short, wantStatus: http.StatusServiceUnavailable},
+		{name: "disabled", target: "/v1/traces", work: 2 * short, wantStatus: http.StatusOK, wantFlushable: true},
+		// Streamed responses are neither buffered nor cut off
+		{name: "exempt path", standard: short, target: "/v1/traces/ndjson", work: 2 * short, wantStatus: http.StatusOK, wantFlushable: true},
+		{name: "exempt path with trailing slash", standard: short, target: "/v1/traces/ndjson/", work: 2 * short, wantStatus: http.StatusOK, wantFlushable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// TimeoutHandler runs the handler on its own goroutine
+			flushable := make(chan bool, 1)
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, ok := w.(http.Flusher)
+				flushable <- ok
+				select {
+				case <-time.After(tt.work):
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := RequestTimeout(tt.standard, long, "/v1/traces/ndjson")(next)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := <-flushable; got != tt.wantFlushable {
+				t.Errorf("handler writer flushable = %v, want %v", got, tt.wantFlushable)
+			}
+			if tt.wantStatus != http.StatusServiceUnavailable {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var body response.ErrorBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", rec.Body, err)
+			}
+			if body.Code != "request_timeout" {
+				t.Errorf("code = %q, want request_timeout", body.Code)
+			}
+		})
+	}
+}
+
+// TestRequestTimeoutStripSlashes routes through chi the way the server
+// does with TRAILING_SLASH=strip: StripSlashes rewrites only the routing
+// path, so r.URL.Path still ends in a slash when RequestTimeout sees it.
+func TestRequestTimeoutStripSlashes(t *testing.T) {
+	flushable := make(chan bool, 1)
+	r := chi.NewRouter()
+	r.Use(chimw.StripSlashes)
+	r.Route("/v1", func(r chi.Router) {
+		r.Use(RequestTimeout(20*time.Millisecond, time.Second, "/v1/traces/ndjson"))
+		r.Post("/traces/ndjson", func(w http.ResponseWriter, r *http.Request) {
+			_, ok := w.(http.Flusher)
+			flushable <- ok
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/traces/ndjson/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !<-flushable {
+		t.Error("handler writer is not flushable, want the NDJSON route exempt from the timeout")
+	}
+}
+
+func TestClientDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string        // X-Request-Timeout-Ms; empty sends none
+		server       time.Duration // Deadline already on the context; zero means none
+		wantDeadline time.Duration // Time left on the handler's context; zero expects none
+		wantStatus   int
+	}{
+		{name: "no header", wantStatus: http.StatusOK},
+		{name: "no header keeps the server deadline", server: time.Minute, wantDeadline: time.Minute, wantStatus: http.StatusOK},
+		{name: "client deadline", header: "10000", wantDeadline: 10 * time.Second, wantStatus: http.StatusOK},
+		{name: "client deadline sooner than the server's", header: "10000", server: time.Minute, wantDeadline: 10 * time.Second, wantStatus: http.StatusOK},
+		{name: "server deadline sooner than the client's", header: "120000", server: time.Minute, wantDeadline: time.Minute, wantStatus: http.StatusOK},
+		{name: "zero", header: "0", wantStatus: http.StatusBadRequest},
+		{name: "negative", header: "-5", wantStatus: http.StatusBadRequest},
+		{name: "not a number", header: "10s", wantStatus: http.StatusBadRequest},
+		{name: "overflows a duration", header: "9223372036854775807", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotDeadline time.Duration
+			handler := ClientDeadline()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if deadline, ok := r.Context().Deadline(); ok {
+					gotDeadline = time.Until(deadline).Round(time.Second)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestTimeoutHeader, tt.header)
+			}
+			if tt.server > 0 {
+				ctx, cancel := context.WithTimeout(req.Context(), tt.server)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusBadRequest {
+				var body response.ErrorBody
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("decode body %q: %v", rec.Body, err)
+				}
+				if body.Code != "invalid_request_timeout" {
+					t.Errorf("code = %q, want invalid_request_timeout", body.Code)
+				}
+				return
+			}
+			if gotDeadline != tt.wantDeadline {
+				t.Errorf("deadline in %v, want %v", gotDeadline, tt.wantDeadline)
+			}
+		})
+	}
+}
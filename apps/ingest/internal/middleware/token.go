@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+// TokenVerifier validates user JWTs. It is built once at startup and shared
+// by the HTTP middleware and the gRPC interceptor; it is safe for concurrent use.
+// HMAC tokens are checked with the shared secret; RSA and ECDSA tokens with
+// keys from JWT_JWKS_URL, looked up by the token's kid.
+type TokenVerifier struct {
+	hmacKey       []byte
+	jwks          *JWKS // nil unless JWT_JWKS_URL is set
+	parser        *jwt.Parser
+	projectsClaim []string // Path to the project memberships claim
+}
+
+// errInvalidProjectsClaim reports a projects claim that isn't a list of memberships
+var errInvalidProjectsClaim = errors.New("invalid projects claim")
+
+// NewTokenVerifier creates a verifier accepting cfg.JWTAllowedAlgorithms.
+// Issuer and audience are enforced when configured.
+func NewTokenVerifier(cfg *config.Config) *TokenVerifier {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(cfg.JWTAllowedAlgorithms)}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+
+	v := &TokenVerifier{
+		hmacKey:       []byte(cfg.JWTSharedSecret),
+		parser:        jwt.NewParser(opts...),
+		projectsClaim: strings.Split(cfg.JWTProjectsClaim, "."),
+	}
+	if cfg.JWTJWKSURL != "" {
+		v.jwks = NewJWKS(cfg.JWTJWKSURL, cfg.JWTJWKSRefreshInterval)
+	}
+	return v
+}
+
+// Run keeps the JWKS key cache fresh until ctx is done. It returns
+// immediately when no JWKS URL is configured.
+func (v *TokenVerifier) Run(ctx context.Context) {
+	if v.jwks != nil {
+		v.jwks.Run(ctx)
+	}
+}
+
+// Verify parses and validates a raw token and returns its claims
+func (v *TokenVerifier) Verify(tokenString string) (*UserClaims, error) {
+	token, err := v.parser.ParseWithClaims(tokenString, &UserClaims{}, v.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if claims.Projects, err = v.projects(claims.raw); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// projects reads project memberships from the configured claim path.
+// A missing claim means no memberships; a claim of the wrong shape, or a
+// path running through a non-object, is an errInvalidProjectsClaim.
+func (v *TokenVerifier) projects(raw map[string]any) ([]ProjectAccess, error) {
+	path := strings.Join(v.projectsClaim, ".")
+
+	var value any = raw
+	for i, name := range v.projectsClaim {
+		object, ok := value.(map[string]any)
+		if !ok {
+			parent := strings.Join(v.projectsClaim[:i], ".")
+			return nil, fmt.Errorf("%w: %q in %q is not an object", errInvalidProjectsClaim, parent, path)
+		}
+		if value, ok = object[name]; !ok {
+			return nil, nil
+		}
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	// Round-trip through JSON to map the claim onto ProjectAccess
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", errInvalidProjectsClaim, path, err)
+	}
+	var projects []ProjectAccess
+	if err := json.Unmarshal(data, &projects); err != nil {
+		return nil, fmt.Errorf("%w: %q must be an array of {\"id\", \"role\"} objects", errInvalidProjectsClaim, path)
+	}
+	return projects, nil
+}
+
+// keyFunc selects the verification key for a token's signing method
+func (v *TokenVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return v.hmacKey, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		if v.jwks == nil {
+			return nil, fmt.Errorf("%w: no JWKS configured for %s", jwt.ErrSignatureInvalid, token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("%w: token has no kid", jwt.ErrSignatureInvalid)
+		}
+		return v.jwks.Key(kid, token.Method.Alg())
+	default:
+		return nil, fmt.Errorf("%w: unsupported signing method %s", jwt.ErrSignatureInvalid, token.Method.Alg())
+	}
+}
+
+// verifyBearer validates an "Authorization: Bearer <token>" value and returns its claims
+func (v *TokenVerifier) verifyBearer(authHeader string) (*UserClaims, *response.APIError) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return nil, response.NewError(http.StatusUnauthorized, "invalid_authorization_header", "Invalid authorization header format")
+	}
+
+	claims, err := v.Verify(parts[1])
+	if errors.Is(err, errInvalidProjectsClaim) {
+		return nil, response.NewError(http.StatusUnauthorized, "invalid_token_claims", "Invalid token claims: "+err.Error())
+	}
+	// Tokens minted for another service with the same secret or keys
+	if errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		return nil, response.NewError(http.StatusUnauthorized, "token_wrong_issuer", "Token was not issued by the expected issuer")
+	}
+	if errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		return nil, response.NewError(http.StatusUnauthorized, "token_wrong_audience", "Token is not intended for this service")
+	}
+	if err != nil {
+		return nil, response.NewError(http.StatusUnauthorized, "invalid_token", "Invalid token")
+	}
+	if claims.Subject == "" {
+		return nil, response.NewError(http.StatusUnauthorized, "invalid_token_claims", "Invalid token claims")
+	}
+	return claims, nil
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/cognobserve/ingest/internal/config"
+)
+
+const testJWTSecret = "test-jwt-secret-0123456789abcdef0123"
+
+// newTestVerifier creates an HS256 TokenVerifier, applying mutate to its config
+func newTestVerifier(mutate func(*config.Config)) *TokenVerifier {
+	cfg := &config.Config{
+		JWTSharedSecret:      testJWTSecret,
+		JWTAllowedAlgorithms: []string{"HS256"},
+		JWTProjectsClaim:     "projects",
+	}
+	if mutate != nil {
+		mutate(cfg)
+	}
+	return NewTokenVerifier(cfg)
+}
+
+// signHMACToken signs claims with the test secret, adding a subject and expiry
+func signHMACToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	all := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	for name, value := range claims {
+		all[name] = value
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, all).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyBearerProjectsClaim(t *testing.T) {
+	memberships := []any{map[string]any{"id": "proj-1", "role": "ADMIN"}}
+
+	tests := []struct {
+		name        string
+		claimPath   string // JWT_PROJECTS_CLAIM
+		claims      jwt.MapClaims
+		want        []ProjectAccess
+		wantMessage string // Substring of an invalid_token_claims message; empty expects success
+	}{
+		{
+			name:      "flat",
+			claimPath: "projects",
+			claims:    jwt.MapClaims{"projects": memberships},
+			want:      []ProjectAccess{{ID: "proj-1", Role: RoleAdmin}},
+		},
+		{
+			name:      "nested",
+			claimPath: "app_metadata.projects",
+			claims:    jwt.MapClaims{"app_metadata": map[string]any{"projects": memberships}},
+			want:      []ProjectAccess{{ID: "proj-1", Role: RoleAdmin}},
+		},
+		{name: "missing", claimPath: "projects"},
+		{name: "missing parent", claimPath: "app_metadata.projects"},
+		{name: "null", claimPath: "projects", claims: jwt.MapClaims{"projects": nil}},
+		{
+			name:        "not an array",
+			claimPath:   "projects",
+			claims:      jwt.MapClaims{"projects": "proj-1"},
+			wantMessage: `"projects" must be an array`,
+		},
+		{
+			name:        "parent not an object",
+			claimPath:   "app_metadata.projects",
+			claims:      jwt.MapClaims{"app_metadata": []any{"projects"}},
+			wantMessage: `"app_metadata" in "app_metadata.projects" is not an object`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(func(cfg *config.Config) { cfg.JWTProjectsClaim = tt.claimPath })
+
+			claims, apiErr := v.verifyBearer("Bearer " + signHMACToken(t, tt.claims))
+			if tt.wantMessage != "" {
+				if apiErr == nil || apiErr.Code != "invalid_token_claims" {
+					t.Fatalf("verifyBearer() error = %v, want invalid_token_claims", apiErr)
+				}
+				if !strings.Contains(apiErr.Message, tt.wantMessage) {
+					t.Errorf("message = %q, want it to contain %q", apiErr.Message, tt.wantMessage)
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("verifyBearer: %v", apiErr)
+			}
+			if !reflect.DeepEqual(claims.Projects, tt.want) {
+				t.Errorf("Projects = %+v, want %+v", claims.Projects, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyBearerIssuerAudience(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuer   string // JWT_ISSUER
+		audience string // JWT_AUDIENCE
+		claims   jwt.MapClaims
+		wantCode string // Empty expects success
+	}{
+		{name: "unchecked", claims: jwt.MapClaims{"iss": "anyone", "aud": "anything"}},
+		{
+			name:     "matching",
+			issuer:   "https://auth.example.com",
+			audience: "cognobserve-ingest",
+			claims:   jwt.MapClaims{"iss": "https://auth.example.com", "aud": []string{"other", "cognobserve-ingest"}},
+		},
+		{
+			name:     "wrong issuer",
+			issuer:   "https://auth.example.com",
+			claims:   jwt.MapClaims{"iss": "https://other.example.com"},
+			wantCode: "token_wrong_issuer",
+		},
+		{
+			name:     "wrong audience",
+			audience: "cognobserve-ingest",
+			claims:   jwt.MapClaims{"aud": "billing-service"},
+			wantCode: "token_wrong_audience",
+		},
+		{name: "missing issuer", issuer: "https://auth.example.com", wantCode: "invalid_token"},
+		{name: "missing audience", audience: "cognobserve-ingest", wantCode: "invalid_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(func(cfg *config.Config) {
+				cfg.JWTIssuer = tt.issuer
+				cfg.JWTAudience = tt.audience
+			})
+
+			claims, apiErr := v.verifyBearer("Bearer " + signHMACToken(t, tt.claims))
+			if tt.wantCode == "" {
+				if apiErr != nil {
+					t.Fatalf("verifyBearer: %v", apiErr)
+				}
+				if claims.Subject != "user-1" {
+					t.Errorf("Subject = %q, want user-1", claims.Subject)
+				}
+				return
+			}
+			if apiErr == nil || apiErr.Code != tt.wantCode {
+				t.Fatalf("verifyBearer() error = %v, want %s", apiErr, tt.wantCode)
+			}
+			if apiErr.Status != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", apiErr.Status, http.StatusUnauthorized)
+			}
+		})
+	}
+}
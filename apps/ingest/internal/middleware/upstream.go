@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+
+	"github.com/cognobserve/ingest/internal/audit"
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/metrics"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+const (
+	// UpstreamSecretHeader carries the secret shared with a trusted API gateway
+	UpstreamSecretHeader = "X-Upstream-Auth-Secret"
+
+	// ForwardedProjectIDHeader is the project a trusted gateway authenticated the caller for
+	ForwardedProjectIDHeader = "X-Forwarded-Project-ID"
+)
+
+// UpstreamProjectIDKey is the context key for the project vouched for by a trusted gateway
+const UpstreamProjectIDKey contextKey = "upstream_project_id"
+
+// UpstreamAuth trusts authentication already done by an API gateway, when
+// TRUST_UPSTREAM_AUTH is set. Requests carrying UPSTREAM_AUTH_SECRET in
+// X-Upstream-Auth-Secret and a project in X-Forwarded-Project-ID skip API
+// key and JWT validation and are bound to that project. A wrong secret is
+// rejected outright rather than falling back to other methods, so a
+// spoofing attempt never passes silently. Requests without the secret
+// header authenticate as usual. Decisions are recorded to auditLog.
+func UpstreamAuth(cfg *config.Config, auditLog *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := r.Header.Get(UpstreamSecretHeader)
+			if !cfg.TrustUpstreamAuth || secret == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// The secret must not reach handlers or anything they log
+			r.Header.Del(UpstreamSecretHeader)
+
+			projectID := r.Header.Get(ForwardedProjectIDHeader)
+			event := audit.Event{Method: AuthMethodUpstream, ProjectID: projectID}
+
+			var apiErr *response.APIError
+			switch {
+			case subtle.ConstantTimeCompare([]byte(secret), []byte(cfg.UpstreamAuthSecret)) != 1:
+				slog.Warn("rejected request with invalid upstream auth secret", "remote_addr", r.RemoteAddr)
+				apiErr = response.NewError(http.StatusUnauthorized, "invalid_upstream_secret", "Invalid upstream auth secret")
+			case projectID == "":
+				apiErr = response.NewError(http.StatusBadRequest, "missing_project_id", "Missing "+ForwardedProjectIDHeader+" header")
+			case !cfg.ProjectIDRegexp.MatchString(projectID):
+				apiErr = response.NewError(http.StatusBadRequest, "invalid_project_id", "Invalid project ID format")
+			}
+			if apiErr != nil {
+				event.Outcome = audit.OutcomeFailure
+				event.Reason = apiErr.Code
+				auditHTTP(auditLog, r, event)
+				metrics.AuthDenials.WithLabelValues(apiErr.Code).Inc()
+				response.WriteError(w, apiErr)
+				return
+			}
+
+			event.Outcome = audit.OutcomeSuccess
+			auditHTTP(auditLog, r, event)
+			slog.Debug("request authenticated by upstream gateway", "project_id", projectID, "path", r.URL.Path)
+
+			// The forwarded project is authoritative, as for API keys
+			r.Header.Set(ProjectIDHeader, projectID)
+			ctx := context.WithValue(r.Context(), AuthMethodContextKey, AuthMethodUpstream)
+			ctx = context.WithValue(ctx, UpstreamProjectIDKey, projectID)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetUpstreamProjectID returns the project vouched for by a trusted gateway.
+// Returns empty string if the request was not authenticated upstream.
+func GetUpstreamProjectID(ctx context.Context) string {
+	if projectID, ok := ctx.Value(UpstreamProjectIDKey).(string); ok {
+		return projectID
+	}
+	return ""
+}
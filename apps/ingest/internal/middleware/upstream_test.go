@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/response"
+)
+
+func TestUpstreamAuth(t *testing.T) {
+	const secret = "upstream-secret-0123456789abcdef0123"
+
+	tests := []struct {
+		name       string
+		trust      bool
+		secret     string
+		forwarded  string
+		projectID  string // Client-sent X-Project-ID
+		wantStatus int
+		wantCode   string
+		wantMethod string // Auth method seen by the next handler
+	}{
+		{name: "trusted", trust: true, secret: secret, forwarded: "proj-1", wantStatus: http.StatusOK, wantMethod: AuthMethodUpstream},
+		// The forwarded project overrides what the client sent
+		{name: "project header override", trust: true, secret: secret, forwarded: "proj-1", projectID: "proj-2", wantStatus: http.StatusOK, wantMethod: AuthMethodUpstream},
+		{name: "wrong secret", trust: true, secret: "not-the-secret", forwarded: "proj-1", wantStatus: http.StatusUnauthorized, wantCode: "invalid_upstream_secret"},
+		{name: "missing project", trust: true, secret: secret, wantStatus: http.StatusBadRequest, wantCode: "missing_project_id"},
+		{name: "malformed project", trust: true, secret: secret, forwarded: "../proj", wantStatus: http.StatusBadRequest, wantCode: "invalid_project_id"},
+		{name: "no secret", trust: true, forwarded: "proj-1", wantStatus: http.StatusOK, wantMethod: AuthMethodNone},
+		{name: "not trusted", secret: secret, forwarded: "proj-1", wantStatus: http.StatusOK, wantMethod: AuthMethodNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				TrustUpstreamAuth:  tt.trust,
+				UpstreamAuthSecret: secret,
+				ProjectIDRegexp:    regexp.MustCompile(`^(?:[A-Za-z0-9_-]{1,64})$`),
+			}
+
+			var method, projectID, leakedSecret string
+			called := false
+			next := RequireProjectAccess(cfg, ProjectIDHeader, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				method = GetAuthMethod(r.Context())
+				projectID = r.Header.Get(ProjectIDHeader)
+				leakedSecret = r.Header.Get(UpstreamSecretHeader)
+			}))
+			if tt.wantMethod == AuthMethodNone {
+				// Without upstream auth, record the request before project checks
+				next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					called = true
+					method = GetAuthMethod(r.Context())
+				})
+			}
+			handler := UpstreamAuth(cfg, nil)(next)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", nil)
+			if tt.secret != "" {
+				req.Header.Set(UpstreamSecretHeader, tt.secret)
+			}
+			if tt.forwarded != "" {
+				req.Header.Set(ForwardedProjectIDHeader, tt.forwarded)
+			}
+			if tt.projectID != "" {
+				req.Header.Set(ProjectIDHeader, tt.projectID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if called {
+					t.Error("next handler was called")
+				}
+				var body response.ErrorBody
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+				return
+			}
+
+			if method != tt.wantMethod {
+				t.Errorf("auth method = %q, want %q", method, tt.wantMethod)
+			}
+			if tt.wantMethod != AuthMethodUpstream {
+				return
+			}
+			if projectID != tt.forwarded {
+				t.Errorf("project ID = %q, want %q", projectID, tt.forwarded)
+			}
+			if leakedSecret != "" {
+				t.Error("upstream secret reached the handler")
+			}
+		})
+	}
+}
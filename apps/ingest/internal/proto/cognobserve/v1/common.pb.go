@@ -79,12 +79,68 @@ func (SpanLevel) EnumDescriptor() ([]byte, []int) {
 	return file_cognobserve_v1_common_proto_rawDescGZIP(), []int{0}
 }
 
+// Outcome of a whole trace
+type TraceStatus int32
+
+const (
+	TraceStatus_TRACE_STATUS_UNSPECIFIED TraceStatus = 0 // Derived from span levels
+	TraceStatus_TRACE_STATUS_SUCCESS     TraceStatus = 1
+	TraceStatus_TRACE_STATUS_ERROR       TraceStatus = 2
+	TraceStatus_TRACE_STATUS_CANCELLED   TraceStatus = 3
+	TraceStatus_TRACE_STATUS_UNKNOWN     TraceStatus = 4
+)
+
+// Enum value maps for TraceStatus.
+var (
+	TraceStatus_name = map[int32]string{
+		0: "TRACE_STATUS_UNSPECIFIED",
+		1: "TRACE_STATUS_SUCCESS",
+		2: "TRACE_STATUS_ERROR",
+		3: "TRACE_STATUS_CANCELLED",
+		4: "TRACE_STATUS_UNKNOWN",
+	}
+	TraceStatus_value = map[string]int32{
+		"TRACE_STATUS_UNSPECIFIED": 0,
+		"TRACE_STATUS_SUCCESS":     1,
+		"TRACE_STATUS_ERROR":       2,
+		"TRACE_STATUS_CANCELLED":   3,
+		"TRACE_STATUS_UNKNOWN":     4,
+	}
+)
+
+func (x TraceStatus) Enum() *TraceStatus {
+	p := new(TraceStatus)
+	*p = x
+	return p
+}
+
+func (x TraceStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TraceStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_cognobserve_v1_common_proto_enumTypes[1].Descriptor()
+}
+
+func (TraceStatus) Type() protoreflect.EnumType {
+	return &file_cognobserve_v1_common_proto_enumTypes[1]
+}
+
+func (x TraceStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TraceStatus.Descriptor instead.
+func (TraceStatus) EnumDescriptor() ([]byte, []int) {
+	return file_cognobserve_v1_common_proto_rawDescGZIP(), []int{1}
+}
+
 // Token usage for LLM calls
 type TokenUsage struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
-	PromptTokens     *int32                 `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3,oneof" json:"prompt_tokens,omitempty"`
-	CompletionTokens *int32                 `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3,oneof" json:"completion_tokens,omitempty"`
-	TotalTokens      *int32                 `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3,oneof" json:"total_tokens,omitempty"`
+	PromptTokens     *int64                 `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3,oneof" json:"prompt_tokens,omitempty"`
+	CompletionTokens *int64                 `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3,oneof" json:"completion_tokens,omitempty"`
+	TotalTokens      *int64                 `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3,oneof" json:"total_tokens,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -119,21 +175,21 @@ func (*TokenUsage) Descriptor() ([]byte, []int) {
 	return file_cognobserve_v1_common_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *TokenUsage) GetPromptTokens() int32 {
+func (x *TokenUsage) GetPromptTokens() int64 {
 	if x != nil && x.PromptTokens != nil {
 		return *x.PromptTokens
 	}
 	return 0
 }
 
-func (x *TokenUsage) GetCompletionTokens() int32 {
+func (x *TokenUsage) GetCompletionTokens() int64 {
 	if x != nil && x.CompletionTokens != nil {
 		return *x.CompletionTokens
 	}
 	return 0
 }
 
-func (x *TokenUsage) GetTotalTokens() int32 {
+func (x *TokenUsage) GetTotalTokens() int64 {
 	if x != nil && x.TotalTokens != nil {
 		return *x.TotalTokens
 	}
@@ -147,9 +203,9 @@ const file_cognobserve_v1_common_proto_rawDesc = "" +
 	"\x1bcognobserve/v1/common.proto\x12\x0ecognobserve.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1cgoogle/protobuf/struct.proto\"\xc9\x01\n" +
 	"\n" +
 	"TokenUsage\x12(\n" +
-	"\rprompt_tokens\x18\x01 \x01(\x05H\x00R\fpromptTokens\x88\x01\x01\x120\n" +
-	"\x11completion_tokens\x18\x02 \x01(\x05H\x01R\x10completionTokens\x88\x01\x01\x12&\n" +
-	"\ftotal_tokens\x18\x03 \x01(\x05H\x02R\vtotalTokens\x88\x01\x01B\x10\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x03H\x00R\fpromptTokens\x88\x01\x01\x120\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x03H\x01R\x10completionTokens\x88\x01\x01\x12&\n" +
+	"\ftotal_tokens\x18\x03 \x01(\x03H\x02R\vtotalTokens\x88\x01\x01B\x10\n" +
 	"\x0e_prompt_tokensB\x14\n" +
 	"\x12_completion_tokensB\x0f\n" +
 	"\r_total_tokens*\x83\x01\n" +
@@ -158,7 +214,13 @@ const file_cognobserve_v1_common_proto_rawDesc = "" +
 	"\x10SPAN_LEVEL_DEBUG\x10\x01\x12\x16\n" +
 	"\x12SPAN_LEVEL_DEFAULT\x10\x02\x12\x16\n" +
 	"\x12SPAN_LEVEL_WARNING\x10\x03\x12\x14\n" +
-	"\x10SPAN_LEVEL_ERROR\x10\x04B\xb6\x01\n" +
+	"\x10SPAN_LEVEL_ERROR\x10\x04*\x93\x01\n" +
+	"\vTraceStatus\x12\x1c\n" +
+	"\x18TRACE_STATUS_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14TRACE_STATUS_SUCCESS\x10\x01\x12\x16\n" +
+	"\x12TRACE_STATUS_ERROR\x10\x02\x12\x1a\n" +
+	"\x16TRACE_STATUS_CANCELLED\x10\x03\x12\x18\n" +
+	"\x14TRACE_STATUS_UNKNOWN\x10\x04B\xb6\x01\n" +
 	"\x12com.cognobserve.v1B\vCommonProtoP\x01Z:github.com/cognobserve/ingest/internal/proto/cognobservev1\xa2\x02\x03CXX\xaa\x02\x0eCognobserve.V1\xca\x02\x0eCognobserve\\V1\xe2\x02\x1aCognobserve\\V1\\GPBMetadata\xea\x02\x0fCognobserve::V1b\x06proto3"
 
 var (
@@ -173,11 +235,12 @@ func file_cognobserve_v1_common_proto_rawDescGZIP() []byte {
 	return file_cognobserve_v1_common_proto_rawDescData
 }
 
-var file_cognobserve_v1_common_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_cognobserve_v1_common_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
 var file_cognobserve_v1_common_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_cognobserve_v1_common_proto_goTypes = []any{
 	(SpanLevel)(0),     // 0: cognobserve.v1.SpanLevel
-	(*TokenUsage)(nil), // 1: cognobserve.v1.TokenUsage
+	(TraceStatus)(0),   // 1: cognobserve.v1.TraceStatus
+	(*TokenUsage)(nil), // 2: cognobserve.v1.TokenUsage
 }
 var file_cognobserve_v1_common_proto_depIdxs = []int32{
 	0, // [0:0] is the sub-list for method output_type
@@ -198,7 +261,7 @@ func file_cognobserve_v1_common_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cognobserve_v1_common_proto_rawDesc), len(file_cognobserve_v1_common_proto_rawDesc)),
-			NumEnums:      1,
+			NumEnums:      2,
 			NumMessages:   1,
 			NumExtensions: 0,
 			NumServices:   0,
@@ -96,8 +96,17 @@ type IngestTraceRequest struct {
 	// Session tracking (for multi-turn conversations)
 	SessionId *string `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3,oneof" json:"session_id,omitempty"`
 	// User tracking (end-users of AI applications)
-	UserId        *string   `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
-	User          *UserInfo `protobuf:"bytes,7,opt,name=user,proto3,oneof" json:"user,omitempty"`
+	UserId *string   `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	User   *UserInfo `protobuf:"bytes,7,opt,name=user,proto3,oneof" json:"user,omitempty"`
+	// Deployment tagging
+	Environment *string `protobuf:"bytes,8,opt,name=environment,proto3,oneof" json:"environment,omitempty"`
+	Release     *string `protobuf:"bytes,9,opt,name=release,proto3,oneof" json:"release,omitempty"`
+	// Searchable labels, e.g. "billing", "beta-feature"
+	Tags []string `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Accept a trace without spans (rejected by default)
+	AllowEmptyTrace bool `protobuf:"varint,11,opt,name=allow_empty_trace,json=allowEmptyTrace,proto3" json:"allow_empty_trace,omitempty"`
+	// Trace outcome; derived from span levels when unspecified
+	Status        TraceStatus `protobuf:"varint,12,opt,name=status,proto3,enum=cognobserve.v1.TraceStatus" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -181,6 +190,41 @@ func (x *IngestTraceRequest) GetUser() *UserInfo {
 	return nil
 }
 
+func (x *IngestTraceRequest) GetEnvironment() string {
+	if x != nil && x.Environment != nil {
+		return *x.Environment
+	}
+	return ""
+}
+
+func (x *IngestTraceRequest) GetRelease() string {
+	if x != nil && x.Release != nil {
+		return *x.Release
+	}
+	return ""
+}
+
+func (x *IngestTraceRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *IngestTraceRequest) GetAllowEmptyTrace() bool {
+	if x != nil {
+		return x.AllowEmptyTrace
+	}
+	return false
+}
+
+func (x *IngestTraceRequest) GetStatus() TraceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TraceStatus_TRACE_STATUS_UNSPECIFIED
+}
+
 // Span data for ingestion
 type IngestSpan struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
@@ -197,6 +241,7 @@ type IngestSpan struct {
 	Usage           *TokenUsage            `protobuf:"bytes,11,opt,name=usage,proto3,oneof" json:"usage,omitempty"`
 	Level           SpanLevel              `protobuf:"varint,12,opt,name=level,proto3,enum=cognobserve.v1.SpanLevel" json:"level,omitempty"`
 	StatusMessage   *string                `protobuf:"bytes,13,opt,name=status_message,json=statusMessage,proto3,oneof" json:"status_message,omitempty"`
+	Attachments     []*IngestAttachment    `protobuf:"bytes,14,rep,name=attachments,proto3" json:"attachments,omitempty"` // Media referenced by URL or small inline content
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -322,19 +367,107 @@ func (x *IngestSpan) GetStatusMessage() string {
 	return ""
 }
 
+func (x *IngestSpan) GetAttachments() []*IngestAttachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+// Media (image, audio, ...) referenced by a span.
+// Exactly one of url or content_base64 must be set.
+type IngestAttachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // image, audio, video or file
+	Mime          string                 `protobuf:"bytes,2,opt,name=mime,proto3" json:"mime,omitempty"` // e.g. image/png
+	Url           *string                `protobuf:"bytes,3,opt,name=url,proto3,oneof" json:"url,omitempty"`
+	ContentBase64 *string                `protobuf:"bytes,4,opt,name=content_base64,json=contentBase64,proto3,oneof" json:"content_base64,omitempty"`
+	Sha256        *string                `protobuf:"bytes,5,opt,name=sha256,proto3,oneof" json:"sha256,omitempty"` // Hex digest of the content
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestAttachment) Reset() {
+	*x = IngestAttachment{}
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestAttachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestAttachment) ProtoMessage() {}
+
+func (x *IngestAttachment) ProtoReflect() protoreflect.Message {
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestAttachment.ProtoReflect.Descriptor instead.
+func (*IngestAttachment) Descriptor() ([]byte, []int) {
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *IngestAttachment) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *IngestAttachment) GetMime() string {
+	if x != nil {
+		return x.Mime
+	}
+	return ""
+}
+
+func (x *IngestAttachment) GetUrl() string {
+	if x != nil && x.Url != nil {
+		return *x.Url
+	}
+	return ""
+}
+
+func (x *IngestAttachment) GetContentBase64() string {
+	if x != nil && x.ContentBase64 != nil {
+		return *x.ContentBase64
+	}
+	return ""
+}
+
+func (x *IngestAttachment) GetSha256() string {
+	if x != nil && x.Sha256 != nil {
+		return *x.Sha256
+	}
+	return ""
+}
+
 // Response after ingesting a trace
 type IngestTraceResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TraceId       string                 `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
 	SpanIds       []string               `protobuf:"bytes,2,rep,name=span_ids,json=spanIds,proto3" json:"span_ids,omitempty"`
 	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,4,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	Duplicate     bool                   `protobuf:"varint,5,opt,name=duplicate,proto3" json:"duplicate,omitempty"`       // True when the trace had already been submitted
+	Deduplicated  bool                   `protobuf:"varint,6,opt,name=deduplicated,proto3" json:"deduplicated,omitempty"` // True when identical content was dropped within the dedup window
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *IngestTraceResponse) Reset() {
 	*x = IngestTraceResponse{}
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[3]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -346,7 +479,7 @@ func (x *IngestTraceResponse) String() string {
 func (*IngestTraceResponse) ProtoMessage() {}
 
 func (x *IngestTraceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[3]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -359,7 +492,7 @@ func (x *IngestTraceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IngestTraceResponse.ProtoReflect.Descriptor instead.
 func (*IngestTraceResponse) Descriptor() ([]byte, []int) {
-	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{3}
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *IngestTraceResponse) GetTraceId() string {
@@ -383,6 +516,27 @@ func (x *IngestTraceResponse) GetSuccess() bool {
 	return false
 }
 
+func (x *IngestTraceResponse) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *IngestTraceResponse) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+func (x *IngestTraceResponse) GetDeduplicated() bool {
+	if x != nil {
+		return x.Deduplicated
+	}
+	return false
+}
+
 // Batch ingestion request
 type IngestBatchRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -393,7 +547,7 @@ type IngestBatchRequest struct {
 
 func (x *IngestBatchRequest) Reset() {
 	*x = IngestBatchRequest{}
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[4]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -405,7 +559,7 @@ func (x *IngestBatchRequest) String() string {
 func (*IngestBatchRequest) ProtoMessage() {}
 
 func (x *IngestBatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[4]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -418,7 +572,7 @@ func (x *IngestBatchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IngestBatchRequest.ProtoReflect.Descriptor instead.
 func (*IngestBatchRequest) Descriptor() ([]byte, []int) {
-	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{4}
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *IngestBatchRequest) GetTraces() []*IngestTraceRequest {
@@ -440,7 +594,7 @@ type IngestBatchResponse struct {
 
 func (x *IngestBatchResponse) Reset() {
 	*x = IngestBatchResponse{}
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[5]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -452,7 +606,7 @@ func (x *IngestBatchResponse) String() string {
 func (*IngestBatchResponse) ProtoMessage() {}
 
 func (x *IngestBatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[5]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -465,7 +619,7 @@ func (x *IngestBatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IngestBatchResponse.ProtoReflect.Descriptor instead.
 func (*IngestBatchResponse) Descriptor() ([]byte, []int) {
-	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{5}
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *IngestBatchResponse) GetResults() []*IngestTraceResponse {
@@ -489,6 +643,241 @@ func (x *IngestBatchResponse) GetErrorCount() int32 {
 	return 0
 }
 
+// Request to ingest a score for a trace, span, session or user
+type IngestScoreRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ScoreId *string                `protobuf:"bytes,1,opt,name=score_id,json=scoreId,proto3,oneof" json:"score_id,omitempty"` // Optional, server generates if not provided
+	Name    string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Score value; the data type is inferred from the variant set
+	//
+	// Types that are valid to be assigned to Value:
+	//
+	//	*IngestScoreRequest_NumericValue
+	//	*IngestScoreRequest_StringValue
+	//	*IngestScoreRequest_BooleanValue
+	Value isIngestScoreRequest_Value `protobuf_oneof:"value"`
+	// Target of the score, at least one is required
+	TraceId       *string          `protobuf:"bytes,6,opt,name=trace_id,json=traceId,proto3,oneof" json:"trace_id,omitempty"`
+	SpanId        *string          `protobuf:"bytes,7,opt,name=span_id,json=spanId,proto3,oneof" json:"span_id,omitempty"`
+	SessionId     *string          `protobuf:"bytes,8,opt,name=session_id,json=sessionId,proto3,oneof" json:"session_id,omitempty"`
+	UserId        *string          `protobuf:"bytes,9,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	ConfigId      *string          `protobuf:"bytes,10,opt,name=config_id,json=configId,proto3,oneof" json:"config_id,omitempty"`
+	Comment       *string          `protobuf:"bytes,11,opt,name=comment,proto3,oneof" json:"comment,omitempty"`
+	Metadata      *structpb.Struct `protobuf:"bytes,12,opt,name=metadata,proto3,oneof" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestScoreRequest) Reset() {
+	*x = IngestScoreRequest{}
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestScoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestScoreRequest) ProtoMessage() {}
+
+func (x *IngestScoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestScoreRequest.ProtoReflect.Descriptor instead.
+func (*IngestScoreRequest) Descriptor() ([]byte, []int) {
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *IngestScoreRequest) GetScoreId() string {
+	if x != nil && x.ScoreId != nil {
+		return *x.ScoreId
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetValue() isIngestScoreRequest_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *IngestScoreRequest) GetNumericValue() float64 {
+	if x != nil {
+		if x, ok := x.Value.(*IngestScoreRequest_NumericValue); ok {
+			return x.NumericValue
+		}
+	}
+	return 0
+}
+
+func (x *IngestScoreRequest) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Value.(*IngestScoreRequest_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetBooleanValue() bool {
+	if x != nil {
+		if x, ok := x.Value.(*IngestScoreRequest_BooleanValue); ok {
+			return x.BooleanValue
+		}
+	}
+	return false
+}
+
+func (x *IngestScoreRequest) GetTraceId() string {
+	if x != nil && x.TraceId != nil {
+		return *x.TraceId
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetSpanId() string {
+	if x != nil && x.SpanId != nil {
+		return *x.SpanId
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetUserId() string {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetConfigId() string {
+	if x != nil && x.ConfigId != nil {
+		return *x.ConfigId
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetComment() string {
+	if x != nil && x.Comment != nil {
+		return *x.Comment
+	}
+	return ""
+}
+
+func (x *IngestScoreRequest) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type isIngestScoreRequest_Value interface {
+	isIngestScoreRequest_Value()
+}
+
+type IngestScoreRequest_NumericValue struct {
+	NumericValue float64 `protobuf:"fixed64,3,opt,name=numeric_value,json=numericValue,proto3,oneof"`
+}
+
+type IngestScoreRequest_StringValue struct {
+	StringValue string `protobuf:"bytes,4,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type IngestScoreRequest_BooleanValue struct {
+	BooleanValue bool `protobuf:"varint,5,opt,name=boolean_value,json=booleanValue,proto3,oneof"`
+}
+
+func (*IngestScoreRequest_NumericValue) isIngestScoreRequest_Value() {}
+
+func (*IngestScoreRequest_StringValue) isIngestScoreRequest_Value() {}
+
+func (*IngestScoreRequest_BooleanValue) isIngestScoreRequest_Value() {}
+
+// Response after ingesting a score
+type IngestScoreResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScoreId       string                 `protobuf:"bytes,1,opt,name=score_id,json=scoreId,proto3" json:"score_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	Success       bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestScoreResponse) Reset() {
+	*x = IngestScoreResponse{}
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestScoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestScoreResponse) ProtoMessage() {}
+
+func (x *IngestScoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestScoreResponse.ProtoReflect.Descriptor instead.
+func (*IngestScoreResponse) Descriptor() ([]byte, []int) {
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IngestScoreResponse) GetScoreId() string {
+	if x != nil {
+		return x.ScoreId
+	}
+	return ""
+}
+
+func (x *IngestScoreResponse) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *IngestScoreResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
 // Health check
 type HealthRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -498,7 +887,7 @@ type HealthRequest struct {
 
 func (x *HealthRequest) Reset() {
 	*x = HealthRequest{}
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[6]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -510,7 +899,7 @@ func (x *HealthRequest) String() string {
 func (*HealthRequest) ProtoMessage() {}
 
 func (x *HealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[6]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -523,7 +912,7 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
 func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{6}
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{9}
 }
 
 type HealthResponse struct {
@@ -536,7 +925,7 @@ type HealthResponse struct {
 
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[7]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -548,7 +937,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_cognobserve_v1_ingest_proto_msgTypes[7]
+	mi := &file_cognobserve_v1_ingest_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -561,7 +950,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{7}
+	return file_cognobserve_v1_ingest_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *HealthResponse) GetStatus() string {
@@ -589,7 +978,7 @@ const file_cognobserve_v1_ingest_proto_rawDesc = "" +
 	"\bmetadata\x18\x03 \x01(\v2\x17.google.protobuf.StructH\x02R\bmetadata\x88\x01\x01B\a\n" +
 	"\x05_nameB\b\n" +
 	"\x06_emailB\v\n" +
-	"\t_metadata\"\xe7\x02\n" +
+	"\t_metadata\"\xbe\x04\n" +
 	"\x12IngestTraceRequest\x12\x1e\n" +
 	"\btrace_id\x18\x01 \x01(\tH\x00R\atraceId\x88\x01\x01\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x128\n" +
@@ -598,13 +987,22 @@ const file_cognobserve_v1_ingest_proto_rawDesc = "" +
 	"\n" +
 	"session_id\x18\x05 \x01(\tH\x02R\tsessionId\x88\x01\x01\x12\x1c\n" +
 	"\auser_id\x18\x06 \x01(\tH\x03R\x06userId\x88\x01\x01\x121\n" +
-	"\x04user\x18\a \x01(\v2\x18.cognobserve.v1.UserInfoH\x04R\x04user\x88\x01\x01B\v\n" +
+	"\x04user\x18\a \x01(\v2\x18.cognobserve.v1.UserInfoH\x04R\x04user\x88\x01\x01\x12%\n" +
+	"\venvironment\x18\b \x01(\tH\x05R\venvironment\x88\x01\x01\x12\x1d\n" +
+	"\arelease\x18\t \x01(\tH\x06R\arelease\x88\x01\x01\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12*\n" +
+	"\x11allow_empty_trace\x18\v \x01(\bR\x0fallowEmptyTrace\x123\n" +
+	"\x06status\x18\f \x01(\x0e2\x1b.cognobserve.v1.TraceStatusR\x06statusB\v\n" +
 	"\t_trace_idB\v\n" +
 	"\t_metadataB\r\n" +
 	"\v_session_idB\n" +
 	"\n" +
 	"\b_user_idB\a\n" +
-	"\x05_user\"\x86\x06\n" +
+	"\x05_userB\x0e\n" +
+	"\f_environmentB\n" +
+	"\n" +
+	"\b_release\"\xca\x06\n" +
 	"\n" +
 	"IngestSpan\x12\x1c\n" +
 	"\aspan_id\x18\x01 \x01(\tH\x00R\x06spanId\x88\x01\x01\x12)\n" +
@@ -621,7 +1019,8 @@ const file_cognobserve_v1_ingest_proto_rawDesc = "" +
 	" \x01(\v2\x17.google.protobuf.StructH\aR\x0fmodelParameters\x88\x01\x01\x125\n" +
 	"\x05usage\x18\v \x01(\v2\x1a.cognobserve.v1.TokenUsageH\bR\x05usage\x88\x01\x01\x12/\n" +
 	"\x05level\x18\f \x01(\x0e2\x19.cognobserve.v1.SpanLevelR\x05level\x12*\n" +
-	"\x0estatus_message\x18\r \x01(\tH\tR\rstatusMessage\x88\x01\x01B\n" +
+	"\x0estatus_message\x18\r \x01(\tH\tR\rstatusMessage\x88\x01\x01\x12B\n" +
+	"\vattachments\x18\x0e \x03(\v2 .cognobserve.v1.IngestAttachmentR\vattachmentsB\n" +
 	"\n" +
 	"\b_span_idB\x11\n" +
 	"\x0f_parent_span_idB\v\n" +
@@ -632,22 +1031,71 @@ const file_cognobserve_v1_ingest_proto_rawDesc = "" +
 	"\x06_modelB\x13\n" +
 	"\x11_model_parametersB\b\n" +
 	"\x06_usageB\x11\n" +
-	"\x0f_status_message\"e\n" +
+	"\x0f_status_message\"\xc0\x01\n" +
+	"\x10IngestAttachment\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04mime\x18\x02 \x01(\tR\x04mime\x12\x15\n" +
+	"\x03url\x18\x03 \x01(\tH\x00R\x03url\x88\x01\x01\x12*\n" +
+	"\x0econtent_base64\x18\x04 \x01(\tH\x01R\rcontentBase64\x88\x01\x01\x12\x1b\n" +
+	"\x06sha256\x18\x05 \x01(\tH\x02R\x06sha256\x88\x01\x01B\x06\n" +
+	"\x04_urlB\x11\n" +
+	"\x0f_content_base64B\t\n" +
+	"\a_sha256\"\xc8\x01\n" +
 	"\x13IngestTraceResponse\x12\x19\n" +
 	"\btrace_id\x18\x01 \x01(\tR\atraceId\x12\x19\n" +
 	"\bspan_ids\x18\x02 \x03(\tR\aspanIds\x12\x18\n" +
-	"\asuccess\x18\x03 \x01(\bR\asuccess\"P\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\x12\x1f\n" +
+	"\vworkflow_id\x18\x04 \x01(\tR\n" +
+	"workflowId\x12\x1c\n" +
+	"\tduplicate\x18\x05 \x01(\bR\tduplicate\x12\"\n" +
+	"\fdeduplicated\x18\x06 \x01(\bR\fdeduplicated\"P\n" +
 	"\x12IngestBatchRequest\x12:\n" +
 	"\x06traces\x18\x01 \x03(\v2\".cognobserve.v1.IngestTraceRequestR\x06traces\"\x9a\x01\n" +
 	"\x13IngestBatchResponse\x12=\n" +
 	"\aresults\x18\x01 \x03(\v2#.cognobserve.v1.IngestTraceResponseR\aresults\x12#\n" +
 	"\rsuccess_count\x18\x02 \x01(\x05R\fsuccessCount\x12\x1f\n" +
 	"\verror_count\x18\x03 \x01(\x05R\n" +
-	"errorCount\"\x0f\n" +
+	"errorCount\"\xa7\x04\n" +
+	"\x12IngestScoreRequest\x12\x1e\n" +
+	"\bscore_id\x18\x01 \x01(\tH\x01R\ascoreId\x88\x01\x01\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12%\n" +
+	"\rnumeric_value\x18\x03 \x01(\x01H\x00R\fnumericValue\x12#\n" +
+	"\fstring_value\x18\x04 \x01(\tH\x00R\vstringValue\x12%\n" +
+	"\rboolean_value\x18\x05 \x01(\bH\x00R\fbooleanValue\x12\x1e\n" +
+	"\btrace_id\x18\x06 \x01(\tH\x02R\atraceId\x88\x01\x01\x12\x1c\n" +
+	"\aspan_id\x18\a \x01(\tH\x03R\x06spanId\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"session_id\x18\b \x01(\tH\x04R\tsessionId\x88\x01\x01\x12\x1c\n" +
+	"\auser_id\x18\t \x01(\tH\x05R\x06userId\x88\x01\x01\x12 \n" +
+	"\tconfig_id\x18\n" +
+	" \x01(\tH\x06R\bconfigId\x88\x01\x01\x12\x1d\n" +
+	"\acomment\x18\v \x01(\tH\aR\acomment\x88\x01\x01\x128\n" +
+	"\bmetadata\x18\f \x01(\v2\x17.google.protobuf.StructH\bR\bmetadata\x88\x01\x01B\a\n" +
+	"\x05valueB\v\n" +
+	"\t_score_idB\v\n" +
+	"\t_trace_idB\n" +
+	"\n" +
+	"\b_span_idB\r\n" +
+	"\v_session_idB\n" +
+	"\n" +
+	"\b_user_idB\f\n" +
+	"\n" +
+	"_config_idB\n" +
+	"\n" +
+	"\b_commentB\v\n" +
+	"\t_metadata\"k\n" +
+	"\x13IngestScoreResponse\x12\x19\n" +
+	"\bscore_id\x18\x01 \x01(\tR\ascoreId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\"\x0f\n" +
 	"\rHealthRequest\"B\n" +
 	"\x0eHealthResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
-	"\aversion\x18\x02 \x01(\tR\aversionB\xb6\x01\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion2\xbf\x01\n" +
+	"\rIngestService\x12V\n" +
+	"\vIngestTrace\x12\".cognobserve.v1.IngestTraceRequest\x1a#.cognobserve.v1.IngestTraceResponse\x12V\n" +
+	"\vIngestScore\x12\".cognobserve.v1.IngestScoreRequest\x1a#.cognobserve.v1.IngestScoreResponseB\xb6\x01\n" +
 	"\x12com.cognobserve.v1B\vIngestProtoP\x01Z:github.com/cognobserve/ingest/internal/proto/cognobservev1\xa2\x02\x03CXX\xaa\x02\x0eCognobserve.V1\xca\x02\x0eCognobserve\\V1\xe2\x02\x1aCognobserve\\V1\\GPBMetadata\xea\x02\x0fCognobserve::V1b\x06proto3"
 
 var (
@@ -662,41 +1110,52 @@ func file_cognobserve_v1_ingest_proto_rawDescGZIP() []byte {
 	return file_cognobserve_v1_ingest_proto_rawDescData
 }
 
-var file_cognobserve_v1_ingest_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_cognobserve_v1_ingest_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_cognobserve_v1_ingest_proto_goTypes = []any{
 	(*UserInfo)(nil),              // 0: cognobserve.v1.UserInfo
 	(*IngestTraceRequest)(nil),    // 1: cognobserve.v1.IngestTraceRequest
 	(*IngestSpan)(nil),            // 2: cognobserve.v1.IngestSpan
-	(*IngestTraceResponse)(nil),   // 3: cognobserve.v1.IngestTraceResponse
-	(*IngestBatchRequest)(nil),    // 4: cognobserve.v1.IngestBatchRequest
-	(*IngestBatchResponse)(nil),   // 5: cognobserve.v1.IngestBatchResponse
-	(*HealthRequest)(nil),         // 6: cognobserve.v1.HealthRequest
-	(*HealthResponse)(nil),        // 7: cognobserve.v1.HealthResponse
-	(*structpb.Struct)(nil),       // 8: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
-	(*TokenUsage)(nil),            // 10: cognobserve.v1.TokenUsage
-	(SpanLevel)(0),                // 11: cognobserve.v1.SpanLevel
+	(*IngestAttachment)(nil),      // 3: cognobserve.v1.IngestAttachment
+	(*IngestTraceResponse)(nil),   // 4: cognobserve.v1.IngestTraceResponse
+	(*IngestBatchRequest)(nil),    // 5: cognobserve.v1.IngestBatchRequest
+	(*IngestBatchResponse)(nil),   // 6: cognobserve.v1.IngestBatchResponse
+	(*IngestScoreRequest)(nil),    // 7: cognobserve.v1.IngestScoreRequest
+	(*IngestScoreResponse)(nil),   // 8: cognobserve.v1.IngestScoreResponse
+	(*HealthRequest)(nil),         // 9: cognobserve.v1.HealthRequest
+	(*HealthResponse)(nil),        // 10: cognobserve.v1.HealthResponse
+	(*structpb.Struct)(nil),       // 11: google.protobuf.Struct
+	(TraceStatus)(0),              // 12: cognobserve.v1.TraceStatus
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+	(*TokenUsage)(nil),            // 14: cognobserve.v1.TokenUsage
+	(SpanLevel)(0),                // 15: cognobserve.v1.SpanLevel
 }
 var file_cognobserve_v1_ingest_proto_depIdxs = []int32{
-	8,  // 0: cognobserve.v1.UserInfo.metadata:type_name -> google.protobuf.Struct
-	8,  // 1: cognobserve.v1.IngestTraceRequest.metadata:type_name -> google.protobuf.Struct
+	11, // 0: cognobserve.v1.UserInfo.metadata:type_name -> google.protobuf.Struct
+	11, // 1: cognobserve.v1.IngestTraceRequest.metadata:type_name -> google.protobuf.Struct
 	2,  // 2: cognobserve.v1.IngestTraceRequest.spans:type_name -> cognobserve.v1.IngestSpan
 	0,  // 3: cognobserve.v1.IngestTraceRequest.user:type_name -> cognobserve.v1.UserInfo
-	9,  // 4: cognobserve.v1.IngestSpan.start_time:type_name -> google.protobuf.Timestamp
-	9,  // 5: cognobserve.v1.IngestSpan.end_time:type_name -> google.protobuf.Timestamp
-	8,  // 6: cognobserve.v1.IngestSpan.input:type_name -> google.protobuf.Struct
-	8,  // 7: cognobserve.v1.IngestSpan.output:type_name -> google.protobuf.Struct
-	8,  // 8: cognobserve.v1.IngestSpan.metadata:type_name -> google.protobuf.Struct
-	8,  // 9: cognobserve.v1.IngestSpan.model_parameters:type_name -> google.protobuf.Struct
-	10, // 10: cognobserve.v1.IngestSpan.usage:type_name -> cognobserve.v1.TokenUsage
-	11, // 11: cognobserve.v1.IngestSpan.level:type_name -> cognobserve.v1.SpanLevel
-	1,  // 12: cognobserve.v1.IngestBatchRequest.traces:type_name -> cognobserve.v1.IngestTraceRequest
-	3,  // 13: cognobserve.v1.IngestBatchResponse.results:type_name -> cognobserve.v1.IngestTraceResponse
-	14, // [14:14] is the sub-list for method output_type
-	14, // [14:14] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	12, // 4: cognobserve.v1.IngestTraceRequest.status:type_name -> cognobserve.v1.TraceStatus
+	13, // 5: cognobserve.v1.IngestSpan.start_time:type_name -> google.protobuf.Timestamp
+	13, // 6: cognobserve.v1.IngestSpan.end_time:type_name -> google.protobuf.Timestamp
+	11, // 7: cognobserve.v1.IngestSpan.input:type_name -> google.protobuf.Struct
+	11, // 8: cognobserve.v1.IngestSpan.output:type_name -> google.protobuf.Struct
+	11, // 9: cognobserve.v1.IngestSpan.metadata:type_name -> google.protobuf.Struct
+	11, // 10: cognobserve.v1.IngestSpan.model_parameters:type_name -> google.protobuf.Struct
+	14, // 11: cognobserve.v1.IngestSpan.usage:type_name -> cognobserve.v1.TokenUsage
+	15, // 12: cognobserve.v1.IngestSpan.level:type_name -> cognobserve.v1.SpanLevel
+	3,  // 13: cognobserve.v1.IngestSpan.attachments:type_name -> cognobserve.v1.IngestAttachment
+	1,  // 14: cognobserve.v1.IngestBatchRequest.traces:type_name -> cognobserve.v1.IngestTraceRequest
+	4,  // 15: cognobserve.v1.IngestBatchResponse.results:type_name -> cognobserve.v1.IngestTraceResponse
+	11, // 16: cognobserve.v1.IngestScoreRequest.metadata:type_name -> google.protobuf.Struct
+	1,  // 17: cognobserve.v1.IngestService.IngestTrace:input_type -> cognobserve.v1.IngestTraceRequest
+	7,  // 18: cognobserve.v1.IngestService.IngestScore:input_type -> cognobserve.v1.IngestScoreRequest
+	4,  // 19: cognobserve.v1.IngestService.IngestTrace:output_type -> cognobserve.v1.IngestTraceResponse
+	8,  // 20: cognobserve.v1.IngestService.IngestScore:output_type -> cognobserve.v1.IngestScoreResponse
+	19, // [19:21] is the sub-list for method output_type
+	17, // [17:19] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_cognobserve_v1_ingest_proto_init() }
@@ -708,15 +1167,21 @@ func file_cognobserve_v1_ingest_proto_init() {
 	file_cognobserve_v1_ingest_proto_msgTypes[0].OneofWrappers = []any{}
 	file_cognobserve_v1_ingest_proto_msgTypes[1].OneofWrappers = []any{}
 	file_cognobserve_v1_ingest_proto_msgTypes[2].OneofWrappers = []any{}
+	file_cognobserve_v1_ingest_proto_msgTypes[3].OneofWrappers = []any{}
+	file_cognobserve_v1_ingest_proto_msgTypes[7].OneofWrappers = []any{
+		(*IngestScoreRequest_NumericValue)(nil),
+		(*IngestScoreRequest_StringValue)(nil),
+		(*IngestScoreRequest_BooleanValue)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cognobserve_v1_ingest_proto_rawDesc), len(file_cognobserve_v1_ingest_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   11,
 			NumExtensions: 0,
-			NumServices:   0,
+			NumServices:   1,
 		},
 		GoTypes:           file_cognobserve_v1_ingest_proto_goTypes,
 		DependencyIndexes: file_cognobserve_v1_ingest_proto_depIdxs,
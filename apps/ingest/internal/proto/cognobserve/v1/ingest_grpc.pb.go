@@ -0,0 +1,167 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: cognobserve/v1/ingest.proto
+
+package cognobservev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	IngestService_IngestTrace_FullMethodName = "/cognobserve.v1.IngestService/IngestTrace"
+	IngestService_IngestScore_FullMethodName = "/cognobserve.v1.IngestService/IngestScore"
+)
+
+// IngestServiceClient is the client API for IngestService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Ingestion over gRPC for internal services.
+// Authenticate with "x-api-key" or "authorization: Bearer <token>" metadata
+// and select the project with "x-project-id".
+type IngestServiceClient interface {
+	IngestTrace(ctx context.Context, in *IngestTraceRequest, opts ...grpc.CallOption) (*IngestTraceResponse, error)
+	IngestScore(ctx context.Context, in *IngestScoreRequest, opts ...grpc.CallOption) (*IngestScoreResponse, error)
+}
+
+type ingestServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIngestServiceClient(cc grpc.ClientConnInterface) IngestServiceClient {
+	return &ingestServiceClient{cc}
+}
+
+func (c *ingestServiceClient) IngestTrace(ctx context.Context, in *IngestTraceRequest, opts ...grpc.CallOption) (*IngestTraceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestTraceResponse)
+	err := c.cc.Invoke(ctx, IngestService_IngestTrace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ingestServiceClient) IngestScore(ctx context.Context, in *IngestScoreRequest, opts ...grpc.CallOption) (*IngestScoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestScoreResponse)
+	err := c.cc.Invoke(ctx, IngestService_IngestScore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IngestServiceServer is the server API for IngestService service.
+// All implementations must embed UnimplementedIngestServiceServer
+// for forward compatibility.
+//
+// Ingestion over gRPC for internal services.
+// Authenticate with "x-api-key" or "authorization: Bearer <token>" metadata
+// and select the project with "x-project-id".
+type IngestServiceServer interface {
+	IngestTrace(context.Context, *IngestTraceRequest) (*IngestTraceResponse, error)
+	IngestScore(context.Context, *IngestScoreRequest) (*IngestScoreResponse, error)
+	mustEmbedUnimplementedIngestServiceServer()
+}
+
+// UnimplementedIngestServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIngestServiceServer struct{}
+
+func (UnimplementedIngestServiceServer) IngestTrace(context.Context, *IngestTraceRequest) (*IngestTraceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IngestTrace not implemented")
+}
+func (UnimplementedIngestServiceServer) IngestScore(context.Context, *IngestScoreRequest) (*IngestScoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IngestScore not implemented")
+}
+func (UnimplementedIngestServiceServer) mustEmbedUnimplementedIngestServiceServer() {}
+func (UnimplementedIngestServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeIngestServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IngestServiceServer will
+// result in compilation errors.
+type UnsafeIngestServiceServer interface {
+	mustEmbedUnimplementedIngestServiceServer()
+}
+
+func RegisterIngestServiceServer(s grpc.ServiceRegistrar, srv IngestServiceServer) {
+	// If the following call pancis, it indicates UnimplementedIngestServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IngestService_ServiceDesc, srv)
+}
+
+func _IngestService_IngestTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IngestTraceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngestServiceServer).IngestTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IngestService_IngestTrace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngestServiceServer).IngestTrace(ctx, req.(*IngestTraceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IngestService_IngestScore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IngestScoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngestServiceServer).IngestScore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IngestService_IngestScore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngestServiceServer).IngestScore(ctx, req.(*IngestScoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IngestService_ServiceDesc is the grpc.ServiceDesc for IngestService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IngestService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cognobserve.v1.IngestService",
+	HandlerType: (*IngestServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "IngestTrace",
+			Handler:    _IngestService_IngestTrace_Handler,
+		},
+		{
+			MethodName: "IngestScore",
+			Handler:    _IngestService_IngestScore_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cognobserve/v1/ingest.proto",
+}
@@ -0,0 +1,42 @@
+package response
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentTypeMsgPack is the media type of MessagePack responses
+const ContentTypeMsgPack = "application/msgpack"
+
+// AcceptsMsgPack reports whether the request's Accept header asks for
+// MessagePack (application/msgpack or application/x-msgpack)
+func AcceptsMsgPack(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		if mediaType == ContentTypeMsgPack || mediaType == "application/x-msgpack" {
+			return true
+		}
+	}
+	return false
+}
+
+// MsgPack writes v as a MessagePack response with the given status.
+// Fields are named by their json tags, so both encodings share one struct.
+func MsgPack(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", ContentTypeMsgPack)
+	w.WriteHeader(status)
+
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,35 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptsMsgPack(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "", want: false},
+		{accept: "application/json", want: false},
+		{accept: "application/msgpack", want: true},
+		{accept: "application/x-msgpack", want: true},
+		{accept: "application/json, application/msgpack;q=0.9", want: true},
+		{accept: "application/msgpack;q=0", want: false},
+		{accept: "*/*", want: false},
+		{accept: "not a media type;;", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/traces/batch", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := AcceptsMsgPack(r); got != tt.want {
+				t.Errorf("AcceptsMsgPack(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cognobserve/ingest/pkg/api"
+)
+
+// errorDomain identifies our errors in gRPC ErrorInfo details
+const errorDomain = "ingest.cognobserve"
+
+// ErrorBody is the JSON error envelope returned by all endpoints, and
+// ErrorDetail a single error within it (see pkg/api)
+type (
+	ErrorBody   = api.ErrorBody
+	ErrorDetail = api.ErrorDetail
+)
+
+// NewErrorBody builds the envelope for detail
+func NewErrorBody(detail ErrorDetail) ErrorBody {
+	return ErrorBody{Error: detail.Message, ErrorDetail: detail}
+}
+
+// JSON writes v as a JSON response with the given status
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+// Error writes a structured error response
+func Error(w http.ResponseWriter, status int, code, message string) {
+	JSON(w, status, NewErrorBody(ErrorDetail{Code: code, Message: message}))
+}
+
+// APIError is an error that maps onto a structured error response
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Details map[string]any // Optional, included in the envelope
+}
+
+// NewError creates an APIError
+func NewError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Detail returns the error as it appears inside the envelope
+// The field is lifted from details.field, so clients can read it directly.
+func (e *APIError) Detail() ErrorDetail {
+	field, _ := e.Details["field"].(string)
+	return ErrorDetail{Code: e.Code, Message: e.Message, Field: field, Details: e.Details}
+}
+
+// GRPCStatus converts the error to a gRPC status so it can be returned from RPC
+// handlers directly. The error code is carried as the ErrorInfo reason.
+func (e *APIError) GRPCStatus() *status.Status {
+	st := status.New(grpcCode(e.Status), e.Message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Code, Domain: errorDomain}); err == nil {
+		return detailed
+	}
+	return st
+}
+
+// grpcCode maps an HTTP status onto the closest gRPC code
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusPaymentRequired, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// WriteError writes err as a structured error response.
+// Errors that aren't an *APIError are reported as 500 internal_error.
+func WriteError(w http.ResponseWriter, err error) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		JSON(w, apiErr.Status, NewErrorBody(apiErr.Detail()))
+		return
+	}
+	Error(w, http.StatusInternalServerError, "internal_error", "internal server error")
+}
@@ -0,0 +1,167 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/cognobserve/ingest/internal/webapi"
+)
+
+// MaxViolations caps the violations reported per span
+const MaxViolations = 10
+
+// printer renders validation messages
+var printer = message.NewPrinter(language.English)
+
+// Set is a project's compiled output schemas, keyed by span name
+type Set struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+// Validate checks output against the schema registered for spanName.
+// matched is false when no schema is registered for the span name.
+func (s *Set) Validate(spanName string, output any) (matched bool, violations []string) {
+	if s == nil {
+		return false, nil
+	}
+	sch, ok := s.schemas[spanName]
+	if !ok {
+		return false, nil
+	}
+
+	err := sch.Validate(output)
+	if err == nil {
+		return true, nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return true, []string{err.Error()}
+	}
+	return true, collectViolations(verr, nil)
+}
+
+// collectViolations flattens the leaf errors of a validation error tree
+func collectViolations(verr *jsonschema.ValidationError, out []string) []string {
+	if len(verr.Causes) == 0 {
+		if len(out) < MaxViolations {
+			out = append(out, fmt.Sprintf("/%s: %s", strings.Join(verr.InstanceLocation, "/"), verr.ErrorKind.LocalizedString(printer)))
+		}
+		return out
+	}
+	for _, cause := range verr.Causes {
+		out = collectViolations(cause, out)
+	}
+	return out
+}
+
+// failureTTL is how long a failed lookup is remembered, so an unavailable
+// web API isn't called on every trace
+const failureTTL = 10 * time.Second
+
+type cachedSet struct {
+	set       *Set  // nil when the project hasn't opted in
+	err       error // set when the lookup failed
+	expiresAt time.Time
+}
+
+// Registry resolves each project's output schemas from the web API,
+// caching them (including "not opted in") for ttl and failed lookups for
+// failureTTL.
+type Registry struct {
+	client *webapi.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedSet
+}
+
+// NewRegistry creates a Registry backed by the internal web API
+func NewRegistry(client *webapi.Client, ttl time.Duration) *Registry {
+	return &Registry{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]cachedSet),
+	}
+}
+
+// ForProject returns the project's schemas, or nil if it hasn't opted in.
+// Schemas that fail to compile are logged and skipped so one bad entry
+// doesn't disable validation for the rest.
+func (r *Registry) ForProject(ctx context.Context, projectID string) (*Set, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[projectID]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.set, cached.err
+	}
+
+	registry, err := r.client.GetOutputSchemas(ctx, projectID)
+	if err != nil {
+		// A cancelled request says nothing about the web API's health
+		if ctx.Err() == nil {
+			r.mu.Lock()
+			r.cache[projectID] = cachedSet{err: err, expiresAt: time.Now().Add(min(r.ttl, failureTTL))}
+			r.mu.Unlock()
+		}
+		return nil, err
+	}
+
+	var set *Set
+	if registry.Enabled && len(registry.Schemas) > 0 {
+		set = compile(projectID, registry.Schemas)
+	}
+
+	r.mu.Lock()
+	r.cache[projectID] = cachedSet{set: set, expiresAt: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+	return set, nil
+}
+
+// compile compiles each span name's schema independently
+func compile(projectID string, raw map[string]json.RawMessage) *Set {
+	set := &Set{schemas: make(map[string]*jsonschema.Schema, len(raw))}
+	for spanName, doc := range raw {
+		sch, err := compileOne(doc)
+		if err != nil {
+			slog.Warn("skipping invalid output schema", "error", err, "project_id", projectID, "span_name", spanName)
+			continue
+		}
+		set.schemas[spanName] = sch
+	}
+	return set
+}
+
+func compileOne(doc []byte) (*jsonschema.Schema, error) {
+	parsed, err := jsonschema.UnmarshalJSON(bytes.NewReader(doc))
+	if err != nil {
+		return nil, err
+	}
+
+	// Each schema gets its own compiler so resources can't collide
+	const url = "urn:cognobserve:output-schema"
+	c := jsonschema.NewCompiler()
+	// Schemas are tenant-supplied: never follow $refs to files or URLs
+	c.UseLoader(noLoader{})
+	if err := c.AddResource(url, parsed); err != nil {
+		return nil, err
+	}
+	return c.Compile(url)
+}
+
+// noLoader refuses to load external schema resources
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("external schema references are not supported: %s", url)
+}
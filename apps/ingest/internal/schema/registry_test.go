@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/webapi"
+)
+
+func newTestRegistry(t *testing.T, handler http.HandlerFunc) (*Registry, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{WebAPIURL: srv.URL, InternalAPISecrets: []string{"secret"}}
+	return NewRegistry(webapi.New(cfg), time.Minute), &calls
+}
+
+func TestRegistryForProject(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      any
+		wantErr   bool
+		wantSet   bool
+		wantCalls int32
+	}{
+		{
+			name:      "opted in",
+			status:    http.StatusOK,
+			body:      map[string]any{"enabled": true, "schemas": map[string]any{"answer": map[string]any{"type": "string"}}},
+			wantSet:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "not opted in",
+			status:    http.StatusOK,
+			body:      map[string]any{"enabled": false, "schemas": map[string]any{}},
+			wantCalls: 1,
+		},
+		{
+			name:      "lookup failure is cached",
+			status:    http.StatusInternalServerError,
+			body:      map[string]any{"success": false, "error": "Internal server error"},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, calls := newTestRegistry(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_ = json.NewEncoder(w).Encode(tt.body)
+			})
+
+			for range 3 {
+				set, err := registry.ForProject(context.Background(), "proj-1")
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("ForProject() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if (set != nil) != tt.wantSet {
+					t.Fatalf("ForProject() set = %v, wantSet %v", set, tt.wantSet)
+				}
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("web API called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRegistryFailureExpires(t *testing.T) {
+	fail := atomic.Bool{}
+	fail.Store(true)
+	registry, calls := newTestRegistry(t, func(w http.ResponseWriter, _ *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"enabled": false})
+	})
+
+	if _, err := registry.ForProject(context.Background(), "proj-1"); err == nil {
+		t.Fatal("ForProject() succeeded, want error")
+	}
+
+	// Expire the cached failure instead of waiting out failureTTL
+	registry.mu.Lock()
+	cached := registry.cache["proj-1"]
+	if ttl := time.Until(cached.expiresAt); ttl > failureTTL {
+		t.Errorf("failure cached for %s, want at most %s", ttl, failureTTL)
+	}
+	cached.expiresAt = time.Now().Add(-time.Second)
+	registry.cache["proj-1"] = cached
+	registry.mu.Unlock()
+
+	fail.Store(false)
+	if _, err := registry.ForProject(context.Background(), "proj-1"); err != nil {
+		t.Fatalf("ForProject() after expiry error = %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("web API called %d times, want 2", got)
+	}
+}
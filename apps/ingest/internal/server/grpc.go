@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // OTel exporters compress with gzip by default
+
+	"github.com/cognobserve/ingest/internal/handler"
+	authmw "github.com/cognobserve/ingest/internal/middleware"
+	cognobservev1 "github.com/cognobserve/ingest/internal/proto/cognobserve/v1"
+)
+
+// setupGRPC creates the gRPC server exposing IngestService and the OTLP
+// TraceService, so OTel Collectors can export to the same port.
+// Calls are authenticated with the same rules as the HTTP /v1/traces routes.
+func (s *Server) setupGRPC() {
+	s.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			authmw.GRPCMaintenance(s.maintenance),
+			authmw.GRPCAuth(s.cfg, s.tokenVerifier, s.auditLog),
+		),
+	)
+	cognobservev1.RegisterIngestServiceServer(s.grpcServer, handler.NewIngestService(s.handler))
+	coltracepb.RegisterTraceServiceServer(s.grpcServer, handler.NewOTLPTraceService(s.handler))
+}
+
+// stopGRPC drains in-flight RPCs, forcing a stop if ctx expires first
+func (s *Server) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+}
@@ -3,31 +3,71 @@ package server
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"google.golang.org/grpc"
 
+	"github.com/cognobserve/ingest/internal/audit"
+	"github.com/cognobserve/ingest/internal/budget"
 	"github.com/cognobserve/ingest/internal/config"
+	"github.com/cognobserve/ingest/internal/dedup"
+	"github.com/cognobserve/ingest/internal/geoip"
 	"github.com/cognobserve/ingest/internal/handler"
+	"github.com/cognobserve/ingest/internal/idempotency"
+	"github.com/cognobserve/ingest/internal/liveness"
+	"github.com/cognobserve/ingest/internal/maintenance"
+	"github.com/cognobserve/ingest/internal/metrics"
 	authmw "github.com/cognobserve/ingest/internal/middleware"
 	"github.com/cognobserve/ingest/internal/temporal"
+	"github.com/cognobserve/ingest/internal/upload"
 )
 
-// Server represents the HTTP server
+// TemporalClient is the Temporal client the server runs against: what the
+// handlers and the watchdog use, plus Close. *temporal.Client implements it.
+type TemporalClient interface {
+	handler.TemporalClient
+	temporal.Probe
+	Close()
+}
+
+// Server represents the HTTP server and, when enabled, the gRPC server
 type Server struct {
 	cfg            *config.Config
 	handler        *handler.Handler
 	router         chi.Router
 	server         *http.Server
-	temporalClient *temporal.Client
+	grpcServer     *grpc.Server
+	temporalClient TemporalClient
+	watchdog       *temporal.Watchdog
+	heartbeat      *liveness.Heartbeat
+	idempotency    *idempotency.Store
+	tokenVerifier  *authmw.TokenVerifier
+	auditLog       *audit.Logger
+	maintenance    *maintenance.Mode
 }
 
 // New creates a new server with Temporal client
-func New(cfg *config.Config, temporalClient *temporal.Client) *Server {
-	h := handler.New(temporalClient)
+// deduplicator is optional (nil disables trace deduplication), as are
+// uploads (nil disables upload sessions), budgets (nil disables cost
+// budgets) and geo (nil skips client location lookups); auditLog records
+// auth decisions (nil discards them).
+// startedAt is the process start time reported as uptime by /health.
+func New(cfg *config.Config, temporalClient TemporalClient, deduplicator *dedup.Deduplicator, uploads *upload.Store, budgets *budget.Tracker, geo geoip.Lookup, auditLog *audit.Logger, startedAt time.Time) *Server {
+	watchdog := temporal.NewWatchdog(
+		temporalClient,
+		cfg.TemporalHealthCheckInterval,
+		cfg.TemporalHealthFailureThreshold,
+		cfg.EstimateReadyAt,
+	)
+	heartbeat := liveness.New(cfg.LivenessInterval)
+	h := handler.New(cfg, temporalClient, watchdog, heartbeat, deduplicator, uploads, budgets, geo, startedAt)
 	r := chi.NewRouter()
 
 	s := &Server{
@@ -35,9 +75,18 @@ func New(cfg *config.Config, temporalClient *temporal.Client) *Server {
 		handler:        h,
 		router:         r,
 		temporalClient: temporalClient,
+		watchdog:       watchdog,
+		heartbeat:      heartbeat,
+		idempotency:    idempotency.NewStore(cfg.IdempotencyTTL, cfg.IdempotencyMaxKeys),
+		tokenVerifier:  authmw.NewTokenVerifier(cfg),
+		auditLog:       auditLog,
+		maintenance:    maintenance.New(cfg.MaintenanceMode, cfg.MaintenanceFile),
 	}
 
 	s.setupRoutes()
+	if cfg.GRPCPort != "" {
+		s.setupGRPC()
+	}
 	return s
 }
 
@@ -46,73 +95,219 @@ func (s *Server) setupRoutes() {
 
 	// Middleware
 	r.Use(middleware.RequestID)
+	r.Use(authmw.CorrelationID)
 	r.Use(middleware.RealIP)
+	r.Use(authmw.ClientIP)
 	r.Use(middleware.Logger)
+	r.Use(authmw.SlowRequests(s.cfg.SlowRequestThreshold))
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * time.Second))
+
+	// Canonical paths have no trailing slash; SDKs append one inconsistently
+	switch s.cfg.TrailingSlash {
+	case "strip":
+		r.Use(middleware.StripSlashes)
+	case "redirect":
+		r.Use(authmw.RedirectSlashes)
+	}
+
+	// Unmatched routes get the JSON error envelope instead of chi's plain text.
+	// Set before routes are added so subrouters inherit them.
+	r.NotFound(s.handler.NotFound)
+	r.MethodNotAllowed(s.handler.MethodNotAllowed)
 
 	// CORS
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Project-ID", "X-API-Key"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Project-ID", "X-API-Key", "Idempotency-Key", authmw.CorrelationIDHeader, authmw.RequestTimeoutHeader},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed", authmw.CorrelationIDHeader, handler.BudgetSpendHeader, handler.BudgetLimitHeader},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
 	// Health check (no auth)
+	// HEAD responses carry the same status with the body discarded by net/http
 	r.Get("/health", s.handler.Health)
+	r.Head("/health", s.handler.Health)
+	r.Options("/health", s.handler.Options)
+	r.Get("/health/ready", s.handler.Ready)
+	r.Get("/health/live", s.handler.Live)
+
+	// Prometheus metrics (no auth)
+	r.Handle("/metrics", metrics.Handler())
+
+	// Live profiling for internal callers only. CPU profiles and traces
+	// must finish within the server write timeout, e.g. ?seconds=20.
+	if s.cfg.PprofEnabled {
+		r.Route("/debug/pprof", func(r chi.Router) {
+			r.Use(authmw.RequireInternalSecret(s.cfg))
+			r.HandleFunc("/", pprof.Index)
+			r.HandleFunc("/cmdline", pprof.Cmdline)
+			r.HandleFunc("/profile", pprof.Profile)
+			r.HandleFunc("/symbol", pprof.Symbol)
+			r.HandleFunc("/trace", pprof.Trace)
+			r.HandleFunc("/*", pprof.Index) // Named profiles: heap, goroutine, allocs, ...
+		})
+	}
 
 	// API routes
 	r.Route("/v1", func(r chi.Router) {
-		// Authentication middleware chain:
-		// 1. API key auth (if X-API-Key header present)
-		// 2. Optional JWT auth (if Authorization header present)
-		// 3. Require at least one auth method
-		r.Use(authmw.APIKeyAuth(s.cfg))
-		r.Use(authmw.OptionalJWTAuth)
-		r.Use(authmw.RequireAuth)
-
-		// Trace endpoints (require project access)
-		r.Route("/traces", func(r chi.Router) {
-			r.Use(authmw.RequireProjectAccess("X-Project-ID"))
-			r.Post("/", s.handler.IngestTrace)
+		// Reject with a retryable 503 during planned maintenance; /health stays up
+		r.Use(authmw.Maintenance(s.maintenance, s.cfg.MaintenanceRetryAfter))
+
+		// Bound handling per request; ?wait=true requests get the longer
+		// timeout. NDJSON streams are bounded per chunk by their handler.
+		r.Use(authmw.RequestTimeout(s.cfg.RequestTimeout, s.cfg.WaitRequestTimeout, "/v1/traces/ndjson"))
+		// A client's tighter X-Request-Timeout-Ms shortens the deadline
+		r.Use(authmw.ClientDeadline())
+
+		// Bound concurrent work; health and metrics endpoints are exempt
+		r.Use(authmw.ConcurrencyLimit(s.cfg.MaxConcurrentRequests))
+		r.Use(authmw.Compress(s.cfg.CompressionMinSize))
+
+		// Cap the body as sent, and again once inflated
+		r.Use(authmw.LimitRequestBody(s.cfg.MaxRawRequestBytes))
+		r.Use(authmw.Decompress(s.cfg.MaxDecompressedRequestBytes))
+
+		// Version endpoint (no auth) so SDKs can negotiate capabilities
+		r.Get("/version", s.handler.Version)
+		r.Head("/version", s.handler.Version)
+		r.Options("/version", s.handler.Options)
+
+		r.Group(func(r chi.Router) {
+			// Authentication middleware chain:
+			// 1. Trusted gateway auth (if TRUST_UPSTREAM_AUTH and its secret header present)
+			// 2. API key auth (if X-API-Key header present)
+			// 3. Optional JWT auth (if Authorization header present)
+			// 4. Require at least one auth method
+			// Every decision is recorded to the audit log
+			r.Use(authmw.UpstreamAuth(s.cfg, s.auditLog))
+			r.Use(authmw.APIKeyAuth(s.cfg, s.auditLog))
+			r.Use(authmw.OptionalJWTAuth(s.tokenVerifier, s.auditLog))
+			r.Use(authmw.RequireAuth(s.auditLog))
+
+			// Trace endpoints (require project access)
+			r.Route("/traces", func(r chi.Router) {
+				r.Use(authmw.RequireProjectAccess(s.cfg, "X-Project-ID", s.auditLog))
+
+				// Streamed responses can't be buffered for idempotent replay
+				r.With(authmw.RequireFeature(authmw.FeatureStreaming)).Post("/ndjson", s.handler.IngestNDJSON)
+
+				r.Group(func(r chi.Router) {
+					r.Use(authmw.Idempotency(s.idempotency, "X-Project-ID"))
+					r.Post("/", s.handler.IngestTrace)
+					r.Post("/batch", s.handler.IngestBatch)
+					r.Post("/validate", s.handler.ValidateTrace)
+					if s.cfg.UploadSessionsEnabled {
+						r.Group(func(r chi.Router) {
+							r.Use(authmw.RequireFeature(authmw.FeatureStreaming))
+							r.Post("/sessions", s.handler.CreateUploadSession)
+							r.Post("/sessions/{sessionID}/chunk", s.handler.AppendUploadChunk)
+							r.Post("/sessions/{sessionID}/commit", s.handler.CommitUploadSession)
+						})
+					}
+					r.Get("/{traceID}/status", s.handler.GetTraceStatus)
+					r.With(authmw.RequireScope(authmw.ScopeTracesRead, s.auditLog)).Get("/{traceID}", s.handler.GetTrace)
+					r.Patch("/{traceID}/spans/{spanID}", s.handler.UpdateSpan)
+				})
+			})
+
+			// Session endpoints (require project access). Sessions are
+			// looked up by search attribute, so need them enabled.
+			if s.cfg.TemporalSearchAttributes {
+				r.Route("/sessions", func(r chi.Router) {
+					r.Use(authmw.RequireProjectAccess(s.cfg, "X-Project-ID", s.auditLog))
+					r.Post("/{sessionID}/cancel", s.handler.CancelSession)
+				})
+			}
 		})
 	})
 }
 
-// Run starts the server and blocks until context is cancelled
+// Run starts the servers and blocks until context is cancelled.
+// If either server fails, both are shut down and the error is returned.
+// The audit log and webhook queue are flushed once both servers have drained.
 func (s *Server) Run(ctx context.Context) error {
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%s", s.cfg.Port),
 		Handler:      s.router,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		WriteTimeout: s.cfg.WriteTimeout(),
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Start server in goroutine
-	errCh := make(chan error, 1)
+	// Bind the gRPC port up front so a conflict fails startup cleanly
+	var grpcListener net.Listener
+	if s.grpcServer != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", s.cfg.GRPCPort))
+		if err != nil {
+			return fmt.Errorf("failed to listen on gRPC port %s: %w", s.cfg.GRPCPort, err)
+		}
+		grpcListener = lis
+	}
+
+	// Watch the Temporal connection and re-dial on sustained failure
+	go s.watchdog.Run(ctx)
+
+	// Beat for /health/live, which fails if the process wedges
+	go s.heartbeat.Run(ctx)
+
+	// Keep JWKS signing keys fresh (no-op without JWT_JWKS_URL)
+	go s.tokenVerifier.Run(ctx)
+
+	// Start servers in goroutines
+	errCh := make(chan error, 2)
 	go func() {
 		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			errCh <- err
 		}
 	}()
+	if grpcListener != nil {
+		go func() {
+			// Serve returns nil once GracefulStop or Stop is called
+			if err := s.grpcServer.Serve(grpcListener); err != nil {
+				errCh <- fmt.Errorf("gRPC server: %w", err)
+			}
+		}()
+	}
 
 	// Wait for context cancellation or error
+	var runErr error
 	select {
 	case <-ctx.Done():
-		// Graceful shutdown
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		return s.server.Shutdown(shutdownCtx)
-	case err := <-errCh:
-		return err
+	case runErr = <-errCh:
 	}
+
+	// Graceful shutdown of both servers
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if s.grpcServer != nil {
+		s.stopGRPC(shutdownCtx)
+	}
+	if err := s.server.Shutdown(shutdownCtx); err != nil && runErr == nil {
+		runErr = err
+	}
+
+	// In-flight requests have drained, so their audit events and webhook
+	// deliveries are queued; flush them within what's left of the shutdown timeout
+	if err := s.auditLog.Close(shutdownCtx); err != nil {
+		slog.Error("failed to flush audit log", "error", err)
+	}
+	if err := s.handler.Close(shutdownCtx); err != nil {
+		slog.Error("failed to flush webhooks", "error", err)
+	}
+	return runErr
+}
+
+// Reload applies configuration that can change at runtime (on SIGHUP).
+// Currently this is maintenance mode.
+func (s *Server) Reload() {
+	s.maintenance.Reload()
 }
 
-// Close cleans up server resources
+// Close cleans up server resources, including the Temporal client.
+// Call it after Run returns, once in-flight requests have drained.
 func (s *Server) Close() {
 	if s.temporalClient != nil {
 		s.temporalClient.Close()
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/config"
+	authmw "github.com/cognobserve/ingest/internal/middleware"
+	"github.com/cognobserve/ingest/internal/response"
+	"github.com/cognobserve/ingest/internal/temporal/temporaltest"
+)
+
+// testUpstreamSecret authenticates test requests as a trusted gateway
+const testUpstreamSecret = "test-upstream-secret-0123456789abcdef"
+
+// newTestServer serves the HTTP routes over client, with the default
+// configuration plus trusted gateway auth. mutate, if non-nil, adjusts it.
+func newTestServer(t *testing.T, client *temporaltest.Client, mutate func(*config.Config)) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(newServer(t, client, mutate).router)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// newServer creates the Server behind newTestServer
+func newServer(t *testing.T, client *temporaltest.Client, mutate func(*config.Config)) *Server {
+	t.Helper()
+	t.Setenv("INTERNAL_API_SECRET", "test-internal-secret-0123456789abcdef")
+	t.Setenv("JWT_SHARED_SECRET", "test-jwt-secret-0123456789abcdef0123")
+	t.Setenv("TRUST_UPSTREAM_AUTH", "true")
+	t.Setenv("UPSTREAM_AUTH_SECRET", testUpstreamSecret)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.GRPCPort = ""
+	if mutate != nil {
+		mutate(cfg)
+	}
+
+	return New(cfg, client, nil, nil, nil, nil, nil, time.Now())
+}
+
+// post sends body to path on ts as project proj-1
+func post(t *testing.T, ts *httptest.Server, path, contentType, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(authmw.UpstreamSecretHeader, testUpstreamSecret)
+	req.Header.Set(authmw.ForwardedProjectIDHeader, "proj-1")
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	return resp, string(respBody)
+}
+
+func TestTrailingSlashStrip(t *testing.T) {
+	const trace = `{"trace_id":"t1","name":"chat","spans":[{"name":"llm"}]}`
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		wantStatus  int
+	}{
+		{name: "traces", path: "/v1/traces", contentType: "application/json", wantStatus: http.StatusAccepted},
+		{name: "traces with slash", path: "/v1/traces/", contentType: "application/json", wantStatus: http.StatusAccepted},
+		{name: "ndjson with slash", path: "/v1/traces/ndjson/", contentType: "application/x-ndjson", wantStatus: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &temporaltest.Client{}
+			ts := newTestServer(t, client, func(cfg *config.Config) { cfg.TrailingSlash = "strip" })
+
+			resp, body := post(t, ts, tt.path, tt.contentType, trace+"\n", nil)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", got, tt.contentType)
+			}
+			if started := client.Started(); len(started) != 1 || started[0].ID != "t1" {
+				t.Errorf("started = %+v, want trace t1", started)
+			}
+		})
+	}
+}
+
+func TestIdempotentReplayTrailingSlash(t *testing.T) {
+	const trace = `{"trace_id":"t1","name":"chat","spans":[{"name":"llm"}]}`
+	client := &temporaltest.Client{}
+	ts := newTestServer(t, client, func(cfg *config.Config) { cfg.TrailingSlash = "strip" })
+	header := http.Header{authmw.IdempotencyKeyHeader: {"key-1"}}
+
+	first, firstBody := post(t, ts, "/v1/traces", "application/json", trace, header)
+	if first.StatusCode != http.StatusAccepted {
+		t.Fatalf("first status = %d, want %d: %s", first.StatusCode, http.StatusAccepted, firstBody)
+	}
+
+	// A retry that adds a trailing slash is the same request
+	retry, retryBody := post(t, ts, "/v1/traces/", "application/json", trace, header)
+	if retry.StatusCode != http.StatusAccepted {
+		t.Fatalf("retry status = %d, want %d: %s", retry.StatusCode, http.StatusAccepted, retryBody)
+	}
+	if got := retry.Header.Get(authmw.IdempotentReplayedHeader); got != "true" {
+		t.Errorf("%s = %q, want true", authmw.IdempotentReplayedHeader, got)
+	}
+	if retryBody != firstBody {
+		t.Errorf("retry body = %s, want the replayed %s", retryBody, firstBody)
+	}
+	if started := client.Started(); len(started) != 1 {
+		t.Errorf("started %d workflows, want 1", len(started))
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	const trace = `{"trace_id":"t1","name":"chat","spans":[{"name":"llm"}]}`
+
+	tests := []struct {
+		name            string
+		initial         bool // MAINTENANCE_MODE
+		flagFile        bool // MAINTENANCE_FILE exists when the server reloads
+		reload          bool
+		wantMaintenance bool
+	}{
+		{name: "off"},
+		{name: "on at startup", initial: true, wantMaintenance: true},
+		{name: "reload turns it on", flagFile: true, reload: true, wantMaintenance: true},
+		{name: "reload turns it off", initial: true, reload: true},
+		// The flag file only counts once the server reloads
+		{name: "file without reload", flagFile: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flagFile := filepath.Join(t.TempDir(), "maintenance")
+			s := newServer(t, &temporaltest.Client{}, func(cfg *config.Config) {
+				cfg.MaintenanceMode = tt.initial
+				cfg.MaintenanceFile = flagFile
+				cfg.MaintenanceRetryAfter = 45 * time.Second
+			})
+			ts := httptest.NewServer(s.router)
+			t.Cleanup(ts.Close)
+
+			if tt.flagFile {
+				if err := os.WriteFile(flagFile, nil, 0o600); err != nil {
+					t.Fatalf("write flag file: %v", err)
+				}
+			}
+			if tt.reload {
+				s.Reload()
+			}
+
+			resp, body := post(t, ts, "/v1/traces", "application/json", trace, nil)
+			if !tt.wantMaintenance {
+				if resp.StatusCode != http.StatusAccepted {
+					t.Errorf("POST /v1/traces status = %d, want %d: %s", resp.StatusCode, http.StatusAccepted, body)
+				}
+			} else {
+				if resp.StatusCode != http.StatusServiceUnavailable {
+					t.Fatalf("POST /v1/traces status = %d, want %d: %s", resp.StatusCode, http.StatusServiceUnavailable, body)
+				}
+				if got := resp.Header.Get("Retry-After"); got != "45" {
+					t.Errorf("Retry-After = %q, want 45", got)
+				}
+				var errBody response.ErrorBody
+				if err := json.Unmarshal([]byte(body), &errBody); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if errBody.Code != "maintenance" {
+					t.Errorf("code = %q, want maintenance", errBody.Code)
+				}
+			}
+
+			// Health checks stay up so the instance isn't restarted
+			health, err := ts.Client().Get(ts.URL + "/health")
+			if err != nil {
+				t.Fatalf("GET /health: %v", err)
+			}
+			health.Body.Close()
+			if health.StatusCode != http.StatusOK {
+				t.Errorf("GET /health status = %d, want %d", health.StatusCode, http.StatusOK)
+			}
+		})
+	}
+}
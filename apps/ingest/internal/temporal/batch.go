@@ -0,0 +1,40 @@
+package temporal
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchStartResult is the outcome of one workflow start within a batch
+type BatchStartResult struct {
+	Result *StartResult
+	Err    error
+}
+
+// StartTraceWorkflowsBatch starts trace workflows concurrently using a bounded
+// worker pool shared by all callers. Results are returned in input order.
+// If ctx is cancelled while waiting for a slot, the remaining items fail with
+// the context error.
+func (c *Client) StartTraceWorkflowsBatch(ctx context.Context, inputs []TraceWorkflowInput) []BatchStartResult {
+	results := make([]BatchStartResult, len(inputs))
+
+	var wg sync.WaitGroup
+	for i := range inputs {
+		if err := c.startSem.Acquire(ctx, 1); err != nil {
+			for j := i; j < len(inputs); j++ {
+				results[j].Err = err
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer c.startSem.Release(1)
+			results[i].Result, results[i].Err = c.StartTraceWorkflow(ctx, inputs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
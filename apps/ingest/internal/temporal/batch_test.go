@@ -0,0 +1,110 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"golang.org/x/sync/semaphore"
+)
+
+// newBatchClient returns a Client whose starts take latency each and that
+// records the peak number of starts in flight
+func newBatchClient(maxConcurrent int, latency time.Duration, peak *int64) *Client {
+	var inFlight int64
+	sdk := &fakeSDK{execute: func(opts client.StartWorkflowOptions) (client.WorkflowRun, error) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			p := atomic.LoadInt64(peak)
+			if n <= p || atomic.CompareAndSwapInt64(peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(latency)
+		return fakeRun{id: opts.ID, runID: "run-" + opts.ID}, nil
+	}}
+	return &Client{
+		client:              sdk,
+		taskQueue:           "traces",
+		maxConcurrentStarts: maxConcurrent,
+		startSem:            semaphore.NewWeighted(int64(maxConcurrent)),
+	}
+}
+
+func traceInputs(n int) []TraceWorkflowInput {
+	inputs := make([]TraceWorkflowInput, n)
+	for i := range inputs {
+		inputs[i] = TraceWorkflowInput{ID: strconv.Itoa(i)}
+	}
+	return inputs
+}
+
+func TestStartTraceWorkflowsBatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxConcurrent int
+		items         int
+		cancelled     bool
+	}{
+		{name: "fewer items than slots", maxConcurrent: 8, items: 3},
+		{name: "more items than slots", maxConcurrent: 2, items: 10},
+		{name: "cancelled", maxConcurrent: 2, items: 4, cancelled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var peak int64
+			c := newBatchClient(tt.maxConcurrent, time.Millisecond, &peak)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			results := c.StartTraceWorkflowsBatch(ctx, traceInputs(tt.items))
+			if len(results) != tt.items {
+				t.Fatalf("got %d results, want %d", len(results), tt.items)
+			}
+			for i, r := range results {
+				if tt.cancelled {
+					if !errors.Is(r.Err, context.Canceled) {
+						t.Errorf("results[%d].Err = %v, want context.Canceled", i, r.Err)
+					}
+					continue
+				}
+				if r.Err != nil {
+					t.Fatalf("results[%d].Err = %v", i, r.Err)
+				}
+				// Results keep the input order
+				if want := "trace-" + strconv.Itoa(i); r.Result.WorkflowID != want {
+					t.Errorf("results[%d].WorkflowID = %q, want %q", i, r.Result.WorkflowID, want)
+				}
+			}
+			if peak > int64(tt.maxConcurrent) {
+				t.Errorf("peak in-flight starts = %d, want at most %d", peak, tt.maxConcurrent)
+			}
+		})
+	}
+}
+
+func BenchmarkStartTraceWorkflowsBatch(b *testing.B) {
+	const items = 100
+	for _, maxConcurrent := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("concurrency=%d", maxConcurrent), func(b *testing.B) {
+			var peak int64
+			c := newBatchClient(maxConcurrent, 100*time.Microsecond, &peak)
+			inputs := traceInputs(items)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.StartTraceWorkflowsBatch(context.Background(), inputs)
+			}
+		})
+	}
+}
@@ -2,10 +2,21 @@ package temporal
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	sdktemporal "go.temporal.io/sdk/temporal"
+	"golang.org/x/sync/semaphore"
 )
 
 // Workflow names must match the TypeScript workflow function names
@@ -14,20 +25,111 @@ const (
 	ScoreWorkflowName = "scoreWorkflow"
 )
 
+// Signal names must match the TypeScript signal definitions
+const (
+	UpdateSpanSignalName = "updateSpan"
+)
+
+// Query names must match the TypeScript query definitions
+const (
+	ProgressQueryName = "progress"
+	InputQueryName    = "input"
+)
+
+// Memo keys set on trace workflows
+const (
+	ProjectIDMemoKey = "projectId"
+	SpanIDsMemoKey   = "spanIds"
+
+	// RetentionDaysMemoKey holds the trace's retention in days, when set
+	RetentionDaysMemoKey = "retentionDays"
+
+	// CorrelationIDMemoKey holds the correlation ID of the ingest request
+	CorrelationIDMemoKey = "correlationId"
+)
+
 // Workflow execution timeouts
 const (
 	TraceWorkflowTimeout = 5 * time.Minute
 	ScoreWorkflowTimeout = 2 * time.Minute
 )
 
+// Search attributes set on trace workflows when the client is created with
+// searchAttributes enabled. These must be registered on the Temporal
+// namespace first (scripts/temporal-search-attributes.sh runs the same):
+//
+//	temporal operator search-attribute create --name Environment --type Keyword
+//	temporal operator search-attribute create --name Release --type Keyword
+//	temporal operator search-attribute create --name Tags --type KeywordList
+//	temporal operator search-attribute create --name TraceStatus --type Keyword
+//	temporal operator search-attribute create --name ProjectID --type Keyword
+//	temporal operator search-attribute create --name SessionID --type Keyword
+//	temporal operator search-attribute create --name RetentionDays --type Int
+var (
+	EnvironmentSearchAttribute   = sdktemporal.NewSearchAttributeKeyKeyword("Environment")
+	ReleaseSearchAttribute       = sdktemporal.NewSearchAttributeKeyKeyword("Release")
+	TagsSearchAttribute          = sdktemporal.NewSearchAttributeKeyKeywordList("Tags")
+	TraceStatusSearchAttribute   = sdktemporal.NewSearchAttributeKeyKeyword("TraceStatus")
+	ProjectIDSearchAttribute     = sdktemporal.NewSearchAttributeKeyKeyword("ProjectID")
+	SessionIDSearchAttribute     = sdktemporal.NewSearchAttributeKeyKeyword("SessionID")
+	RetentionDaysSearchAttribute = sdktemporal.NewSearchAttributeKeyInt64("RetentionDays")
+)
+
+// Errors returned when signalling a trace workflow
+var (
+	ErrTraceNotFound  = errors.New("trace not found")
+	ErrTraceFinalized = errors.New("trace is finalized")
+	ErrSpanNotFound   = errors.New("span not found in trace")
+	ErrTraceGone      = errors.New("trace workflow no longer holds its input")
+
+	// ErrQueryUnsupported is returned when the worker that ran a trace
+	// doesn't register the query, e.g. it predates the query handler
+	ErrQueryUnsupported = errors.New("trace workflow does not support this query")
+
+	// ErrSearchAttributesDisabled is returned by lookups that rely on the
+	// trace search attributes when they aren't being set
+	ErrSearchAttributesDisabled = errors.New("trace search attributes are disabled")
+)
+
+// StartResult describes the outcome of starting a workflow
+type StartResult struct {
+	WorkflowID string
+	RunID      string
+	Duplicate  bool // True when the workflow had already been started with this ID
+}
+
 // Client wraps the Temporal SDK client for workflow operations
+// The underlying SDK client can be swapped by Reconnect, so all access
+// goes through sdk() under the read lock.
 type Client struct {
+	mu        sync.RWMutex
+	closeOnce sync.Once
+	closed    bool // Set by Close; a concurrent Reconnect drops its fresh connection
 	client    client.Client
+	address   string
+	namespace string
 	taskQueue string
+
+	// Caps concurrent batch workflow starts across all requests
+	maxConcurrentStarts int
+	startSem            *semaphore.Weighted
+
+	// How long a completed trace workflow keeps its ID from being started again;
+	// zero keeps it for as long as the namespace retains the run
+	duplicateWindow time.Duration
+
+	// Whether trace workflows carry search attributes; starts fail on a
+	// namespace where they aren't registered
+	searchAttributes bool
 }
 
 // New creates a new Temporal client connection
-func New(address, namespace, taskQueue string) (*Client, error) {
+// maxConcurrentStarts bounds how many workflow starts batch ingestion may
+// have in flight at once, to protect the Temporal frontend.
+// duplicateWindow is how long after a trace workflow closes a start with the
+// same trace ID is still reported as a duplicate (zero: until retention).
+// searchAttributes enables setting the trace search attributes on start.
+func New(address, namespace, taskQueue string, maxConcurrentStarts int, duplicateWindow time.Duration, searchAttributes bool) (*Client, error) {
 	c, err := client.Dial(client.Options{
 		HostPort:  address,
 		Namespace: namespace,
@@ -37,28 +139,272 @@ func New(address, namespace, taskQueue string) (*Client, error) {
 	}
 
 	return &Client{
-		client:    c,
-		taskQueue: taskQueue,
+		client:              c,
+		address:             address,
+		namespace:           namespace,
+		taskQueue:           taskQueue,
+		maxConcurrentStarts: maxConcurrentStarts,
+		startSem:            semaphore.NewWeighted(int64(maxConcurrentStarts)),
+		duplicateWindow:     duplicateWindow,
+		searchAttributes:    searchAttributes,
 	}, nil
 }
 
+// sdk returns the current underlying SDK client
+func (c *Client) sdk() client.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
+}
+
+// Reconnect dials a fresh connection and swaps it in place of the current one.
+// The previous connection is closed after the swap. On dial failure the
+// current connection is kept.
+func (c *Client) Reconnect() error {
+	fresh, err := New(c.address, c.namespace, c.taskQueue, c.maxConcurrentStarts, c.duplicateWindow, c.searchAttributes)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		fresh.client.Close()
+		return nil
+	}
+	stale := c.client
+	c.client = fresh.client
+	c.mu.Unlock()
+
+	if stale != nil {
+		stale.Close()
+	}
+	return nil
+}
+
 // StartTraceWorkflow starts a trace ingestion workflow
-// Returns the workflow ID for tracking
-func (c *Client) StartTraceWorkflow(ctx context.Context, input TraceWorkflowInput) (string, error) {
+// If a workflow with the same trace ID is running, or completed within the
+// duplicate window, the existing execution is returned with Duplicate set
+// so client retries are safe. A trace whose run failed is started again.
+func (c *Client) StartTraceWorkflow(ctx context.Context, input TraceWorkflowInput) (*StartResult, error) {
 	workflowID := "trace-" + input.ID
+	opts := c.traceStartOptions(workflowID, input)
+
+	we, err := c.sdk().ExecuteWorkflow(ctx, opts, TraceWorkflowName, input)
+	var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+	if errors.As(err, &alreadyStarted) && c.duplicateWindow > 0 {
+		expired, descErr := c.closedBefore(ctx, workflowID, alreadyStarted.RunId, time.Now().Add(-c.duplicateWindow))
+		if descErr != nil {
+			return nil, descErr
+		}
+		if expired {
+			// A running workflow still fails the start, so this can't
+			// race with another submission into a second live run
+			opts.WorkflowIDReusePolicy = enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE
+			we, err = c.sdk().ExecuteWorkflow(ctx, opts, TraceWorkflowName, input)
+		}
+	}
+	if err != nil {
+		if errors.As(err, &alreadyStarted) {
+			return &StartResult{
+				WorkflowID: workflowID,
+				RunID:      alreadyStarted.RunId,
+				Duplicate:  true,
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to start trace workflow: %w", err)
+	}
+
+	return &StartResult{
+		WorkflowID: we.GetID(),
+		RunID:      we.GetRunID(),
+	}, nil
+}
+
+// traceStartOptions returns the options starting a trace's workflow
+func (c *Client) traceStartOptions(workflowID string, input TraceWorkflowInput) client.StartWorkflowOptions {
+	// Projects with a dedicated worker pool name their own queue
+	taskQueue := c.taskQueue
+	if input.TaskQueue != "" {
+		taskQueue = input.TaskQueue
+	}
 
 	opts := client.StartWorkflowOptions{
 		ID:                       workflowID,
-		TaskQueue:                c.taskQueue,
+		TaskQueue:                taskQueue,
 		WorkflowExecutionTimeout: TraceWorkflowTimeout,
+		WorkflowRunTimeout:       input.RunTimeout,
+		Memo:                     traceMemo(input),
+		// Completed runs keep their trace ID, so a resubmitted trace isn't
+		// processed twice, but a trace whose run failed, timed out or was
+		// terminated can be resubmitted. The namespace default may allow
+		// duplicates of completed runs too.
+		WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY,
+		// Surface the already-started error instead of silently returning the
+		// existing run, so we can report the duplicate to the caller
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+
+	if c.searchAttributes {
+		opts.TypedSearchAttributes = traceSearchAttributes(input)
 	}
+	return opts
+}
+
+// WaitTraceWorkflow blocks until a trace workflow run completes and returns
+// its result, or until ctx is done
+func (c *Client) WaitTraceWorkflow(ctx context.Context, workflowID, runID string) (*TraceWorkflowResult, error) {
+	var result TraceWorkflowResult
+	if err := c.sdk().GetWorkflow(ctx, workflowID, runID).Get(ctx, &result); err != nil {
+		return nil, fmt.Errorf("failed waiting for trace workflow: %w", err)
+	}
+	return &result, nil
+}
 
-	we, err := c.client.ExecuteWorkflow(ctx, opts, TraceWorkflowName, input)
+// closedBefore reports whether a workflow run closed before cutoff.
+// Running workflows report false.
+func (c *Client) closedBefore(ctx context.Context, workflowID, runID string, cutoff time.Time) (bool, error) {
+	desc, err := c.sdk().DescribeWorkflowExecution(ctx, workflowID, runID)
 	if err != nil {
-		return "", fmt.Errorf("failed to start trace workflow: %w", err)
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			// Removed by retention since the start attempt
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to describe trace workflow: %w", err)
 	}
+	info := desc.GetWorkflowExecutionInfo()
+	if info.GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING || info.GetCloseTime() == nil {
+		return false, nil
+	}
+	return info.GetCloseTime().AsTime().Before(cutoff), nil
+}
 
-	return we.GetID(), nil
+// traceSearchAttributes builds the search attributes for a trace workflow
+func traceSearchAttributes(input TraceWorkflowInput) sdktemporal.SearchAttributes {
+	updates := []sdktemporal.SearchAttributeUpdate{ProjectIDSearchAttribute.ValueSet(input.ProjectID)}
+	if input.SessionID != "" {
+		updates = append(updates, SessionIDSearchAttribute.ValueSet(input.SessionID))
+	}
+	if input.Environment != "" {
+		updates = append(updates, EnvironmentSearchAttribute.ValueSet(input.Environment))
+	}
+	if input.Release != "" {
+		updates = append(updates, ReleaseSearchAttribute.ValueSet(input.Release))
+	}
+	if len(input.Tags) > 0 {
+		updates = append(updates, TagsSearchAttribute.ValueSet(input.Tags))
+	}
+	if input.Status != "" {
+		updates = append(updates, TraceStatusSearchAttribute.ValueSet(input.Status))
+	}
+	if input.RetentionDays > 0 {
+		updates = append(updates, RetentionDaysSearchAttribute.ValueSet(int64(input.RetentionDays)))
+	}
+	return sdktemporal.NewSearchAttributes(updates...)
+}
+
+// traceMemo builds the memo for a trace workflow.
+// The memo lets us verify project ownership and span membership for
+// follow-up requests without querying the workflow.
+func traceMemo(input TraceWorkflowInput) map[string]interface{} {
+	spanIDs := make([]string, len(input.Spans))
+	for i, span := range input.Spans {
+		spanIDs[i] = span.ID
+	}
+	memo := map[string]interface{}{
+		ProjectIDMemoKey: input.ProjectID,
+		SpanIDsMemoKey:   spanIDs,
+	}
+	if input.RetentionDays > 0 {
+		memo[RetentionDaysMemoKey] = input.RetentionDays
+	}
+	if input.CorrelationID != "" {
+		memo[CorrelationIDMemoKey] = input.CorrelationID
+	}
+	return memo
+}
+
+// describeTrace returns the execution info for a trace workflow owned by projectID.
+// Traces of other projects are reported as not found to avoid leaking their existence.
+func (c *Client) describeTrace(ctx context.Context, projectID, traceID string) (*workflowpb.WorkflowExecutionInfo, error) {
+	desc, err := c.sdk().DescribeWorkflowExecution(ctx, "trace-"+traceID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return nil, ErrTraceNotFound
+		}
+		return nil, fmt.Errorf("failed to describe trace workflow: %w", err)
+	}
+
+	info := desc.GetWorkflowExecutionInfo()
+
+	var ownerProjectID string
+	if payload, ok := info.GetMemo().GetFields()[ProjectIDMemoKey]; ok {
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &ownerProjectID); err != nil {
+			return nil, fmt.Errorf("failed to decode trace memo: %w", err)
+		}
+	}
+	if ownerProjectID != projectID {
+		return nil, ErrTraceNotFound
+	}
+
+	return info, nil
+}
+
+// memoSpanIDs returns the span IDs recorded in a trace workflow's memo
+func memoSpanIDs(info *workflowpb.WorkflowExecutionInfo) ([]string, error) {
+	var spanIDs []string
+	if payload, ok := info.GetMemo().GetFields()[SpanIDsMemoKey]; ok {
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &spanIDs); err != nil {
+			return nil, fmt.Errorf("failed to decode trace memo: %w", err)
+		}
+	}
+	return spanIDs, nil
+}
+
+// UpdateSpan signals a running trace workflow with a partial span update.
+// The trace must belong to projectID and contain the span being updated.
+func (c *Client) UpdateSpan(ctx context.Context, projectID, traceID string, update SpanUpdateInput) error {
+	workflowID := "trace-" + traceID
+
+	info, err := c.describeTrace(ctx, projectID, traceID)
+	if err != nil {
+		return err
+	}
+
+	if info.GetStatus() != enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		return ErrTraceFinalized
+	}
+
+	spanIDs, err := memoSpanIDs(info)
+	if err != nil {
+		return err
+	}
+	if !containsString(spanIDs, update.SpanID) {
+		return ErrSpanNotFound
+	}
+
+	runID := info.GetExecution().GetRunId()
+	if err := c.sdk().SignalWorkflow(ctx, workflowID, runID, UpdateSpanSignalName, update); err != nil {
+		// The workflow may have completed between describe and signal
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return ErrTraceFinalized
+		}
+		return fmt.Errorf("failed to signal trace workflow: %w", err)
+	}
+
+	return nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
 }
 
 // StartScoreWorkflow starts a score ingestion workflow
@@ -72,7 +418,7 @@ func (c *Client) StartScoreWorkflow(ctx context.Context, input ScoreWorkflowInpu
 		WorkflowExecutionTimeout: ScoreWorkflowTimeout,
 	}
 
-	we, err := c.client.ExecuteWorkflow(ctx, opts, ScoreWorkflowName, input)
+	we, err := c.sdk().ExecuteWorkflow(ctx, opts, ScoreWorkflowName, input)
 	if err != nil {
 		return "", fmt.Errorf("failed to start score workflow: %w", err)
 	}
@@ -80,16 +426,82 @@ func (c *Client) StartScoreWorkflow(ctx context.Context, input ScoreWorkflowInpu
 	return we.GetID(), nil
 }
 
-// Close closes the Temporal client connection
+// Close closes the Temporal client connection. Later calls are no-ops.
 func (c *Client) Close() {
-	if c.client != nil {
-		c.client.Close()
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closed = true
+		sdk := c.client
+		c.mu.Unlock()
+
+		if sdk != nil {
+			sdk.Close()
+		}
+	})
+}
+
+// Backlog is a sample of the default task queue's workflow task backlog
+type Backlog struct {
+	Count        int64     // Approximate workflow tasks waiting for a worker
+	DispatchRate float64   // Tasks handed to workers per second, as observed by the server
+	SampledAt    time.Time // When the sample was taken
+}
+
+// TaskQueueBacklog samples the backlog of the default task queue.
+// Servers that don't report task queue stats return an error.
+func (c *Client) TaskQueueBacklog(ctx context.Context) (Backlog, error) {
+	resp, err := c.sdk().WorkflowService().DescribeTaskQueue(ctx, &workflowservice.DescribeTaskQueueRequest{
+		Namespace:     c.namespace,
+		TaskQueue:     &taskqueuepb.TaskQueue{Name: c.taskQueue, Kind: enumspb.TASK_QUEUE_KIND_NORMAL},
+		TaskQueueType: enumspb.TASK_QUEUE_TYPE_WORKFLOW,
+		ReportStats:   true,
+	})
+	if err != nil {
+		return Backlog{}, fmt.Errorf("failed to describe task queue %s: %w", c.taskQueue, err)
+	}
+	stats := resp.GetStats()
+	if stats == nil {
+		return Backlog{}, errors.New("task queue stats not reported")
+	}
+	return Backlog{
+		Count:        stats.GetApproximateBacklogCount(),
+		DispatchRate: float64(stats.GetTasksDispatchRate()),
+		SampledAt:    time.Now().UTC(),
+	}, nil
+}
+
+// VerifyNamespace checks that the configured namespace exists. Dialing is
+// lazy, so a misconfigured namespace would otherwise only show up as
+// failing workflow starts. When it's missing, the error lists the
+// namespaces the server has, if listing them is permitted.
+func (c *Client) VerifyNamespace(ctx context.Context) error {
+	_, err := c.sdk().WorkflowService().DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{
+		Namespace: c.namespace,
+	})
+	if err == nil {
+		return nil
+	}
+
+	var notFound *serviceerror.NamespaceNotFound
+	if !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to describe Temporal namespace %q: %w", c.namespace, err)
+	}
+
+	resp, listErr := c.sdk().WorkflowService().ListNamespaces(ctx, &workflowservice.ListNamespacesRequest{})
+	if listErr != nil {
+		return fmt.Errorf("namespace %q does not exist on Temporal at %s (check TEMPORAL_NAMESPACE)", c.namespace, c.address)
+	}
+	names := make([]string, 0, len(resp.GetNamespaces()))
+	for _, ns := range resp.GetNamespaces() {
+		names = append(names, ns.GetNamespaceInfo().GetName())
 	}
+	return fmt.Errorf("namespace %q does not exist on Temporal at %s (check TEMPORAL_NAMESPACE; available: %s)",
+		c.namespace, c.address, strings.Join(names, ", "))
 }
 
 // IsHealthy checks if the Temporal connection is healthy
 func (c *Client) IsHealthy(ctx context.Context) bool {
-	// Try to describe the default namespace as a health check
-	_, err := c.client.WorkflowService().DescribeNamespace(ctx, nil)
+	// Use the gRPC health check against the frontend
+	_, err := c.sdk().CheckHealth(ctx, &client.CheckHealthRequest{})
 	return err == nil
 }
@@ -0,0 +1,328 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	namespacepb "go.temporal.io/api/namespace/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeSDK stubs the SDK calls made when starting a trace workflow.
+// Calls to other methods panic on the nil embedded client.
+type fakeSDK struct {
+	client.Client
+	execute  func(opts client.StartWorkflowOptions) (client.WorkflowRun, error)
+	describe func(workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error)
+	service  workflowservice.WorkflowServiceClient
+
+	mu     sync.Mutex
+	starts []client.StartWorkflowOptions
+}
+
+func (f *fakeSDK) ExecuteWorkflow(_ context.Context, opts client.StartWorkflowOptions, _ interface{}, _ ...interface{}) (client.WorkflowRun, error) {
+	f.mu.Lock()
+	f.starts = append(f.starts, opts)
+	f.mu.Unlock()
+	return f.execute(opts)
+}
+
+func (f *fakeSDK) DescribeWorkflowExecution(_ context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	return f.describe(workflowID, runID)
+}
+
+func (f *fakeSDK) WorkflowService() workflowservice.WorkflowServiceClient {
+	return f.service
+}
+
+// fakeWorkflowService stubs the namespace RPCs. Calls to other methods
+// panic on the nil embedded client.
+type fakeWorkflowService struct {
+	workflowservice.WorkflowServiceClient
+	describeErr error
+	namespaces  []string
+	listErr     error
+}
+
+func (f *fakeWorkflowService) DescribeNamespace(context.Context, *workflowservice.DescribeNamespaceRequest, ...grpc.CallOption) (*workflowservice.DescribeNamespaceResponse, error) {
+	if f.describeErr != nil {
+		return nil, f.describeErr
+	}
+	return &workflowservice.DescribeNamespaceResponse{}, nil
+}
+
+func (f *fakeWorkflowService) ListNamespaces(context.Context, *workflowservice.ListNamespacesRequest, ...grpc.CallOption) (*workflowservice.ListNamespacesResponse, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	resp := &workflowservice.ListNamespacesResponse{}
+	for _, name := range f.namespaces {
+		resp.Namespaces = append(resp.Namespaces, &workflowservice.DescribeNamespaceResponse{
+			NamespaceInfo: &namespacepb.NamespaceInfo{Name: name},
+		})
+	}
+	return resp, nil
+}
+
+type fakeRun struct {
+	client.WorkflowRun
+	id, runID string
+}
+
+func (r fakeRun) GetID() string    { return r.id }
+func (r fakeRun) GetRunID() string { return r.runID }
+
+func closedAt(t time.Time) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+			Status:    enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED,
+			CloseTime: timestamppb.New(t),
+		},
+	}, nil
+}
+
+func TestTraceStartOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         TraceWorkflowInput
+		wantTaskQueue string
+	}{
+		{name: "default queue", input: TraceWorkflowInput{ID: "t1"}, wantTaskQueue: "traces"},
+		{name: "project queue", input: TraceWorkflowInput{ID: "t1", TaskQueue: "traces-acme"}, wantTaskQueue: "traces-acme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{taskQueue: "traces", duplicateWindow: time.Hour}
+			opts := c.traceStartOptions("trace-"+tt.input.ID, tt.input)
+
+			// Failed runs must stay retryable by resubmitting the trace
+			if opts.WorkflowIDReusePolicy != enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY {
+				t.Errorf("WorkflowIDReusePolicy = %v, want ALLOW_DUPLICATE_FAILED_ONLY", opts.WorkflowIDReusePolicy)
+			}
+			if !opts.WorkflowExecutionErrorWhenAlreadyStarted {
+				t.Error("WorkflowExecutionErrorWhenAlreadyStarted = false, want true")
+			}
+			if opts.TaskQueue != tt.wantTaskQueue {
+				t.Errorf("TaskQueue = %q, want %q", opts.TaskQueue, tt.wantTaskQueue)
+			}
+			if opts.ID != "trace-t1" {
+				t.Errorf("ID = %q, want trace-t1", opts.ID)
+			}
+		})
+	}
+}
+
+func TestStartTraceWorkflow(t *testing.T) {
+	alreadyStarted := serviceerror.NewWorkflowExecutionAlreadyStarted("already started", "", "run-old")
+	running := func(string, string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+		return &workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{Status: enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING},
+		}, nil
+	}
+
+	tests := []struct {
+		name            string
+		duplicateWindow time.Duration
+		execute         func(opts client.StartWorkflowOptions) (client.WorkflowRun, error)
+		describe        func(workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error)
+		want            *StartResult
+		wantErr         bool
+		wantStarts      int
+	}{
+		{
+			name: "new trace",
+			execute: func(opts client.StartWorkflowOptions) (client.WorkflowRun, error) {
+				return fakeRun{id: opts.ID, runID: "run-new"}, nil
+			},
+			want:       &StartResult{WorkflowID: "trace-t1", RunID: "run-new"},
+			wantStarts: 1,
+		},
+		{
+			name: "already started",
+			execute: func(client.StartWorkflowOptions) (client.WorkflowRun, error) {
+				return nil, alreadyStarted
+			},
+			want:       &StartResult{WorkflowID: "trace-t1", RunID: "run-old", Duplicate: true},
+			wantStarts: 1,
+		},
+		{
+			name:            "still running within window",
+			duplicateWindow: time.Hour,
+			execute: func(client.StartWorkflowOptions) (client.WorkflowRun, error) {
+				return nil, alreadyStarted
+			},
+			describe:   running,
+			want:       &StartResult{WorkflowID: "trace-t1", RunID: "run-old", Duplicate: true},
+			wantStarts: 1,
+		},
+		{
+			name:            "closed within window",
+			duplicateWindow: time.Hour,
+			execute: func(client.StartWorkflowOptions) (client.WorkflowRun, error) {
+				return nil, alreadyStarted
+			},
+			describe: func(string, string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+				return closedAt(time.Now().Add(-time.Minute))
+			},
+			want:       &StartResult{WorkflowID: "trace-t1", RunID: "run-old", Duplicate: true},
+			wantStarts: 1,
+		},
+		{
+			name:            "closed before window",
+			duplicateWindow: time.Hour,
+			execute: func(opts client.StartWorkflowOptions) (client.WorkflowRun, error) {
+				if opts.WorkflowIDReusePolicy != enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE {
+					return nil, alreadyStarted
+				}
+				return fakeRun{id: opts.ID, runID: "run-new"}, nil
+			},
+			describe: func(string, string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+				return closedAt(time.Now().Add(-2 * time.Hour))
+			},
+			want:       &StartResult{WorkflowID: "trace-t1", RunID: "run-new"},
+			wantStarts: 2,
+		},
+		{
+			name: "start failure",
+			execute: func(client.StartWorkflowOptions) (client.WorkflowRun, error) {
+				return nil, errors.New("unavailable")
+			},
+			wantErr:    true,
+			wantStarts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdk := &fakeSDK{execute: tt.execute, describe: tt.describe}
+			c := &Client{client: sdk, taskQueue: "traces", duplicateWindow: tt.duplicateWindow}
+
+			got, err := c.StartTraceWorkflow(context.Background(), TraceWorkflowInput{ID: "t1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(sdk.starts) != tt.wantStarts {
+				t.Errorf("started %d times, want %d", len(sdk.starts), tt.wantStarts)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got != *tt.want {
+				t.Errorf("result = %+v, want %+v", *got, *tt.want)
+			}
+			if !sdk.starts[0].WorkflowExecutionErrorWhenAlreadyStarted {
+				t.Error("WorkflowExecutionErrorWhenAlreadyStarted = false, want duplicates reported")
+			}
+		})
+	}
+}
+
+func TestTraceSearchAttributesTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   []string
+		wantOK bool
+	}{
+		{name: "no tags"},
+		{name: "tags", tags: []string{"billing", "beta"}, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := traceSearchAttributes(TraceWorkflowInput{ProjectID: "proj-1", Tags: tt.tags})
+			got, ok := attrs.GetKeywordList(TagsSearchAttribute)
+			if ok != tt.wantOK {
+				t.Fatalf("Tags set = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !slices.Equal(got, tt.tags) {
+				t.Errorf("Tags = %v, want %v", got, tt.tags)
+			}
+		})
+	}
+}
+
+func TestStartTraceWorkflowTimeouts(t *testing.T) {
+	tests := []struct {
+		name       string
+		runTimeout time.Duration
+	}{
+		{name: "no run timeout"},
+		{name: "run timeout", runTimeout: 45 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdk := &fakeSDK{execute: func(opts client.StartWorkflowOptions) (client.WorkflowRun, error) {
+				return fakeRun{id: opts.ID, runID: "run-new"}, nil
+			}}
+			c := &Client{client: sdk, taskQueue: "traces"}
+
+			if _, err := c.StartTraceWorkflow(context.Background(), TraceWorkflowInput{ID: "t1", RunTimeout: tt.runTimeout}); err != nil {
+				t.Fatalf("StartTraceWorkflow: %v", err)
+			}
+			opts := sdk.starts[0]
+			if opts.WorkflowRunTimeout != tt.runTimeout {
+				t.Errorf("WorkflowRunTimeout = %v, want %v", opts.WorkflowRunTimeout, tt.runTimeout)
+			}
+			if opts.WorkflowExecutionTimeout != TraceWorkflowTimeout {
+				t.Errorf("WorkflowExecutionTimeout = %v, want %v", opts.WorkflowExecutionTimeout, TraceWorkflowTimeout)
+			}
+		})
+	}
+}
+
+func TestVerifyNamespace(t *testing.T) {
+	notFound := serviceerror.NewNamespaceNotFound("cognobserve")
+
+	tests := []struct {
+		name    string
+		service *fakeWorkflowService
+		wantErr string // Substring of the error; empty expects success
+	}{
+		{name: "exists", service: &fakeWorkflowService{}},
+		{
+			name:    "missing",
+			service: &fakeWorkflowService{describeErr: notFound, namespaces: []string{"default", "temporal-system"}},
+			wantErr: `namespace "cognobserve" does not exist on Temporal at localhost:7233 (check TEMPORAL_NAMESPACE; available: default, temporal-system)`,
+		},
+		{
+			name:    "missing and not listable",
+			service: &fakeWorkflowService{describeErr: notFound, listErr: serviceerror.NewPermissionDenied("denied", "")},
+			wantErr: `namespace "cognobserve" does not exist on Temporal at localhost:7233 (check TEMPORAL_NAMESPACE)`,
+		},
+		{
+			name:    "describe failure",
+			service: &fakeWorkflowService{describeErr: serviceerror.NewUnavailable("connection refused")},
+			wantErr: `failed to describe Temporal namespace "cognobserve"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{client: &fakeSDK{service: tt.service}, address: "localhost:7233", namespace: "cognobserve"}
+
+			err := c.VerifyNamespace(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyNamespace: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("VerifyNamespace() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
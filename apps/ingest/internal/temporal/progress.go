@@ -0,0 +1,133 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+)
+
+// TraceProgress matches the TypeScript result of the progress query
+type TraceProgress struct {
+	SpansProcessed int    `json:"spansProcessed"`
+	SpansTotal     int    `json:"spansTotal"`
+	Phase          string `json:"phase"`
+}
+
+// TraceStatus combines the workflow execution status with its progress
+type TraceStatus struct {
+	WorkflowID string
+	Status     string // Lower-cased workflow execution status, e.g. "running"
+	Progress   TraceProgress
+}
+
+// QueryTraceProgress returns live progress for a trace workflow owned by projectID.
+// It uses the workflow's progress query handler, falling back to the coarse
+// describe status for runs that don't support the query.
+func (c *Client) QueryTraceProgress(ctx context.Context, projectID, traceID string) (*TraceStatus, error) {
+	workflowID := "trace-" + traceID
+
+	info, err := c.describeTrace(ctx, projectID, traceID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TraceStatus{
+		WorkflowID: workflowID,
+		Status:     executionStatusName(info.GetStatus()),
+	}
+
+	value, err := c.sdk().QueryWorkflow(ctx, workflowID, info.GetExecution().GetRunId(), ProgressQueryName)
+	if err == nil {
+		if err := value.Get(&status.Progress); err != nil {
+			return nil, fmt.Errorf("failed to decode trace progress: %w", err)
+		}
+		return status, nil
+	}
+
+	// Older runs don't register the progress query
+	if !isUnknownQuery(err) {
+		return nil, fmt.Errorf("failed to query trace progress: %w", err)
+	}
+	slog.Debug("progress query unsupported, falling back to describe", "workflow_id", workflowID, "error", err)
+
+	progress, err := describedProgress(info)
+	if err != nil {
+		return nil, err
+	}
+	status.Progress = progress
+	return status, nil
+}
+
+// describedProgress approximates progress from execution info alone
+func describedProgress(info *workflowpb.WorkflowExecutionInfo) (TraceProgress, error) {
+	spanIDs, err := memoSpanIDs(info)
+	if err != nil {
+		return TraceProgress{}, err
+	}
+
+	progress := TraceProgress{
+		SpansTotal: len(spanIDs),
+		Phase:      executionStatusName(info.GetStatus()),
+	}
+	if info.GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED {
+		progress.SpansProcessed = progress.SpansTotal
+	}
+	return progress, nil
+}
+
+// executionStatusName converts WORKFLOW_EXECUTION_STATUS_RUNNING to "running"
+func executionStatusName(status enumspb.WorkflowExecutionStatus) string {
+	return strings.ToLower(strings.TrimPrefix(status.String(), "WORKFLOW_EXECUTION_STATUS_"))
+}
+
+// QueryTraceInput returns the normalized input a trace workflow owned by
+// projectID was started with, via the workflow's input query handler.
+// ErrTraceGone is returned when the run can't answer the query, e.g. it
+// has completed and its state is no longer available. ErrQueryUnsupported
+// is returned when the worker doesn't register the input query.
+func (c *Client) QueryTraceInput(ctx context.Context, projectID, traceID string) (*TraceWorkflowInput, error) {
+	workflowID := "trace-" + traceID
+
+	info, err := c.describeTrace(ctx, projectID, traceID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := c.sdk().QueryWorkflow(ctx, workflowID, info.GetExecution().GetRunId(), InputQueryName)
+	if err != nil {
+		if isUnknownQuery(err) {
+			return nil, ErrQueryUnsupported
+		}
+		var queryFailed *serviceerror.QueryFailed
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &queryFailed) || errors.As(err, &notFound) {
+			slog.Debug("input query unavailable", "workflow_id", workflowID, "status", executionStatusName(info.GetStatus()), "error", err)
+			return nil, ErrTraceGone
+		}
+		return nil, fmt.Errorf("failed to query trace input: %w", err)
+	}
+
+	var input TraceWorkflowInput
+	if err := value.Get(&input); err != nil {
+		return nil, fmt.Errorf("failed to decode trace input: %w", err)
+	}
+	return &input, nil
+}
+
+// isUnknownQuery reports whether a query failed because the workflow
+// doesn't register a handler for it. The Go SDK reports "unknown
+// queryType" and the TypeScript SDK "did not register a handler for".
+func isUnknownQuery(err error) bool {
+	var queryFailed *serviceerror.QueryFailed
+	if !errors.As(err, &queryFailed) {
+		return false
+	}
+	msg := queryFailed.Error()
+	return strings.Contains(msg, "unknown queryType") || strings.Contains(msg, "did not register a handler for")
+}
@@ -0,0 +1,56 @@
+package temporal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.temporal.io/api/serviceerror"
+)
+
+func TestIsUnknownQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "go sdk unknown query",
+			err:  serviceerror.NewQueryFailed("unknown queryType input. KnownQueryTypes=[progress]"),
+			want: true,
+		},
+		{
+			name: "typescript sdk unknown query",
+			err:  serviceerror.NewQueryFailed("Workflow did not register a handler for input. Registered queries: [progress]"),
+			want: true,
+		},
+		{
+			name: "wrapped unknown query",
+			err:  fmt.Errorf("query: %w", serviceerror.NewQueryFailed("unknown queryType input")),
+			want: true,
+		},
+		{
+			name: "query handler failed",
+			err:  serviceerror.NewQueryFailed("TypeError: cannot read properties of undefined"),
+			want: false,
+		},
+		{
+			name: "workflow not found",
+			err:  serviceerror.NewNotFound("workflow not found"),
+			want: false,
+		},
+		{
+			name: "other error",
+			err:  errors.New("unknown queryType"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnknownQuery(tt.err); got != tt.want {
+				t.Errorf("isUnknownQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// CancelSessionTraces requests cancellation of every running trace
+// workflow of projectID tagged with sessionID, for deleting a
+// conversation's data. Workflows are found through the ProjectID and
+// SessionID search attributes; each match's memo is checked again so
+// another project's traces are never touched. Returns how many workflows
+// were cancelled. Workflows that finish before the cancel arrives are not
+// counted. ErrSearchAttributesDisabled is returned when the client doesn't
+// set search attributes.
+func (c *Client) CancelSessionTraces(ctx context.Context, projectID, sessionID string) (int, error) {
+	if !c.searchAttributes {
+		return 0, ErrSearchAttributesDisabled
+	}
+
+	query := fmt.Sprintf("WorkflowType = %s AND ExecutionStatus = 'Running' AND %s = %s AND %s = %s",
+		quoteQueryValue(TraceWorkflowName),
+		ProjectIDSearchAttribute.GetName(), quoteQueryValue(projectID),
+		SessionIDSearchAttribute.GetName(), quoteQueryValue(sessionID))
+
+	cancelled := 0
+	var pageToken []byte
+	for {
+		resp, err := c.sdk().ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     c.namespace,
+			Query:         query,
+			NextPageToken: pageToken,
+		})
+		if err != nil {
+			return cancelled, fmt.Errorf("failed to list session trace workflows: %w", err)
+		}
+
+		for _, info := range resp.GetExecutions() {
+			var ownerProjectID string
+			if payload, ok := info.GetMemo().GetFields()[ProjectIDMemoKey]; ok {
+				if err := converter.GetDefaultDataConverter().FromPayload(payload, &ownerProjectID); err != nil {
+					return cancelled, fmt.Errorf("failed to decode trace memo: %w", err)
+				}
+			}
+			if ownerProjectID != projectID {
+				continue
+			}
+
+			execution := info.GetExecution()
+			if err := c.sdk().CancelWorkflow(ctx, execution.GetWorkflowId(), execution.GetRunId()); err != nil {
+				// The workflow may have completed since it was listed
+				var notFound *serviceerror.NotFound
+				if errors.As(err, &notFound) {
+					continue
+				}
+				return cancelled, fmt.Errorf("failed to cancel trace workflow %s: %w", execution.GetWorkflowId(), err)
+			}
+			cancelled++
+		}
+
+		pageToken = resp.GetNextPageToken()
+		if len(pageToken) == 0 {
+			return cancelled, nil
+		}
+	}
+}
+
+// quoteQueryValue quotes s as a string literal in a visibility query
+func quoteQueryValue(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
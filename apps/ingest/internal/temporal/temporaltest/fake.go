@@ -0,0 +1,144 @@
+// Package temporaltest provides an in-memory Temporal client for tests of
+// the handlers and server.
+package temporaltest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cognobserve/ingest/internal/temporal"
+)
+
+// Client is a fake *temporal.Client. Each method calls the matching func
+// field when it is set, and otherwise succeeds: trace workflows start with
+// ID "trace-<id>", and queries report an empty result. Started trace
+// inputs and Close calls are recorded. It is safe for concurrent use.
+type Client struct {
+	StartTraceWorkflowFunc  func(ctx context.Context, input temporal.TraceWorkflowInput) (*temporal.StartResult, error)
+	WaitTraceWorkflowFunc   func(ctx context.Context, workflowID, runID string) (*temporal.TraceWorkflowResult, error)
+	QueryTraceProgressFunc  func(ctx context.Context, projectID, traceID string) (*temporal.TraceStatus, error)
+	QueryTraceInputFunc     func(ctx context.Context, projectID, traceID string) (*temporal.TraceWorkflowInput, error)
+	UpdateSpanFunc          func(ctx context.Context, projectID, traceID string, update temporal.SpanUpdateInput) error
+	CancelSessionTracesFunc func(ctx context.Context, projectID, sessionID string) (int, error)
+	StartScoreWorkflowFunc  func(ctx context.Context, input temporal.ScoreWorkflowInput) (string, error)
+	CloseFunc               func()
+
+	mu      sync.Mutex
+	started []temporal.TraceWorkflowInput
+	closes  int
+}
+
+// Started returns the inputs of the trace workflows started so far
+func (c *Client) Started() []temporal.TraceWorkflowInput {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]temporal.TraceWorkflowInput(nil), c.started...)
+}
+
+// Closes returns how many times Close was called
+func (c *Client) Closes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closes
+}
+
+// StartTraceWorkflow records input and starts its workflow
+func (c *Client) StartTraceWorkflow(ctx context.Context, input temporal.TraceWorkflowInput) (*temporal.StartResult, error) {
+	if c.StartTraceWorkflowFunc != nil {
+		result, err := c.StartTraceWorkflowFunc(ctx, input)
+		if err == nil {
+			c.record(input)
+		}
+		return result, err
+	}
+	c.record(input)
+	return &temporal.StartResult{WorkflowID: "trace-" + input.ID, RunID: "run-" + input.ID}, nil
+}
+
+func (c *Client) record(input temporal.TraceWorkflowInput) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started = append(c.started, input)
+}
+
+// StartTraceWorkflowsBatch starts each input in order with StartTraceWorkflow
+func (c *Client) StartTraceWorkflowsBatch(ctx context.Context, inputs []temporal.TraceWorkflowInput) []temporal.BatchStartResult {
+	results := make([]temporal.BatchStartResult, len(inputs))
+	for i, input := range inputs {
+		results[i].Result, results[i].Err = c.StartTraceWorkflow(ctx, input)
+	}
+	return results
+}
+
+// WaitTraceWorkflow reports a processed trace with no spans
+func (c *Client) WaitTraceWorkflow(ctx context.Context, workflowID, runID string) (*temporal.TraceWorkflowResult, error) {
+	if c.WaitTraceWorkflowFunc != nil {
+		return c.WaitTraceWorkflowFunc(ctx, workflowID, runID)
+	}
+	return &temporal.TraceWorkflowResult{}, nil
+}
+
+// QueryTraceProgress reports a running workflow with no progress
+func (c *Client) QueryTraceProgress(ctx context.Context, projectID, traceID string) (*temporal.TraceStatus, error) {
+	if c.QueryTraceProgressFunc != nil {
+		return c.QueryTraceProgressFunc(ctx, projectID, traceID)
+	}
+	return &temporal.TraceStatus{WorkflowID: "trace-" + traceID, Status: "running"}, nil
+}
+
+// QueryTraceInput reports an empty trace
+func (c *Client) QueryTraceInput(ctx context.Context, projectID, traceID string) (*temporal.TraceWorkflowInput, error) {
+	if c.QueryTraceInputFunc != nil {
+		return c.QueryTraceInputFunc(ctx, projectID, traceID)
+	}
+	return &temporal.TraceWorkflowInput{ID: traceID, ProjectID: projectID}, nil
+}
+
+// UpdateSpan accepts the update
+func (c *Client) UpdateSpan(ctx context.Context, projectID, traceID string, update temporal.SpanUpdateInput) error {
+	if c.UpdateSpanFunc != nil {
+		return c.UpdateSpanFunc(ctx, projectID, traceID, update)
+	}
+	return nil
+}
+
+// CancelSessionTraces reports no cancelled traces
+func (c *Client) CancelSessionTraces(ctx context.Context, projectID, sessionID string) (int, error) {
+	if c.CancelSessionTracesFunc != nil {
+		return c.CancelSessionTracesFunc(ctx, projectID, sessionID)
+	}
+	return 0, nil
+}
+
+// StartScoreWorkflow starts a score workflow with ID "score-<id>"
+func (c *Client) StartScoreWorkflow(ctx context.Context, input temporal.ScoreWorkflowInput) (string, error) {
+	if c.StartScoreWorkflowFunc != nil {
+		return c.StartScoreWorkflowFunc(ctx, input)
+	}
+	return "score-" + input.ID, nil
+}
+
+// IsHealthy reports a healthy connection
+func (c *Client) IsHealthy(context.Context) bool {
+	return true
+}
+
+// Reconnect succeeds without doing anything
+func (c *Client) Reconnect() error {
+	return nil
+}
+
+// TaskQueueBacklog reports an empty task queue
+func (c *Client) TaskQueueBacklog(context.Context) (temporal.Backlog, error) {
+	return temporal.Backlog{}, nil
+}
+
+// Close records the call, then calls CloseFunc when set
+func (c *Client) Close() {
+	c.mu.Lock()
+	c.closes++
+	c.mu.Unlock()
+	if c.CloseFunc != nil {
+		c.CloseFunc()
+	}
+}
@@ -1,16 +1,31 @@
 package temporal
 
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
 // TraceWorkflowInput matches the TypeScript TraceWorkflowInput type
 type TraceWorkflowInput struct {
-	ID        string                 `json:"id"`
-	ProjectID string                 `json:"projectId"`
-	Name      string                 `json:"name"`
-	Timestamp string                 `json:"timestamp"` // ISO 8601 string
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
-	SessionID string                 `json:"sessionId,omitempty"`
-	UserID    string                 `json:"userId,omitempty"`
-	User      *UserInput             `json:"user,omitempty"`
-	Spans     []SpanInput            `json:"spans"`
+	ID            string                 `json:"id"`
+	ProjectID     string                 `json:"projectId"`
+	Name          string                 `json:"name"`
+	Timestamp     string                 `json:"timestamp"` // ISO 8601 string
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	SessionID     string                 `json:"sessionId,omitempty"`
+	UserID        string                 `json:"userId,omitempty"`
+	User          *UserInput             `json:"user,omitempty"`
+	Environment   string                 `json:"environment,omitempty"`
+	Release       string                 `json:"release,omitempty"`
+	Tags          []string               `json:"tags,omitempty"`
+	Status        string                 `json:"status,omitempty"`        // success, error, cancelled or unknown
+	RetentionDays int                    `json:"retentionDays,omitempty"` // Days to keep the trace; 0 uses the project default downstream
+	Spans         []SpanInput            `json:"spans"`
+	TaskQueue     string                 `json:"-"` // Per-project queue override; not sent to the worker
+	RunTimeout    time.Duration          `json:"-"` // Workflow run timeout; zero keeps TraceWorkflowTimeout alone
+	CorrelationID string                 `json:"-"` // Client correlation ID, recorded in the workflow memo
 }
 
 // UserInput matches TypeScript UserInput
@@ -22,21 +37,80 @@ type UserInput struct {
 
 // SpanInput matches TypeScript SpanInput
 type SpanInput struct {
-	ID               string                 `json:"id"`
-	ParentSpanID     string                 `json:"parentSpanId,omitempty"`
-	Name             string                 `json:"name"`
-	StartTime        string                 `json:"startTime"` // ISO 8601 string
-	EndTime          string                 `json:"endTime,omitempty"`
-	Input            interface{}            `json:"input,omitempty"`
-	Output           interface{}            `json:"output,omitempty"`
-	Metadata         map[string]interface{} `json:"metadata,omitempty"`
-	Model            string                 `json:"model,omitempty"`
-	ModelParameters  map[string]interface{} `json:"modelParameters,omitempty"`
-	PromptTokens     int                    `json:"promptTokens,omitempty"`
-	CompletionTokens int                    `json:"completionTokens,omitempty"`
-	TotalTokens      int                    `json:"totalTokens,omitempty"`
-	Level            string                 `json:"level,omitempty"` // DEBUG, DEFAULT, WARNING, ERROR
-	StatusMessage    string                 `json:"statusMessage,omitempty"`
+	ID                        string                 `json:"id"`
+	ParentSpanID              string                 `json:"parentSpanId,omitempty"`
+	Name                      string                 `json:"name"`
+	StartTime                 string                 `json:"startTime"` // ISO 8601 string
+	EndTime                   string                 `json:"endTime,omitempty"`
+	Input                     interface{}            `json:"input,omitempty"`
+	Output                    interface{}            `json:"output,omitempty"`
+	Metadata                  map[string]interface{} `json:"metadata,omitempty"`
+	Model                     string                 `json:"model,omitempty"`
+	Provider                  string                 `json:"provider,omitempty"`
+	ModelParameters           map[string]interface{} `json:"modelParameters,omitempty"`
+	PromptTokens              TokenCount             `json:"promptTokens,omitempty"`
+	CompletionTokens          TokenCount             `json:"completionTokens,omitempty"`
+	TotalTokens               TokenCount             `json:"totalTokens,omitempty"`
+	CostOverrideUSD           *float64               `json:"costOverrideUsd,omitempty"` // Takes precedence over the price table
+	CostSource                string                 `json:"costSource,omitempty"`      // "override", "client" or "estimated_blended"
+	PromptCostUSD             *float64               `json:"promptCostUsd,omitempty"`   // Client-supplied breakdown of CostOverrideUSD
+	CompletionCostUSD         *float64               `json:"completionCostUsd,omitempty"`
+	EstimatedPromptTokens     int64                  `json:"estimatedPromptTokens,omitempty"` // Split of TotalTokens for pricing when no breakdown was reported; only priced, so a plain number
+	EstimatedCompletionTokens int64                  `json:"estimatedCompletionTokens,omitempty"`
+	Level                     string                 `json:"level,omitempty"` // DEBUG, DEFAULT, WARNING, ERROR
+	StatusMessage             string                 `json:"statusMessage,omitempty"`
+	Attachments               []AttachmentInput      `json:"attachments,omitempty"`
+}
+
+// TokenCount is a token count, sent to the worker as a decimal string so
+// counts beyond 2^53 survive JavaScript's JSON parsing exactly. Plain
+// numbers, as in workflows started before counts were widened, are
+// still accepted.
+type TokenCount int64
+
+// MarshalJSON encodes the count as a decimal string
+func (c TokenCount) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, strconv.FormatInt(int64(c), 10)), nil
+}
+
+// UnmarshalJSON accepts a decimal string or a plain integer
+func (c *TokenCount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid token count %s", data)
+	}
+	*c = TokenCount(v)
+	return nil
+}
+
+// AttachmentInput matches TypeScript AttachmentInput
+// Exactly one of URL and ContentBase64 is set.
+type AttachmentInput struct {
+	Type          string `json:"type"` // image, audio, video, file
+	MimeType      string `json:"mimeType"`
+	URL           string `json:"url,omitempty"`
+	ContentBase64 string `json:"contentBase64,omitempty"`
+	SHA256        string `json:"sha256,omitempty"`
+}
+
+// SpanUpdateInput matches TypeScript SpanUpdateInput (updateSpan signal payload)
+// Only fields that are set are applied to the span; omitted fields are left intact.
+type SpanUpdateInput struct {
+	SpanID           string      `json:"spanId"`
+	Output           interface{} `json:"output,omitempty"`
+	EndTime          *string     `json:"endTime,omitempty"` // ISO 8601 string
+	PromptTokens     *TokenCount `json:"promptTokens,omitempty"`
+	CompletionTokens *TokenCount `json:"completionTokens,omitempty"`
+	TotalTokens      *TokenCount `json:"totalTokens,omitempty"`
+	Level            *string     `json:"level,omitempty"`
+	StatusMessage    *string     `json:"statusMessage,omitempty"`
 }
 
 // ScoreWorkflowInput matches TypeScript ScoreWorkflowInput
@@ -58,7 +132,8 @@ type ScoreWorkflowInput struct {
 type TraceWorkflowResult struct {
 	TraceID         string `json:"traceId"`
 	SpanCount       int    `json:"spanCount"`
-	CostsCalculated int    `json:"costsCalculated"`
+	CostsProvided   int    `json:"costsProvided"`   // Spans whose cost was supplied at ingest
+	CostsCalculated int    `json:"costsCalculated"` // Spans with a cost, including CostsProvided
 }
 
 // ScoreWorkflowResult matches TypeScript ScoreWorkflowResult
@@ -0,0 +1,55 @@
+package temporal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTokenCountJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    TokenCount
+		wantErr bool
+	}{
+		{name: "string", json: `{"promptTokens":"42"}`, want: 42},
+		{name: "beyond 2^53", json: `{"promptTokens":"9007199254740993"}`, want: 9007199254740993},
+		// Workflows started before counts were widened hold plain numbers
+		{name: "number", json: `{"promptTokens":42}`, want: 42},
+		{name: "null", json: `{"promptTokens":null}`},
+		{name: "fractional", json: `{"promptTokens":"12.5"}`, wantErr: true},
+		{name: "not a number", json: `{"promptTokens":"many"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var span SpanInput
+			err := json.Unmarshal([]byte(tt.json), &span)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal() = %d, want an error", span.PromptTokens)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if span.PromptTokens != tt.want {
+				t.Errorf("PromptTokens = %d, want %d", span.PromptTokens, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenCountMarshal(t *testing.T) {
+	update := SpanUpdateInput{SpanID: "s1", TotalTokens: new(TokenCount)}
+	*update.TotalTokens = 9007199254740993
+
+	got, err := json.Marshal(update)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `{"spanId":"s1","totalTokens":"9007199254740993"}`; string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
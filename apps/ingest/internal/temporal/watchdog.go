@@ -0,0 +1,139 @@
+package temporal
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds each individual health probe
+const healthCheckTimeout = 5 * time.Second
+
+// WatchdogStatus is a snapshot of the Temporal connection state
+type WatchdogStatus struct {
+	Connected           bool
+	ConsecutiveFailures int
+	LastReconnect       time.Time // Zero if the client has never been re-dialed
+	Backlog             *Backlog  // Latest task queue sample; nil when sampling is off or failing
+}
+
+// Probe is the part of Client a Watchdog drives
+type Probe interface {
+	IsHealthy(ctx context.Context) bool
+	Reconnect() error
+	TaskQueueBacklog(ctx context.Context) (Backlog, error)
+}
+
+// Watchdog periodically probes the Temporal connection and re-dials the
+// client after sustained failures, so a restarted frontend doesn't leave
+// us with stale connections until the next deploy. It can also sample the
+// task queue backlog on each healthy probe.
+type Watchdog struct {
+	client        Probe
+	interval      time.Duration
+	threshold     int
+	sampleBacklog bool
+
+	mu     sync.RWMutex
+	status WatchdogStatus
+}
+
+// NewWatchdog creates a watchdog that probes every interval and reconnects
+// after threshold consecutive failed probes. With sampleBacklog set, each
+// healthy probe also records the task queue backlog.
+func NewWatchdog(client Probe, interval time.Duration, threshold int, sampleBacklog bool) *Watchdog {
+	return &Watchdog{
+		client:        client,
+		interval:      interval,
+		threshold:     threshold,
+		sampleBacklog: sampleBacklog,
+		status:        WatchdogStatus{Connected: true}, // New only returns once dialed
+	}
+}
+
+// Run probes the connection until the context is cancelled
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.probe(ctx)
+		}
+	}
+}
+
+// Status returns the latest connection state
+func (w *Watchdog) Status() WatchdogStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.status
+}
+
+func (w *Watchdog) probe(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	healthy := w.client.IsHealthy(probeCtx)
+	cancel()
+
+	if healthy {
+		backlog := w.probeBacklog(ctx)
+
+		w.mu.Lock()
+		if !w.status.Connected {
+			slog.Info("temporal connection recovered")
+		}
+		w.status.Connected = true
+		w.status.ConsecutiveFailures = 0
+		w.status.Backlog = backlog
+		w.mu.Unlock()
+		return
+	}
+
+	w.mu.Lock()
+	w.status.ConsecutiveFailures++
+	w.status.Backlog = nil
+	failures := w.status.ConsecutiveFailures
+	if failures >= w.threshold {
+		w.status.Connected = false
+	}
+	w.mu.Unlock()
+
+	slog.Warn("temporal health check failed", "consecutive_failures", failures)
+	if failures < w.threshold {
+		return
+	}
+
+	// Re-dial outside the lock so Status() stays responsive
+	slog.Warn("temporal unhealthy, reconnecting", "consecutive_failures", failures)
+	if err := w.client.Reconnect(); err != nil {
+		slog.Error("failed to reconnect to temporal", "error", err)
+		return
+	}
+	slog.Info("temporal client reconnected")
+
+	w.mu.Lock()
+	w.status.Connected = true
+	w.status.ConsecutiveFailures = 0
+	w.status.LastReconnect = time.Now().UTC()
+	w.mu.Unlock()
+}
+
+// probeBacklog samples the task queue backlog, returning nil if sampling
+// is off or the server can't report it
+func (w *Watchdog) probeBacklog(ctx context.Context) *Backlog {
+	if !w.sampleBacklog {
+		return nil
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	backlog, err := w.client.TaskQueueBacklog(probeCtx)
+	if err != nil {
+		slog.Debug("task queue backlog sample failed", "error", err)
+		return nil
+	}
+	return &backlog
+}
@@ -0,0 +1,161 @@
+package upload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces upload session keys in Redis
+const keyPrefix = "ingest:upload:"
+
+// Errors returned by Store
+var (
+	ErrSessionNotFound = errors.New("upload session not found or expired")
+	ErrSessionTooLarge = errors.New("upload session exceeds the size limit")
+)
+
+// Session identifies an open upload session
+type Session struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+// Store keeps upload sessions in Redis: a trace header plus span chunks,
+// appended one request at a time and assembled when the session is
+// committed. Sessions expire ttl after creation; their total size is
+// capped at maxBytes.
+type Store struct {
+	redis    *redis.Client
+	ttl      time.Duration
+	maxBytes int64
+}
+
+// New creates a Store keeping sessions in redisURL
+func New(redisURL string, ttl time.Duration, maxBytes int64) (*Store, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	return &Store{redis: redis.NewClient(opts), ttl: ttl, maxBytes: maxBytes}, nil
+}
+
+func metaKey(id string) string   { return keyPrefix + id + ":meta" }
+func chunksKey(id string) string { return keyPrefix + id + ":chunks" }
+
+// Create opens a session for projectID holding the encoded trace header
+func (s *Store) Create(ctx context.Context, projectID string, header []byte) (Session, error) {
+	if int64(len(header)) > s.maxBytes {
+		return Session{}, ErrSessionTooLarge
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Session{}, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	session := Session{ID: hex.EncodeToString(b), ExpiresAt: time.Now().Add(s.ttl)}
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, metaKey(session.ID), "project_id", projectID, "header", header, "bytes", len(header))
+	pipe.ExpireAt(ctx, metaKey(session.ID), session.ExpiresAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Session{}, fmt.Errorf("failed to create upload session: %w", err)
+	}
+	return session, nil
+}
+
+// appendScript appends the chunk in ARGV[2] to the session at KEYS[1]
+// (meta) and KEYS[2] (chunks) and returns the chunk count. The owner
+// check, size check and append run atomically, so a session expiring
+// part-way can't leave the meta key recreated without a TTL. It returns
+// -1 when the session is missing or not owned by ARGV[1], and -2 when the
+// chunk would take the session past ARGV[3] bytes. The chunk list expires
+// with the session.
+var appendScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'project_id') ~= ARGV[1] then
+	return -1
+end
+local size = string.len(ARGV[2])
+if tonumber(redis.call('HGET', KEYS[1], 'bytes')) + size > tonumber(ARGV[3]) then
+	return -2
+end
+redis.call('HINCRBY', KEYS[1], 'bytes', size)
+local count = redis.call('RPUSH', KEYS[2], ARGV[2])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[2], ttl)
+end
+return count
+`)
+
+// Append adds an encoded chunk to a session owned by projectID and
+// returns the number of chunks received so far
+func (s *Store) Append(ctx context.Context, projectID, id string, chunk []byte) (int64, error) {
+	count, err := appendScript.Run(ctx, s.redis, []string{metaKey(id), chunksKey(id)},
+		projectID, chunk, s.maxBytes).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to append upload chunk: %w", err)
+	}
+	switch count {
+	case -1:
+		return 0, ErrSessionNotFound
+	case -2:
+		return 0, ErrSessionTooLarge
+	}
+	return count, nil
+}
+
+// Load returns the header and chunks, in upload order, of a session owned by projectID
+func (s *Store) Load(ctx context.Context, projectID, id string) ([]byte, [][]byte, error) {
+	if err := s.checkOwner(ctx, projectID, id); err != nil {
+		return nil, nil, err
+	}
+
+	header, err := s.redis.HGet(ctx, metaKey(id), "header").Bytes()
+	if err != nil {
+		return nil, nil, s.notFound(err)
+	}
+	values, err := s.redis.LRange(ctx, chunksKey(id), 0, -1).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read upload chunks: %w", err)
+	}
+	chunks := make([][]byte, len(values))
+	for i, v := range values {
+		chunks[i] = []byte(v)
+	}
+	return header, chunks, nil
+}
+
+// Delete removes a session once committed
+func (s *Store) Delete(ctx context.Context, id string) error {
+	return s.redis.Del(ctx, metaKey(id), chunksKey(id)).Err()
+}
+
+// Close closes the Redis connection
+func (s *Store) Close() error {
+	return s.redis.Close()
+}
+
+// checkOwner reports other projects' sessions as not found, so their IDs can't be probed
+func (s *Store) checkOwner(ctx context.Context, projectID, id string) error {
+	owner, err := s.redis.HGet(ctx, metaKey(id), "project_id").Result()
+	if err != nil {
+		return s.notFound(err)
+	}
+	if owner != projectID {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *Store) notFound(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return ErrSessionNotFound
+	}
+	return fmt.Errorf("failed to read upload session: %w", err)
+}
@@ -0,0 +1,110 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestStore(t *testing.T, maxBytes int64) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	store, err := New("redis://"+mr.Addr(), time.Hour, maxBytes)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store, mr
+}
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name      string
+		projectID string
+		expire    bool // Let the session expire before appending
+		chunk     string
+		wantCount int64
+		wantErr   error
+		wantBytes string // Session size afterwards; empty when the session is gone
+	}{
+		{name: "appended", projectID: "proj-1", chunk: "chunk", wantCount: 1, wantBytes: "11"},
+		{name: "at the size limit", projectID: "proj-1", chunk: "chunk-chunk-", wantCount: 1, wantBytes: "18"},
+		{name: "over the size limit", projectID: "proj-1", chunk: "chunk-chunk-x", wantErr: ErrSessionTooLarge, wantBytes: "6"},
+		{name: "other project", projectID: "proj-2", chunk: "chunk", wantErr: ErrSessionNotFound, wantBytes: "6"},
+		// The meta key must not be recreated without a TTL
+		{name: "expired session", projectID: "proj-1", expire: true, chunk: "chunk", wantErr: ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, mr := newTestStore(t, 18)
+			session, err := store.Create(ctx, "proj-1", []byte("header"))
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if tt.expire {
+				mr.FastForward(2 * time.Hour)
+			}
+
+			count, err := store.Append(ctx, tt.projectID, session.ID, []byte(tt.chunk))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Append() error = %v, want %v", err, tt.wantErr)
+			}
+			if count != tt.wantCount {
+				t.Errorf("count = %d, want %d", count, tt.wantCount)
+			}
+
+			if tt.wantBytes == "" {
+				for _, key := range []string{metaKey(session.ID), chunksKey(session.ID)} {
+					if mr.Exists(key) {
+						t.Errorf("%s exists after appending to an expired session", key)
+					}
+				}
+				return
+			}
+			if got := mr.HGet(metaKey(session.ID), "bytes"); got != tt.wantBytes {
+				t.Errorf("session bytes = %s, want %s", got, tt.wantBytes)
+			}
+			if tt.wantErr != nil {
+				if mr.Exists(chunksKey(session.ID)) {
+					t.Error("chunk list exists after a rejected append")
+				}
+				return
+			}
+			// The chunk list expires with the session
+			if got, want := mr.TTL(chunksKey(session.ID)), mr.TTL(metaKey(session.ID)); got <= 0 || (want-got).Abs() > time.Millisecond {
+				t.Errorf("chunk list TTL = %s, want the session's %s", got, want)
+			}
+		})
+	}
+}
+
+func TestAppendLoadOrder(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t, 1024)
+	session, err := store.Create(ctx, "proj-1", []byte("header"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for i, chunk := range []string{"a", "b", "c"} {
+		count, err := store.Append(ctx, "proj-1", session.ID, []byte(chunk))
+		if err != nil {
+			t.Fatalf("Append(%s): %v", chunk, err)
+		}
+		if count != int64(i+1) {
+			t.Errorf("Append(%s) count = %d, want %d", chunk, count, i+1)
+		}
+	}
+
+	header, chunks, err := store.Load(ctx, "proj-1", session.ID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(header) != "header" || len(chunks) != 3 || string(chunks[0]) != "a" || string(chunks[2]) != "c" {
+		t.Errorf("Load() = %q, %q, want header and chunks a, b, c", header, chunks)
+	}
+}
@@ -0,0 +1,178 @@
+package webapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/config"
+)
+
+const (
+	// InternalSecretHeader authenticates calls to /api/internal/*
+	InternalSecretHeader = "X-Internal-Secret"
+
+	// requestTimeout bounds calls to the internal web API
+	requestTimeout = 5 * time.Second
+)
+
+// ErrScoreConfigNotFound is returned when a score config doesn't exist in the project
+var ErrScoreConfigNotFound = errors.New("score config not found")
+
+// NewHTTPClient creates the HTTP client for internal web API calls.
+// When mTLS is configured the client presents its certificate and verifies
+// the web API against the configured CA.
+func NewHTTPClient(cfg *config.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.InternalTLSConfig != nil {
+		transport.TLSClientConfig = cfg.InternalTLSConfig.Clone()
+	}
+	return &http.Client{Timeout: requestTimeout, Transport: transport}
+}
+
+// Client calls the web app's internal API
+type Client struct {
+	cfg  *config.Config
+	http *http.Client
+}
+
+// New creates an internal web API client
+func New(cfg *config.Config) *Client {
+	return &Client{cfg: cfg, http: NewHTTPClient(cfg)}
+}
+
+// ScoreConfig constrains the values accepted for a score
+type ScoreConfig struct {
+	ID         string   `json:"id"`
+	DataType   string   `json:"dataType"` // NUMERIC, CATEGORICAL, BOOLEAN
+	MinValue   *float64 `json:"minValue,omitempty"`
+	MaxValue   *float64 `json:"maxValue,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+type scoreConfigRequest struct {
+	ProjectID string `json:"projectId"`
+	ConfigID  string `json:"configId"`
+}
+
+type scoreConfigResponse struct {
+	Found  bool         `json:"found"`
+	Config *ScoreConfig `json:"config,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// GetScoreConfig fetches a score config belonging to projectID via
+// POST /api/internal/score-config {projectId, configId}, which responds
+// with {found, config?, error?}.
+func (c *Client) GetScoreConfig(ctx context.Context, projectID, configID string) (*ScoreConfig, error) {
+	var result scoreConfigResponse
+	if err := c.post(ctx, "/api/internal/score-config", scoreConfigRequest{ProjectID: projectID, ConfigID: configID}, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != "" {
+		return nil, fmt.Errorf("score config lookup failed: %s", result.Error)
+	}
+	if !result.Found || result.Config == nil {
+		return nil, ErrScoreConfigNotFound
+	}
+	return result.Config, nil
+}
+
+// OutputSchemas is a project's structured-output schema registry
+type OutputSchemas struct {
+	Enabled bool                       `json:"enabled"` // Projects opt in to validation
+	Schemas map[string]json.RawMessage `json:"schemas"` // JSON schema per span name
+}
+
+type outputSchemasRequest struct {
+	ProjectID string `json:"projectId"`
+}
+
+type outputSchemasResponse struct {
+	OutputSchemas
+	Error string `json:"error,omitempty"`
+}
+
+// GetOutputSchemas fetches the output schemas registered for projectID via
+// POST /api/internal/output-schemas {projectId}, which responds with
+// {enabled, schemas, error?}.
+func (c *Client) GetOutputSchemas(ctx context.Context, projectID string) (*OutputSchemas, error) {
+	var result outputSchemasResponse
+	if err := c.post(ctx, "/api/internal/output-schemas", outputSchemasRequest{ProjectID: projectID}, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != "" {
+		return nil, fmt.Errorf("output schema lookup failed: %s", result.Error)
+	}
+	return &result.OutputSchemas, nil
+}
+
+// ModelPrice is a model's current price from the pricing table
+type ModelPrice struct {
+	InputPricePerMillion  float64 `json:"inputPricePerMillion"`
+	OutputPricePerMillion float64 `json:"outputPricePerMillion"`
+}
+
+type modelPricesRequest struct {
+	Models []string `json:"models"`
+}
+
+type modelPricesResponse struct {
+	Prices map[string]ModelPrice `json:"prices"`
+	Error  string                `json:"error,omitempty"`
+}
+
+// GetModelPrices fetches current prices for models via
+// POST /api/internal/model-pricing {models}, which responds with
+// {prices, error?}. Models without a price are absent from the result.
+func (c *Client) GetModelPrices(ctx context.Context, models []string) (map[string]ModelPrice, error) {
+	var result modelPricesResponse
+	if err := c.post(ctx, "/api/internal/model-pricing", modelPricesRequest{Models: models}, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != "" {
+		return nil, fmt.Errorf("model pricing lookup failed: %s", result.Error)
+	}
+	return result.Prices, nil
+}
+
+// post sends a JSON request to an internal endpoint and decodes the JSON response
+func (c *Client) post(ctx context.Context, path string, body, out any) error {
+	url := strings.TrimSuffix(c.cfg.WebAPIURL, "/") + path
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(InternalSecretHeader, c.cfg.InternalAPISecret())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("internal API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("internal API returned %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
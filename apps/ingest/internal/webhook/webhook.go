@@ -0,0 +1,290 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cognobserve/ingest/internal/metrics"
+)
+
+// Headers sent with each delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the project's webhook secret, so receivers
+// can verify the sender and reject replays.
+const (
+	SignatureHeader = "X-CognObserve-Signature"
+	TimestampHeader = "X-CognObserve-Timestamp"
+)
+
+// Delivery results recorded in metrics
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+	resultDropped = "dropped"
+)
+
+// Event is the payload posted when a trace is ingested
+type Event struct {
+	TraceID   string    `json:"trace_id"`
+	ProjectID string    `json:"project_id"`
+	SpanCount int       `json:"span_count"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Target is where a project's events are delivered
+type Target struct {
+	URL    string
+	Secret string
+}
+
+type delivery struct {
+	target   Target
+	event    Event
+	attempts int // Failed attempts so far
+}
+
+// ErrForbiddenAddress is returned when a webhook resolves to an address
+// that isn't publicly routable, e.g. loopback or the cloud metadata service
+var ErrForbiddenAddress = errors.New("webhook address is not publicly routable")
+
+// Dispatcher posts events to project webhooks from a bounded worker pool.
+// Enqueue never blocks: when the queue is full the event is dropped and
+// counted, so webhooks can't slow ingestion. Failed deliveries are
+// re-queued after a backoff rather than holding a worker.
+//
+// Webhook URLs are tenant-supplied, so only https URLs are delivered to,
+// and connections to private, loopback and link-local addresses are
+// refused after DNS resolution.
+type Dispatcher struct {
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+
+	queue   chan delivery
+	done    chan struct{} // Closed to stop the workers
+	stopped sync.WaitGroup
+	once    sync.Once
+}
+
+// New starts workers delivering queued events, each attempt bounded by
+// timeout and failed attempts retried up to maxAttempts in total
+func New(workers, queueSize, maxAttempts int, timeout time.Duration) *Dispatcher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialled instead of the target, bypassing the check
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: timeout,
+		Control: checkDialAddress,
+	}).DialContext
+
+	d := &Dispatcher{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// Deliver only to the configured URL
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		maxAttempts: maxAttempts,
+		backoff:     500 * time.Millisecond,
+		queue:       make(chan delivery, queueSize),
+		done:        make(chan struct{}),
+	}
+	d.stopped.Add(workers)
+	for range workers {
+		go d.run()
+	}
+	return d
+}
+
+// Enqueue queues an event for target. It never blocks; a nil Dispatcher
+// or an empty target URL discards the event.
+func (d *Dispatcher) Enqueue(target Target, e Event) {
+	if d == nil || target.URL == "" {
+		return
+	}
+	select {
+	case d.queue <- delivery{target: target, event: e}:
+	default:
+		metrics.WebhookDeliveries.WithLabelValues(resultDropped).Inc()
+	}
+}
+
+// Close delivers queued events, giving up when ctx is done.
+// Events still queued at the deadline are counted as dropped.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.once.Do(func() { close(d.done) })
+
+	stopped := make(chan struct{})
+	go func() {
+		d.stopped.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		metrics.WebhookDeliveries.WithLabelValues(resultDropped).Add(float64(len(d.queue)))
+		return fmt.Errorf("webhook flush incomplete: %w", ctx.Err())
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer d.stopped.Done()
+	for {
+		select {
+		case dl := <-d.queue:
+			d.deliver(dl)
+		case <-d.done:
+			// Drain what was queued before shutdown
+			for {
+				select {
+				case dl := <-d.queue:
+					d.deliver(dl)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver makes one attempt to post an event. Failures are retried with
+// exponential backoff until maxAttempts; invalid URLs aren't retried.
+func (d *Dispatcher) deliver(dl delivery) {
+	body, err := json.Marshal(dl.event)
+	if err != nil {
+		slog.Error("failed to encode webhook event", "error", err)
+		return
+	}
+
+	retryable := true
+	if err = validateURL(dl.target.URL); err == nil {
+		err = d.post(dl.target, body)
+	} else {
+		retryable = false
+	}
+	if err == nil {
+		metrics.WebhookDeliveries.WithLabelValues(resultSuccess).Inc()
+		return
+	}
+
+	dl.attempts++
+	if retryable && !errors.Is(err, ErrForbiddenAddress) && dl.attempts < d.maxAttempts {
+		d.retryAfter(d.backoff<<(dl.attempts-1), dl)
+		return
+	}
+
+	metrics.WebhookDeliveries.WithLabelValues(resultFailure).Inc()
+	slog.Warn("webhook delivery failed",
+		"error", err,
+		"project_id", dl.event.ProjectID,
+		"trace_id", dl.event.TraceID,
+		"attempts", dl.attempts,
+	)
+}
+
+// retryAfter re-queues a failed delivery once backoff has passed. Retries
+// due after shutdown, or that find the queue full, are dropped.
+func (d *Dispatcher) retryAfter(backoff time.Duration, dl delivery) {
+	time.AfterFunc(backoff, func() {
+		select {
+		case <-d.done:
+			metrics.WebhookDeliveries.WithLabelValues(resultDropped).Inc()
+			return
+		default:
+		}
+		select {
+		case d.queue <- dl:
+		default:
+			metrics.WebhookDeliveries.WithLabelValues(resultDropped).Inc()
+		}
+	})
+}
+
+func (d *Dispatcher) post(target Target, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(target.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// validateURL accepts only absolute https URLs
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("webhook URL must be an absolute https URL (got %q)", u.Redacted())
+	}
+	return nil
+}
+
+// checkDialAddress refuses connections to addresses that aren't publicly
+// routable. It runs on the resolved address, so DNS can't be used to
+// point a public hostname at an internal service.
+func checkDialAddress(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// publicAddr reports whether addr is a publicly routable unicast address
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!cgnat.Contains(addr)
+}
+
+// cgnat is the shared address space used inside carrier and cloud networks
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// Sign returns the hex HMAC-SHA256 signature of a delivery
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://hooks.example.com/cognobserve", wantErr: false},
+		{url: "http://hooks.example.com/cognobserve", wantErr: true},
+		{url: "file:///etc/passwd", wantErr: true},
+		{url: "https:///no-host", wantErr: true},
+		{url: "hooks.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := validateURL(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("validateURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("publicAddr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostRefusesLoopback(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d := New(1, 1, 1, time.Second)
+	defer d.Close(context.Background())
+
+	err := d.post(Target{URL: srv.URL}, []byte(`{}`))
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("post() error = %v, want %v", err, ErrForbiddenAddress)
+	}
+}
+
+func TestDeliverRetriesWithoutBlocking(t *testing.T) {
+	var calls atomic.Int32
+	delivered := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		close(delivered)
+	}))
+	defer srv.Close()
+
+	d := New(1, 10, 3, time.Second)
+	defer d.Close(context.Background())
+	// The test server listens on loopback, which the production dialer refuses
+	d.client = srv.Client()
+	d.backoff = 10 * time.Millisecond
+
+	d.Enqueue(Target{URL: srv.URL, Secret: "s"}, Event{TraceID: "t1", ProjectID: "p1"})
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not redelivered")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("webhook called %d times, want 2", got)
+	}
+}
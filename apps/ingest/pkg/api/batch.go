@@ -0,0 +1,26 @@
+package api
+
+// IngestBatchRequest represents a batch of traces
+// This mirrors the proto definition but uses JSON-friendly types
+type IngestBatchRequest struct {
+	Traces []IngestTraceRequest `json:"traces"`
+}
+
+// BatchItemResult is the outcome for one trace in a batch, in request order
+type BatchItemResult struct {
+	Index        int          `json:"index"`
+	TraceID      string       `json:"trace_id,omitempty"`
+	SpanIDs      []string     `json:"span_ids,omitempty"`
+	WorkflowID   string       `json:"workflow_id,omitempty"`
+	Duplicate    bool         `json:"duplicate,omitempty"`
+	Deduplicated bool         `json:"deduplicated,omitempty"`
+	Success      bool         `json:"success"`
+	Error        *ErrorDetail `json:"error,omitempty"`
+}
+
+// IngestBatchResponse represents the response after ingesting a batch
+type IngestBatchResponse struct {
+	Results      []BatchItemResult `json:"results"`
+	SuccessCount int               `json:"success_count"`
+	ErrorCount   int               `json:"error_count"`
+}
@@ -0,0 +1,20 @@
+package api
+
+// ErrorBody is the JSON error envelope returned by all endpoints:
+//
+//	{"error": "Invalid API key", "code": "invalid_api_key", "message": "Invalid API key"}
+//
+// "error" keeps the original plain-message shape for existing clients;
+// the structured fields sit alongside it.
+type ErrorBody struct {
+	Error string `json:"error"`
+	ErrorDetail
+}
+
+// ErrorDetail describes a single error
+type ErrorDetail struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Field   string         `json:"field,omitempty"`   // Offending request field, when the error concerns one
+	Details map[string]any `json:"details,omitempty"` // Machine-readable context, e.g. the offending field
+}
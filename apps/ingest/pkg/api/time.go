@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Accepted range for epoch timestamps. Values outside this range are almost
+// certainly a unit mix-up rather than a real timestamp.
+var (
+	minEpochTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxEpochTime = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// timeLayouts are the accepted string formats, tried in order.
+// Layouts without a zone are interpreted as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// FlexibleTime is a timestamp that accepts RFC3339 strings or numeric Unix
+// epochs. The epoch unit (s, ms, µs or ns) is inferred from its magnitude,
+// and a fractional part is preserved down to nanoseconds.
+type FlexibleTime struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *FlexibleTime) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return errors.New("empty timestamp")
+	}
+
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := parseTimeString(s)
+		if err != nil {
+			return err
+		}
+		t.Time = parsed
+		return nil
+	}
+
+	parsed, err := parseEpoch(string(data))
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// parseTimeString parses a timestamp string in any accepted layout
+func parseTimeString(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if parsed, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q: expected RFC3339 or Unix epoch", s)
+}
+
+// parseEpoch parses a non-negative decimal epoch, inferring the unit by magnitude:
+//
+//	< 1e11 seconds, < 1e14 milliseconds, < 1e17 microseconds, otherwise nanoseconds
+func parseEpoch(s string) (time.Time, error) {
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	n, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || n < 0 || !isDigits(fracPart) {
+		return time.Time{}, fmt.Errorf("invalid epoch timestamp %s", s)
+	}
+
+	var (
+		sec       int64
+		nsec      int64
+		fracScale int // Number of fractional digits that fit in nanoseconds
+	)
+	switch {
+	case n < 1e11:
+		sec, nsec, fracScale = n, 0, 9
+	case n < 1e14:
+		sec, nsec, fracScale = n/1e3, (n%1e3)*1e6, 6
+	case n < 1e17:
+		sec, nsec, fracScale = n/1e6, (n%1e6)*1e3, 3
+	default:
+		sec, nsec, fracScale = n/1e9, n%1e9, 0
+	}
+
+	if fracScale > 0 && fracPart != "" {
+		if len(fracPart) > fracScale {
+			fracPart = fracPart[:fracScale]
+		}
+		frac, _ := strconv.ParseInt(fracPart+strings.Repeat("0", fracScale-len(fracPart)), 10, 64)
+		nsec += frac
+	}
+
+	parsed := time.Unix(sec, nsec).UTC()
+	if parsed.Before(minEpochTime) || !parsed.Before(maxEpochTime) {
+		return time.Time{}, fmt.Errorf("epoch timestamp %s is out of range", s)
+	}
+	return parsed, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFlexibleTimeUnmarshalJSON(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		data    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "rfc3339", data: `"2024-05-01T12:30:00Z"`, want: want},
+		{name: "rfc3339 with offset", data: `"2024-05-01T14:30:00+02:00"`, want: want},
+		{name: "no zone", data: `"2024-05-01T12:30:00"`, want: want},
+		{name: "space separated", data: `"2024-05-01 12:30:00"`, want: want},
+		{name: "epoch seconds", data: `1714566600`, want: want},
+		{name: "epoch seconds with fraction", data: `1714566600.5`, want: want.Add(500 * time.Millisecond)},
+		{name: "epoch milliseconds", data: `1714566600123`, want: want.Add(123 * time.Millisecond)},
+		{name: "epoch microseconds", data: `1714566600123456`, want: want.Add(123456 * time.Microsecond)},
+		{name: "epoch nanoseconds", data: `1714566600123456789`, want: want.Add(123456789)},
+		{name: "invalid string", data: `"yesterday"`, wantErr: true},
+		{name: "negative epoch", data: `-1`, wantErr: true},
+		{name: "epoch out of range", data: `100`, wantErr: true},
+		{name: "exponent", data: `1.7e9`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got FlexibleTime
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %v, want error", tt.data, got.Time)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.data, err)
+			}
+			if !got.Time.Equal(tt.want) {
+				t.Errorf("Unmarshal(%s) = %v, want %v", tt.data, got.Time, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package api defines the ingest API's request and response bodies.
+//
+// It depends only on the standard library, so the server and the Go client
+// share one definition of the wire format without the client pulling in
+// the server's dependencies.
+package api
+
+import "time"
+
+// UserInfoInput represents user information in the request
+type UserInfoInput struct {
+	Name     *string        `json:"name,omitempty"`
+	Email    *string        `json:"email,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// IngestTraceRequest represents the incoming trace request
+// This mirrors the proto definition but uses JSON-friendly types
+type IngestTraceRequest struct {
+	TraceID       *string           `json:"trace_id,omitempty"`
+	SessionID     *string           `json:"session_id,omitempty"`     // External session ID for conversations
+	UserID        *string           `json:"user_id,omitempty"`        // External user ID for tracking end-users
+	User          *UserInfoInput    `json:"user,omitempty"`           // Optional user metadata
+	Environment   *string           `json:"environment,omitempty"`    // Deployment environment, e.g. production, staging
+	Release       *string           `json:"release,omitempty"`        // Application release/version identifier
+	Tags          []string          `json:"tags,omitempty"`           // Searchable labels, e.g. billing, beta-feature
+	Status        string            `json:"status,omitempty"`         // success, error, cancelled or unknown; derived from span levels when omitted
+	RetentionDays *int              `json:"retention_days,omitempty"` // Days to keep the trace, up to the project's maximum; defaults to the project's retention
+	Name          string            `json:"name"`
+	Metadata      map[string]any    `json:"metadata,omitempty"`
+	Spans         []IngestSpanInput `json:"spans"`
+
+	// AllowEmptyTrace accepts a trace without spans, for clients that create
+	// the trace shell first. Note that PATCH /v1/traces/{id}/spans/{id} only
+	// updates spans declared at ingest, so an empty trace cannot gain spans later.
+	AllowEmptyTrace bool `json:"allow_empty_trace,omitempty"`
+}
+
+// IngestSpanInput represents a span in the request
+type IngestSpanInput struct {
+	SpanID          *string           `json:"span_id,omitempty"`
+	ParentSpanID    *string           `json:"parent_span_id,omitempty"`
+	Name            string            `json:"name"`
+	StartTime       *FlexibleTime     `json:"start_time,omitempty"`  // RFC3339 or Unix epoch; defaults to now
+	EndTime         *FlexibleTime     `json:"end_time,omitempty"`    // RFC3339 or Unix epoch; defaults to now
+	DurationMs      *float64          `json:"duration_ms,omitempty"` // Sets end_time from start_time when end_time is omitted
+	Input           any               `json:"input,omitempty"`       // Object; other JSON values are wrapped as {"value": ...}
+	Output          any               `json:"output,omitempty"`      // Same as Input
+	Metadata        map[string]any    `json:"metadata,omitempty"`
+	Model           *string           `json:"model,omitempty"`
+	Provider        *string           `json:"provider,omitempty"` // e.g. openai, anthropic, bedrock; inferred from metadata or model when omitted
+	ModelParameters map[string]any    `json:"model_parameters,omitempty"`
+	Usage           *TokenUsageInput  `json:"usage,omitempty"`
+	Cost            *SpanCostInput    `json:"cost,omitempty"` // Exact costs known to the client; skips the price table
+	Level           string            `json:"level,omitempty"`
+	StatusMessage   *string           `json:"status_message,omitempty"`
+	Attachments     []AttachmentInput `json:"attachments,omitempty"` // Media referenced by URL or small inline content
+}
+
+// SpanCostInput represents client-computed span costs in USD
+type SpanCostInput struct {
+	PromptCostUSD     *float64 `json:"prompt_cost_usd,omitempty"`
+	CompletionCostUSD *float64 `json:"completion_cost_usd,omitempty"`
+	TotalCostUSD      *float64 `json:"total_cost_usd,omitempty"` // Defaults to prompt plus completion cost
+}
+
+// TokenUsageInput represents token usage in the request. Counts are
+// 64-bit and kept exact, including counts beyond 2^53.
+type TokenUsageInput struct {
+	PromptTokens     *int64 `json:"prompt_tokens,omitempty"`
+	CompletionTokens *int64 `json:"completion_tokens,omitempty"`
+	TotalTokens      *int64 `json:"total_tokens,omitempty"`
+}
+
+// AttachmentInput references media (image, audio, ...) used by a span.
+// Exactly one of URL and ContentBase64 must be set.
+type AttachmentInput struct {
+	Type          string  `json:"type"` // image, audio, video or file
+	Mime          string  `json:"mime"` // e.g. image/png
+	URL           *string `json:"url,omitempty"`
+	ContentBase64 *string `json:"content_base64,omitempty"`
+	SHA256        *string `json:"sha256,omitempty"` // Hex digest of the content
+}
+
+// IngestTraceResponse represents the response after ingesting
+type IngestTraceResponse struct {
+	TraceID      string   `json:"trace_id"`
+	SpanIDs      []string `json:"span_ids"`
+	WorkflowID   string   `json:"workflow_id,omitempty"`  // Present when using Temporal
+	Duplicate    bool     `json:"duplicate,omitempty"`    // True when the trace had already been submitted
+	Deduplicated bool     `json:"deduplicated,omitempty"` // True when a repeat send was dropped within the dedup window
+	Success      bool     `json:"success"`
+
+	// CorrelationID is the request's X-Correlation-ID, or the generated
+	// request ID when none was sent
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// UsageSummary is the trace's token usage summed across spans, omitted
+	// when no span reported usage
+	UsageSummary *UsageSummary `json:"usage_summary,omitempty"`
+
+	// EstimatedReadyAt is an advisory hint of when the trace will have been
+	// processed, derived from the task queue backlog. It is not a guarantee,
+	// and is omitted when no recent backlog sample is available.
+	EstimatedReadyAt *time.Time `json:"estimated_ready_at,omitempty"`
+
+	// Warnings lists the non-fatal issues ingestion corrected or flagged.
+	// Only reported with ?warnings=true.
+	Warnings []Warning `json:"warnings,omitempty"`
+
+	// Result is the processed trace, only with ?wait=true once the workflow
+	// completed within the request timeout
+	Result *TraceResult `json:"result,omitempty"`
+}
+
+// UsageSummary is the token usage of a trace, summed across its spans
+type UsageSummary struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	SpansWithUsage   int   `json:"spans_with_usage"` // Spans that reported any usage
+}
+
+// Warning describes a non-fatal issue ingestion corrected or flagged in a
+// trace, so clients can fix their instrumentation
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"` // Request field the warning is about, e.g. spans[2].model
+}
+
+// TraceResult reports a processed trace, for ?wait=true requests
+type TraceResult struct {
+	SpanCount       int `json:"span_count"`
+	CostsProvided   int `json:"costs_provided"`   // Spans whose cost the request supplied
+	CostsCalculated int `json:"costs_calculated"` // Spans with a cost, including CostsProvided
+}
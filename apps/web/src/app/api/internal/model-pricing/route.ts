@@ -0,0 +1,72 @@
+/**
+ * Internal API: Model Pricing
+ *
+ * Called by the Go ingest service to estimate trace costs for project
+ * budgets. Returns the current price of each requested model, using the
+ * same lookup as the worker's cost calculation.
+ */
+
+import { NextRequest } from "next/server";
+import { z } from "zod";
+import { getModelPricing } from "@cognobserve/api/lib/cost";
+import { validateInternalSecret } from "@cognobserve/shared";
+import { env } from "@/lib/env";
+import { internalApiError, apiSuccess } from "@/lib/api-responses";
+
+const INTERNAL_SECRET_HEADER = "X-Internal-Secret";
+
+// Bounds one lookup; the ingest service only asks for a trace's models
+const MAX_MODELS = 100;
+
+const ModelPricingRequestSchema = z.object({
+  models: z.array(z.string().min(1)).max(MAX_MODELS),
+});
+
+interface ModelPrice {
+  inputPricePerMillion: number;
+  outputPricePerMillion: number;
+}
+
+export async function POST(req: NextRequest) {
+  // 1. Validate internal secret
+  const providedSecret = req.headers.get(INTERNAL_SECRET_HEADER);
+  if (!validateInternalSecret(providedSecret, env.INTERNAL_API_SECRET)) {
+    console.warn("Invalid internal API secret attempt for model pricing", {
+      ip: req.headers.get("x-forwarded-for") || "unknown",
+      timestamp: new Date().toISOString(),
+    });
+    return internalApiError.unauthorized();
+  }
+
+  // 2. Parse and validate input
+  let body: unknown;
+  try {
+    body = await req.json();
+  } catch {
+    return internalApiError.invalidJson();
+  }
+
+  const parseResult = ModelPricingRequestSchema.safeParse(body);
+  if (!parseResult.success) {
+    return internalApiError.validation("Invalid request", parseResult.error.flatten());
+  }
+
+  const models = [...new Set(parseResult.data.models)];
+
+  // 3. Look up current prices. Models without a price are left out.
+  try {
+    const prices: Record<string, ModelPrice> = {};
+    for (const model of models) {
+      const pricing = await getModelPricing(model);
+      if (!pricing) continue;
+      prices[model] = {
+        inputPricePerMillion: pricing.inputPricePerMillion.toNumber(),
+        outputPricePerMillion: pricing.outputPricePerMillion.toNumber(),
+      };
+    }
+    return apiSuccess.ok({ prices });
+  } catch (error) {
+    console.error("Database error during model pricing lookup:", error);
+    return internalApiError.internal();
+  }
+}
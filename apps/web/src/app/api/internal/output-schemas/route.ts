@@ -0,0 +1,70 @@
+/**
+ * Internal API: Output Schema Registry
+ *
+ * Called by the Go ingest service to validate generation span outputs.
+ * Returns the JSON schema registered per span name for a project.
+ */
+
+import { NextRequest } from "next/server";
+import { z } from "zod";
+import { prisma } from "@cognobserve/db";
+import { validateInternalSecret } from "@cognobserve/shared";
+import { env } from "@/lib/env";
+import { internalApiError, apiSuccess } from "@/lib/api-responses";
+
+const INTERNAL_SECRET_HEADER = "X-Internal-Secret";
+
+const OutputSchemasRequestSchema = z.object({
+  projectId: z.string().min(1),
+});
+
+export async function POST(req: NextRequest) {
+  // 1. Validate internal secret
+  const providedSecret = req.headers.get(INTERNAL_SECRET_HEADER);
+  if (!validateInternalSecret(providedSecret, env.INTERNAL_API_SECRET)) {
+    console.warn("Invalid internal API secret attempt for output schemas", {
+      ip: req.headers.get("x-forwarded-for") || "unknown",
+      timestamp: new Date().toISOString(),
+    });
+    return internalApiError.unauthorized();
+  }
+
+  // 2. Parse and validate input
+  let body: unknown;
+  try {
+    body = await req.json();
+  } catch {
+    return internalApiError.invalidJson();
+  }
+
+  const parseResult = OutputSchemasRequestSchema.safeParse(body);
+  if (!parseResult.success) {
+    return internalApiError.validation("Invalid request", parseResult.error.flatten());
+  }
+
+  const { projectId } = parseResult.data;
+
+  // 3. Load the project's opt-in flag and schemas
+  try {
+    const project = await prisma.project.findUnique({
+      where: { id: projectId },
+      select: {
+        outputSchemaValidation: true,
+        outputSchemas: { select: { spanName: true, schema: true } },
+      },
+    });
+
+    // Unknown projects and projects that haven't opted in validate nothing
+    if (!project || !project.outputSchemaValidation) {
+      return apiSuccess.ok({ enabled: false, schemas: {} });
+    }
+
+    const schemas = Object.fromEntries(
+      project.outputSchemas.map((s) => [s.spanName, s.schema])
+    );
+    return apiSuccess.ok({ enabled: true, schemas });
+  } catch (error) {
+    console.error("Database error during output schema lookup:", error);
+    return internalApiError.internal();
+  }
+}
@@ -4,6 +4,7 @@ import { prisma } from "@cognobserve/db";
 import { validateInternalSecret } from "@cognobserve/shared";
 import { env } from "@/lib/env";
 import { internalApiError, internalApiSuccess } from "@/lib/api-responses";
+import { buildIngestSettings } from "@/lib/ingest-settings";
 
 // Constants
 const INTERNAL_SECRET_HEADER = "X-Internal-Secret";
@@ -25,6 +26,9 @@ const validateKeySchema = z.object({
  * 2. Input validation (hash format)
  * 3. Expiration check
  * 4. No sensitive data in logs
+ *
+ * Valid keys return their project ID along with the key's scopes and the
+ * project's ingest settings (see buildIngestSettings).
  */
 export async function POST(req: NextRequest) {
   // 1. Validate internal secret (constant-time)
@@ -60,6 +64,21 @@ export async function POST(req: NextRequest) {
         id: true,
         projectId: true,
         expiresAt: true,
+        scopes: true,
+        project: {
+          select: {
+            webhookUrl: true,
+            webhookSecret: true,
+            taskQueue: true,
+            retentionDefaultDays: true,
+            retentionMaxDays: true,
+            requiredMetadata: true,
+            dailyBudgetUsd: true,
+            features: true,
+            metadataPolicyMode: true,
+            metadataPolicyKeys: true,
+          },
+        },
       },
     });
 
@@ -80,13 +99,16 @@ export async function POST(req: NextRequest) {
       return internalApiSuccess.invalid("Invalid or expired API key");
     }
 
-    // 6. Success - return project ID
+    // 6. Success - return project ID and ingest settings
     console.info("API key validated", {
       keyId: apiKey.id,
       projectId: apiKey.projectId,
     });
 
-    return internalApiSuccess.valid({ projectId: apiKey.projectId });
+    return internalApiSuccess.valid({
+      projectId: apiKey.projectId,
+      ...buildIngestSettings(apiKey.project, apiKey.scopes),
+    });
   } catch (error) {
     console.error("Database error during key validation:", error);
     return internalApiError.internal();
@@ -0,0 +1,81 @@
+import { describe, expect, it } from "vitest";
+import { buildIngestSettings, type ProjectIngestFields } from "./ingest-settings";
+
+const defaultProject: ProjectIngestFields = {
+  webhookUrl: null,
+  webhookSecret: null,
+  taskQueue: null,
+  retentionDefaultDays: null,
+  retentionMaxDays: null,
+  requiredMetadata: [],
+  dailyBudgetUsd: null,
+  features: null,
+  metadataPolicyMode: null,
+  metadataPolicyKeys: [],
+};
+
+describe("buildIngestSettings", () => {
+  it("omits every setting for a default project", () => {
+    expect(buildIngestSettings(defaultProject, [])).toEqual({});
+  });
+
+  it.each([
+    { mode: "ALLOW" as const, want: "allow" },
+    { mode: "BLOCK" as const, want: "block" },
+  ])("returns the $want metadata policy", ({ mode, want }) => {
+    const settings = buildIngestSettings(
+      { ...defaultProject, metadataPolicyMode: mode, metadataPolicyKeys: ["team", "env"] },
+      []
+    );
+
+    expect(settings.metadataPolicy).toEqual({ mode: want, keys: ["team", "env"] });
+  });
+
+  it("omits the metadata policy without a mode", () => {
+    const settings = buildIngestSettings({ ...defaultProject, metadataPolicyKeys: ["team"] }, []);
+
+    expect(settings.metadataPolicy).toBeUndefined();
+  });
+
+  it("returns the project's ingest settings", () => {
+    const settings = buildIngestSettings(
+      {
+        ...defaultProject,
+        webhookUrl: "https://hooks.example.com/cognobserve",
+        webhookSecret: "whsec",
+        taskQueue: "traces-acme",
+        retentionDefaultDays: 30,
+        retentionMaxDays: 90,
+        requiredMetadata: ["team"],
+        dailyBudgetUsd: { toNumber: () => 25.5 },
+        features: { streaming: false, schema_validation: true, note: "ignored" },
+      },
+      ["traces:write"]
+    );
+
+    expect(settings).toEqual({
+      scopes: ["traces:write"],
+      features: { streaming: false, schema_validation: true },
+      webhook: { url: "https://hooks.example.com/cognobserve", secret: "whsec" },
+      taskQueue: "traces-acme",
+      requiredMetadata: ["team"],
+      dailyBudgetUsd: 25.5,
+      retention: { defaultDays: 30, maxDays: 90 },
+    });
+  });
+
+  it("omits a webhook without a secret", () => {
+    const settings = buildIngestSettings(
+      { ...defaultProject, webhookUrl: "https://hooks.example.com/cognobserve" },
+      []
+    );
+
+    expect(settings.webhook).toBeUndefined();
+  });
+
+  it("sends retention limits without a default", () => {
+    const settings = buildIngestSettings({ ...defaultProject, retentionMaxDays: 90 }, []);
+
+    expect(settings.retention).toEqual({ defaultDays: 0, maxDays: 90 });
+  });
+});
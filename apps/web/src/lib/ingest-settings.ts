@@ -0,0 +1,103 @@
+/**
+ * Ingest Settings
+ *
+ * Per-project settings the Go ingest service applies to traces sent with a
+ * validated API key, in the shape its validate-key client reads. Unset
+ * settings are omitted so the ingest service falls back to its defaults.
+ */
+
+import type { MetadataPolicyMode } from "@cognobserve/db";
+
+/**
+ * Project fields read by buildIngestSettings
+ */
+export interface ProjectIngestFields {
+  webhookUrl: string | null;
+  webhookSecret: string | null;
+  taskQueue: string | null;
+  retentionDefaultDays: number | null;
+  retentionMaxDays: number | null;
+  requiredMetadata: string[];
+  dailyBudgetUsd: { toNumber(): number } | null;
+  features: unknown;
+  metadataPolicyMode: MetadataPolicyMode | null;
+  metadataPolicyKeys: string[];
+}
+
+export interface IngestSettings {
+  scopes?: string[];
+  features?: Record<string, boolean>;
+  webhook?: { url: string; secret: string };
+  taskQueue?: string;
+  requiredMetadata?: string[];
+  dailyBudgetUsd?: number;
+  retention?: { defaultDays: number; maxDays?: number };
+  metadataPolicy?: { mode: "allow" | "block"; keys: string[] };
+}
+
+/**
+ * Build the ingest settings for an API key's project. Keys created before
+ * scopes existed have none stored and hold every scope, so scopes are
+ * omitted for them.
+ */
+export function buildIngestSettings(
+  project: ProjectIngestFields,
+  keyScopes: string[]
+): IngestSettings {
+  const settings: IngestSettings = {};
+
+  if (keyScopes.length > 0) {
+    settings.scopes = keyScopes;
+  }
+
+  const features = featureFlags(project.features);
+  if (features) {
+    settings.features = features;
+  }
+
+  // A webhook without a secret couldn't be verified by the receiver
+  if (project.webhookUrl && project.webhookSecret) {
+    settings.webhook = { url: project.webhookUrl, secret: project.webhookSecret };
+  }
+
+  if (project.taskQueue) {
+    settings.taskQueue = project.taskQueue;
+  }
+
+  if (project.requiredMetadata.length > 0) {
+    settings.requiredMetadata = project.requiredMetadata;
+  }
+
+  if (project.dailyBudgetUsd) {
+    settings.dailyBudgetUsd = project.dailyBudgetUsd.toNumber();
+  }
+
+  if (project.retentionDefaultDays !== null || project.retentionMaxDays !== null) {
+    settings.retention = {
+      defaultDays: project.retentionDefaultDays ?? 0,
+      ...(project.retentionMaxDays !== null && { maxDays: project.retentionMaxDays }),
+    };
+  }
+
+  if (project.metadataPolicyMode) {
+    settings.metadataPolicy = {
+      mode: project.metadataPolicyMode === "ALLOW" ? "allow" : "block",
+      keys: project.metadataPolicyKeys,
+    };
+  }
+
+  return settings;
+}
+
+/**
+ * Keep the boolean entries of a project's stored feature flags
+ */
+function featureFlags(raw: unknown): Record<string, boolean> | undefined {
+  if (!raw || typeof raw !== "object" || Array.isArray(raw)) {
+    return undefined;
+  }
+  const flags = Object.entries(raw).filter(
+    (entry): entry is [string, boolean] => typeof entry[1] === "boolean"
+  );
+  return flags.length > 0 ? Object.fromEntries(flags) : undefined;
+}
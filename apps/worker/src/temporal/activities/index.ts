@@ -11,6 +11,7 @@
 // Trace activities
 export {
   persistTrace,
+  applySpanUpdates,
   calculateTraceCosts,
   updateCostSummaries,
 } from "./trace.activities";
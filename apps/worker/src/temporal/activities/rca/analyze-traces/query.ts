@@ -50,8 +50,8 @@ export async function querySpansInWindow(
     model: r.model,
     startTime: r.startTime,
     endTime: r.endTime,
-    promptTokens: r.promptTokens,
-    completionTokens: r.completionTokens,
+    promptTokens: r.promptTokens === null ? null : Number(r.promptTokens),
+    completionTokens: r.completionTokens === null ? null : Number(r.completionTokens),
     totalCost: r.totalCost,
     output: r.output,
   }));
@@ -7,7 +7,7 @@
 
 import { prisma } from "@cognobserve/db";
 import { getInternalCaller } from "@/lib/trpc-caller";
-import type { TraceWorkflowInput } from "../types";
+import type { SpanTokenEstimate, SpanUpdateInput, TraceWorkflowInput } from "../types";
 
 /**
  * Persist trace and spans via internal tRPC.
@@ -45,6 +45,10 @@ export async function persistTrace(input: TraceWorkflowInput): Promise<string> {
       promptTokens: span.promptTokens,
       completionTokens: span.completionTokens,
       totalTokens: span.totalTokens,
+      costOverrideUsd: span.costOverrideUsd,
+      promptCostUsd: span.promptCostUsd,
+      completionCostUsd: span.completionCostUsd,
+      costSource: span.costSource,
       level: span.level,
       statusMessage: span.statusMessage,
     })),
@@ -54,17 +58,39 @@ export async function persistTrace(input: TraceWorkflowInput): Promise<string> {
   return result.traceId;
 }
 
+/**
+ * Apply partial span updates to an already persisted trace via internal tRPC.
+ *
+ * @returns Number of spans updated
+ */
+export async function applySpanUpdates(
+  traceId: string,
+  updates: SpanUpdateInput[]
+): Promise<number> {
+  console.log(`[Activity:applySpanUpdates] Applying ${updates.length} updates to trace: ${traceId}`);
+
+  const caller = getInternalCaller();
+  const result = await caller.internal.updateSpans({ traceId, updates });
+
+  console.log(`[Activity:applySpanUpdates] Updated ${result.updatedCount} spans`);
+  return result.updatedCount;
+}
+
 /**
  * Calculate costs for spans with LLM model usage.
  * Calls tRPC to calculate and update costs.
  *
+ * @param estimates - Token splits for spans that only reported a total
  * @returns Number of spans that had costs calculated
  */
-export async function calculateTraceCosts(traceId: string): Promise<number> {
+export async function calculateTraceCosts(
+  traceId: string,
+  estimates: SpanTokenEstimate[] = []
+): Promise<number> {
   console.log(`[Activity:calculateTraceCosts] Calculating costs for trace: ${traceId}`);
 
   const caller = getInternalCaller();
-  const result = await caller.internal.calculateTraceCosts({ traceId });
+  const result = await caller.internal.calculateTraceCosts({ traceId, estimates });
 
   console.log(`[Activity:calculateTraceCosts] Updated costs for ${result.updatedCount} spans`);
   return result.updatedCount;
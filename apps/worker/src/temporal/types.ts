@@ -14,6 +14,12 @@ export interface UserInput {
   [key: string]: unknown;
 }
 
+/**
+ * Token count as a decimal string, so counts beyond 2^53 stay exact.
+ * Workflows started before counts were widened hold plain numbers.
+ */
+export type TokenCount = string | number;
+
 /**
  * Span input for trace ingestion
  */
@@ -28,9 +34,45 @@ export interface SpanInput {
   metadata?: Record<string, unknown>;
   model?: string;
   modelParameters?: Record<string, unknown>;
-  promptTokens?: number;
-  completionTokens?: number;
-  totalTokens?: number;
+  promptTokens?: TokenCount;
+  completionTokens?: TokenCount;
+  totalTokens?: TokenCount;
+  costOverrideUsd?: number; // Takes precedence over the price table
+  promptCostUsd?: number; // Client-supplied breakdown of costOverrideUsd
+  completionCostUsd?: number;
+  costSource?: CostSource;
+  estimatedPromptTokens?: number; // Split of totalTokens for pricing when no breakdown was reported
+  estimatedCompletionTokens?: number;
+  level?: "DEBUG" | "DEFAULT" | "WARNING" | "ERROR";
+  statusMessage?: string;
+}
+
+/**
+ * Where a span's cost comes from when not priced from the table
+ */
+export type CostSource = "override" | "client" | "estimated_blended";
+
+/**
+ * Estimated token split for a span that only reported a total,
+ * priced by calculateTraceCosts
+ */
+export interface SpanTokenEstimate {
+  spanId: string;
+  promptTokens: number;
+  completionTokens: number;
+}
+
+/**
+ * Partial span update delivered by the updateSpan signal.
+ * Only fields that are set are applied; omitted fields are left intact.
+ */
+export interface SpanUpdateInput {
+  spanId: string;
+  output?: unknown;
+  endTime?: string; // ISO 8601 string
+  promptTokens?: TokenCount;
+  completionTokens?: TokenCount;
+  totalTokens?: TokenCount;
   level?: "DEBUG" | "DEFAULT" | "WARNING" | "ERROR";
   statusMessage?: string;
 }
@@ -56,7 +98,17 @@ export interface TraceWorkflowInput {
 export interface TraceWorkflowResult {
   traceId: string;
   spanCount: number;
-  costsCalculated: number;
+  costsProvided: number; // Spans whose cost was supplied at ingest
+  costsCalculated: number; // Spans with a cost: costsProvided plus spans priced from the table
+}
+
+/**
+ * Trace workflow progress, answered by the progress query
+ */
+export interface TraceProgress {
+  spansProcessed: number;
+  spansTotal: number;
+  phase: "persisting" | "calculating_costs" | "updating_summaries" | "completed";
 }
 
 /**
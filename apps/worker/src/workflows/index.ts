@@ -9,7 +9,7 @@
 // ============================================================
 
 // Trace ingestion workflow
-export { traceWorkflow } from "./trace.workflow";
+export { traceWorkflow, updateSpanSignal, progressQuery, inputQuery } from "./trace.workflow";
 
 // Score ingestion workflow
 export { scoreWorkflow } from "./score.workflow";
@@ -6,25 +6,72 @@
 // All I/O is done through activities.
 // ============================================================
 
-import { proxyActivities, log } from "@temporalio/workflow";
+import {
+  proxyActivities,
+  defineSignal,
+  defineQuery,
+  setHandler,
+  log,
+} from "@temporalio/workflow";
 import type * as activities from "../temporal/activities";
-import type { TraceWorkflowInput, TraceWorkflowResult } from "../temporal/types";
+import type {
+  SpanInput,
+  SpanTokenEstimate,
+  SpanUpdateInput,
+  TraceProgress,
+  TraceWorkflowInput,
+  TraceWorkflowResult,
+} from "../temporal/types";
 import { ACTIVITY_RETRY, WORKFLOW_TIMEOUTS } from "@cognobserve/shared";
 
 // Proxy activities with retry configuration
-const { persistTrace, calculateTraceCosts, updateCostSummaries } =
+const { persistTrace, applySpanUpdates, calculateTraceCosts, updateCostSummaries } =
   proxyActivities<typeof activities>({
     startToCloseTimeout: WORKFLOW_TIMEOUTS.TRACE.ACTIVITY,
     retry: ACTIVITY_RETRY.DEFAULT,
   });
 
+/**
+ * Signal carrying a partial span update (sent by the ingest service on
+ * PATCH /v1/traces/{id}/spans/{spanId})
+ */
+export const updateSpanSignal = defineSignal<[SpanUpdateInput]>("updateSpan");
+
+/**
+ * Query returning how far the workflow has got (GET /v1/traces/{id}/status)
+ */
+export const progressQuery = defineQuery<TraceProgress>("progress");
+
+/**
+ * Query returning the trace as the ingest service submitted it, with any
+ * span updates merged (GET /v1/traces/{id})
+ */
+export const inputQuery = defineQuery<TraceWorkflowInput>("input");
+
+/**
+ * Apply the fields set in an update to a span in place
+ */
+function mergeSpanUpdate(span: SpanInput, update: SpanUpdateInput): void {
+  if (update.output !== undefined) span.output = update.output;
+  if (update.endTime !== undefined) span.endTime = update.endTime;
+  if (update.promptTokens !== undefined) span.promptTokens = update.promptTokens;
+  if (update.completionTokens !== undefined) span.completionTokens = update.completionTokens;
+  if (update.totalTokens !== undefined) span.totalTokens = update.totalTokens;
+  if (update.level !== undefined) span.level = update.level;
+  if (update.statusMessage !== undefined) span.statusMessage = update.statusMessage;
+}
+
 /**
  * Trace ingestion workflow.
  *
  * Steps:
  * 1. Persist trace and spans (critical - retries on failure)
- * 2. Calculate costs for LLM spans (non-critical - logged on failure)
- * 3. Update daily cost summaries (non-critical - logged on failure)
+ * 2. Apply span updates signalled during persistence (critical)
+ * 3. Calculate costs for LLM spans (non-critical - logged on failure)
+ * 4. Update daily cost summaries (non-critical - logged on failure)
+ *
+ * updateSpan signals received before persistence are merged into the
+ * input; later ones are written through the applySpanUpdates activity.
  *
  * @param input - Trace data from ingest service
  * @returns Result with trace ID and processing stats
@@ -38,14 +85,68 @@ export async function traceWorkflow(
     spanCount: input.spans.length,
   });
 
+  const progress: TraceProgress = {
+    spansProcessed: 0,
+    spansTotal: input.spans.length,
+    phase: "persisting",
+  };
+  setHandler(progressQuery, () => progress);
+  setHandler(inputQuery, () => input);
+
+  // Set once the input has been handed to persistTrace
+  let inputSent = false;
+  let pendingUpdates: SpanUpdateInput[] = [];
+
+  setHandler(updateSpanSignal, (update) => {
+    const span = input.spans.find((s) => s.id === update.spanId);
+    if (!span) {
+      log.warn("Ignoring update for unknown span", { traceId: input.id, spanId: update.spanId });
+      return;
+    }
+    // Keep the input current so later reads see the update
+    mergeSpanUpdate(span, update);
+    if (inputSent) {
+      pendingUpdates.push(update);
+    }
+  });
+
   // Step 1: Persist trace and spans (CRITICAL - will retry)
-  const traceId = await persistTrace(input);
+  // Spans are serialized when the activity is scheduled; updates after
+  // that point are queued for Step 2
+  const persisting = persistTrace(input);
+  inputSent = true;
+  const traceId = await persisting;
+  progress.spansProcessed = input.spans.length;
   log.info("Trace persisted successfully", { traceId });
 
-  // Step 2: Calculate costs (NON-CRITICAL - log errors but don't fail)
-  let costsCalculated = 0;
+  // Step 2: Write updates that arrived while persisting (CRITICAL - will retry)
+  const flushUpdates = async (): Promise<void> => {
+    while (pendingUpdates.length > 0) {
+      const updates = pendingUpdates;
+      pendingUpdates = [];
+      await applySpanUpdates(traceId, updates);
+      log.info("Span updates applied", { traceId, updateCount: updates.length });
+    }
+  };
+  await flushUpdates();
+
+  // Step 3: Calculate costs (NON-CRITICAL - log errors but don't fail)
+  progress.phase = "calculating_costs";
+  // Costs supplied at ingest were stored with the spans and count as
+  // calculated alongside the spans priced here
+  const costsProvided = input.spans.filter((s) => s.costOverrideUsd !== undefined).length;
+  let costsCalculated = costsProvided;
+  // Token counts aren't stored split for total-only spans, so the
+  // estimated split travels with the activity call
+  const estimates: SpanTokenEstimate[] = input.spans
+    .filter((s) => s.estimatedPromptTokens || s.estimatedCompletionTokens)
+    .map((s) => ({
+      spanId: s.id,
+      promptTokens: s.estimatedPromptTokens ?? 0,
+      completionTokens: s.estimatedCompletionTokens ?? 0,
+    }));
   try {
-    costsCalculated = await calculateTraceCosts(traceId);
+    costsCalculated += await calculateTraceCosts(traceId, estimates);
     log.info("Costs calculated", { traceId, costsCalculated });
   } catch (error) {
     log.warn("Cost calculation failed (non-critical)", {
@@ -54,7 +155,8 @@ export async function traceWorkflow(
     });
   }
 
-  // Step 3: Update summaries (NON-CRITICAL - log errors but don't fail)
+  // Step 4: Update summaries (NON-CRITICAL - log errors but don't fail)
+  progress.phase = "updating_summaries";
   try {
     await updateCostSummaries(input.projectId, input.timestamp);
     log.info("Cost summaries updated", { projectId: input.projectId });
@@ -65,15 +167,21 @@ export async function traceWorkflow(
     });
   }
 
+  // Updates signalled while costs were computed
+  await flushUpdates();
+  progress.phase = "completed";
+
   log.info("Trace workflow completed", {
     traceId,
     spanCount: input.spans.length,
+    costsProvided,
     costsCalculated,
   });
 
   return {
     traceId,
     spanCount: input.spans.length,
+    costsProvided,
     costsCalculated,
   };
 }
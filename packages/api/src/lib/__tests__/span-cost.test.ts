@@ -0,0 +1,95 @@
+import { describe, it, expect } from "vitest";
+import { planSpanCost } from "../cost/span-cost";
+
+describe("planSpanCost", () => {
+  it("uses a cost override over the price table", () => {
+    const plan = planSpanCost({
+      costOverrideUsd: 0.42,
+      costSource: "override",
+      promptTokens: 1000,
+      completionTokens: 500,
+    });
+
+    expect(plan).toEqual({
+      kind: "provided",
+      totalCost: 0.42,
+      inputCost: null,
+      outputCost: null,
+      costSource: "override",
+    });
+  });
+
+  it("defaults the source of an override without one", () => {
+    const plan = planSpanCost({ costOverrideUsd: 0 });
+
+    expect(plan).toEqual({
+      kind: "provided",
+      totalCost: 0,
+      inputCost: null,
+      outputCost: null,
+      costSource: "override",
+    });
+  });
+
+  it("keeps a client-supplied cost breakdown", () => {
+    const plan = planSpanCost({
+      costOverrideUsd: 0.03,
+      promptCostUsd: 0.01,
+      completionCostUsd: 0.02,
+      costSource: "client",
+      promptTokens: 1000,
+      completionTokens: 500,
+    });
+
+    expect(plan).toEqual({
+      kind: "provided",
+      totalCost: 0.03,
+      inputCost: 0.01,
+      outputCost: 0.02,
+      costSource: "client",
+    });
+  });
+
+  it("prices reported tokens from the table", () => {
+    const plan = planSpanCost({ promptTokens: 1000, completionTokens: 500 });
+
+    expect(plan).toEqual({
+      kind: "table",
+      promptTokens: 1000,
+      completionTokens: 500,
+      costSource: null,
+    });
+  });
+
+  it("prices an estimated split of total-only usage", () => {
+    const plan = planSpanCost({ estimatedPromptTokens: 750, estimatedCompletionTokens: 250 });
+
+    expect(plan).toEqual({
+      kind: "table",
+      promptTokens: 750,
+      completionTokens: 250,
+      costSource: "estimated_blended",
+    });
+  });
+
+  it("prefers reported tokens over an estimate", () => {
+    const plan = planSpanCost({
+      promptTokens: 100,
+      completionTokens: 50,
+      estimatedPromptTokens: 750,
+      estimatedCompletionTokens: 250,
+    });
+
+    expect(plan).toEqual({
+      kind: "table",
+      promptTokens: 100,
+      completionTokens: 50,
+      costSource: null,
+    });
+  });
+
+  it("falls back to no cost without usage", () => {
+    expect(planSpanCost({})).toEqual({ kind: "none" });
+    expect(planSpanCost({ promptTokens: 0, completionTokens: null })).toEqual({ kind: "none" });
+  });
+});
@@ -13,7 +13,15 @@ export {
 
 export {
   type SpanCost,
+  getModelPricing,
   calculateSpanCost,
   calculateBulkCosts,
   clearPricingCache,
 } from "./pricing-service";
+
+export {
+  type CostSource,
+  type SpanCostFields,
+  type SpanCostPlan,
+  planSpanCost,
+} from "./span-cost";
@@ -36,9 +36,9 @@ const CACHE_TTL = 5 * 60 * 1000; // 5 minutes
 const pricingCache = new Map<string, CachedPricing>();
 
 /**
- * Get pricing for a model. Returns null if no pricing found.
+ * Get current pricing for a model. Returns null if no pricing found.
  */
-async function getPricing(model: string) {
+export async function getModelPricing(model: string) {
   const provider = detectProvider(model);
   const normalizedModel = normalizeModelName(model);
   const cacheKey = `${provider}:${normalizedModel}`;
@@ -78,7 +78,7 @@ export async function calculateSpanCost(span: SpanTokens): Promise<SpanCost | nu
   if (!span.model) return null;
   if (!span.promptTokens && !span.completionTokens) return null;
 
-  const pricing = await getPricing(span.model);
+  const pricing = await getModelPricing(span.model);
   if (!pricing) return null;
 
   const inputTokens = span.promptTokens ?? 0;
@@ -0,0 +1,73 @@
+/**
+ * Span Cost Planning
+ *
+ * Decides how a span is costed. Precedence:
+ * 1. A cost supplied at ingest (cost override), stored as is along with
+ *    the client's prompt/completion breakdown, when it sent one
+ * 2. The price table, from the span's token usage
+ * 3. The price table, from the ingest service's split of a total-only
+ *    token count (estimated_blended)
+ * 4. No cost, when the span reported no usage
+ */
+
+/**
+ * Where a span's cost comes from when not priced from the table
+ */
+export type CostSource = "override" | "client" | "estimated_blended";
+
+export interface SpanCostFields {
+  costOverrideUsd?: number;
+  promptCostUsd?: number; // Client-supplied breakdown of costOverrideUsd
+  completionCostUsd?: number;
+  costSource?: CostSource;
+  promptTokens?: number | null;
+  completionTokens?: number | null;
+  estimatedPromptTokens?: number;
+  estimatedCompletionTokens?: number;
+}
+
+export type SpanCostPlan =
+  | {
+      kind: "provided";
+      totalCost: number;
+      inputCost: number | null;
+      outputCost: number | null;
+      costSource: CostSource;
+    }
+  | { kind: "table"; promptTokens: number; completionTokens: number; costSource: CostSource | null }
+  | { kind: "none" };
+
+/**
+ * Plan how to cost a span from the fields sent by the ingest service.
+ */
+export function planSpanCost(span: SpanCostFields): SpanCostPlan {
+  if (span.costOverrideUsd !== undefined) {
+    return {
+      kind: "provided",
+      totalCost: span.costOverrideUsd,
+      inputCost: span.promptCostUsd ?? null,
+      outputCost: span.completionCostUsd ?? null,
+      costSource: span.costSource ?? "override",
+    };
+  }
+
+  if (span.promptTokens || span.completionTokens) {
+    return {
+      kind: "table",
+      promptTokens: span.promptTokens ?? 0,
+      completionTokens: span.completionTokens ?? 0,
+      costSource: null,
+    };
+  }
+
+  if (span.estimatedPromptTokens || span.estimatedCompletionTokens) {
+    return {
+      kind: "table",
+      promptTokens: span.estimatedPromptTokens ?? 0,
+      completionTokens: span.estimatedCompletionTokens ?? 0,
+      costSource: "estimated_blended",
+    };
+  }
+
+  return { kind: "none" };
+}
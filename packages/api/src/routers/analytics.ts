@@ -244,7 +244,7 @@ async function getFilteredProjectAnalytics(
 
   for (const span of spans) {
     if (span.level === "ERROR") errorCount++;
-    if (span.totalTokens) totalTokens += span.totalTokens;
+    if (span.totalTokens) totalTokens += Number(span.totalTokens);
     if (span.startTime && span.endTime) {
       const duration = span.endTime.getTime() - span.startTime.getTime();
       totalLatency += duration;
@@ -298,9 +298,9 @@ async function getFilteredProjectAnalytics(
     group.traceIds.add(span.trace.id);
 
     if (span.level === "ERROR") group.errors++;
-    if (span.promptTokens) group.promptTokens += span.promptTokens;
-    if (span.completionTokens) group.completionTokens += span.completionTokens;
-    if (span.totalTokens) group.totalTokens += span.totalTokens;
+    if (span.promptTokens) group.promptTokens += Number(span.promptTokens);
+    if (span.completionTokens) group.completionTokens += Number(span.completionTokens);
+    if (span.totalTokens) group.totalTokens += Number(span.totalTokens);
     if (span.startTime && span.endTime) {
       group.latencies.push(span.endTime.getTime() - span.startTime.getTime());
     }
@@ -344,7 +344,7 @@ async function getFilteredProjectAnalytics(
     if (span.model) {
       const existing = modelCounts.get(span.model) ?? { count: 0, tokens: 0 };
       existing.count++;
-      existing.tokens += span.totalTokens ?? 0;
+      existing.tokens += Number(span.totalTokens ?? 0);
       modelCounts.set(span.model, existing);
     }
   }
@@ -431,7 +431,7 @@ export const analyticsRouter = createRouter({
         totalSpans += trace.spans.length;
         for (const span of trace.spans) {
           if (span.level === "ERROR") errorCount++;
-          if (span.totalTokens) totalTokens += span.totalTokens;
+          if (span.totalTokens) totalTokens += Number(span.totalTokens);
           if (span.startTime && span.endTime) {
             const duration = span.endTime.getTime() - span.startTime.getTime();
             totalLatency += duration;
@@ -484,9 +484,9 @@ export const analyticsRouter = createRouter({
 
         for (const span of trace.spans) {
           if (span.level === "ERROR") group.errors++;
-          if (span.promptTokens) group.promptTokens += span.promptTokens;
-          if (span.completionTokens) group.completionTokens += span.completionTokens;
-          if (span.totalTokens) group.totalTokens += span.totalTokens;
+          if (span.promptTokens) group.promptTokens += Number(span.promptTokens);
+          if (span.completionTokens) group.completionTokens += Number(span.completionTokens);
+          if (span.totalTokens) group.totalTokens += Number(span.totalTokens);
           if (span.startTime && span.endTime) {
             group.latencies.push(span.endTime.getTime() - span.startTime.getTime());
           }
@@ -532,7 +532,7 @@ export const analyticsRouter = createRouter({
           if (span.model) {
             const existing = modelCounts.get(span.model) ?? { count: 0, tokens: 0 };
             existing.count++;
-            existing.tokens += span.totalTokens ?? 0;
+            existing.tokens += Number(span.totalTokens ?? 0);
             modelCounts.set(span.model, existing);
           }
         }
@@ -648,8 +648,8 @@ export const analyticsRouter = createRouter({
             pStats.errorCount++;
           }
           if (span.totalTokens) {
-            totalTokens += span.totalTokens;
-            pStats.totalTokens += span.totalTokens;
+            totalTokens += Number(span.totalTokens);
+            pStats.totalTokens += Number(span.totalTokens);
           }
           if (span.startTime && span.endTime) {
             const duration = span.endTime.getTime() - span.startTime.getTime();
@@ -715,9 +715,9 @@ export const analyticsRouter = createRouter({
 
         for (const span of trace.spans) {
           if (span.level === "ERROR") group.errors++;
-          if (span.promptTokens) group.promptTokens += span.promptTokens;
-          if (span.completionTokens) group.completionTokens += span.completionTokens;
-          if (span.totalTokens) group.totalTokens += span.totalTokens;
+          if (span.promptTokens) group.promptTokens += Number(span.promptTokens);
+          if (span.completionTokens) group.completionTokens += Number(span.completionTokens);
+          if (span.totalTokens) group.totalTokens += Number(span.totalTokens);
           if (span.startTime && span.endTime) {
             group.latencies.push(span.endTime.getTime() - span.startTime.getTime());
           }
@@ -763,7 +763,7 @@ export const analyticsRouter = createRouter({
           if (span.model) {
             const existing = modelCounts.get(span.model) ?? { count: 0, tokens: 0 };
             existing.count++;
-            existing.tokens += span.totalTokens ?? 0;
+            existing.tokens += Number(span.totalTokens ?? 0);
             modelCounts.set(span.model, existing);
           }
         }
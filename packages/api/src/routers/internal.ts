@@ -12,7 +12,7 @@ import { z } from "zod";
 import { TRPCError } from "@trpc/server";
 import { prisma, Prisma, SpanLevel, setChunkEmbeddings } from "@cognobserve/db";
 import { createRouter, publicProcedure, middleware } from "../trpc";
-import { calculateSpanCost } from "../lib/cost";
+import { calculateSpanCost, planSpanCost } from "../lib/cost";
 import { SEVERITY_DEFAULTS, type AlertPayload, type ChannelProvider } from "../schemas/alerting";
 import { StoreGitHubIndexSchema } from "../schemas/github";
 import { AdapterRegistry } from "../lib/alerting/registry";
@@ -63,6 +63,12 @@ const UserInputSchema = z.object({
   email: z.string().optional(),
 }).optional();
 
+// Token counts arrive as decimal strings so counts beyond 2^53 stay exact;
+// workflows started before counts were widened send plain numbers
+const TokenCountSchema = z
+  .union([z.string().regex(/^-?\d+$/), z.number().int()])
+  .transform((value) => BigInt(value));
+
 const SpanInputSchema = z.object({
   id: z.string(),
   parentSpanId: z.string().optional(),
@@ -74,9 +80,13 @@ const SpanInputSchema = z.object({
   metadata: z.record(z.string(), z.unknown()).optional(),
   model: z.string().optional(),
   modelParameters: z.record(z.string(), z.unknown()).optional(),
-  promptTokens: z.number().optional(),
-  completionTokens: z.number().optional(),
-  totalTokens: z.number().optional(),
+  promptTokens: TokenCountSchema.optional(),
+  completionTokens: TokenCountSchema.optional(),
+  totalTokens: TokenCountSchema.optional(),
+  costOverrideUsd: z.number().nonnegative().optional(),
+  promptCostUsd: z.number().nonnegative().optional(),
+  completionCostUsd: z.number().nonnegative().optional(),
+  costSource: z.enum(["override", "client", "estimated_blended"]).optional(),
   level: z.string().optional(),
   statusMessage: z.string().optional(),
 });
@@ -95,6 +105,23 @@ const TraceIngestSchema = z.object({
   spans: z.array(SpanInputSchema),
 });
 
+const SpanTokenEstimateSchema = z.object({
+  spanId: z.string(),
+  promptTokens: z.number().int().nonnegative(),
+  completionTokens: z.number().int().nonnegative(),
+});
+
+const SpanUpdateSchema = z.object({
+  spanId: z.string(),
+  output: z.unknown().optional(),
+  endTime: z.string().optional(),
+  promptTokens: TokenCountSchema.optional(),
+  completionTokens: TokenCountSchema.optional(),
+  totalTokens: TokenCountSchema.optional(),
+  level: z.string().optional(),
+  statusMessage: z.string().optional(),
+});
+
 const ScoreIngestSchema = z.object({
   id: z.string(),
   projectId: z.string(),
@@ -232,27 +259,36 @@ export const internalRouter = createRouter({
           },
         });
 
-        // Create spans
+        // Create spans. Costs supplied at ingest, with the client's
+        // breakdown when sent, are stored as they are, which also keeps
+        // calculateTraceCosts from repricing them.
         if (spans.length > 0) {
           await tx.span.createMany({
-            data: spans.map((span) => ({
-              id: span.id,
-              traceId: trace.id,
-              parentSpanId: span.parentSpanId ?? null,
-              name: span.name,
-              startTime: parseDate(span.startTime),
-              endTime: span.endTime ? parseDate(span.endTime) : null,
-              input: (span.input as Prisma.InputJsonValue) ?? Prisma.JsonNull,
-              output: (span.output as Prisma.InputJsonValue) ?? Prisma.JsonNull,
-              metadata: (span.metadata as Prisma.InputJsonValue) ?? Prisma.JsonNull,
-              model: span.model ?? null,
-              modelParameters: (span.modelParameters as Prisma.InputJsonValue) ?? Prisma.JsonNull,
-              promptTokens: span.promptTokens ?? null,
-              completionTokens: span.completionTokens ?? null,
-              totalTokens: span.totalTokens ?? null,
-              level: convertSpanLevel(span.level),
-              statusMessage: span.statusMessage ?? null,
-            })),
+            data: spans.map((span) => {
+              const plan = planSpanCost(span);
+              return {
+                id: span.id,
+                traceId: trace.id,
+                parentSpanId: span.parentSpanId ?? null,
+                name: span.name,
+                startTime: parseDate(span.startTime),
+                endTime: span.endTime ? parseDate(span.endTime) : null,
+                input: (span.input as Prisma.InputJsonValue) ?? Prisma.JsonNull,
+                output: (span.output as Prisma.InputJsonValue) ?? Prisma.JsonNull,
+                metadata: (span.metadata as Prisma.InputJsonValue) ?? Prisma.JsonNull,
+                model: span.model ?? null,
+                modelParameters: (span.modelParameters as Prisma.InputJsonValue) ?? Prisma.JsonNull,
+                promptTokens: span.promptTokens ?? null,
+                completionTokens: span.completionTokens ?? null,
+                totalTokens: span.totalTokens ?? null,
+                level: convertSpanLevel(span.level),
+                statusMessage: span.statusMessage ?? null,
+                inputCost: plan.kind === "provided" && plan.inputCost !== null ? new Decimal(plan.inputCost) : null,
+                outputCost: plan.kind === "provided" && plan.outputCost !== null ? new Decimal(plan.outputCost) : null,
+                totalCost: plan.kind === "provided" ? new Decimal(plan.totalCost) : null,
+                costSource: plan.kind === "provided" ? plan.costSource : null,
+              };
+            }),
           });
         }
 
@@ -263,16 +299,58 @@ export const internalRouter = createRouter({
       return { traceId: result.id };
     }),
 
+  /**
+   * Apply partial updates to persisted spans (updateSpan signals)
+   * Called by: trace.activities.ts → applySpanUpdates
+   */
+  updateSpans: internalProcedure
+    .input(z.object({
+      traceId: z.string(),
+      updates: z.array(SpanUpdateSchema),
+    }))
+    .mutation(async ({ input }) => {
+      const { traceId, updates } = input;
+
+      const updatedCount = await prisma.$transaction(async (tx) => {
+        let count = 0;
+        for (const update of updates) {
+          const result = await tx.span.updateMany({
+            where: { id: update.spanId, traceId },
+            data: {
+              ...(update.output !== undefined && {
+                output: update.output as Prisma.InputJsonValue,
+              }),
+              ...(update.endTime !== undefined && { endTime: parseDate(update.endTime) }),
+              ...(update.promptTokens !== undefined && { promptTokens: update.promptTokens }),
+              ...(update.completionTokens !== undefined && { completionTokens: update.completionTokens }),
+              ...(update.totalTokens !== undefined && { totalTokens: update.totalTokens }),
+              ...(update.level !== undefined && { level: convertSpanLevel(update.level) }),
+              ...(update.statusMessage !== undefined && { statusMessage: update.statusMessage }),
+            },
+          });
+          count += result.count;
+        }
+        return count;
+      });
+
+      console.log(`[Internal:updateSpans] Applied ${updates.length} updates to trace ${traceId}`);
+      return { updatedCount };
+    }),
+
   /**
    * Calculate costs for a trace's spans
    * Called by: trace.activities.ts → calculateTraceCosts
    */
   calculateTraceCosts: internalProcedure
-    .input(z.object({ traceId: z.string() }))
+    .input(z.object({
+      traceId: z.string(),
+      estimates: z.array(SpanTokenEstimateSchema).optional(),
+    }))
     .mutation(async ({ input }) => {
       const { traceId } = input;
+      const estimates = new Map(input.estimates?.map((e) => [e.spanId, e]));
 
-      // Find spans with model and tokens but no cost
+      // Find spans with model and tokens (reported or estimated) but no cost
       const spans = await prisma.span.findMany({
         where: {
           traceId,
@@ -280,6 +358,7 @@ export const internalRouter = createRouter({
           OR: [
             { promptTokens: { gt: 0 } },
             { completionTokens: { gt: 0 } },
+            { id: { in: [...estimates.keys()] } },
           ],
           totalCost: null,
         },
@@ -300,10 +379,20 @@ export const internalRouter = createRouter({
       for (const span of spans) {
         if (!span.model) continue;
 
+        const estimate = estimates.get(span.id);
+        // Prices are per million tokens, so counts needn't be exact here
+        const plan = planSpanCost({
+          promptTokens: span.promptTokens === null ? null : Number(span.promptTokens),
+          completionTokens: span.completionTokens === null ? null : Number(span.completionTokens),
+          estimatedPromptTokens: estimate?.promptTokens,
+          estimatedCompletionTokens: estimate?.completionTokens,
+        });
+        if (plan.kind !== "table") continue;
+
         const cost = await calculateSpanCost({
           model: span.model,
-          promptTokens: span.promptTokens,
-          completionTokens: span.completionTokens,
+          promptTokens: plan.promptTokens,
+          completionTokens: plan.completionTokens,
         });
 
         if (cost) {
@@ -314,6 +403,7 @@ export const internalRouter = createRouter({
               outputCost: cost.outputCost,
               totalCost: cost.totalCost,
               pricingId: cost.pricingId,
+              ...(plan.costSource && { costSource: plan.costSource }),
             },
           });
           updatedCount++;
@@ -94,7 +94,7 @@ export const sessionsRouter = createRouter({
 
           for (const trace of session.traces) {
             for (const span of trace.spans) {
-              totalTokens += span.totalTokens ?? 0;
+              totalTokens += Number(span.totalTokens ?? 0);
               totalCost += Number(span.totalCost ?? 0);
               if (span.level === SpanLevel.ERROR) errorCount++;
               if (span.endTime && span.startTime) {
@@ -321,7 +321,13 @@ export const sessionsRouter = createRouter({
         },
       });
 
-      return traces;
+      return traces.map((trace) => ({
+        ...trace,
+        spans: trace.spans.map((span) => ({
+          ...span,
+          totalTokens: span.totalTokens === null ? null : Number(span.totalTokens),
+        })),
+      }));
     }),
 });
 
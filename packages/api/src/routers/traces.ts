@@ -339,7 +339,7 @@ export const tracesRouter = createRouter({
 
       const items = traces.map((trace) => {
         const totalTokens = trace.spans.reduce(
-          (sum, span) => sum + (span.totalTokens ?? 0),
+          (sum, span) => sum + Number(span.totalTokens ?? 0),
           0
         );
 
@@ -500,9 +500,9 @@ export const tracesRouter = createRouter({
             duration: spanEndTime ? spanEndTime - spanStartTime : null,
             offsetFromTraceStart: spanStartTime - traceStartTime,
             model: span.model,
-            promptTokens: span.promptTokens,
-            completionTokens: span.completionTokens,
-            totalTokens: span.totalTokens,
+            promptTokens: span.promptTokens === null ? null : Number(span.promptTokens),
+            completionTokens: span.completionTokens === null ? null : Number(span.completionTokens),
+            totalTokens: span.totalTokens === null ? null : Number(span.totalTokens),
             level: span.level,
             statusMessage: span.statusMessage,
           };
@@ -571,9 +571,9 @@ export const tracesRouter = createRouter({
         duration: spanEndTime ? spanEndTime - spanStartTime : null,
         model: span.model,
         modelParameters: span.modelParameters,
-        promptTokens: span.promptTokens,
-        completionTokens: span.completionTokens,
-        totalTokens: span.totalTokens,
+        promptTokens: span.promptTokens === null ? null : Number(span.promptTokens),
+        completionTokens: span.completionTokens === null ? null : Number(span.completionTokens),
+        totalTokens: span.totalTokens === null ? null : Number(span.totalTokens),
         level: span.level,
         statusMessage: span.statusMessage,
         input: span.input,
@@ -197,7 +197,7 @@ export class TrackedUserService {
 
     return {
       ...user,
-      totalTokens: metrics._sum.totalTokens ?? 0,
+      totalTokens: Number(metrics._sum.totalTokens ?? 0),
       totalCost: Number(metrics._sum.totalCost ?? 0),
       errorCount,
     };
@@ -276,7 +276,15 @@ export class TrackedUserService {
       nextCursor = next?.id;
     }
 
-    return { items: traces, nextCursor };
+    const items = traces.map((trace) => ({
+      ...trace,
+      spans: trace.spans.map((span) => ({
+        ...span,
+        totalTokens: span.totalTokens === null ? null : Number(span.totalTokens),
+      })),
+    }));
+
+    return { items, nextCursor };
   }
 
   /**
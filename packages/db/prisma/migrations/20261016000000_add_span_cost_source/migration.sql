@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "Span" ADD COLUMN     "costSource" TEXT;
@@ -0,0 +1,23 @@
+-- AlterTable
+ALTER TABLE "Project" ADD COLUMN     "outputSchemaValidation" BOOLEAN NOT NULL DEFAULT false;
+
+-- CreateTable
+CREATE TABLE "OutputSchema" (
+    "id" TEXT NOT NULL,
+    "projectId" TEXT NOT NULL,
+    "spanName" TEXT NOT NULL,
+    "schema" JSONB NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP(3) NOT NULL,
+
+    CONSTRAINT "OutputSchema_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateIndex
+CREATE INDEX "OutputSchema_projectId_idx" ON "OutputSchema"("projectId");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "OutputSchema_projectId_spanName_key" ON "OutputSchema"("projectId", "spanName");
+
+-- AddForeignKey
+ALTER TABLE "OutputSchema" ADD CONSTRAINT "OutputSchema_projectId_fkey" FOREIGN KEY ("projectId") REFERENCES "Project"("id") ON DELETE CASCADE ON UPDATE CASCADE;
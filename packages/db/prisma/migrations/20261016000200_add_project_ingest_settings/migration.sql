@@ -0,0 +1,17 @@
+-- CreateEnum
+CREATE TYPE "MetadataPolicyMode" AS ENUM ('ALLOW', 'BLOCK');
+
+-- AlterTable
+ALTER TABLE "ApiKey" ADD COLUMN     "scopes" TEXT[] DEFAULT ARRAY[]::TEXT[];
+
+-- AlterTable
+ALTER TABLE "Project" ADD COLUMN     "dailyBudgetUsd" DECIMAL(12,2),
+ADD COLUMN     "features" JSONB,
+ADD COLUMN     "metadataPolicyKeys" TEXT[] DEFAULT ARRAY[]::TEXT[],
+ADD COLUMN     "metadataPolicyMode" "MetadataPolicyMode",
+ADD COLUMN     "requiredMetadata" TEXT[] DEFAULT ARRAY[]::TEXT[],
+ADD COLUMN     "retentionDefaultDays" INTEGER,
+ADD COLUMN     "retentionMaxDays" INTEGER,
+ADD COLUMN     "taskQueue" TEXT,
+ADD COLUMN     "webhookSecret" TEXT,
+ADD COLUMN     "webhookUrl" TEXT;
@@ -0,0 +1,4 @@
+-- AlterTable
+ALTER TABLE "Span" ALTER COLUMN "promptTokens" SET DATA TYPE BIGINT,
+ALTER COLUMN "completionTokens" SET DATA TYPE BIGINT,
+ALTER COLUMN "totalTokens" SET DATA TYPE BIGINT;
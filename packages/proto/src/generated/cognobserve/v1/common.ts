@@ -77,6 +77,77 @@ export function spanLevelToNumber(object: SpanLevel): number {
   }
 }
 
+/** Outcome of a whole trace */
+export enum TraceStatus {
+  /** TRACE_STATUS_UNSPECIFIED - Derived from span levels */
+  TRACE_STATUS_UNSPECIFIED = "TRACE_STATUS_UNSPECIFIED",
+  TRACE_STATUS_SUCCESS = "TRACE_STATUS_SUCCESS",
+  TRACE_STATUS_ERROR = "TRACE_STATUS_ERROR",
+  TRACE_STATUS_CANCELLED = "TRACE_STATUS_CANCELLED",
+  TRACE_STATUS_UNKNOWN = "TRACE_STATUS_UNKNOWN",
+  UNRECOGNIZED = "UNRECOGNIZED",
+}
+
+export function traceStatusFromJSON(object: any): TraceStatus {
+  switch (object) {
+    case 0:
+    case "TRACE_STATUS_UNSPECIFIED":
+      return TraceStatus.TRACE_STATUS_UNSPECIFIED;
+    case 1:
+    case "TRACE_STATUS_SUCCESS":
+      return TraceStatus.TRACE_STATUS_SUCCESS;
+    case 2:
+    case "TRACE_STATUS_ERROR":
+      return TraceStatus.TRACE_STATUS_ERROR;
+    case 3:
+    case "TRACE_STATUS_CANCELLED":
+      return TraceStatus.TRACE_STATUS_CANCELLED;
+    case 4:
+    case "TRACE_STATUS_UNKNOWN":
+      return TraceStatus.TRACE_STATUS_UNKNOWN;
+    case -1:
+    case "UNRECOGNIZED":
+    default:
+      return TraceStatus.UNRECOGNIZED;
+  }
+}
+
+export function traceStatusToJSON(object: TraceStatus): string {
+  switch (object) {
+    case TraceStatus.TRACE_STATUS_UNSPECIFIED:
+      return "TRACE_STATUS_UNSPECIFIED";
+    case TraceStatus.TRACE_STATUS_SUCCESS:
+      return "TRACE_STATUS_SUCCESS";
+    case TraceStatus.TRACE_STATUS_ERROR:
+      return "TRACE_STATUS_ERROR";
+    case TraceStatus.TRACE_STATUS_CANCELLED:
+      return "TRACE_STATUS_CANCELLED";
+    case TraceStatus.TRACE_STATUS_UNKNOWN:
+      return "TRACE_STATUS_UNKNOWN";
+    case TraceStatus.UNRECOGNIZED:
+    default:
+      return "UNRECOGNIZED";
+  }
+}
+
+export function traceStatusToNumber(object: TraceStatus): number {
+  switch (object) {
+    case TraceStatus.TRACE_STATUS_UNSPECIFIED:
+      return 0;
+    case TraceStatus.TRACE_STATUS_SUCCESS:
+      return 1;
+    case TraceStatus.TRACE_STATUS_ERROR:
+      return 2;
+    case TraceStatus.TRACE_STATUS_CANCELLED:
+      return 3;
+    case TraceStatus.TRACE_STATUS_UNKNOWN:
+      return 4;
+    case TraceStatus.UNRECOGNIZED:
+    default:
+      return -1;
+  }
+}
+
 /** Token usage for LLM calls */
 export interface TokenUsage {
   promptTokens?: number | undefined;
@@ -91,13 +162,13 @@ function createBaseTokenUsage(): TokenUsage {
 export const TokenUsage: MessageFns<TokenUsage> = {
   encode(message: TokenUsage, writer: BinaryWriter = new BinaryWriter()): BinaryWriter {
     if (message.promptTokens !== undefined) {
-      writer.uint32(8).int32(message.promptTokens);
+      writer.uint32(8).int64(message.promptTokens);
     }
     if (message.completionTokens !== undefined) {
-      writer.uint32(16).int32(message.completionTokens);
+      writer.uint32(16).int64(message.completionTokens);
     }
     if (message.totalTokens !== undefined) {
-      writer.uint32(24).int32(message.totalTokens);
+      writer.uint32(24).int64(message.totalTokens);
     }
     return writer;
   },
@@ -114,7 +185,7 @@ export const TokenUsage: MessageFns<TokenUsage> = {
             break;
           }
 
-          message.promptTokens = reader.int32();
+          message.promptTokens = longToNumber(reader.int64());
           continue;
         }
         case 2: {
@@ -122,7 +193,7 @@ export const TokenUsage: MessageFns<TokenUsage> = {
             break;
           }
 
-          message.completionTokens = reader.int32();
+          message.completionTokens = longToNumber(reader.int64());
           continue;
         }
         case 3: {
@@ -130,7 +201,7 @@ export const TokenUsage: MessageFns<TokenUsage> = {
             break;
           }
 
-          message.totalTokens = reader.int32();
+          message.totalTokens = longToNumber(reader.int64());
           continue;
         }
       }
@@ -188,6 +259,17 @@ type KeysOfUnion<T> = T extends T ? keyof T : never;
 type Exact<P, I extends P> = P extends Builtin ? P
   : P & { [K in keyof P]: Exact<P[K], I[K]> } & { [K in Exclude<keyof I, KeysOfUnion<P>>]: never };
 
+function longToNumber(int64: { toString(): string }): number {
+  const num = globalThis.Number(int64.toString());
+  if (num > globalThis.Number.MAX_SAFE_INTEGER) {
+    throw new globalThis.Error("Value is larger than Number.MAX_SAFE_INTEGER");
+  }
+  if (num < globalThis.Number.MIN_SAFE_INTEGER) {
+    throw new globalThis.Error("Value is smaller than Number.MIN_SAFE_INTEGER");
+  }
+  return num;
+}
+
 function isSet(value: any): boolean {
   return value !== null && value !== undefined;
 }
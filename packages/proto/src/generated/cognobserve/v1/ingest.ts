@@ -8,7 +8,17 @@
 import { BinaryReader, BinaryWriter } from "@bufbuild/protobuf/wire";
 import { Struct } from "../../google/protobuf/struct";
 import { Timestamp } from "../../google/protobuf/timestamp";
-import { SpanLevel, spanLevelFromJSON, spanLevelToJSON, spanLevelToNumber, TokenUsage } from "./common";
+import {
+  SpanLevel,
+  spanLevelFromJSON,
+  spanLevelToJSON,
+  spanLevelToNumber,
+  TokenUsage,
+  TraceStatus,
+  traceStatusFromJSON,
+  traceStatusToJSON,
+  traceStatusToNumber,
+} from "./common";
 
 /** User information for tracking end-users */
 export interface UserInfo {
@@ -33,7 +43,20 @@ export interface IngestTraceRequest {
     | undefined;
   /** User tracking (end-users of AI applications) */
   userId?: string | undefined;
-  user?: UserInfo | undefined;
+  user?:
+    | UserInfo
+    | undefined;
+  /** Deployment tagging */
+  environment?: string | undefined;
+  release?:
+    | string
+    | undefined;
+  /** Searchable labels, e.g. "billing", "beta-feature" */
+  tags: string[];
+  /** Accept a trace without spans (rejected by default) */
+  allowEmptyTrace: boolean;
+  /** Trace outcome; derived from span levels when unspecified */
+  status: TraceStatus;
 }
 
 /** Span data for ingestion */
@@ -51,7 +74,28 @@ export interface IngestSpan {
   modelParameters?: { [key: string]: any } | undefined;
   usage?: TokenUsage | undefined;
   level: SpanLevel;
-  statusMessage?: string | undefined;
+  statusMessage?:
+    | string
+    | undefined;
+  /** Media referenced by URL or small inline content */
+  attachments: IngestAttachment[];
+}
+
+/**
+ * Media (image, audio, ...) referenced by a span.
+ * Exactly one of url or content_base64 must be set.
+ */
+export interface IngestAttachment {
+  /** image, audio, video or file */
+  type: string;
+  /** e.g. image/png */
+  mime: string;
+  url?: string | undefined;
+  contentBase64?:
+    | string
+    | undefined;
+  /** Hex digest of the content */
+  sha256?: string | undefined;
 }
 
 /** Response after ingesting a trace */
@@ -59,6 +103,11 @@ export interface IngestTraceResponse {
   traceId: string;
   spanIds: string[];
   success: boolean;
+  workflowId: string;
+  /** True when the trace had already been submitted */
+  duplicate: boolean;
+  /** True when identical content was dropped within the dedup window */
+  deduplicated: boolean;
 }
 
 /** Batch ingestion request */
@@ -73,6 +122,33 @@ export interface IngestBatchResponse {
   errorCount: number;
 }
 
+/** Request to ingest a score for a trace, span, session or user */
+export interface IngestScoreRequest {
+  /** Optional, server generates if not provided */
+  scoreId?: string | undefined;
+  name: string;
+  numericValue?: number | undefined;
+  stringValue?: string | undefined;
+  booleanValue?:
+    | boolean
+    | undefined;
+  /** Target of the score, at least one is required */
+  traceId?: string | undefined;
+  spanId?: string | undefined;
+  sessionId?: string | undefined;
+  userId?: string | undefined;
+  configId?: string | undefined;
+  comment?: string | undefined;
+  metadata?: { [key: string]: any } | undefined;
+}
+
+/** Response after ingesting a score */
+export interface IngestScoreResponse {
+  scoreId: string;
+  workflowId: string;
+  success: boolean;
+}
+
 /** Health check */
 export interface HealthRequest {
 }
@@ -183,6 +259,11 @@ function createBaseIngestTraceRequest(): IngestTraceRequest {
     sessionId: undefined,
     userId: undefined,
     user: undefined,
+    environment: undefined,
+    release: undefined,
+    tags: [],
+    allowEmptyTrace: false,
+    status: TraceStatus.TRACE_STATUS_UNSPECIFIED,
   };
 }
 
@@ -209,6 +290,21 @@ export const IngestTraceRequest: MessageFns<IngestTraceRequest> = {
     if (message.user !== undefined) {
       UserInfo.encode(message.user, writer.uint32(58).fork()).join();
     }
+    if (message.environment !== undefined) {
+      writer.uint32(66).string(message.environment);
+    }
+    if (message.release !== undefined) {
+      writer.uint32(74).string(message.release);
+    }
+    for (const v of message.tags) {
+      writer.uint32(82).string(v!);
+    }
+    if (message.allowEmptyTrace !== false) {
+      writer.uint32(88).bool(message.allowEmptyTrace);
+    }
+    if (message.status !== TraceStatus.TRACE_STATUS_UNSPECIFIED) {
+      writer.uint32(96).int32(traceStatusToNumber(message.status));
+    }
     return writer;
   },
 
@@ -275,6 +371,46 @@ export const IngestTraceRequest: MessageFns<IngestTraceRequest> = {
           message.user = UserInfo.decode(reader, reader.uint32());
           continue;
         }
+        case 8: {
+          if (tag !== 66) {
+            break;
+          }
+
+          message.environment = reader.string();
+          continue;
+        }
+        case 9: {
+          if (tag !== 74) {
+            break;
+          }
+
+          message.release = reader.string();
+          continue;
+        }
+        case 10: {
+          if (tag !== 82) {
+            break;
+          }
+
+          message.tags.push(reader.string());
+          continue;
+        }
+        case 11: {
+          if (tag !== 88) {
+            break;
+          }
+
+          message.allowEmptyTrace = reader.bool();
+          continue;
+        }
+        case 12: {
+          if (tag !== 96) {
+            break;
+          }
+
+          message.status = traceStatusFromJSON(reader.int32());
+          continue;
+        }
       }
       if ((tag & 7) === 4 || tag === 0) {
         break;
@@ -293,6 +429,11 @@ export const IngestTraceRequest: MessageFns<IngestTraceRequest> = {
       sessionId: isSet(object.sessionId) ? globalThis.String(object.sessionId) : undefined,
       userId: isSet(object.userId) ? globalThis.String(object.userId) : undefined,
       user: isSet(object.user) ? UserInfo.fromJSON(object.user) : undefined,
+      environment: isSet(object.environment) ? globalThis.String(object.environment) : undefined,
+      release: isSet(object.release) ? globalThis.String(object.release) : undefined,
+      tags: globalThis.Array.isArray(object?.tags) ? object.tags.map((e: any) => globalThis.String(e)) : [],
+      allowEmptyTrace: isSet(object.allowEmptyTrace) ? globalThis.Boolean(object.allowEmptyTrace) : false,
+      status: isSet(object.status) ? traceStatusFromJSON(object.status) : TraceStatus.TRACE_STATUS_UNSPECIFIED,
     };
   },
 
@@ -319,6 +460,21 @@ export const IngestTraceRequest: MessageFns<IngestTraceRequest> = {
     if (message.user !== undefined) {
       obj.user = UserInfo.toJSON(message.user);
     }
+    if (message.environment !== undefined) {
+      obj.environment = message.environment;
+    }
+    if (message.release !== undefined) {
+      obj.release = message.release;
+    }
+    if (message.tags?.length) {
+      obj.tags = message.tags;
+    }
+    if (message.allowEmptyTrace !== false) {
+      obj.allowEmptyTrace = message.allowEmptyTrace;
+    }
+    if (message.status !== TraceStatus.TRACE_STATUS_UNSPECIFIED) {
+      obj.status = traceStatusToJSON(message.status);
+    }
     return obj;
   },
 
@@ -334,6 +490,11 @@ export const IngestTraceRequest: MessageFns<IngestTraceRequest> = {
     message.sessionId = object.sessionId ?? undefined;
     message.userId = object.userId ?? undefined;
     message.user = (object.user !== undefined && object.user !== null) ? UserInfo.fromPartial(object.user) : undefined;
+    message.environment = object.environment ?? undefined;
+    message.release = object.release ?? undefined;
+    message.tags = object.tags?.map((e) => e) || [];
+    message.allowEmptyTrace = object.allowEmptyTrace ?? false;
+    message.status = object.status ?? TraceStatus.TRACE_STATUS_UNSPECIFIED;
     return message;
   },
 };
@@ -353,6 +514,7 @@ function createBaseIngestSpan(): IngestSpan {
     usage: undefined,
     level: SpanLevel.SPAN_LEVEL_UNSPECIFIED,
     statusMessage: undefined,
+    attachments: [],
   };
 }
 
@@ -397,6 +559,9 @@ export const IngestSpan: MessageFns<IngestSpan> = {
     if (message.statusMessage !== undefined) {
       writer.uint32(106).string(message.statusMessage);
     }
+    for (const v of message.attachments) {
+      IngestAttachment.encode(v!, writer.uint32(114).fork()).join();
+    }
     return writer;
   },
 
@@ -511,6 +676,14 @@ export const IngestSpan: MessageFns<IngestSpan> = {
           message.statusMessage = reader.string();
           continue;
         }
+        case 14: {
+          if (tag !== 114) {
+            break;
+          }
+
+          message.attachments.push(IngestAttachment.decode(reader, reader.uint32()));
+          continue;
+        }
       }
       if ((tag & 7) === 4 || tag === 0) {
         break;
@@ -535,6 +708,9 @@ export const IngestSpan: MessageFns<IngestSpan> = {
       usage: isSet(object.usage) ? TokenUsage.fromJSON(object.usage) : undefined,
       level: isSet(object.level) ? spanLevelFromJSON(object.level) : SpanLevel.SPAN_LEVEL_UNSPECIFIED,
       statusMessage: isSet(object.statusMessage) ? globalThis.String(object.statusMessage) : undefined,
+      attachments: globalThis.Array.isArray(object?.attachments)
+        ? object.attachments.map((e: any) => IngestAttachment.fromJSON(e))
+        : [],
     };
   },
 
@@ -579,6 +755,9 @@ export const IngestSpan: MessageFns<IngestSpan> = {
     if (message.statusMessage !== undefined) {
       obj.statusMessage = message.statusMessage;
     }
+    if (message.attachments?.length) {
+      obj.attachments = message.attachments.map((e) => IngestAttachment.toJSON(e));
+    }
     return obj;
   },
 
@@ -602,12 +781,137 @@ export const IngestSpan: MessageFns<IngestSpan> = {
       : undefined;
     message.level = object.level ?? SpanLevel.SPAN_LEVEL_UNSPECIFIED;
     message.statusMessage = object.statusMessage ?? undefined;
+    message.attachments = object.attachments?.map((e) => IngestAttachment.fromPartial(e)) || [];
+    return message;
+  },
+};
+
+function createBaseIngestAttachment(): IngestAttachment {
+  return { type: "", mime: "", url: undefined, contentBase64: undefined, sha256: undefined };
+}
+
+export const IngestAttachment: MessageFns<IngestAttachment> = {
+  encode(message: IngestAttachment, writer: BinaryWriter = new BinaryWriter()): BinaryWriter {
+    if (message.type !== "") {
+      writer.uint32(10).string(message.type);
+    }
+    if (message.mime !== "") {
+      writer.uint32(18).string(message.mime);
+    }
+    if (message.url !== undefined) {
+      writer.uint32(26).string(message.url);
+    }
+    if (message.contentBase64 !== undefined) {
+      writer.uint32(34).string(message.contentBase64);
+    }
+    if (message.sha256 !== undefined) {
+      writer.uint32(42).string(message.sha256);
+    }
+    return writer;
+  },
+
+  decode(input: BinaryReader | Uint8Array, length?: number): IngestAttachment {
+    const reader = input instanceof BinaryReader ? input : new BinaryReader(input);
+    let end = length === undefined ? reader.len : reader.pos + length;
+    const message = createBaseIngestAttachment();
+    while (reader.pos < end) {
+      const tag = reader.uint32();
+      switch (tag >>> 3) {
+        case 1: {
+          if (tag !== 10) {
+            break;
+          }
+
+          message.type = reader.string();
+          continue;
+        }
+        case 2: {
+          if (tag !== 18) {
+            break;
+          }
+
+          message.mime = reader.string();
+          continue;
+        }
+        case 3: {
+          if (tag !== 26) {
+            break;
+          }
+
+          message.url = reader.string();
+          continue;
+        }
+        case 4: {
+          if (tag !== 34) {
+            break;
+          }
+
+          message.contentBase64 = reader.string();
+          continue;
+        }
+        case 5: {
+          if (tag !== 42) {
+            break;
+          }
+
+          message.sha256 = reader.string();
+          continue;
+        }
+      }
+      if ((tag & 7) === 4 || tag === 0) {
+        break;
+      }
+      reader.skip(tag & 7);
+    }
+    return message;
+  },
+
+  fromJSON(object: any): IngestAttachment {
+    return {
+      type: isSet(object.type) ? globalThis.String(object.type) : "",
+      mime: isSet(object.mime) ? globalThis.String(object.mime) : "",
+      url: isSet(object.url) ? globalThis.String(object.url) : undefined,
+      contentBase64: isSet(object.contentBase64) ? globalThis.String(object.contentBase64) : undefined,
+      sha256: isSet(object.sha256) ? globalThis.String(object.sha256) : undefined,
+    };
+  },
+
+  toJSON(message: IngestAttachment): unknown {
+    const obj: any = {};
+    if (message.type !== "") {
+      obj.type = message.type;
+    }
+    if (message.mime !== "") {
+      obj.mime = message.mime;
+    }
+    if (message.url !== undefined) {
+      obj.url = message.url;
+    }
+    if (message.contentBase64 !== undefined) {
+      obj.contentBase64 = message.contentBase64;
+    }
+    if (message.sha256 !== undefined) {
+      obj.sha256 = message.sha256;
+    }
+    return obj;
+  },
+
+  create<I extends Exact<DeepPartial<IngestAttachment>, I>>(base?: I): IngestAttachment {
+    return IngestAttachment.fromPartial(base ?? ({} as any));
+  },
+  fromPartial<I extends Exact<DeepPartial<IngestAttachment>, I>>(object: I): IngestAttachment {
+    const message = createBaseIngestAttachment();
+    message.type = object.type ?? "";
+    message.mime = object.mime ?? "";
+    message.url = object.url ?? undefined;
+    message.contentBase64 = object.contentBase64 ?? undefined;
+    message.sha256 = object.sha256 ?? undefined;
     return message;
   },
 };
 
 function createBaseIngestTraceResponse(): IngestTraceResponse {
-  return { traceId: "", spanIds: [], success: false };
+  return { traceId: "", spanIds: [], success: false, workflowId: "", duplicate: false, deduplicated: false };
 }
 
 export const IngestTraceResponse: MessageFns<IngestTraceResponse> = {
@@ -621,6 +925,15 @@ export const IngestTraceResponse: MessageFns<IngestTraceResponse> = {
     if (message.success !== false) {
       writer.uint32(24).bool(message.success);
     }
+    if (message.workflowId !== "") {
+      writer.uint32(34).string(message.workflowId);
+    }
+    if (message.duplicate !== false) {
+      writer.uint32(40).bool(message.duplicate);
+    }
+    if (message.deduplicated !== false) {
+      writer.uint32(48).bool(message.deduplicated);
+    }
     return writer;
   },
 
@@ -655,6 +968,30 @@ export const IngestTraceResponse: MessageFns<IngestTraceResponse> = {
           message.success = reader.bool();
           continue;
         }
+        case 4: {
+          if (tag !== 34) {
+            break;
+          }
+
+          message.workflowId = reader.string();
+          continue;
+        }
+        case 5: {
+          if (tag !== 40) {
+            break;
+          }
+
+          message.duplicate = reader.bool();
+          continue;
+        }
+        case 6: {
+          if (tag !== 48) {
+            break;
+          }
+
+          message.deduplicated = reader.bool();
+          continue;
+        }
       }
       if ((tag & 7) === 4 || tag === 0) {
         break;
@@ -669,6 +1006,9 @@ export const IngestTraceResponse: MessageFns<IngestTraceResponse> = {
       traceId: isSet(object.traceId) ? globalThis.String(object.traceId) : "",
       spanIds: globalThis.Array.isArray(object?.spanIds) ? object.spanIds.map((e: any) => globalThis.String(e)) : [],
       success: isSet(object.success) ? globalThis.Boolean(object.success) : false,
+      workflowId: isSet(object.workflowId) ? globalThis.String(object.workflowId) : "",
+      duplicate: isSet(object.duplicate) ? globalThis.Boolean(object.duplicate) : false,
+      deduplicated: isSet(object.deduplicated) ? globalThis.Boolean(object.deduplicated) : false,
     };
   },
 
@@ -683,6 +1023,15 @@ export const IngestTraceResponse: MessageFns<IngestTraceResponse> = {
     if (message.success !== false) {
       obj.success = message.success;
     }
+    if (message.workflowId !== "") {
+      obj.workflowId = message.workflowId;
+    }
+    if (message.duplicate !== false) {
+      obj.duplicate = message.duplicate;
+    }
+    if (message.deduplicated !== false) {
+      obj.deduplicated = message.deduplicated;
+    }
     return obj;
   },
 
@@ -694,6 +1043,9 @@ export const IngestTraceResponse: MessageFns<IngestTraceResponse> = {
     message.traceId = object.traceId ?? "";
     message.spanIds = object.spanIds?.map((e) => e) || [];
     message.success = object.success ?? false;
+    message.workflowId = object.workflowId ?? "";
+    message.duplicate = object.duplicate ?? false;
+    message.deduplicated = object.deduplicated ?? false;
     return message;
   },
 };
@@ -854,6 +1206,347 @@ export const IngestBatchResponse: MessageFns<IngestBatchResponse> = {
   },
 };
 
+function createBaseIngestScoreRequest(): IngestScoreRequest {
+  return {
+    scoreId: undefined,
+    name: "",
+    numericValue: undefined,
+    stringValue: undefined,
+    booleanValue: undefined,
+    traceId: undefined,
+    spanId: undefined,
+    sessionId: undefined,
+    userId: undefined,
+    configId: undefined,
+    comment: undefined,
+    metadata: undefined,
+  };
+}
+
+export const IngestScoreRequest: MessageFns<IngestScoreRequest> = {
+  encode(message: IngestScoreRequest, writer: BinaryWriter = new BinaryWriter()): BinaryWriter {
+    if (message.scoreId !== undefined) {
+      writer.uint32(10).string(message.scoreId);
+    }
+    if (message.name !== "") {
+      writer.uint32(18).string(message.name);
+    }
+    if (message.numericValue !== undefined) {
+      writer.uint32(25).double(message.numericValue);
+    }
+    if (message.stringValue !== undefined) {
+      writer.uint32(34).string(message.stringValue);
+    }
+    if (message.booleanValue !== undefined) {
+      writer.uint32(40).bool(message.booleanValue);
+    }
+    if (message.traceId !== undefined) {
+      writer.uint32(50).string(message.traceId);
+    }
+    if (message.spanId !== undefined) {
+      writer.uint32(58).string(message.spanId);
+    }
+    if (message.sessionId !== undefined) {
+      writer.uint32(66).string(message.sessionId);
+    }
+    if (message.userId !== undefined) {
+      writer.uint32(74).string(message.userId);
+    }
+    if (message.configId !== undefined) {
+      writer.uint32(82).string(message.configId);
+    }
+    if (message.comment !== undefined) {
+      writer.uint32(90).string(message.comment);
+    }
+    if (message.metadata !== undefined) {
+      Struct.encode(Struct.wrap(message.metadata), writer.uint32(98).fork()).join();
+    }
+    return writer;
+  },
+
+  decode(input: BinaryReader | Uint8Array, length?: number): IngestScoreRequest {
+    const reader = input instanceof BinaryReader ? input : new BinaryReader(input);
+    let end = length === undefined ? reader.len : reader.pos + length;
+    const message = createBaseIngestScoreRequest();
+    while (reader.pos < end) {
+      const tag = reader.uint32();
+      switch (tag >>> 3) {
+        case 1: {
+          if (tag !== 10) {
+            break;
+          }
+
+          message.scoreId = reader.string();
+          continue;
+        }
+        case 2: {
+          if (tag !== 18) {
+            break;
+          }
+
+          message.name = reader.string();
+          continue;
+        }
+        case 3: {
+          if (tag !== 25) {
+            break;
+          }
+
+          message.numericValue = reader.double();
+          continue;
+        }
+        case 4: {
+          if (tag !== 34) {
+            break;
+          }
+
+          message.stringValue = reader.string();
+          continue;
+        }
+        case 5: {
+          if (tag !== 40) {
+            break;
+          }
+
+          message.booleanValue = reader.bool();
+          continue;
+        }
+        case 6: {
+          if (tag !== 50) {
+            break;
+          }
+
+          message.traceId = reader.string();
+          continue;
+        }
+        case 7: {
+          if (tag !== 58) {
+            break;
+          }
+
+          message.spanId = reader.string();
+          continue;
+        }
+        case 8: {
+          if (tag !== 66) {
+            break;
+          }
+
+          message.sessionId = reader.string();
+          continue;
+        }
+        case 9: {
+          if (tag !== 74) {
+            break;
+          }
+
+          message.userId = reader.string();
+          continue;
+        }
+        case 10: {
+          if (tag !== 82) {
+            break;
+          }
+
+          message.configId = reader.string();
+          continue;
+        }
+        case 11: {
+          if (tag !== 90) {
+            break;
+          }
+
+          message.comment = reader.string();
+          continue;
+        }
+        case 12: {
+          if (tag !== 98) {
+            break;
+          }
+
+          message.metadata = Struct.unwrap(Struct.decode(reader, reader.uint32()));
+          continue;
+        }
+      }
+      if ((tag & 7) === 4 || tag === 0) {
+        break;
+      }
+      reader.skip(tag & 7);
+    }
+    return message;
+  },
+
+  fromJSON(object: any): IngestScoreRequest {
+    return {
+      scoreId: isSet(object.scoreId) ? globalThis.String(object.scoreId) : undefined,
+      name: isSet(object.name) ? globalThis.String(object.name) : "",
+      numericValue: isSet(object.numericValue) ? globalThis.Number(object.numericValue) : undefined,
+      stringValue: isSet(object.stringValue) ? globalThis.String(object.stringValue) : undefined,
+      booleanValue: isSet(object.booleanValue) ? globalThis.Boolean(object.booleanValue) : undefined,
+      traceId: isSet(object.traceId) ? globalThis.String(object.traceId) : undefined,
+      spanId: isSet(object.spanId) ? globalThis.String(object.spanId) : undefined,
+      sessionId: isSet(object.sessionId) ? globalThis.String(object.sessionId) : undefined,
+      userId: isSet(object.userId) ? globalThis.String(object.userId) : undefined,
+      configId: isSet(object.configId) ? globalThis.String(object.configId) : undefined,
+      comment: isSet(object.comment) ? globalThis.String(object.comment) : undefined,
+      metadata: isObject(object.metadata) ? object.metadata : undefined,
+    };
+  },
+
+  toJSON(message: IngestScoreRequest): unknown {
+    const obj: any = {};
+    if (message.scoreId !== undefined) {
+      obj.scoreId = message.scoreId;
+    }
+    if (message.name !== "") {
+      obj.name = message.name;
+    }
+    if (message.numericValue !== undefined) {
+      obj.numericValue = message.numericValue;
+    }
+    if (message.stringValue !== undefined) {
+      obj.stringValue = message.stringValue;
+    }
+    if (message.booleanValue !== undefined) {
+      obj.booleanValue = message.booleanValue;
+    }
+    if (message.traceId !== undefined) {
+      obj.traceId = message.traceId;
+    }
+    if (message.spanId !== undefined) {
+      obj.spanId = message.spanId;
+    }
+    if (message.sessionId !== undefined) {
+      obj.sessionId = message.sessionId;
+    }
+    if (message.userId !== undefined) {
+      obj.userId = message.userId;
+    }
+    if (message.configId !== undefined) {
+      obj.configId = message.configId;
+    }
+    if (message.comment !== undefined) {
+      obj.comment = message.comment;
+    }
+    if (message.metadata !== undefined) {
+      obj.metadata = message.metadata;
+    }
+    return obj;
+  },
+
+  create<I extends Exact<DeepPartial<IngestScoreRequest>, I>>(base?: I): IngestScoreRequest {
+    return IngestScoreRequest.fromPartial(base ?? ({} as any));
+  },
+  fromPartial<I extends Exact<DeepPartial<IngestScoreRequest>, I>>(object: I): IngestScoreRequest {
+    const message = createBaseIngestScoreRequest();
+    message.scoreId = object.scoreId ?? undefined;
+    message.name = object.name ?? "";
+    message.numericValue = object.numericValue ?? undefined;
+    message.stringValue = object.stringValue ?? undefined;
+    message.booleanValue = object.booleanValue ?? undefined;
+    message.traceId = object.traceId ?? undefined;
+    message.spanId = object.spanId ?? undefined;
+    message.sessionId = object.sessionId ?? undefined;
+    message.userId = object.userId ?? undefined;
+    message.configId = object.configId ?? undefined;
+    message.comment = object.comment ?? undefined;
+    message.metadata = object.metadata ?? undefined;
+    return message;
+  },
+};
+
+function createBaseIngestScoreResponse(): IngestScoreResponse {
+  return { scoreId: "", workflowId: "", success: false };
+}
+
+export const IngestScoreResponse: MessageFns<IngestScoreResponse> = {
+  encode(message: IngestScoreResponse, writer: BinaryWriter = new BinaryWriter()): BinaryWriter {
+    if (message.scoreId !== "") {
+      writer.uint32(10).string(message.scoreId);
+    }
+    if (message.workflowId !== "") {
+      writer.uint32(18).string(message.workflowId);
+    }
+    if (message.success !== false) {
+      writer.uint32(24).bool(message.success);
+    }
+    return writer;
+  },
+
+  decode(input: BinaryReader | Uint8Array, length?: number): IngestScoreResponse {
+    const reader = input instanceof BinaryReader ? input : new BinaryReader(input);
+    let end = length === undefined ? reader.len : reader.pos + length;
+    const message = createBaseIngestScoreResponse();
+    while (reader.pos < end) {
+      const tag = reader.uint32();
+      switch (tag >>> 3) {
+        case 1: {
+          if (tag !== 10) {
+            break;
+          }
+
+          message.scoreId = reader.string();
+          continue;
+        }
+        case 2: {
+          if (tag !== 18) {
+            break;
+          }
+
+          message.workflowId = reader.string();
+          continue;
+        }
+        case 3: {
+          if (tag !== 24) {
+            break;
+          }
+
+          message.success = reader.bool();
+          continue;
+        }
+      }
+      if ((tag & 7) === 4 || tag === 0) {
+        break;
+      }
+      reader.skip(tag & 7);
+    }
+    return message;
+  },
+
+  fromJSON(object: any): IngestScoreResponse {
+    return {
+      scoreId: isSet(object.scoreId) ? globalThis.String(object.scoreId) : "",
+      workflowId: isSet(object.workflowId) ? globalThis.String(object.workflowId) : "",
+      success: isSet(object.success) ? globalThis.Boolean(object.success) : false,
+    };
+  },
+
+  toJSON(message: IngestScoreResponse): unknown {
+    const obj: any = {};
+    if (message.scoreId !== "") {
+      obj.scoreId = message.scoreId;
+    }
+    if (message.workflowId !== "") {
+      obj.workflowId = message.workflowId;
+    }
+    if (message.success !== false) {
+      obj.success = message.success;
+    }
+    return obj;
+  },
+
+  create<I extends Exact<DeepPartial<IngestScoreResponse>, I>>(base?: I): IngestScoreResponse {
+    return IngestScoreResponse.fromPartial(base ?? ({} as any));
+  },
+  fromPartial<I extends Exact<DeepPartial<IngestScoreResponse>, I>>(object: I): IngestScoreResponse {
+    const message = createBaseIngestScoreResponse();
+    message.scoreId = object.scoreId ?? "";
+    message.workflowId = object.workflowId ?? "";
+    message.success = object.success ?? false;
+    return message;
+  },
+};
+
 function createBaseHealthRequest(): HealthRequest {
   return {};
 }
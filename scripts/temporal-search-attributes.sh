@@ -0,0 +1,32 @@
+#!/bin/sh
+# scripts/temporal-search-attributes.sh
+#
+# Registers the search attributes the ingest service sets on trace workflows
+# when TEMPORAL_SEARCH_ATTRIBUTES=true. Safe to run repeatedly: attributes
+# that already exist are skipped.
+#
+# Usage: TEMPORAL_ADDRESS=localhost:7233 TEMPORAL_NAMESPACE=default scripts/temporal-search-attributes.sh
+
+set -e
+
+ADDRESS="${TEMPORAL_ADDRESS:-localhost:7233}"
+NAMESPACE="${TEMPORAL_NAMESPACE:-default}"
+
+create() {
+    name="$1"
+    type="$2"
+    if temporal operator search-attribute list --address "$ADDRESS" --namespace "$NAMESPACE" | grep -qw "$name"; then
+        echo "search attribute $name already registered"
+        return
+    fi
+    temporal operator search-attribute create --address "$ADDRESS" --namespace "$NAMESPACE" --name "$name" --type "$type"
+    echo "search attribute $name registered ($type)"
+}
+
+create Environment Keyword
+create Release Keyword
+create Tags KeywordList
+create TraceStatus Keyword
+create ProjectID Keyword
+create SessionID Keyword
+create RetentionDays Int